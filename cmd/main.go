@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"github.com/Ruclo/MyMeals/internal/apperrors"
 	"github.com/Ruclo/MyMeals/internal/auth"
 	"github.com/Ruclo/MyMeals/internal/config"
@@ -8,39 +9,84 @@ import (
 	"github.com/Ruclo/MyMeals/internal/events"
 	"github.com/Ruclo/MyMeals/internal/handlers"
 	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/payments"
 	"github.com/Ruclo/MyMeals/internal/repositories"
 	"github.com/Ruclo/MyMeals/internal/services"
 	"github.com/Ruclo/MyMeals/internal/storage"
 	cloudinary2 "github.com/cloudinary/cloudinary-go/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
 	"log"
+	"net/url"
 	"os"
+	"strings"
+	_ "time/tzdata"
 )
 
 func main() {
 	config.InitConfig()
+	models.TimeZone = config.ConfigInstance.TimeZone()
 
 	db := database.InitDB()
 	sseServer := events.NewSSEServer()
-	cloudinary, err := cloudinary2.NewFromURL(config.ConfigInstance.CloudinaryUrl())
+	imageStorage, imageSigner, err := newImageStorage()
 	if err != nil {
 		log.Fatal(err)
 	}
+	imageProcessor := storage.NewImageProcessor(imageStorage)
 
 	orderBroadcaster := sseServer.NewBroadcaster()
-	imageStorage := storage.NewCloudinaryStorage(cloudinary)
+	stockBroadcaster := sseServer.NewStockBroadcaster()
+	paymentProvider := payments.NewInMemoryProvider()
 
 	mealRepo := repositories.NewMealRepository(db)
 	orderRepo := repositories.NewOrderRepository(db)
 	userRepo := repositories.NewUserRepository(db)
+	paymentRepo := repositories.NewPaymentRepository(db)
+	tableRepo := repositories.NewTableRepository(db)
+	categoryRepo := repositories.NewCategoryRepository(db)
+	ingredientRepo := repositories.NewIngredientRepository(db)
+	menuRepo := repositories.NewMenuRepository(db)
+	promotionRepo := repositories.NewPromotionRepository(db)
+	tipRepo := repositories.NewTipRepository(db)
+	analyticsRepo := repositories.NewAnalyticsRepository(db)
+	exportRepo := repositories.NewExportRepository(db)
+	reviewRepo := repositories.NewReviewRepository(db)
+	imageRepo := repositories.NewImageRepository(db)
+	uploadRepo := repositories.NewUploadRepository(db)
 
 	userService := services.NewUserService(userRepo)
-	mealService := services.NewMealService(mealRepo, imageStorage)
-	orderService := services.NewOrderService(orderRepo, mealRepo, imageStorage, orderBroadcaster)
+	mealService := services.NewMealService(mealRepo, categoryRepo, menuRepo, ingredientRepo, imageProcessor)
+	orderService := services.NewOrderService(orderRepo, mealRepo, menuRepo, promotionRepo, tableRepo, imageProcessor, orderBroadcaster, stockBroadcaster)
+	paymentService := services.NewPaymentService(paymentRepo, orderRepo, paymentProvider)
+	tableService := services.NewTableService(tableRepo)
+	categoryService := services.NewCategoryService(categoryRepo, imageStorage)
+	ingredientService := services.NewIngredientService(ingredientRepo)
+	menuService := services.NewMenuService(menuRepo, mealRepo)
+	promotionService := services.NewPromotionService(promotionRepo, categoryRepo, mealRepo)
+	tipService := services.NewTipService(tipRepo, config.ConfigInstance.Shifts())
+	analyticsService := services.NewAnalyticsService(analyticsRepo)
+	exportService := services.NewExportService(exportRepo)
+	reviewService := services.NewReviewService(reviewRepo, mealRepo, imageProcessor)
+	imageService := services.NewImageService(imageRepo, imageStorage, config.ConfigInstance.ImageGCGracePeriod())
+	uploadService := services.NewUploadService(uploadRepo, imageProcessor, config.ConfigInstance.UploadSessionTTL())
 
 	mealsHandler := handlers.NewMealsHandler(mealService)
 	ordersHandler := handlers.NewOrdersHandler(orderService)
 	usersHandler := handlers.NewUsersHandler(userService)
+	paymentsHandler := handlers.NewPaymentsHandler(paymentService)
+	tablesHandler := handlers.NewTablesHandler(tableService)
+	categoriesHandler := handlers.NewCategoriesHandler(categoryService)
+	ingredientsHandler := handlers.NewIngredientsHandler(ingredientService)
+	menusHandler := handlers.NewMenusHandler(menuService)
+	promotionsHandler := handlers.NewPromotionsHandler(promotionService)
+	tipsHandler := handlers.NewTipsHandler(tipService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	exportsHandler := handlers.NewExportsHandler(exportService)
+	reviewsHandler := handlers.NewReviewsHandler(reviewService)
+	imagesHandler := handlers.NewImagesHandler(imageService, imageSigner)
+	uploadsHandler := handlers.NewUploadsHandler(uploadService)
 
 	adminUsername := getEnvOrDefault("ADMIN_USERNAME", "admin")
 	adminPassword := getEnvOrDefault("ADMIN_PASSWORD", "password")
@@ -53,11 +99,26 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if config.ConfigInstance.ImageGCEnabled() {
+		go imageService.Run(context.Background(), config.ConfigInstance.ImageGCInterval())
+	}
+	go uploadService.Run(context.Background(), config.ConfigInstance.UploadCleanupInterval())
+
 	r := gin.Default()
 	r.Use(apperrors.ErrorHandler())
 
+	// Images stored locally or in a private bucket are served by the API
+	if config.ConfigInstance.ImageStorage() == config.LocalImageStorage {
+		r.Static(imageRoute(), config.ConfigInstance.LocalImageDir())
+	} else if imageSigner != nil {
+		r.GET(imageRoute()+"/*key", imagesHandler.GetImage())
+	}
+
 	// Public routes
 	r.GET("/api/meals", mealsHandler.GetMeals())
+	r.GET("/api/categories", categoriesHandler.GetCategories())
+	r.GET("/api/meals/:mealID/rating", reviewsHandler.GetMealRating())
+	r.GET("/api/reviews", reviewsHandler.GetReviewFeed())
 	r.POST("/api/login", usersHandler.Login())
 	r.POST("/api/logout", usersHandler.Logout())
 	r.POST("/api/orders", ordersHandler.PostOrder())
@@ -67,6 +128,9 @@ func main() {
 	authorized.Use(auth.AuthMiddleware())
 	authorized.GET("/me", usersHandler.GetMe())
 	authorized.GET("/orders/me", ordersHandler.GetMyOrder())
+	authorized.POST("/uploads", uploadsHandler.PostUploadSession())
+	authorized.POST("/uploads/:sessionID/photos", uploadsHandler.PostUpload())
+	authorized.DELETE("/uploads/:sessionID/photos/:uploadID", uploadsHandler.DeleteUpload())
 
 	// AdminRole or RegularStaffRole routes
 	staffRoutes := authorized.Group("/")
@@ -76,7 +140,19 @@ func main() {
 		staffRoutes.GET("/orders/pending", ordersHandler.GetPendingOrders())
 		staffRoutes.GET("/events/orders", sseServer.Handler()...)
 		staffRoutes.PUT("/account/password", usersHandler.ChangePassword())
-		staffRoutes.POST("/orders/:orderID/items/:mealID/status", ordersHandler.UpdateStatus())
+		staffRoutes.POST("/orders/:orderID/items/:orderMealID/status", ordersHandler.UpdateStatus())
+		staffRoutes.POST("/orders/:orderID/items/:orderMealID/void", ordersHandler.PostOrderItemVoid())
+		staffRoutes.GET("/orders/:orderID/payments", paymentsHandler.GetOrderPayments())
+		staffRoutes.POST("/orders/:orderID/payments", paymentsHandler.PostOrderPayment())
+		staffRoutes.POST("/orders/:orderID/split/even", paymentsHandler.PostSplitEvenly())
+		staffRoutes.POST("/orders/:orderID/split/lines", paymentsHandler.PostSplitByLines())
+		staffRoutes.GET("/tables", tablesHandler.GetTables())
+		staffRoutes.GET("/tables/overview", tablesHandler.GetTablesOverview())
+		staffRoutes.POST("/tables/:tableNo/session/close", tablesHandler.PostCloseSession())
+		staffRoutes.PUT("/meals/:mealID/sold-out", mealsHandler.PutMealSoldOut())
+		staffRoutes.GET("/ingredients", ingredientsHandler.GetIngredients())
+		staffRoutes.POST("/ingredients/:ingredientID/restock", ingredientsHandler.PostIngredientRestock())
+		staffRoutes.POST("/reviews/:reviewID/replies", reviewsHandler.PostReviewReply())
 	}
 
 	// AdminRole only access
@@ -85,12 +161,54 @@ func main() {
 	{
 		adminRoutes.GET("/meals/deleted", mealsHandler.GetMealsWithDeleted())
 		adminRoutes.POST("/meals", mealsHandler.PostMeal())
-		adminRoutes.POST("/meals/:mealID/replace", mealsHandler.PostMealReplace())
+		adminRoutes.PUT("/meals/:mealID", mealsHandler.PutMeal())
+		adminRoutes.POST("/meals/:mealID/replace", mealsHandler.PutMeal()) // kept for clients of the former replace endpoint
+		adminRoutes.GET("/meals/:mealID/versions", mealsHandler.GetMealVersions())
+		adminRoutes.PUT("/meals/:mealID/options", mealsHandler.PutMealOptions())
+		adminRoutes.PUT("/meals/:mealID/availability", mealsHandler.PutMealAvailabilityWindows())
+		adminRoutes.PUT("/meals/:mealID/recipe", mealsHandler.PutMealRecipe())
 		adminRoutes.DELETE("/meals/:mealID", mealsHandler.DeleteMeal())
 		adminRoutes.POST("/users", usersHandler.PostUser())
 		adminRoutes.GET("/orders", ordersHandler.GetOrders())
 		adminRoutes.GET("/users/staff", usersHandler.GetStaff())
 		adminRoutes.DELETE("/users/:username", usersHandler.DeleteUser())
+		adminRoutes.POST("/tables", tablesHandler.PostTable())
+		adminRoutes.PUT("/tables/:tableNo", tablesHandler.PutTable())
+		adminRoutes.GET("/tables/:tableNo/qr", tablesHandler.GetTableQRCode())
+		adminRoutes.POST("/tables/:tableNo/token/rotate", tablesHandler.PostRotateToken())
+		adminRoutes.DELETE("/tables/:tableNo/token", tablesHandler.DeleteToken())
+		adminRoutes.GET("/categories/all", categoriesHandler.GetAllCategories())
+		adminRoutes.POST("/categories", categoriesHandler.PostCategory())
+		adminRoutes.PUT("/categories/:categoryID", categoriesHandler.PutCategory())
+		adminRoutes.PUT("/categories/:categoryID/availability", categoriesHandler.PutCategoryAvailabilityWindows())
+		adminRoutes.POST("/ingredients", ingredientsHandler.PostIngredient())
+		adminRoutes.PUT("/ingredients/:ingredientID", ingredientsHandler.PutIngredient())
+		adminRoutes.GET("/menus", menusHandler.GetMenus())
+		adminRoutes.GET("/menus/preview", menusHandler.GetMenuPreview())
+		adminRoutes.POST("/menus", menusHandler.PostMenu())
+		adminRoutes.PUT("/menus/:menuID", menusHandler.PutMenu())
+		adminRoutes.DELETE("/menus/:menuID", menusHandler.DeleteMenu())
+		adminRoutes.PUT("/menus/:menuID/items", menusHandler.PutMenuItems())
+		adminRoutes.PUT("/menus/:menuID/schedules", menusHandler.PutMenuSchedules())
+		adminRoutes.GET("/promotions", promotionsHandler.GetPromotions())
+		adminRoutes.POST("/promotions", promotionsHandler.PostPromotion())
+		adminRoutes.PUT("/promotions/:promotionID", promotionsHandler.PutPromotion())
+		adminRoutes.DELETE("/promotions/:promotionID", promotionsHandler.DeletePromotion())
+		adminRoutes.GET("/reports/tips", tipsHandler.GetTipReport())
+		adminRoutes.GET("/analytics/revenue/days", analyticsHandler.GetRevenueByDay())
+		adminRoutes.GET("/analytics/revenue/hours", analyticsHandler.GetRevenueByHour())
+		adminRoutes.GET("/analytics/revenue/categories", analyticsHandler.GetRevenueByCategory())
+		adminRoutes.GET("/analytics/meals/top", analyticsHandler.GetTopMeals())
+		adminRoutes.GET("/analytics/tickets", analyticsHandler.GetTicketSize())
+		adminRoutes.GET("/analytics/prep-times", analyticsHandler.GetPrepTimes())
+		adminRoutes.GET("/analytics/tables", analyticsHandler.GetTableTurnover())
+		adminRoutes.GET("/exports/orders", exportsHandler.GetOrdersExport())
+		adminRoutes.GET("/exports/reviews", exportsHandler.GetReviewsExport())
+		adminRoutes.GET("/exports/sales", exportsHandler.GetDailySalesExport())
+		adminRoutes.GET("/reviews", reviewsHandler.GetReviews())
+		adminRoutes.PUT("/reviews/:reviewID/status", reviewsHandler.PutReviewStatus())
+		adminRoutes.DELETE("/reviews/:reviewID/photos/:photoIndex", reviewsHandler.DeleteReviewPhoto())
+		adminRoutes.GET("/images/orphans", imagesHandler.GetOrphanedImages())
 	}
 
 	// Order Creator access only
@@ -99,11 +217,64 @@ func main() {
 	{
 		orderRoutes.POST("/items", ordersHandler.PostOrderItems())
 		orderRoutes.POST("/review", ordersHandler.PostOrderReview())
+		orderRoutes.POST("/cancel", ordersHandler.PostOrderCancel())
+		orderRoutes.PUT("/tip", ordersHandler.PutOrderTip())
 	}
 
 	r.Run()
 }
 
+// newImageStorage creates the image storage selected by the configuration.
+// The signer is only returned for images in a private S3 bucket, which have to be served through presigned urls.
+func newImageStorage() (storage.ImageStorage, storage.ImageSigner, error) {
+	switch config.ConfigInstance.ImageStorage() {
+	case config.LocalImageStorage:
+		imageStorage, err := storage.NewLocalImageStorage(config.ConfigInstance.LocalImageDir(),
+			config.ConfigInstance.ImageUrl())
+		return imageStorage, nil, err
+	case config.S3ImageStorage:
+		client, err := minio.New(config.ConfigInstance.S3Endpoint(), &minio.Options{
+			Creds: credentials.NewStaticV4(config.ConfigInstance.S3AccessKey(),
+				config.ConfigInstance.S3SecretKey(), ""),
+			Secure: config.ConfigInstance.S3UseSSL(),
+			Region: config.ConfigInstance.S3Region(),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		imageStorage, err := storage.NewS3ImageStorage(context.Background(), client, storage.S3Options{
+			Bucket:        config.ConfigInstance.S3Bucket(),
+			PublicURL:     config.ConfigInstance.S3PublicUrl(),
+			BaseURL:       config.ConfigInstance.ImageUrl(),
+			PresignExpiry: config.ConfigInstance.S3PresignExpiry(),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if config.ConfigInstance.S3PublicUrl() != "" {
+			return imageStorage, nil, nil
+		}
+		return imageStorage, imageStorage, nil
+	default:
+		cloudinary, err := cloudinary2.NewFromURL(config.ConfigInstance.CloudinaryUrl())
+		if err != nil {
+			return nil, nil, err
+		}
+		return storage.NewCloudinaryStorage(cloudinary), nil, nil
+	}
+}
+
+// imageRoute returns the route the API serves images on, the path of the image url.
+func imageRoute() string {
+	imageUrl, err := url.Parse(config.ConfigInstance.ImageUrl())
+	if err != nil || strings.Trim(imageUrl.Path, "/") == "" {
+		log.Fatal("Invalid image url " + config.ConfigInstance.ImageUrl())
+	}
+	return strings.TrimSuffix(imageUrl.Path, "/")
+}
+
 func getEnvOrDefault(key, fallback string) string {
 	value, exists := os.LookupEnv(key)
 	if !exists || value == "" {
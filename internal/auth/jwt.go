@@ -18,11 +18,14 @@ const (
 // The type is used to determine the type of claims in the token.
 // Staff JWT tokens hold information about authenticated staff members.
 // Customer JWT tokens are used to authorize review postings and order modifications by anonymous customers.
+// Table JWT tokens are printed on the tables as QR codes and identify the table customers order to.
+// Table tokens never expire and are not accepted as auth cookies.
 type JWTType string
 
 const (
 	StaffJWT    JWTType = "staff"
 	CustomerJWT JWTType = "customer"
+	TableJWT    JWTType = "table"
 )
 
 // StaffClaims represents the claims in a staff JWT token.
@@ -37,6 +40,14 @@ type CustomerClaims struct {
 	jwt.RegisteredClaims
 }
 
+// TableClaims represents the claims in a table JWT token.
+// Version has to match the current token version of the table for the token to be accepted.
+type TableClaims struct {
+	TableNo int  `json:"table_no"`
+	Version uint `json:"version"`
+	jwt.RegisteredClaims
+}
+
 // GenerateStaffJWT generates a JWT token for staff members
 // and returns the encoded token, expiration time and error.
 func GenerateStaffJWT(username string, role models.Role) (string, time.Time, error) {
@@ -78,6 +89,45 @@ func GenerateCustomerJWT(orderID uint) (string, time.Time, error) {
 	return encodedToken, expirationTime, err
 }
 
+// GenerateTableJWT generates a non-expiring JWT token identifying a table for the given token version
+// and returns the encoded token and error.
+func GenerateTableJWT(tableNo int, version uint) (string, error) {
+	claims := TableClaims{
+		TableNo: tableNo,
+		Version: version,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Issuer:   "mymeals-api",
+			Subject:  "table-" + strconv.Itoa(tableNo),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["typ"] = TableJWT
+
+	encodedToken, err := token.SignedString([]byte(config.ConfigInstance.JWTSecret()))
+	if err != nil {
+		return "", apperrors.NewInternalServerErr("Failed to generate a table jwt", err)
+	}
+	return encodedToken, nil
+}
+
+// ParseTableJWT verifies the signature of a table JWT token and returns its claims.
+// It does not check the token version against the table.
+func ParseTableJWT(tokenString string) (*TableClaims, error) {
+	claims := &TableClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, getSecret)
+	if err != nil || !token.Valid {
+		return nil, apperrors.NewUnauthorizedErr("Invalid table token", err)
+	}
+
+	if tokenType, ok := token.Header["typ"].(string); !ok || JWTType(tokenType) != TableJWT {
+		return nil, apperrors.NewUnauthorizedErr("Invalid table token", nil)
+	}
+
+	return claims, nil
+}
+
 // getSecret returns the secret used to sign the JWT tokens.
 func getSecret(_ *jwt.Token) (interface{}, error) {
 	return config.ConfigInstance.JWTSecret(), nil
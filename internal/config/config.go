@@ -1,9 +1,21 @@
 package config
 
 import (
+	"github.com/Ruclo/MyMeals/internal/models"
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Image storages the images of meals, categories and reviews can be stored in.
+const (
+	CloudinaryImageStorage = "cloudinary"
+	LocalImageStorage      = "local"
+	S3ImageStorage         = "s3"
 )
 
 // ConfigInstance is the global config instance.
@@ -13,13 +25,36 @@ var ConfigInstance Config
 // Config represents the configuration of the application.
 // A single global instance of Config is used throughout the application and is initialized in InitConfig().
 type Config struct {
-	dbHost        string
-	dbUser        string
-	dbPassword    string
-	dbName        string
-	dbPort        string
-	jwtSecret     []byte
-	cloudinaryUrl string
+	dbHost                string
+	dbUser                string
+	dbPassword            string
+	dbName                string
+	dbPort                string
+	jwtSecret             []byte
+	imageStorage          string
+	cloudinaryUrl         string
+	localImageDir         string
+	imageUrl              string
+	s3Endpoint            string
+	s3AccessKey           string
+	s3SecretKey           string
+	s3Region              string
+	s3UseSSL              bool
+	s3Bucket              string
+	s3PublicUrl           string
+	s3PresignExpiry       time.Duration
+	imageGCEnabled        bool
+	imageGCInterval       time.Duration
+	imageGCGracePeriod    time.Duration
+	uploadSessionTTL      time.Duration
+	uploadCleanupInterval time.Duration
+	vatRates              map[string]decimal.Decimal
+	defaultVatRate        decimal.Decimal
+	tableOrderUrl         string
+	serviceChargeRate     decimal.Decimal
+	serviceChargeSeats    uint
+	shifts                []models.Shift
+	timeZone              *time.Location
 }
 
 // DBHost returns the host of the database.
@@ -52,12 +87,132 @@ func (c *Config) JWTSecret() []byte {
 	return c.jwtSecret
 }
 
+// ImageStorage returns the storage images are uploaded to, one of the image storage constants.
+func (c *Config) ImageStorage() string {
+	return c.imageStorage
+}
+
 // CloudinaryUrl returns the url of the cloudinary account.
 // This is used to upload images to cloudinary and retrieve the url of the uploaded image.
 func (c *Config) CloudinaryUrl() string {
 	return c.cloudinaryUrl
 }
 
+// LocalImageDir returns the directory the local image storage writes images to.
+func (c *Config) LocalImageDir() string {
+	return c.localImageDir
+}
+
+// ImageUrl returns the url the API serves images under, if they are stored locally or in a private S3 bucket.
+// Its path is the route the API serves the images on.
+func (c *Config) ImageUrl() string {
+	return c.imageUrl
+}
+
+// S3Endpoint returns the host and optional port of the S3 compatible object storage, e.g. localhost:9000 for MinIO.
+func (c *Config) S3Endpoint() string {
+	return c.s3Endpoint
+}
+
+// S3AccessKey returns the access key of the object storage.
+func (c *Config) S3AccessKey() string {
+	return c.s3AccessKey
+}
+
+// S3SecretKey returns the secret key of the object storage.
+func (c *Config) S3SecretKey() string {
+	return c.s3SecretKey
+}
+
+// S3Region returns the region of the bucket, which is looked up if empty.
+func (c *Config) S3Region() string {
+	return c.s3Region
+}
+
+// S3UseSSL returns whether the object storage is accessed over HTTPS.
+func (c *Config) S3UseSSL() bool {
+	return c.s3UseSSL
+}
+
+// S3Bucket returns the bucket images are stored in.
+func (c *Config) S3Bucket() string {
+	return c.s3Bucket
+}
+
+// S3PublicUrl returns the url the bucket is publicly readable under.
+// If empty, the bucket is private and images are served through presigned urls.
+func (c *Config) S3PublicUrl() string {
+	return c.s3PublicUrl
+}
+
+// S3PresignExpiry returns how long the presigned urls of images in a private bucket are valid.
+func (c *Config) S3PresignExpiry() time.Duration {
+	return c.s3PresignExpiry
+}
+
+// ImageGCEnabled returns whether orphaned images are deleted from the image storage in the background.
+func (c *Config) ImageGCEnabled() bool {
+	return c.imageGCEnabled
+}
+
+// ImageGCInterval returns how often orphaned images are deleted.
+func (c *Config) ImageGCInterval() time.Duration {
+	return c.imageGCInterval
+}
+
+// ImageGCGracePeriod returns how old unreferenced images have to be to count as orphaned.
+func (c *Config) ImageGCGracePeriod() time.Duration {
+	return c.imageGCGracePeriod
+}
+
+// UploadSessionTTL returns how long photos can be uploaded into an upload session and attached from it.
+func (c *Config) UploadSessionTTL() time.Duration {
+	return c.uploadSessionTTL
+}
+
+// UploadCleanupInterval returns how often expired upload sessions are deleted along with their photos.
+func (c *Config) UploadCleanupInterval() time.Duration {
+	return c.uploadCleanupInterval
+}
+
+// VATRate returns the VAT rate applied to meals of the given category, e.g. 0.2 for 20%.
+// Categories without a configured rate use the default VAT rate.
+func (c *Config) VATRate(category string) decimal.Decimal {
+	if rate, ok := c.vatRates[category]; ok {
+		return rate
+	}
+	return c.defaultVatRate
+}
+
+// TableOrderUrl returns the url of the ordering page customers are sent to by the QR codes on the tables.
+// The table token gets appended as the table_token query parameter. If empty, the QR codes hold the bare token.
+func (c *Config) TableOrderUrl() string {
+	return c.tableOrderUrl
+}
+
+// ServiceChargeRate returns the rate of the service charge added to the bills of orders made at a table
+// with the given number of seats, e.g. 0.1 for 10%. Only tables with at least the configured number of seats are charged.
+func (c *Config) ServiceChargeRate(seats uint) decimal.Decimal {
+	if seats < c.serviceChargeSeats {
+		return decimal.Zero
+	}
+	return c.serviceChargeRate
+}
+
+// Shifts returns the shifts staff work in, which tips are reported by.
+func (c *Config) Shifts() []models.Shift {
+	return c.shifts
+}
+
+// TimeZone returns the time zone of the restaurant, which availability windows, menu schedules
+// and promotions are defined in. Defaults to the local time zone of the server.
+func (c *Config) TimeZone() *time.Location {
+	if c.timeZone == nil {
+		return time.Local
+	}
+	return c.timeZone
+}
+
 // InitConfig initializes the config instance with values from the .env file.
 // It exits the program if the .env file is not found or if any of the required
 // environment variables are not set.
@@ -77,7 +232,34 @@ func InitConfig() {
 	ConfigInstance.dbName = getEnvOrExit("DB_NAME")
 	ConfigInstance.dbPort = getEnvOrExit("DB_PORT")
 	ConfigInstance.jwtSecret = []byte(getEnvOrExit("JWT_SECRET"))
-	ConfigInstance.cloudinaryUrl = getEnvOrExit("CLOUDINARY_URL")
+	ConfigInstance.imageStorage = parseImageStorage(getEnvOrDefault("IMAGE_STORAGE", CloudinaryImageStorage))
+	if ConfigInstance.imageStorage == CloudinaryImageStorage {
+		ConfigInstance.cloudinaryUrl = getEnvOrExit("CLOUDINARY_URL")
+	}
+	ConfigInstance.localImageDir = getEnvOrDefault("LOCAL_IMAGE_DIR", "uploads")
+	ConfigInstance.imageUrl = getEnvOrDefault("IMAGE_URL", "/images")
+	if ConfigInstance.imageStorage == S3ImageStorage {
+		ConfigInstance.s3Endpoint = getEnvOrExit("S3_ENDPOINT")
+		ConfigInstance.s3AccessKey = getEnvOrExit("S3_ACCESS_KEY")
+		ConfigInstance.s3SecretKey = getEnvOrExit("S3_SECRET_KEY")
+		ConfigInstance.s3Bucket = getEnvOrExit("S3_BUCKET")
+	}
+	ConfigInstance.s3Region = getEnvOrDefault("S3_REGION", "")
+	ConfigInstance.s3UseSSL = parseBool(getEnvOrDefault("S3_USE_SSL", "true"))
+	ConfigInstance.s3PublicUrl = getEnvOrDefault("S3_PUBLIC_URL", "")
+	ConfigInstance.s3PresignExpiry = parseDuration(getEnvOrDefault("S3_PRESIGN_EXPIRY", "15m"))
+	ConfigInstance.imageGCEnabled = parseBool(getEnvOrDefault("IMAGE_GC_ENABLED", "true"))
+	ConfigInstance.imageGCInterval = parseDuration(getEnvOrDefault("IMAGE_GC_INTERVAL", "24h"))
+	ConfigInstance.imageGCGracePeriod = parseDuration(getEnvOrDefault("IMAGE_GC_GRACE_PERIOD", "24h"))
+	ConfigInstance.uploadSessionTTL = parseDuration(getEnvOrDefault("UPLOAD_SESSION_TTL", "1h"))
+	ConfigInstance.uploadCleanupInterval = parseDuration(getEnvOrDefault("UPLOAD_CLEANUP_INTERVAL", "10m"))
+	ConfigInstance.defaultVatRate = parseRate(getEnvOrDefault("DEFAULT_VAT_RATE", "0"))
+	ConfigInstance.vatRates = parseVatRates(getEnvOrDefault("VAT_RATES", ""))
+	ConfigInstance.tableOrderUrl = getEnvOrDefault("TABLE_ORDER_URL", "")
+	ConfigInstance.serviceChargeRate = parseRate(getEnvOrDefault("SERVICE_CHARGE_RATE", "0"))
+	ConfigInstance.serviceChargeSeats = parseSeats(getEnvOrDefault("SERVICE_CHARGE_MIN_SEATS", "8"))
+	ConfigInstance.shifts = parseShifts(getEnvOrDefault("SHIFTS", "Day:00:00-00:00"))
+	ConfigInstance.timeZone = parseTimeZone(getEnvOrDefault("TIME_ZONE", "Local"))
 
 }
 
@@ -90,3 +272,114 @@ func getEnvOrExit(key string) string {
 	}
 	return value
 }
+
+// getEnvOrDefault returns the value of the environment variable with the given key,
+// or the fallback if it is not set.
+func getEnvOrDefault(key, fallback string) string {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback
+	}
+	return value
+}
+
+// parseImageStorage parses the name of an image storage. It exits the program if the storage is unknown.
+func parseImageStorage(value string) string {
+	storage := strings.ToLower(strings.TrimSpace(value))
+	if storage != CloudinaryImageStorage && storage != LocalImageStorage && storage != S3ImageStorage {
+		log.Fatal("Invalid image storage " + value)
+	}
+	return storage
+}
+
+// parseBool parses a boolean like true, false, 1 or 0. It exits the program if the value is invalid.
+func parseBool(value string) bool {
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		log.Fatal("Invalid boolean " + value)
+	}
+	return b
+}
+
+// parseDuration parses a positive duration like 15m or 1h. It exits the program if the duration is invalid.
+func parseDuration(value string) time.Duration {
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || duration <= 0 {
+		log.Fatal("Invalid duration " + value)
+	}
+	return duration
+}
+
+// parseVatRates parses VAT rates per meal category in the format "Drinks:0.2,Main Courses:0.1".
+// It exits the program if the format is invalid.
+func parseVatRates(value string) map[string]decimal.Decimal {
+	rates := make(map[string]decimal.Decimal)
+	if strings.TrimSpace(value) == "" {
+		return rates
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		category, rate, found := strings.Cut(entry, ":")
+		if !found {
+			log.Fatal("Invalid VAT rate entry " + entry)
+		}
+		rates[strings.TrimSpace(category)] = parseRate(rate)
+	}
+	return rates
+}
+
+// parseRate parses a single non-negative rate. It exits the program if the rate is invalid.
+func parseRate(value string) decimal.Decimal {
+	rate, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil || rate.IsNegative() {
+		log.Fatal("Invalid rate " + value)
+	}
+	return rate
+}
+
+// parseSeats parses a positive number of seats. It exits the program if the number is invalid.
+func parseSeats(value string) uint {
+	seats, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seats < 1 {
+		log.Fatal("Invalid number of seats " + value)
+	}
+	return uint(seats)
+}
+
+// parseShifts parses shifts in the format "Morning:06:00-14:00,Evening:14:00-22:00".
+// It exits the program if the format is invalid.
+func parseShifts(value string) []models.Shift {
+	var shifts []models.Shift
+	for _, entry := range strings.Split(value, ",") {
+		name, times, found := strings.Cut(entry, ":")
+		start, end, foundEnd := strings.Cut(times, "-")
+		if !found || !foundEnd || strings.TrimSpace(name) == "" {
+			log.Fatal("Invalid shift entry " + entry)
+		}
+		shifts = append(shifts, models.Shift{
+			Name:        strings.TrimSpace(name),
+			StartMinute: parseMinute(start),
+			EndMinute:   parseMinute(end),
+		})
+	}
+	return shifts
+}
+
+// parseMinute parses a time of day in the HH:MM format to minutes since midnight.
+// It exits the program if the time is invalid.
+func parseMinute(value string) uint {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		log.Fatal("Invalid time of day " + value)
+	}
+	return uint(t.Hour()*60 + t.Minute())
+}
+
+// parseTimeZone parses the IANA name of a time zone like Europe/Prague. It exits the program if the time zone is unknown.
+func parseTimeZone(value string) *time.Location {
+	location, err := time.LoadLocation(strings.TrimSpace(value))
+	if err != nil {
+		log.Fatal("Invalid time zone " + value)
+	}
+	return location
+}
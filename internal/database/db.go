@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/Ruclo/MyMeals/internal/config"
 	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/lib/pq"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -37,11 +38,117 @@ func CreateConnection() *gorm.DB {
 // It exits the program if the migration fails.
 // Make sure to add new models to the migration function.
 func migrateSchema(db *gorm.DB) {
+	if err := migrateOrderMealLines(db); err != nil {
+		log.Fatal("Order meal line migration failed: ", err)
+	}
+
+	if err := migrateMealCategories(db); err != nil {
+		log.Fatal("Meal category migration failed: ", err)
+	}
 
-	err := db.AutoMigrate(&models.Meal{}, &models.Order{}, &models.User{}, &models.Review{}, &models.OrderMeal{})
+	err := db.AutoMigrate(&models.Category{}, &models.Meal{}, &models.MealVersion{},
+		&models.Menu{}, &models.MenuItem{}, &models.Promotion{}, &models.PromotionComboMeal{},
+		&models.AvailabilityWindow{}, &models.OptionGroup{}, &models.MealOption{},
+		&models.Ingredient{}, &models.RecipeItem{},
+		&models.Order{}, &models.User{}, &models.Review{}, &models.ReviewReply{}, &models.ReviewMealRating{},
+		&models.OrderMeal{}, &models.OrderMealOption{}, &models.OrderMealStatusChange{}, &models.OrderMealVoid{}, &models.OrderDiscount{},
+		&models.Payment{}, &models.Tip{}, &models.Table{}, &models.TableSession{},
+		&models.UploadSession{}, &models.Upload{})
 	if err != nil {
 		log.Fatal("Schema migration failed: ", err)
 	}
+
+	if err = migrateMealVersions(db); err != nil {
+		log.Fatal("Meal version migration failed: ", err)
+	}
+}
+
+// migrateOrderMealLines replaces the composite primary key (order_id, meal_id) of order meals with a line id
+// and moves the status changes and voids of order meals over to the line id.
+// It does nothing if the database is already migrated or has no order meals yet.
+func migrateOrderMealLines(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable("order_meals") || migrator.HasColumn("order_meals", "id") {
+		return nil
+	}
+
+	statements := []string{
+		"ALTER TABLE order_meals DROP CONSTRAINT order_meals_pkey",
+		"ALTER TABLE order_meals ADD COLUMN id BIGSERIAL PRIMARY KEY",
+	}
+
+	for _, table := range []string{"order_meal_status_changes", "order_meal_voids"} {
+		if !migrator.HasTable(table) {
+			continue
+		}
+
+		statements = append(statements,
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN order_meal_id BIGINT", table),
+			fmt.Sprintf("UPDATE %[1]s SET order_meal_id = order_meals.id FROM order_meals "+
+				"WHERE order_meals.order_id = %[1]s.order_id AND order_meals.meal_id = %[1]s.meal_id", table),
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN meal_id", table),
+		)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// legacyMealCategories are the categories meals had before categories were managed by admins,
+// in the order they are displayed on the menu.
+var legacyMealCategories = []string{"Drinks", "Starters", "Main Courses", "Side Dishes", "Desserts"}
+
+// migrateMealCategories converts the category names stored on meals into category rows
+// and makes meals reference their category by id.
+// Categories get displayed in the order of legacyMealCategories, unknown categories come first.
+// It does nothing if the database is already migrated or has no meals yet.
+func migrateMealCategories(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable("meals") || !migrator.HasColumn("meals", "category") ||
+		migrator.HasColumn("meals", "category_id") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(&models.Category{}); err != nil {
+			return err
+		}
+
+		err := tx.Exec("INSERT INTO categories (name, display_order, icon_url, icon_public_id, active) "+
+			"SELECT DISTINCT category, COALESCE(array_position(?::text[], category), 0), '', '', true FROM meals "+
+			"ON CONFLICT (name) DO NOTHING", pq.StringArray(legacyMealCategories)).Error
+		if err != nil {
+			return err
+		}
+
+		statements := []string{
+			"ALTER TABLE meals ADD COLUMN category_id BIGINT",
+			"UPDATE meals SET category_id = categories.id FROM categories WHERE categories.name = meals.category",
+			"ALTER TABLE meals DROP COLUMN category",
+		}
+
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateMealVersions records the current details of every meal without a version history as its first version.
+// Order meals ordered before meals had versions point at version 1 by default.
+func migrateMealVersions(db *gorm.DB) error {
+	return db.Exec("INSERT INTO meal_versions " +
+		"(meal_id, version, name, category_id, description, image_url, price, allergens, dietary_tags, spicy_level, created_at) " +
+		"SELECT id, version, name, category_id, description, image_url, price, allergens, dietary_tags, spicy_level, NOW() " +
+		"FROM meals WHERE NOT EXISTS (SELECT 1 FROM meal_versions WHERE meal_versions.meal_id = meals.id)").Error
 }
 
 // InitDB creates a new database connection and migrates the schema and returns the database connection.
@@ -0,0 +1,158 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+	"math"
+)
+
+// TopMealsQuery is the date range of the top selling meals along with the number of meals to report.
+type TopMealsQuery struct {
+	DateRangeQuery
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type DayRevenueResponse struct {
+	Date    string          `json:"date"`
+	Orders  uint            `json:"orders"`
+	Revenue decimal.Decimal `json:"revenue"`
+}
+
+type HourRevenueResponse struct {
+	Hour    uint            `json:"hour"`
+	Orders  uint            `json:"orders"`
+	Revenue decimal.Decimal `json:"revenue"`
+}
+
+type CategoryRevenueResponse struct {
+	CategoryID uint            `json:"category_id"`
+	Category   string          `json:"category"`
+	Quantity   uint            `json:"quantity"`
+	Revenue    decimal.Decimal `json:"revenue"`
+}
+
+type MealSalesResponse struct {
+	MealID   uint            `json:"meal_id"`
+	MealName string          `json:"meal_name"`
+	Quantity uint            `json:"quantity"`
+	Revenue  decimal.Decimal `json:"revenue"`
+}
+
+type TicketSizeResponse struct {
+	Orders  uint            `json:"orders"`
+	Revenue decimal.Decimal `json:"revenue"`
+	Average decimal.Decimal `json:"average"`
+}
+
+type PrepTimeReportResponse struct {
+	Units          uint                   `json:"units"`
+	AverageSeconds int64                  `json:"average_seconds"`
+	Meals          []MealPrepTimeResponse `json:"meals"`
+}
+
+type MealPrepTimeResponse struct {
+	MealID         uint   `json:"meal_id"`
+	MealName       string `json:"meal_name"`
+	Units          uint   `json:"units"`
+	AverageSeconds int64  `json:"average_seconds"`
+}
+
+type TableTurnoverResponse struct {
+	TableNo        int     `json:"table_no"`
+	Sessions       uint    `json:"sessions"`
+	TurnsPerDay    float64 `json:"turns_per_day"`
+	AverageSeconds int64   `json:"average_seconds"`
+}
+
+func ToDayRevenueResponses(revenues []models.DayRevenue) []DayRevenueResponse {
+	responses := make([]DayRevenueResponse, len(revenues))
+	for i, revenue := range revenues {
+		responses[i] = DayRevenueResponse{
+			Date:    revenue.Day,
+			Orders:  revenue.Orders,
+			Revenue: revenue.Revenue.Round(2),
+		}
+	}
+	return responses
+}
+
+func ToHourRevenueResponses(revenues []models.HourRevenue) []HourRevenueResponse {
+	responses := make([]HourRevenueResponse, len(revenues))
+	for i, revenue := range revenues {
+		responses[i] = HourRevenueResponse{
+			Hour:    revenue.Hour,
+			Orders:  revenue.Orders,
+			Revenue: revenue.Revenue.Round(2),
+		}
+	}
+	return responses
+}
+
+func ToCategoryRevenueResponses(revenues []models.CategoryRevenue) []CategoryRevenueResponse {
+	responses := make([]CategoryRevenueResponse, len(revenues))
+	for i, revenue := range revenues {
+		responses[i] = CategoryRevenueResponse{
+			CategoryID: revenue.CategoryID,
+			Category:   revenue.Category,
+			Quantity:   revenue.Quantity,
+			Revenue:    revenue.Revenue.Round(2),
+		}
+	}
+	return responses
+}
+
+func ToMealSalesResponses(sales []models.MealSales) []MealSalesResponse {
+	responses := make([]MealSalesResponse, len(sales))
+	for i, mealSales := range sales {
+		responses[i] = MealSalesResponse{
+			MealID:   mealSales.MealID,
+			MealName: mealSales.MealName,
+			Quantity: mealSales.Quantity,
+			Revenue:  mealSales.Revenue.Round(2),
+		}
+	}
+	return responses
+}
+
+func ToTicketSizeResponse(ticketSize *models.TicketSize) *TicketSizeResponse {
+	return &TicketSizeResponse{
+		Orders:  ticketSize.Orders,
+		Revenue: ticketSize.Revenue.Round(2),
+		Average: ticketSize.Average(),
+	}
+}
+
+// ToPrepTimeReportResponse converts the report to a response with prep times rounded to whole seconds.
+func ToPrepTimeReportResponse(report *models.PrepTimeReport) *PrepTimeReportResponse {
+	response := &PrepTimeReportResponse{
+		Units:          report.Overall.Units,
+		AverageSeconds: int64(math.Round(report.Overall.AverageSeconds)),
+		Meals:          make([]MealPrepTimeResponse, len(report.Meals)),
+	}
+
+	for i, prepTime := range report.Meals {
+		response.Meals[i] = MealPrepTimeResponse{
+			MealID:         prepTime.MealID,
+			MealName:       prepTime.MealName,
+			Units:          prepTime.Units,
+			AverageSeconds: int64(math.Round(prepTime.AverageSeconds)),
+		}
+	}
+
+	return response
+}
+
+// ToTableTurnoverResponses converts the turnovers to responses with durations rounded to whole seconds
+// and turns per day rounded to two decimal places.
+func ToTableTurnoverResponses(turnovers []models.TableTurnover) []TableTurnoverResponse {
+	responses := make([]TableTurnoverResponse, len(turnovers))
+	for i, turnover := range turnovers {
+		responses[i] = TableTurnoverResponse{
+			TableNo:        turnover.TableNo,
+			Sessions:       turnover.Sessions,
+			TurnsPerDay:    math.Round(turnover.TurnsPerDay*100) / 100,
+			AverageSeconds: int64(math.Round(turnover.AverageSeconds)),
+		}
+	}
+	return responses
+}
@@ -0,0 +1,54 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"time"
+)
+
+type SetAvailabilityWindowsRequest struct {
+	Windows []AvailabilityWindowRequest `json:"windows" binding:"dive"`
+}
+
+// AvailabilityWindowRequest is a recurring time window. Days are days of the week the window starts on,
+// 0 is Sunday. Start and End are times of day in the HH:MM format, windows ending before they start span midnight.
+type AvailabilityWindowRequest struct {
+	Days  []time.Weekday `json:"days" binding:"required,min=1,dive,min=0,max=6"`
+	Start string         `json:"start" binding:"required,datetime=15:04"`
+	End   string         `json:"end" binding:"required,datetime=15:04"`
+}
+
+func (req *SetAvailabilityWindowsRequest) ToModel() []models.AvailabilityWindow {
+	windows := make([]models.AvailabilityWindow, len(req.Windows))
+	for i, window := range req.Windows {
+		windows[i] = models.NewAvailabilityWindow(window.Days, parseMinute(window.Start), parseMinute(window.End))
+	}
+	return windows
+}
+
+// parseMinute converts a time of day in the HH:MM format validated by the binding to minutes since midnight.
+func parseMinute(timeOfDay string) uint {
+	t, _ := time.Parse("15:04", timeOfDay)
+	return uint(t.Hour()*60 + t.Minute())
+}
+
+type SoldOutRequest struct {
+	SoldOut *bool `json:"sold_out" binding:"required"`
+}
+
+type AvailabilityWindowResponse struct {
+	Days  []time.Weekday `json:"days"`
+	Start string         `json:"start"`
+	End   string         `json:"end"`
+}
+
+func ToAvailabilityWindowResponses(windows []models.AvailabilityWindow) []AvailabilityWindowResponse {
+	responses := make([]AvailabilityWindowResponse, len(windows))
+	for i, window := range windows {
+		responses[i] = AvailabilityWindowResponse{
+			Days:  window.Weekdays(),
+			Start: models.FormatMinute(window.StartMinute),
+			End:   models.FormatMinute(window.EndMinute),
+		}
+	}
+	return responses
+}
@@ -0,0 +1,52 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+)
+
+type CategoryRequest struct {
+	Name         string `form:"name" binding:"required,min=1"`
+	DisplayOrder int    `form:"display_order"`
+	Active       *bool  `form:"active"`
+}
+
+func (req *CategoryRequest) ToModel() *models.Category {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	return &models.Category{
+		Name:         req.Name,
+		DisplayOrder: req.DisplayOrder,
+		Active:       active,
+	}
+}
+
+type CategoryResponse struct {
+	ID                  uint                         `json:"id"`
+	Name                string                       `json:"name"`
+	DisplayOrder        int                          `json:"display_order"`
+	IconURL             string                       `json:"icon_url"`
+	Active              bool                         `json:"active"`
+	AvailabilityWindows []AvailabilityWindowResponse `json:"availability_windows"`
+}
+
+func ToCategoryResponse(category *models.Category) *CategoryResponse {
+	return &CategoryResponse{
+		ID:                  category.ID,
+		Name:                category.Name,
+		DisplayOrder:        category.DisplayOrder,
+		IconURL:             category.IconURL,
+		Active:              category.Active,
+		AvailabilityWindows: ToAvailabilityWindowResponses(category.AvailabilityWindows),
+	}
+}
+
+func ToCategoryResponses(categories []*models.Category) []*CategoryResponse {
+	responses := make([]*CategoryResponse, len(categories))
+	for i, category := range categories {
+		responses[i] = ToCategoryResponse(category)
+	}
+	return responses
+}
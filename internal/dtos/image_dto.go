@@ -0,0 +1,95 @@
+package dtos
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/storage"
+)
+
+// ImageVariantResponse is a copy of an image in one of the sizes and formats images are stored in.
+type ImageVariantResponse struct {
+	Size   string `json:"size"`
+	Type   string `json:"type"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+}
+
+// ImageSourceResponse lists the variants of an image in one format as the srcset of a <source> element.
+type ImageSourceResponse struct {
+	Type   string `json:"type"`
+	Srcset string `json:"srcset"`
+}
+
+// ImageResponse is an image with its variants. URL can be shown by clients which do not pick a variant.
+// Sources are ordered by preference, WebP first. Images uploaded before variants were stored have none.
+type ImageResponse struct {
+	URL      string                 `json:"url"`
+	Variants []ImageVariantResponse `json:"variants"`
+	Sources  []ImageSourceResponse  `json:"sources"`
+}
+
+func ToImageResponse(url string, variants models.ImageVariants) ImageResponse {
+	response := ImageResponse{
+		URL:      url,
+		Variants: make([]ImageVariantResponse, len(variants)),
+		Sources:  []ImageSourceResponse{},
+	}
+
+	srcsets := make(map[string][]string)
+	var types []string
+	for i, variant := range variants {
+		response.Variants[i] = ImageVariantResponse{
+			Size:   variant.Size,
+			Type:   variant.ContentType,
+			Width:  variant.Width,
+			Height: variant.Height,
+			URL:    variant.URL,
+		}
+
+		if _, ok := srcsets[variant.ContentType]; !ok {
+			types = append(types, variant.ContentType)
+		}
+		srcsets[variant.ContentType] = append(srcsets[variant.ContentType],
+			fmt.Sprintf("%s %dw", variant.URL, variant.Width))
+	}
+
+	if _, ok := srcsets["image/webp"]; ok {
+		response.Sources = append(response.Sources, ImageSourceResponse{
+			Type:   "image/webp",
+			Srcset: strings.Join(srcsets["image/webp"], ", "),
+		})
+	}
+	for _, contentType := range types {
+		if contentType != "image/webp" {
+			response.Sources = append(response.Sources, ImageSourceResponse{
+				Type:   contentType,
+				Srcset: strings.Join(srcsets[contentType], ", "),
+			})
+		}
+	}
+
+	return response
+}
+
+// OrphanedImageResponse is an image in the image storage which nothing references.
+type OrphanedImageResponse struct {
+	PublicID  string    `json:"public_id"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToOrphanedImageResponses(images []storage.StoredImage) []OrphanedImageResponse {
+	responses := make([]OrphanedImageResponse, len(images))
+	for i, image := range images {
+		responses[i] = OrphanedImageResponse{
+			PublicID:  image.PublicID,
+			URL:       image.URL,
+			CreatedAt: image.CreatedAt,
+		}
+	}
+	return responses
+}
@@ -0,0 +1,97 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+type IngredientRequest struct {
+	Name              string          `json:"name" binding:"required,min=1"`
+	Unit              string          `json:"unit" binding:"required,min=1"`
+	Stock             decimal.Decimal `json:"stock"`
+	LowStockThreshold decimal.Decimal `json:"low_stock_threshold"`
+}
+
+func (req *IngredientRequest) ToModel() *models.Ingredient {
+	return &models.Ingredient{
+		Name:              req.Name,
+		Unit:              req.Unit,
+		Stock:             req.Stock,
+		LowStockThreshold: req.LowStockThreshold,
+	}
+}
+
+type RestockRequest struct {
+	Quantity decimal.Decimal `json:"quantity" binding:"required"`
+}
+
+type IngredientResponse struct {
+	ID                uint            `json:"id"`
+	Name              string          `json:"name"`
+	Unit              string          `json:"unit"`
+	Stock             decimal.Decimal `json:"stock"`
+	LowStockThreshold decimal.Decimal `json:"low_stock_threshold"`
+	LowStock          bool            `json:"low_stock"`
+}
+
+func ToIngredientResponse(ingredient *models.Ingredient) *IngredientResponse {
+	return &IngredientResponse{
+		ID:                ingredient.ID,
+		Name:              ingredient.Name,
+		Unit:              ingredient.Unit,
+		Stock:             ingredient.Stock,
+		LowStockThreshold: ingredient.LowStockThreshold,
+		LowStock:          ingredient.IsLowStock(),
+	}
+}
+
+func ToIngredientResponses(ingredients []*models.Ingredient) []*IngredientResponse {
+	responses := make([]*IngredientResponse, len(ingredients))
+	for i, ingredient := range ingredients {
+		responses[i] = ToIngredientResponse(ingredient)
+	}
+	return responses
+}
+
+type SetRecipeRequest struct {
+	Items []RecipeItemRequest `json:"items" binding:"dive"`
+}
+
+type RecipeItemRequest struct {
+	IngredientID uint            `json:"ingredient_id" binding:"required"`
+	Quantity     decimal.Decimal `json:"quantity" binding:"required"`
+}
+
+func (req *SetRecipeRequest) ToModel() []models.RecipeItem {
+	recipe := make([]models.RecipeItem, len(req.Items))
+	for i, item := range req.Items {
+		recipe[i] = models.RecipeItem{
+			IngredientID: item.IngredientID,
+			Quantity:     item.Quantity,
+		}
+	}
+	return recipe
+}
+
+type RecipeItemResponse struct {
+	IngredientID uint            `json:"ingredient_id"`
+	Ingredient   string          `json:"ingredient"`
+	Unit         string          `json:"unit"`
+	Quantity     decimal.Decimal `json:"quantity"`
+}
+
+func ToRecipeItemResponses(recipe []models.RecipeItem) []RecipeItemResponse {
+	responses := make([]RecipeItemResponse, len(recipe))
+	for i, item := range recipe {
+		responses[i] = RecipeItemResponse{
+			IngredientID: item.IngredientID,
+			Quantity:     item.Quantity,
+		}
+
+		if item.Ingredient != nil {
+			responses[i].Ingredient = item.Ingredient.Name
+			responses[i].Unit = item.Ingredient.Unit
+		}
+	}
+	return responses
+}
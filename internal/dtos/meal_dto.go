@@ -3,42 +3,212 @@ package dtos
 import (
 	"github.com/Ruclo/MyMeals/internal/models"
 	"github.com/shopspring/decimal"
+	"math"
+	"strings"
+	"time"
 )
 
+// CreateMealRequest holds the details of a meal. The photo of the meal is either sent as a file
+// or PhotoID is the ID of a photo uploaded into an upload session beforehand.
 type CreateMealRequest struct {
 	Name        string              `form:"name" binding:"required,min=1"`
-	Category    models.MealCategory `form:"category" binding:"required"`
+	CategoryID  uint                `form:"category_id" binding:"required"`
 	Description string              `form:"description" binding:"required,min=1"`
 	Price       decimal.Decimal     `form:"price" binding:"required"`
+	Allergens   []models.Allergen   `form:"allergens"`
+	DietaryTags []models.DietaryTag `form:"dietary_tags"`
+	SpicyLevel  uint                `form:"spicy_level"`
+	PhotoID     string              `form:"photo_id"`
+}
+
+// PhotoUpload returns the reference to the uploaded photo of the meal in a session of the owner,
+// nil if the photo is sent as a file.
+func (req *CreateMealRequest) PhotoUpload(owner string) *models.UploadRef {
+	if req.PhotoID == "" {
+		return nil
+	}
+	return &models.UploadRef{ID: req.PhotoID, Owner: owner}
 }
 
 func (req *CreateMealRequest) ToModel() *models.Meal {
 	return &models.Meal{
 		Name:        req.Name,
-		Category:    req.Category,
+		CategoryID:  req.CategoryID,
 		Description: req.Description,
 		Price:       req.Price,
+		Allergens:   req.Allergens,
+		DietaryTags: req.DietaryTags,
+		SpicyLevel:  req.SpicyLevel,
+	}
+}
+
+// MealFilterQuery holds the comma separated filters of the menu.
+// Available hides the meals which cannot be ordered right now.
+type MealFilterQuery struct {
+	ExcludeAllergens string `form:"excludeAllergens"`
+	Tags             string `form:"tags"`
+	MaxSpicyLevel    *uint  `form:"maxSpicyLevel"`
+	Available        bool   `form:"available"`
+}
+
+func (q *MealFilterQuery) ToModel() models.MealFilter {
+	filter := models.MealFilter{MaxSpicyLevel: q.MaxSpicyLevel}
+
+	if q.Available {
+		now := time.Now()
+		filter.AvailableAt = &now
+	}
+
+	for _, allergen := range splitList(q.ExcludeAllergens) {
+		filter.ExcludeAllergens = append(filter.ExcludeAllergens, models.Allergen(allergen))
+	}
+
+	for _, tag := range splitList(q.Tags) {
+		filter.Tags = append(filter.Tags, models.DietaryTag(tag))
+	}
+
+	return filter
+}
+
+// splitList splits a comma separated list, ignoring blank items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+type SetOptionGroupsRequest struct {
+	OptionGroups []OptionGroupRequest `json:"option_groups" binding:"dive"`
+}
+
+type OptionGroupRequest struct {
+	Name        string              `json:"name" binding:"required,min=1"`
+	MultiSelect bool                `json:"multi_select"`
+	MinSelected uint                `json:"min_selected"`
+	MaxSelected uint                `json:"max_selected" binding:"required,gte=1"`
+	Options     []MealOptionRequest `json:"options" binding:"required,min=1,dive"`
+}
+
+type MealOptionRequest struct {
+	Name       string          `json:"name" binding:"required,min=1"`
+	PriceDelta decimal.Decimal `json:"price_delta"`
+}
+
+func (req *SetOptionGroupsRequest) ToModel() []models.OptionGroup {
+	optionGroups := make([]models.OptionGroup, len(req.OptionGroups))
+	for i, group := range req.OptionGroups {
+		optionGroups[i] = models.OptionGroup{
+			Name:        group.Name,
+			MultiSelect: group.MultiSelect,
+			MinSelected: group.MinSelected,
+			MaxSelected: group.MaxSelected,
+			Options:     make([]models.MealOption, len(group.Options)),
+		}
+
+		for j, option := range group.Options {
+			optionGroups[i].Options[j] = models.MealOption{
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			}
+		}
 	}
+	return optionGroups
 }
 
 type MealResponse struct {
 	ID          uint                `json:"id"`
 	Name        string              `json:"name"`
-	Category    models.MealCategory `json:"category"`
+	CategoryID  uint                `json:"category_id"`
+	Category    string              `json:"category"`
 	Description string              `json:"description"`
 	ImageURL    string              `json:"image_url"`
+	Image       ImageResponse       `json:"image"`
 	Price       decimal.Decimal     `json:"price"`
+	Allergens   []models.Allergen   `json:"allergens"`
+	DietaryTags []models.DietaryTag `json:"dietary_tags"`
+	SpicyLevel  uint                `json:"spicy_level"`
+	Version     uint                `json:"version"`
+	// SoldOut is set by staff, Available additionally considers the stock of the ingredients
+	// and the availability windows at the time of the response.
+	SoldOut             bool                         `json:"sold_out"`
+	Available           bool                         `json:"available"`
+	AvailabilityWindows []AvailabilityWindowResponse `json:"availability_windows"`
+	Recipe              []RecipeItemResponse         `json:"recipe"`
+	OptionGroups        []OptionGroupResponse        `json:"option_groups"`
+	// Rating is the average rating of the meal in reviews, null if it has not been rated yet.
+	Rating      *float64 `json:"rating"`
+	RatingCount uint     `json:"rating_count"`
+}
+
+type OptionGroupResponse struct {
+	ID          uint                 `json:"id"`
+	Name        string               `json:"name"`
+	MultiSelect bool                 `json:"multi_select"`
+	MinSelected uint                 `json:"min_selected"`
+	MaxSelected uint                 `json:"max_selected"`
+	Options     []MealOptionResponse `json:"options"`
+}
+
+type MealOptionResponse struct {
+	ID         uint            `json:"id"`
+	Name       string          `json:"name"`
+	PriceDelta decimal.Decimal `json:"price_delta"`
 }
 
 func ToMealResponse(meal *models.Meal) *MealResponse {
-	return &MealResponse{
-		ID:          meal.ID,
-		Name:        meal.Name,
-		Category:    meal.Category,
-		Description: meal.Description,
-		ImageURL:    meal.ImageURL,
-		Price:       meal.Price,
+	mealResponse := &MealResponse{
+		ID:                  meal.ID,
+		Name:                meal.Name,
+		CategoryID:          meal.CategoryID,
+		Category:            meal.CategoryName(),
+		Description:         meal.Description,
+		ImageURL:            meal.ImageURL,
+		Image:               ToImageResponse(meal.ImageURL, meal.ImageVariants),
+		Price:               meal.Price,
+		Allergens:           make([]models.Allergen, len(meal.Allergens)),
+		DietaryTags:         make([]models.DietaryTag, len(meal.DietaryTags)),
+		SpicyLevel:          meal.SpicyLevel,
+		Version:             meal.Version,
+		SoldOut:             meal.SoldOut,
+		Available:           meal.AvailableAt(time.Now()),
+		AvailabilityWindows: ToAvailabilityWindowResponses(meal.AvailabilityWindows),
+		Recipe:              ToRecipeItemResponses(meal.Recipe),
+		OptionGroups:        make([]OptionGroupResponse, len(meal.OptionGroups)),
 	}
+
+	copy(mealResponse.Allergens, meal.Allergens)
+	copy(mealResponse.DietaryTags, meal.DietaryTags)
+
+	if meal.RatingCount > 0 {
+		rating := math.Round(meal.AverageRating*100) / 100
+		mealResponse.Rating = &rating
+		mealResponse.RatingCount = meal.RatingCount
+	}
+
+	for i, group := range meal.OptionGroups {
+		mealResponse.OptionGroups[i] = OptionGroupResponse{
+			ID:          group.ID,
+			Name:        group.Name,
+			MultiSelect: group.MultiSelect,
+			MinSelected: group.MinSelected,
+			MaxSelected: group.MaxSelected,
+			Options:     make([]MealOptionResponse, len(group.Options)),
+		}
+
+		for j, option := range group.Options {
+			mealResponse.OptionGroups[i].Options[j] = MealOptionResponse{
+				ID:         option.ID,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			}
+		}
+	}
+
+	return mealResponse
 }
 
 func ToMealResponses(meals []*models.Meal) []*MealResponse {
@@ -48,3 +218,62 @@ func ToMealResponses(meals []*models.Meal) []*MealResponse {
 	}
 	return result
 }
+
+type MealVersionResponse struct {
+	Version     uint                `json:"version"`
+	Name        string              `json:"name"`
+	CategoryID  uint                `json:"category_id"`
+	Description string              `json:"description"`
+	ImageURL    string              `json:"image_url"`
+	Price       decimal.Decimal     `json:"price"`
+	Allergens   []models.Allergen   `json:"allergens"`
+	DietaryTags []models.DietaryTag `json:"dietary_tags"`
+	SpicyLevel  uint                `json:"spicy_level"`
+	CreatedAt   time.Time           `json:"created_at"`
+	// Changes lists the fields changed by the version compared to the previous version.
+	Changes []MealChangeResponse `json:"changes"`
+}
+
+type MealChangeResponse struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// ToMealVersionResponses converts the version history of a meal, ordered by version, into responses
+// with the changes of every version to its previous version.
+func ToMealVersionResponses(versions []*models.MealVersion) []*MealVersionResponse {
+	responses := make([]*MealVersionResponse, len(versions))
+	for i, version := range versions {
+		var previous *models.MealVersion
+		if i > 0 {
+			previous = versions[i-1]
+		}
+
+		responses[i] = &MealVersionResponse{
+			Version:     version.Version,
+			Name:        version.Name,
+			CategoryID:  version.CategoryID,
+			Description: version.Description,
+			ImageURL:    version.ImageURL,
+			Price:       version.Price,
+			Allergens:   make([]models.Allergen, len(version.Allergens)),
+			DietaryTags: make([]models.DietaryTag, len(version.DietaryTags)),
+			SpicyLevel:  version.SpicyLevel,
+			CreatedAt:   version.CreatedAt,
+			Changes:     []MealChangeResponse{},
+		}
+
+		copy(responses[i].Allergens, version.Allergens)
+		copy(responses[i].DietaryTags, version.DietaryTags)
+
+		for _, change := range version.Diff(previous) {
+			responses[i].Changes = append(responses[i].Changes, MealChangeResponse{
+				Field: change.Field,
+				From:  change.From,
+				To:    change.To,
+			})
+		}
+	}
+	return responses
+}
@@ -0,0 +1,145 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// dateLayout is the format of the dates seasonal menus start and end on.
+const dateLayout = "2006-01-02"
+
+// MenuRequest holds the details of a menu. StartDate and EndDate are optional inclusive dates in the YYYY-MM-DD format.
+type MenuRequest struct {
+	Name      string `json:"name" binding:"required,min=1"`
+	Priority  int    `json:"priority"`
+	Active    *bool  `json:"active"`
+	StartDate string `json:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" binding:"omitempty,datetime=2006-01-02"`
+}
+
+func (req *MenuRequest) ToModel() *models.Menu {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	return &models.Menu{
+		Name:      req.Name,
+		Priority:  req.Priority,
+		Active:    active,
+		StartDate: parseDate(req.StartDate),
+		EndDate:   parseDate(req.EndDate),
+	}
+}
+
+// parseDate converts a date in the YYYY-MM-DD format validated by the binding to a time, or nil if it is empty.
+func parseDate(date string) *time.Time {
+	if date == "" {
+		return nil
+	}
+
+	t, _ := time.Parse(dateLayout, date)
+	return &t
+}
+
+// formatDate formats a date in the YYYY-MM-DD format, or returns nil if there is no date.
+func formatDate(date *time.Time) *string {
+	if date == nil {
+		return nil
+	}
+
+	formatted := date.Format(dateLayout)
+	return &formatted
+}
+
+type SetMenuItemsRequest struct {
+	Items []MenuItemRequest `json:"items" binding:"dive"`
+}
+
+type MenuItemRequest struct {
+	MealID       uint            `json:"meal_id" binding:"required"`
+	Price        decimal.Decimal `json:"price" binding:"required"`
+	DisplayOrder int             `json:"display_order"`
+}
+
+func (req *SetMenuItemsRequest) ToModel() []models.MenuItem {
+	items := make([]models.MenuItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.MenuItem{
+			MealID:       item.MealID,
+			Price:        item.Price,
+			DisplayOrder: item.DisplayOrder,
+		}
+	}
+	return items
+}
+
+type MenuResponse struct {
+	ID        uint                         `json:"id"`
+	Name      string                       `json:"name"`
+	Priority  int                          `json:"priority"`
+	Active    bool                         `json:"active"`
+	StartDate *string                      `json:"start_date"`
+	EndDate   *string                      `json:"end_date"`
+	Items     []MenuItemResponse           `json:"items"`
+	Schedules []AvailabilityWindowResponse `json:"schedules"`
+}
+
+type MenuItemResponse struct {
+	MealID       uint            `json:"meal_id"`
+	Price        decimal.Decimal `json:"price"`
+	DisplayOrder int             `json:"display_order"`
+}
+
+func ToMenuResponse(menu *models.Menu) *MenuResponse {
+	response := &MenuResponse{
+		ID:        menu.ID,
+		Name:      menu.Name,
+		Priority:  menu.Priority,
+		Active:    menu.Active,
+		StartDate: formatDate(menu.StartDate),
+		EndDate:   formatDate(menu.EndDate),
+		Items:     make([]MenuItemResponse, len(menu.Items)),
+		Schedules: ToAvailabilityWindowResponses(menu.Schedules),
+	}
+
+	for i, item := range menu.Items {
+		response.Items[i] = MenuItemResponse{
+			MealID:       item.MealID,
+			Price:        item.Price,
+			DisplayOrder: item.DisplayOrder,
+		}
+	}
+
+	return response
+}
+
+func ToMenuResponses(menus []*models.Menu) []*MenuResponse {
+	responses := make([]*MenuResponse, len(menus))
+	for i, menu := range menus {
+		responses[i] = ToMenuResponse(menu)
+	}
+	return responses
+}
+
+// MenuPreviewResponse is the menu active at the previewed time along with its meals.
+// Menu is null if no menu is active, the meals are all meals at their regular prices then.
+type MenuPreviewResponse struct {
+	At    time.Time       `json:"at"`
+	Menu  *MenuResponse   `json:"menu"`
+	Meals []*MealResponse `json:"meals"`
+}
+
+func ToMenuPreviewResponse(at time.Time, menu *models.Menu, meals []*models.Meal) *MenuPreviewResponse {
+	response := &MenuPreviewResponse{
+		At:    at,
+		Meals: ToMealResponses(meals),
+	}
+
+	if menu != nil {
+		response.Menu = ToMenuResponse(menu)
+	}
+
+	return response
+}
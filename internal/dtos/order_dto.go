@@ -2,61 +2,192 @@ package dtos
 
 import (
 	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+	"strings"
 	"time"
 )
 
 type CreateOrderRequest struct {
-	TableNo int                `json:"table_no" binding:"required,gte=1"`
-	Notes   string             `json:"notes"`
-	Items   []OrderMealRequest `json:"items" binding:"required"`
+	TableToken string             `json:"table_token" binding:"required"`
+	Notes      string             `json:"notes"`
+	PromoCode  string             `json:"promo_code" binding:"max=50"`
+	Tip        *TipRequest        `json:"tip"`
+	Items      []OrderMealRequest `json:"items" binding:"required"`
+}
+
+// TipRequest is the tip the customer adds to the bill of an order, either a fixed amount or a percentage of the bill.
+type TipRequest struct {
+	Amount  decimal.Decimal `json:"amount"`
+	Percent decimal.Decimal `json:"percent"`
 }
 
 type OrderMealRequest struct {
-	MealID   uint `json:"meal_id" binding:"required"`
-	Quantity uint `json:"quantity" binding:"required,gte=1"`
+	MealID    uint              `json:"meal_id" binding:"required"`
+	Quantity  uint              `json:"quantity" binding:"required,gte=1"`
+	OptionIDs []uint            `json:"option_ids"`
+	Note      string            `json:"note" binding:"max=500"`
+	Allergens []models.Allergen `json:"allergens"`
+}
+
+// ToModel converts the request to an order meal with the chosen options, note and allergies.
+func (req *OrderMealRequest) ToModel() models.OrderMeal {
+	orderMeal := models.OrderMeal{
+		MealID:    req.MealID,
+		Quantity:  req.Quantity,
+		Note:      strings.TrimSpace(req.Note),
+		Allergens: req.Allergens,
+		Options:   make([]models.OrderMealOption, len(req.OptionIDs)),
+	}
+
+	for i, optionID := range req.OptionIDs {
+		orderMeal.Options[i] = models.OrderMealOption{MealOptionID: optionID}
+	}
+
+	return orderMeal
 }
 
-func (req *CreateOrderRequest) ToModel() *models.Order {
+// ToModel converts the request to an order for the table the table token was issued for.
+func (req *CreateOrderRequest) ToModel(tableNo int) *models.Order {
 	order := &models.Order{
-		TableNo:    req.TableNo,
+		TableNo:    tableNo,
 		Notes:      req.Notes,
+		PromoCode:  req.PromoCode,
 		OrderMeals: make([]models.OrderMeal, len(req.Items)),
 	}
 
+	if req.Tip != nil {
+		order.TipAmount = req.Tip.Amount
+		order.TipPercent = req.Tip.Percent
+	}
+
 	for i, mealDTO := range req.Items {
-		order.OrderMeals[i] = models.OrderMeal{
-			MealID:    mealDTO.MealID,
-			Quantity:  mealDTO.Quantity,
-			Completed: 0, // Initialize as 0
-		}
+		order.OrderMeals[i] = mealDTO.ToModel()
 	}
 
 	return order
 }
 
 type OrderResponse struct {
-	ID        uint                `json:"id"`
-	TableNo   int                 `json:"table_no"`
-	Notes     string              `json:"notes"`
-	CreatedAt time.Time           `json:"created_at"`
-	Items     []OrderMealResponse `json:"items"`
-	Review    *ReviewResponse     `json:"review,omitempty"`
+	ID           uint                `json:"id"`
+	TableNo      int                 `json:"table_no"`
+	Notes        string              `json:"notes"`
+	PromoCode    string              `json:"promo_code,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	CancelledAt  *time.Time          `json:"cancelled_at,omitempty"`
+	PaidAt       *time.Time          `json:"paid_at,omitempty"`
+	HasAllergies bool                `json:"has_allergies"`
+	Items        []OrderMealResponse `json:"items"`
+	Bill         *BillResponse       `json:"bill"`
+	Review       *ReviewResponse     `json:"review,omitempty"`
+}
+
+type BillResponse struct {
+	Subtotal      decimal.Decimal        `json:"subtotal"`
+	Discounts     []DiscountLineResponse `json:"discounts"`
+	ServiceCharge decimal.Decimal        `json:"service_charge"`
+	TaxLines      []TaxLineResponse      `json:"tax_lines"`
+	Tip           decimal.Decimal        `json:"tip"`
+	Total         decimal.Decimal        `json:"total"`
+}
+
+type DiscountLineResponse struct {
+	Name   string          `json:"name"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+type TaxLineResponse struct {
+	Rate   decimal.Decimal `json:"rate"`
+	Base   decimal.Decimal `json:"base"`
+	Amount decimal.Decimal `json:"amount"`
 }
 
 type OrderMealResponse struct {
-	MealID    uint `json:"meal_id"`
-	Quantity  uint `json:"quantity"`
-	Completed uint `json:"completed"`
+	ID            uint                      `json:"id"`
+	MealID        uint                      `json:"meal_id"`
+	MealVersion   uint                      `json:"meal_version"`
+	MealName      string                    `json:"meal_name"`
+	Options       []OrderMealOptionResponse `json:"options"`
+	Note          string                    `json:"note"`
+	Allergens     []models.Allergen         `json:"allergens"`
+	UnitPrice     decimal.Decimal           `json:"unit_price"`
+	LineTotal     decimal.Decimal           `json:"line_total"`
+	Quantity      uint                      `json:"quantity"`
+	Completed     uint                      `json:"completed"`
+	Pending       uint                      `json:"pending"`
+	Accepted      uint                      `json:"accepted"`
+	Cooking       uint                      `json:"cooking"`
+	Ready         uint                      `json:"ready"`
+	Served        uint                      `json:"served"`
+	Cancelled     uint                      `json:"cancelled"`
+	Voided        uint                      `json:"voided"`
+	StatusChanges []StatusChangeResponse    `json:"status_changes"`
+	Voids         []VoidResponse            `json:"voids"`
+}
+
+type OrderMealOptionResponse struct {
+	OptionID   uint            `json:"option_id"`
+	Group      string          `json:"group"`
+	Name       string          `json:"name"`
+	PriceDelta decimal.Decimal `json:"price_delta"`
+}
+
+type UpdateStatusRequest struct {
+	Status   models.OrderMealStatus `json:"status" binding:"required"`
+	From     models.OrderMealStatus `json:"from"`
+	Quantity uint                   `json:"quantity"`
+}
+
+func (req *UpdateStatusRequest) ToModel() *models.OrderMealStatusChange {
+	return &models.OrderMealStatusChange{
+		FromStatus: req.From,
+		ToStatus:   req.Status,
+		Quantity:   req.Quantity,
+	}
+}
+
+type VoidRequest struct {
+	From     models.OrderMealStatus `json:"from"`
+	Quantity uint                   `json:"quantity"`
+	Reason   string                 `json:"reason" binding:"required"`
+}
+
+func (req *VoidRequest) ToModel() *models.OrderMealVoid {
+	return &models.OrderMealVoid{
+		FromStatus: req.From,
+		Quantity:   req.Quantity,
+		Reason:     req.Reason,
+	}
+}
+
+type VoidResponse struct {
+	From     models.OrderMealStatus `json:"from"`
+	Quantity uint                   `json:"quantity"`
+	Reason   string                 `json:"reason"`
+	VoidedBy string                 `json:"voided_by"`
+	VoidedAt time.Time              `json:"voided_at"`
+}
+
+type StatusChangeResponse struct {
+	From      models.OrderMealStatus `json:"from"`
+	To        models.OrderMealStatus `json:"to"`
+	Quantity  uint                   `json:"quantity"`
+	ChangedBy string                 `json:"changed_by"`
+	ChangedAt time.Time              `json:"changed_at"`
 }
 
 func ToOrderResponse(order *models.Order) *OrderResponse {
 	orderResponse := &OrderResponse{
-		ID:        order.ID,
-		TableNo:   order.TableNo,
-		Notes:     order.Notes,
-		CreatedAt: order.CreatedAt,
-		Items:     make([]OrderMealResponse, len(order.OrderMeals)),
-		Review:    ModelToReviewResponse(order.Review),
+		ID:           order.ID,
+		TableNo:      order.TableNo,
+		Notes:        order.Notes,
+		PromoCode:    order.PromoCode,
+		CreatedAt:    order.CreatedAt,
+		CancelledAt:  order.CancelledAt,
+		PaidAt:       order.PaidAt,
+		HasAllergies: order.HasAllergies(),
+		Items:        make([]OrderMealResponse, len(order.OrderMeals)),
+		Bill:         ToBillResponse(order.Bill()),
+		Review:       ModelToReviewResponse(order.Review),
 	}
 
 	for i, orderMeal := range order.OrderMeals {
@@ -67,11 +198,89 @@ func ToOrderResponse(order *models.Order) *OrderResponse {
 }
 
 func ToOrderMealResponse(orderMeal *models.OrderMeal) *OrderMealResponse {
-	return &OrderMealResponse{
-		MealID:    orderMeal.MealID,
-		Quantity:  orderMeal.Quantity,
-		Completed: orderMeal.Completed,
+	orderMealResponse := &OrderMealResponse{
+		ID:            orderMeal.ID,
+		MealID:        orderMeal.MealID,
+		MealVersion:   orderMeal.MealVersion,
+		MealName:      orderMeal.MealName,
+		Options:       make([]OrderMealOptionResponse, len(orderMeal.Options)),
+		Note:          orderMeal.Note,
+		Allergens:     make([]models.Allergen, len(orderMeal.Allergens)),
+		UnitPrice:     orderMeal.UnitPrice,
+		LineTotal:     orderMeal.LineTotal(),
+		Quantity:      orderMeal.Quantity,
+		Completed:     orderMeal.Completed,
+		Pending:       orderMeal.CountIn(models.PendingStatus),
+		Accepted:      orderMeal.CountIn(models.AcceptedStatus),
+		Cooking:       orderMeal.CountIn(models.CookingStatus),
+		Ready:         orderMeal.CountIn(models.ReadyStatus),
+		Served:        orderMeal.CountIn(models.ServedStatus),
+		Cancelled:     orderMeal.CountIn(models.CancelledStatus),
+		Voided:        orderMeal.CountIn(models.VoidedStatus),
+		StatusChanges: make([]StatusChangeResponse, len(orderMeal.StatusChanges)),
+		Voids:         make([]VoidResponse, len(orderMeal.Voids)),
+	}
+
+	copy(orderMealResponse.Allergens, orderMeal.Allergens)
+
+	for i, option := range orderMeal.Options {
+		orderMealResponse.Options[i] = OrderMealOptionResponse{
+			OptionID:   option.MealOptionID,
+			Group:      option.GroupName,
+			Name:       option.Name,
+			PriceDelta: option.PriceDelta,
+		}
 	}
+
+	for i, statusChange := range orderMeal.StatusChanges {
+		orderMealResponse.StatusChanges[i] = StatusChangeResponse{
+			From:      statusChange.FromStatus,
+			To:        statusChange.ToStatus,
+			Quantity:  statusChange.Quantity,
+			ChangedBy: statusChange.ChangedBy,
+			ChangedAt: statusChange.ChangedAt,
+		}
+	}
+
+	for i, void := range orderMeal.Voids {
+		orderMealResponse.Voids[i] = VoidResponse{
+			From:     void.FromStatus,
+			Quantity: void.Quantity,
+			Reason:   void.Reason,
+			VoidedBy: void.VoidedBy,
+			VoidedAt: void.VoidedAt,
+		}
+	}
+
+	return orderMealResponse
+}
+
+func ToBillResponse(bill *models.Bill) *BillResponse {
+	billResponse := &BillResponse{
+		Subtotal:      bill.Subtotal,
+		Discounts:     make([]DiscountLineResponse, len(bill.Discounts)),
+		ServiceCharge: bill.ServiceCharge,
+		TaxLines:      make([]TaxLineResponse, len(bill.TaxLines)),
+		Tip:           bill.Tip,
+		Total:         bill.Total,
+	}
+
+	for i, discountLine := range bill.Discounts {
+		billResponse.Discounts[i] = DiscountLineResponse{
+			Name:   discountLine.Name,
+			Amount: discountLine.Amount,
+		}
+	}
+
+	for i, taxLine := range bill.TaxLines {
+		billResponse.TaxLines[i] = TaxLineResponse{
+			Rate:   taxLine.Rate,
+			Base:   taxLine.Base,
+			Amount: taxLine.Amount,
+		}
+	}
+
+	return billResponse
 }
 
 func ToOrderReponseList(orders []*models.Order) []*OrderResponse {
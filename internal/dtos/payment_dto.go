@@ -0,0 +1,132 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type PaymentRequest struct {
+	Method    models.PaymentMethod `json:"method" binding:"required"`
+	Amount    decimal.Decimal      `json:"amount" binding:"required"`
+	Tip       decimal.Decimal      `json:"tip"`
+	Payer     string               `json:"payer"`
+	Reference string               `json:"reference"`
+}
+
+func (req *PaymentRequest) ToModel() *models.Payment {
+	return &models.Payment{
+		Method:    req.Method,
+		Amount:    req.Amount,
+		Tip:       req.Tip,
+		Payer:     req.Payer,
+		Reference: req.Reference,
+	}
+}
+
+type PaymentResponse struct {
+	ID            uint                 `json:"id"`
+	Method        models.PaymentMethod `json:"method"`
+	Amount        decimal.Decimal      `json:"amount"`
+	Tip           decimal.Decimal      `json:"tip"`
+	Payer         string               `json:"payer"`
+	Reference     string               `json:"reference"`
+	TransactionID string               `json:"transaction_id"`
+	RecordedBy    string               `json:"recorded_by"`
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+type BalanceResponse struct {
+	Total       decimal.Decimal `json:"total"`
+	Paid        decimal.Decimal `json:"paid"`
+	Outstanding decimal.Decimal `json:"outstanding"`
+}
+
+type OrderPaymentsResponse struct {
+	Payments []*PaymentResponse `json:"payments"`
+	Balance  *BalanceResponse   `json:"balance"`
+}
+
+type SplitEvenlyRequest struct {
+	Payers uint `json:"payers" binding:"required,gte=1"`
+}
+
+type SplitByLinesRequest struct {
+	Payers []PayerLinesRequest `json:"payers" binding:"required,min=1,dive"`
+}
+
+type PayerLinesRequest struct {
+	Payer string             `json:"payer" binding:"required"`
+	Items []LineShareRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type LineShareRequest struct {
+	OrderMealID uint `json:"order_meal_id" binding:"required"`
+	Quantity    uint `json:"quantity" binding:"required,gte=1"`
+}
+
+func (req *SplitByLinesRequest) ToModel() []models.PayerLines {
+	assignments := make([]models.PayerLines, len(req.Payers))
+	for i, payer := range req.Payers {
+		assignments[i] = models.PayerLines{
+			Payer: payer.Payer,
+			Lines: make([]models.LineShare, len(payer.Items)),
+		}
+		for j, item := range payer.Items {
+			assignments[i].Lines[j] = models.LineShare{
+				OrderMealID: item.OrderMealID,
+				Quantity:    item.Quantity,
+			}
+		}
+	}
+	return assignments
+}
+
+type SplitShareResponse struct {
+	Payer  string          `json:"payer"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+func ToPaymentResponse(payment *models.Payment) *PaymentResponse {
+	return &PaymentResponse{
+		ID:            payment.ID,
+		Method:        payment.Method,
+		Amount:        payment.Amount,
+		Tip:           payment.Tip,
+		Payer:         payment.Payer,
+		Reference:     payment.Reference,
+		TransactionID: payment.TransactionID,
+		RecordedBy:    payment.RecordedBy,
+		CreatedAt:     payment.CreatedAt,
+	}
+}
+
+func ToBalanceResponse(balance *models.Balance) *BalanceResponse {
+	return &BalanceResponse{
+		Total:       balance.Total,
+		Paid:        balance.Paid,
+		Outstanding: balance.Outstanding,
+	}
+}
+
+func ToOrderPaymentsResponse(payments []*models.Payment, balance *models.Balance) *OrderPaymentsResponse {
+	response := &OrderPaymentsResponse{
+		Payments: make([]*PaymentResponse, len(payments)),
+		Balance:  ToBalanceResponse(balance),
+	}
+	for i, payment := range payments {
+		response.Payments[i] = ToPaymentResponse(payment)
+	}
+	return response
+}
+
+func ToSplitShareResponses(shares []models.SplitShare) []SplitShareResponse {
+	responses := make([]SplitShareResponse, len(shares))
+	for i, share := range shares {
+		responses[i] = SplitShareResponse{
+			Payer:  share.Payer,
+			Amount: share.Amount,
+		}
+	}
+	return responses
+}
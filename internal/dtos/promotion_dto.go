@@ -0,0 +1,108 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// PromotionRequest holds the settings of a promotion. Which settings are required depends on the kind:
+// percent for percentage_off, amount for fixed_off and combo, buy_quantity and free_quantity for buy_x_get_y
+// and combo_meal_ids for combo. Windows limit the times the promotion applies at, it applies at any time without them.
+type PromotionRequest struct {
+	Name         string                      `json:"name" binding:"required,min=1"`
+	Kind         models.PromotionKind        `json:"kind" binding:"required"`
+	Active       *bool                       `json:"active"`
+	Priority     int                         `json:"priority"`
+	CategoryID   *uint                       `json:"category_id"`
+	Percent      decimal.Decimal             `json:"percent"`
+	Amount       decimal.Decimal             `json:"amount"`
+	BuyQuantity  uint                        `json:"buy_quantity"`
+	FreeQuantity uint                        `json:"free_quantity"`
+	ComboMealIDs []uint                      `json:"combo_meal_ids"`
+	Code         *string                     `json:"code" binding:"omitempty,max=50"`
+	UsageLimit   *uint                       `json:"usage_limit" binding:"omitempty,gte=1"`
+	Windows      []AvailabilityWindowRequest `json:"windows" binding:"dive"`
+}
+
+func (req *PromotionRequest) ToModel() *models.Promotion {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	promotion := &models.Promotion{
+		Name:         req.Name,
+		Kind:         req.Kind,
+		Active:       active,
+		Priority:     req.Priority,
+		CategoryID:   req.CategoryID,
+		Percent:      req.Percent,
+		Amount:       req.Amount,
+		BuyQuantity:  req.BuyQuantity,
+		FreeQuantity: req.FreeQuantity,
+		Code:         req.Code,
+		UsageLimit:   req.UsageLimit,
+		ComboMeals:   make([]models.PromotionComboMeal, len(req.ComboMealIDs)),
+	}
+
+	for i, mealID := range req.ComboMealIDs {
+		promotion.ComboMeals[i] = models.PromotionComboMeal{MealID: mealID}
+	}
+
+	windows := SetAvailabilityWindowsRequest{Windows: req.Windows}
+	promotion.Windows = windows.ToModel()
+
+	return promotion
+}
+
+type PromotionResponse struct {
+	ID           uint                         `json:"id"`
+	Name         string                       `json:"name"`
+	Kind         models.PromotionKind         `json:"kind"`
+	Active       bool                         `json:"active"`
+	Priority     int                          `json:"priority"`
+	CategoryID   *uint                        `json:"category_id"`
+	Percent      decimal.Decimal              `json:"percent"`
+	Amount       decimal.Decimal              `json:"amount"`
+	BuyQuantity  uint                         `json:"buy_quantity"`
+	FreeQuantity uint                         `json:"free_quantity"`
+	ComboMealIDs []uint                       `json:"combo_meal_ids"`
+	Code         *string                      `json:"code"`
+	UsageLimit   *uint                        `json:"usage_limit"`
+	UsageCount   uint                         `json:"usage_count"`
+	Windows      []AvailabilityWindowResponse `json:"windows"`
+}
+
+func ToPromotionResponse(promotion *models.Promotion) *PromotionResponse {
+	response := &PromotionResponse{
+		ID:           promotion.ID,
+		Name:         promotion.Name,
+		Kind:         promotion.Kind,
+		Active:       promotion.Active,
+		Priority:     promotion.Priority,
+		CategoryID:   promotion.CategoryID,
+		Percent:      promotion.Percent,
+		Amount:       promotion.Amount,
+		BuyQuantity:  promotion.BuyQuantity,
+		FreeQuantity: promotion.FreeQuantity,
+		ComboMealIDs: make([]uint, len(promotion.ComboMeals)),
+		Code:         promotion.Code,
+		UsageLimit:   promotion.UsageLimit,
+		UsageCount:   promotion.UsageCount,
+		Windows:      ToAvailabilityWindowResponses(promotion.Windows),
+	}
+
+	for i, comboMeal := range promotion.ComboMeals {
+		response.ComboMealIDs[i] = comboMeal.MealID
+	}
+
+	return response
+}
+
+func ToPromotionResponses(promotions []*models.Promotion) []*PromotionResponse {
+	responses := make([]*PromotionResponse, len(promotions))
+	for i, promotion := range promotions {
+		responses[i] = ToPromotionResponse(promotion)
+	}
+	return responses
+}
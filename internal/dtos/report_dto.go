@@ -0,0 +1,94 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// DateRangeQuery is a range of whole days in the YYYY-MM-DD format, both days are included.
+// From defaults to today and To defaults to From.
+type DateRangeQuery struct {
+	From string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// ToRange converts the days to a time range in the time zone of the restaurant,
+// from the start of the first day until the end of the last day.
+func (q *DateRangeQuery) ToRange() (time.Time, time.Time) {
+	now := time.Now().In(models.TimeZone)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, models.TimeZone)
+	if q.From != "" {
+		from, _ = time.ParseInLocation(dateLayout, q.From, models.TimeZone)
+	}
+
+	to := from
+	if q.To != "" {
+		to, _ = time.ParseInLocation(dateLayout, q.To, models.TimeZone)
+	}
+
+	return from, to.AddDate(0, 0, 1)
+}
+
+type TipReportResponse struct {
+	From   string              `json:"from"`
+	To     string              `json:"to"`
+	Total  decimal.Decimal     `json:"total"`
+	Shifts []ShiftTipsResponse `json:"shifts"`
+	Staff  []StaffTipsResponse `json:"staff"`
+}
+
+type ShiftTipsResponse struct {
+	Date  string              `json:"date"`
+	Shift string              `json:"shift"`
+	Start string              `json:"start"`
+	End   string              `json:"end"`
+	Total decimal.Decimal     `json:"total"`
+	Staff []StaffTipsResponse `json:"staff"`
+}
+
+type StaffTipsResponse struct {
+	Username string          `json:"username"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+// ToTipReportResponse converts the report to a response, To is the last day included in the report.
+func ToTipReportResponse(report *models.TipReport) *TipReportResponse {
+	response := &TipReportResponse{
+		From:   report.From.Format(dateLayout),
+		To:     report.To.AddDate(0, 0, -1).Format(dateLayout),
+		Total:  report.Total,
+		Shifts: make([]ShiftTipsResponse, len(report.Shifts)),
+		Staff:  ToStaffTipsResponses(report.Staff),
+	}
+
+	for i, shiftTips := range report.Shifts {
+		response.Shifts[i] = ShiftTipsResponse{
+			Date:  shiftTips.Date.Format(dateLayout),
+			Shift: shiftTips.Shift.Name,
+			Start: models.FormatMinute(shiftTips.Shift.StartMinute),
+			End:   models.FormatMinute(shiftTips.Shift.EndMinute),
+			Total: shiftTips.Total,
+			Staff: ToStaffTipsResponses(shiftTips.Staff),
+		}
+	}
+
+	return response
+}
+
+func ToStaffTipsResponses(staffTips []models.StaffTips) []StaffTipsResponse {
+	responses := make([]StaffTipsResponse, len(staffTips))
+	for i, tips := range staffTips {
+		responses[i] = StaffTipsResponse{
+			Username: tips.Username,
+			Amount:   tips.Amount,
+		}
+	}
+	return responses
+}
+
+// ExportQuery is the date range of an export along with the format of the exported file, which defaults to csv.
+type ExportQuery struct {
+	DateRangeQuery
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
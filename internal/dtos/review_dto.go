@@ -3,35 +3,163 @@ package dtos
 import (
 	"github.com/Ruclo/MyMeals/internal/models"
 	"github.com/lib/pq"
+	"math"
+	"time"
 )
 
+// ReviewRequest is the review of an order. Multipart forms send every meal rating as a JSON object
+// in a separate meal_ratings field. PhotoIDs are the IDs of photos uploaded into upload sessions beforehand.
 type ReviewRequest struct {
-	Rating  int     `json:"rating" form:"rating" binding:"required"`
-	Comment *string `json:"comment" form:"comment"`
+	Rating      int                       `json:"rating" form:"rating" binding:"required"`
+	Comment     *string                   `json:"comment" form:"comment"`
+	MealRatings []ReviewMealRatingRequest `json:"meal_ratings" form:"meal_ratings" binding:"dive"`
+	PhotoIDs    []string                  `json:"photo_ids" form:"photo_ids"`
+}
+
+type ReviewMealRatingRequest struct {
+	OrderMealID uint    `json:"order_meal_id" binding:"required"`
+	Rating      int     `json:"rating" binding:"required,min=1,max=5"`
+	Comment     *string `json:"comment"`
 }
 
 func (r *ReviewRequest) ToModel() *models.Review {
+	mealRatings := make([]models.ReviewMealRating, len(r.MealRatings))
+	for i, mealRating := range r.MealRatings {
+		mealRatings[i] = models.ReviewMealRating{
+			OrderMealID: mealRating.OrderMealID,
+			Rating:      mealRating.Rating,
+			Comment:     mealRating.Comment,
+		}
+	}
+
 	return &models.Review{
-		Rating:  r.Rating,
-		Comment: r.Comment,
+		Rating:      r.Rating,
+		Comment:     r.Comment,
+		MealRatings: mealRatings,
+	}
+}
+
+type ReviewStatusRequest struct {
+	Status models.ReviewStatus `json:"status" binding:"required,oneof=published flagged hidden"`
+}
+
+type ReviewReplyRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+func (r *ReviewReplyRequest) ToModel(reviewID uint, repliedBy string) *models.ReviewReply {
+	return &models.ReviewReply{
+		ReviewID:  reviewID,
+		Message:   r.Message,
+		RepliedBy: repliedBy,
 	}
 }
 
+type ReviewReplyResponse struct {
+	ID        uint      `json:"id"`
+	Message   string    `json:"message"`
+	RepliedBy string    `json:"replied_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReviewMealRatingResponse struct {
+	OrderMealID uint    `json:"order_meal_id"`
+	MealID      uint    `json:"meal_id"`
+	Rating      int     `json:"rating"`
+	Comment     *string `json:"comment"`
+}
+
 type ReviewResponse struct {
-	ID        uint           `json:"id"`
-	Rating    int            `json:"rating"`
-	Comment   *string        `json:"comment"`
-	PhotoURLs pq.StringArray `json:"photo_urls"`
+	ID          uint                        `json:"id"`
+	Rating      int                         `json:"rating"`
+	Comment     *string                     `json:"comment"`
+	PhotoURLs   pq.StringArray              `json:"photo_urls"`
+	Photos      []ImageResponse             `json:"photos"`
+	Status      models.ReviewStatus         `json:"status"`
+	CreatedAt   time.Time                   `json:"created_at"`
+	Replies     []*ReviewReplyResponse      `json:"replies"`
+	MealRatings []*ReviewMealRatingResponse `json:"meal_ratings"`
 }
 
 func ModelToReviewResponse(review *models.Review) *ReviewResponse {
 	if review == nil {
 		return nil
 	}
+
+	replies := make([]*ReviewReplyResponse, len(review.Replies))
+	for i, reply := range review.Replies {
+		replies[i] = &ReviewReplyResponse{
+			ID:        reply.ID,
+			Message:   reply.Message,
+			RepliedBy: reply.RepliedBy,
+			CreatedAt: reply.CreatedAt,
+		}
+	}
+
+	mealRatings := make([]*ReviewMealRatingResponse, len(review.MealRatings))
+	for i, mealRating := range review.MealRatings {
+		mealRatings[i] = &ReviewMealRatingResponse{
+			OrderMealID: mealRating.OrderMealID,
+			MealID:      mealRating.MealID,
+			Rating:      mealRating.Rating,
+			Comment:     mealRating.Comment,
+		}
+	}
+
+	// Reviews created before variants were stored only have their photo URLs
+	photos := make([]ImageResponse, len(review.PhotoURLs))
+	for i, url := range review.PhotoURLs {
+		var variants models.ImageVariants
+		if len(review.PhotoVariants) == len(review.PhotoURLs) {
+			variants = review.PhotoVariants[i]
+		}
+		photos[i] = ToImageResponse(url, variants)
+	}
+
 	return &ReviewResponse{
-		ID:        review.ID,
-		Rating:    review.Rating,
-		Comment:   review.Comment,
-		PhotoURLs: review.PhotoURLs,
+		ID:          review.ID,
+		Rating:      review.Rating,
+		Comment:     review.Comment,
+		PhotoURLs:   review.PhotoURLs,
+		Photos:      photos,
+		Status:      review.Status,
+		CreatedAt:   review.CreatedAt,
+		Replies:     replies,
+		MealRatings: mealRatings,
+	}
+}
+
+// ReviewFeedResponse is a review along with the names of the meals of the reviewed order.
+type ReviewFeedResponse struct {
+	ReviewResponse
+	MealNames []string `json:"meal_names"`
+}
+
+func ToReviewFeedResponseList(items []*models.ReviewFeedItem) []*ReviewFeedResponse {
+	responses := make([]*ReviewFeedResponse, len(items))
+	for i, item := range items {
+		mealNames := item.MealNames
+		if mealNames == nil {
+			mealNames = []string{}
+		}
+		responses[i] = &ReviewFeedResponse{
+			ReviewResponse: *ModelToReviewResponse(&item.Review),
+			MealNames:      mealNames,
+		}
+	}
+	return responses
+}
+
+type MealRatingResponse struct {
+	MealID  uint    `json:"meal_id"`
+	Reviews uint    `json:"reviews"`
+	Average float64 `json:"average"`
+}
+
+func ToMealRatingResponse(rating *models.MealRating) *MealRatingResponse {
+	return &MealRatingResponse{
+		MealID:  rating.MealID,
+		Reviews: rating.Reviews,
+		Average: math.Round(rating.Average*100) / 100,
 	}
 }
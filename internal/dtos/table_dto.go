@@ -0,0 +1,117 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"time"
+)
+
+type CreateTableRequest struct {
+	Number int    `json:"number" binding:"required,gte=1"`
+	Seats  uint   `json:"seats" binding:"required,gte=1"`
+	Area   string `json:"area" binding:"required,min=1"`
+	Active *bool  `json:"active"`
+}
+
+func (req *CreateTableRequest) ToModel() *models.Table {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	return &models.Table{
+		Number: req.Number,
+		Seats:  req.Seats,
+		Area:   req.Area,
+		Active: active,
+	}
+}
+
+type UpdateTableRequest struct {
+	Seats  uint   `json:"seats" binding:"required,gte=1"`
+	Area   string `json:"area" binding:"required,min=1"`
+	Active *bool  `json:"active" binding:"required"`
+}
+
+func (req *UpdateTableRequest) ToModel(number int) *models.Table {
+	return &models.Table{
+		Number: number,
+		Seats:  req.Seats,
+		Area:   req.Area,
+		Active: *req.Active,
+	}
+}
+
+type TableResponse struct {
+	Number int    `json:"number"`
+	Seats  uint   `json:"seats"`
+	Area   string `json:"area"`
+	Active bool   `json:"active"`
+}
+
+type TableTokenResponse struct {
+	TableNo int    `json:"table_no"`
+	Version uint   `json:"version"`
+	Token   string `json:"token"`
+}
+
+type TableSessionResponse struct {
+	ID            uint             `json:"id"`
+	OpenedAt      time.Time        `json:"opened_at"`
+	OrderIDs      []uint           `json:"order_ids"`
+	UnservedMeals uint             `json:"unserved_meals"`
+	Balance       *BalanceResponse `json:"balance"`
+}
+
+type TableOverviewResponse struct {
+	TableResponse
+	State   string                `json:"state"`
+	Session *TableSessionResponse `json:"session,omitempty"`
+}
+
+func ToTableResponse(table *models.Table) *TableResponse {
+	return &TableResponse{
+		Number: table.Number,
+		Seats:  table.Seats,
+		Area:   table.Area,
+		Active: table.Active,
+	}
+}
+
+func ToTableResponses(tables []*models.Table) []*TableResponse {
+	result := make([]*TableResponse, len(tables))
+	for i, table := range tables {
+		result[i] = ToTableResponse(table)
+	}
+	return result
+}
+
+func ToTableSessionResponse(session *models.TableSession) *TableSessionResponse {
+	orderIDs := make([]uint, len(session.Orders))
+	for i, order := range session.Orders {
+		orderIDs[i] = order.ID
+	}
+
+	return &TableSessionResponse{
+		ID:            session.ID,
+		OpenedAt:      session.OpenedAt,
+		OrderIDs:      orderIDs,
+		UnservedMeals: session.UnservedMeals(),
+		Balance:       ToBalanceResponse(session.Balance()),
+	}
+}
+
+func ToTableOverviewResponses(overview []*models.TableOverview) []*TableOverviewResponse {
+	result := make([]*TableOverviewResponse, len(overview))
+	for i, table := range overview {
+		result[i] = &TableOverviewResponse{
+			TableResponse: *ToTableResponse(table.Table),
+			State:         "free",
+		}
+
+		if table.Session != nil {
+			result[i].State = "occupied"
+			result[i].Session = ToTableSessionResponse(table.Session)
+		}
+	}
+	return result
+}
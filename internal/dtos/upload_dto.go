@@ -0,0 +1,47 @@
+package dtos
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"time"
+)
+
+type UploadSessionRequest struct {
+	Purpose models.UploadPurpose `json:"purpose" binding:"required,oneof=review meal"`
+}
+
+// UploadSessionResponse is an upload session along with the photos uploaded into it so far.
+type UploadSessionResponse struct {
+	ID         string           `json:"id"`
+	Purpose    string           `json:"purpose"`
+	MaxUploads int              `json:"max_uploads"`
+	ExpiresAt  time.Time        `json:"expires_at"`
+	Uploads    []UploadResponse `json:"uploads"`
+}
+
+// UploadResponse is a photo uploaded into an upload session. The ID attaches the photo to a review or a meal.
+type UploadResponse struct {
+	ID    string        `json:"id"`
+	Image ImageResponse `json:"image"`
+}
+
+func ToUploadSessionResponse(session *models.UploadSession) UploadSessionResponse {
+	uploads := make([]UploadResponse, len(session.Uploads))
+	for i := range session.Uploads {
+		uploads[i] = ToUploadResponse(&session.Uploads[i])
+	}
+
+	return UploadSessionResponse{
+		ID:         session.ID,
+		Purpose:    string(session.Purpose),
+		MaxUploads: session.Purpose.MaxUploads(),
+		ExpiresAt:  session.ExpiresAt,
+		Uploads:    uploads,
+	}
+}
+
+func ToUploadResponse(upload *models.Upload) UploadResponse {
+	return UploadResponse{
+		ID:    upload.ID,
+		Image: ToImageResponse(upload.ImageURL, upload.ImageVariants),
+	}
+}
@@ -4,7 +4,18 @@ import (
 	"github.com/Ruclo/MyMeals/internal/models"
 )
 
+// Names of the events sent to staff.
+const (
+	OrderEvent    = "message"
+	LowStockEvent = "low-stock"
+)
+
 // OrderBroadcaster defines an interface for broadcasting orders.
 type OrderBroadcaster interface {
 	BroadcastOrder(order *models.Order) error
 }
+
+// StockBroadcaster defines an interface for notifying staff about ingredients running low.
+type StockBroadcaster interface {
+	BroadcastLowStock(ingredient *models.Ingredient) error
+}
@@ -7,32 +7,38 @@ import (
 	"io"
 )
 
-// OrderChan is a channel for sending Orders.
-type OrderChan chan *dtos.OrderResponse
+// Event is a named server-sent event, Data gets sent as JSON.
+type Event struct {
+	Name string
+	Data any
+}
+
+// EventChan is a channel for sending Events.
+type EventChan chan Event
 
 // SSEServer is a server-sent events implementation that manages client connections and broadcasts messages.
 // It maintains channels for broadcasting events, registering new clients, and unregistering disconnected clients.
 type SSEServer struct {
-	// The orders sent to this channel get broadcasted to all clients connected to the SSE.
-	broadcast OrderChan
+	// The events sent to this channel get broadcasted to all clients connected to the SSE.
+	broadcast EventChan
 
 	// New client connections
-	register chan OrderChan
+	register chan EventChan
 
 	// Closed client connections
-	unregister chan OrderChan
+	unregister chan EventChan
 
 	// Total client connections
-	clients map[OrderChan]bool
+	clients map[EventChan]bool
 }
 
 // NewSSEServer initializes and returns a new SSEServer instance. It starts a goroutine which manages these channels.
 func NewSSEServer() *SSEServer {
 	server := &SSEServer{
-		broadcast:  make(OrderChan),
-		register:   make(chan OrderChan),
-		unregister: make(chan OrderChan),
-		clients:    make(map[OrderChan]bool),
+		broadcast:  make(EventChan),
+		register:   make(chan EventChan),
+		unregister: make(chan EventChan),
+		clients:    make(map[EventChan]bool),
 	}
 
 	go server.listen()
@@ -43,6 +49,10 @@ func (s *SSEServer) NewBroadcaster() OrderBroadcaster {
 	return &sseOrderBroadcaster{broadcastChan: s.broadcast}
 }
 
+func (s *SSEServer) NewStockBroadcaster() StockBroadcaster {
+	return &sseStockBroadcaster{broadcastChan: s.broadcast}
+}
+
 func (s *SSEServer) listen() {
 	for {
 		select {
@@ -56,10 +66,10 @@ func (s *SSEServer) listen() {
 			close(client)
 
 		// Broadcast message to clients
-		case order := <-s.broadcast:
+		case event := <-s.broadcast:
 			for client := range s.clients {
 				select {
-				case client <- order:
+				case client <- event:
 
 				default:
 					s.unregister <- client
@@ -77,7 +87,7 @@ func (s *SSEServer) Handler() gin.HandlersChain {
 
 func (s *SSEServer) clientConnectMiddleware(c *gin.Context) {
 	// Initialize client channel
-	clientChan := make(OrderChan)
+	clientChan := make(EventChan)
 
 	// Send new connection to event server
 	s.register <- clientChan
@@ -102,17 +112,17 @@ func headersMiddleware(c *gin.Context) {
 }
 
 func handler(c *gin.Context) {
-	clientChan := c.MustGet("clientChan").(OrderChan)
+	clientChan := c.MustGet("clientChan").(EventChan)
 	done := c.Request.Context().Done()
 	c.Stream(func(w io.Writer) bool {
 		select {
 		case <-done:
 			return false
-		case order, ok := <-clientChan:
+		case event, ok := <-clientChan:
 			if !ok {
 				return false
 			}
-			c.SSEvent("message", order)
+			c.SSEvent(event.Name, event.Data)
 			return true
 
 		}
@@ -121,11 +131,22 @@ func handler(c *gin.Context) {
 
 // sseOrderBroadcaster implements the OrderBroadcaster interface
 type sseOrderBroadcaster struct {
-	broadcastChan OrderChan
+	broadcastChan EventChan
 }
 
 // BroadcastOrder sends an order to the SSE message channel
 func (b *sseOrderBroadcaster) BroadcastOrder(order *models.Order) error {
-	b.broadcastChan <- dtos.ToOrderResponse(order)
+	b.broadcastChan <- Event{Name: OrderEvent, Data: dtos.ToOrderResponse(order)}
+	return nil
+}
+
+// sseStockBroadcaster implements the StockBroadcaster interface
+type sseStockBroadcaster struct {
+	broadcastChan EventChan
+}
+
+// BroadcastLowStock sends an ingredient running low to the SSE message channel
+func (b *sseStockBroadcaster) BroadcastLowStock(ingredient *models.Ingredient) error {
+	b.broadcastChan <- Event{Name: LowStockEvent, Data: dtos.ToIngredientResponse(ingredient)}
 	return nil
 }
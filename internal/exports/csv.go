@@ -0,0 +1,36 @@
+package exports
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+// WriteRow writes the cells as a CSV record. Text cells starting like a formula are prefixed with a quote,
+// so spreadsheet applications opening the file do not evaluate what customers wrote.
+func (cw *csvWriter) WriteRow(cells ...any) error {
+	cw.record = cw.record[:0]
+	for _, cell := range cells {
+		text, number := formatCell(cell)
+		if !number && text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+			text = "'" + text
+		}
+		cw.record = append(cw.record, text)
+	}
+
+	return cw.writer.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
@@ -0,0 +1,84 @@
+package exports
+
+import (
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format is the file format data gets exported in.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// TimeLayout is the format times are exported in, in the time zone of the restaurant.
+const TimeLayout = "2006-01-02 15:04:05"
+
+// Valid checks whether the format is one of the supported formats.
+func (f Format) Valid() bool {
+	return f == CSV || f == XLSX
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer writes a table row by row straight to the underlying writer, so exports never hold more than a row in memory.
+// Cells are strings, integers, decimals, times or nil for empty cells, numbers are exported as numbers.
+// Close has to be called once all rows are written to complete the file.
+type Writer interface {
+	WriteRow(cells ...any) error
+	Close() error
+}
+
+// NewWriter creates a writer of the table in the given format. XLSX files hold the table in a single sheet.
+func NewWriter(w io.Writer, format Format, sheet string) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w), nil
+	case XLSX:
+		writer, err := newXLSXWriter(w, sheet)
+		if err != nil {
+			return nil, apperrors.NewInternalServerErr("Failed to start the export", err)
+		}
+		return writer, nil
+	default:
+		return nil, apperrors.NewValidationErr(fmt.Sprintf("Unsupported export format %s", format), nil)
+	}
+}
+
+// formatCell formats the cell as text and reports whether it is a number.
+func formatCell(cell any) (string, bool) {
+	switch value := cell.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, false
+	case int:
+		return strconv.Itoa(value), true
+	case uint:
+		return strconv.FormatUint(uint64(value), 10), true
+	case decimal.Decimal:
+		return value.String(), true
+	case time.Time:
+		return value.In(models.TimeZone).Format(TimeLayout), false
+	case *time.Time:
+		if value == nil {
+			return "", false
+		}
+		return value.In(models.TimeZone).Format(TimeLayout), false
+	default:
+		return fmt.Sprint(value), false
+	}
+}
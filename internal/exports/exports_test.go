@@ -0,0 +1,81 @@
+package exports_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/exports"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := exports.NewWriter(&out, exports.CSV, "Orders")
+	require.NoError(t, err)
+
+	orderedAt := time.Date(2025, time.March, 3, 12, 30, 0, 0, time.Local)
+	require.NoError(t, writer.WriteRow("Order ID", "Ordered at", "Comment", "Total"))
+	require.NoError(t, writer.WriteRow(uint(7), orderedAt, "=HYPERLINK(\"x\"), really", decimal.RequireFromString("-2.50")))
+	require.NoError(t, writer.WriteRow(1, nil, "", decimal.Zero))
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, "Order ID,Ordered at,Comment,Total\n"+
+		"7,2025-03-03 12:30:00,\"'=HYPERLINK(\"\"x\"\"), really\",-2.5\n"+
+		"1,,,0\n", out.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := exports.NewWriter(&out, exports.XLSX, "Sales & tips")
+	require.NoError(t, err)
+
+	require.NoError(t, writer.WriteRow("Date", "Sales"))
+	require.NoError(t, writer.WriteRow("2025-03-03", decimal.RequireFromString("12.50")))
+	require.NoError(t, writer.WriteRow("<b>", nil))
+	require.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.NoError(t, err)
+
+	parts := make(map[string][]byte)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		parts[file.Name], err = io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+	}
+	assert.Contains(t, parts, "[Content_Types].xml")
+	assert.Contains(t, parts, "_rels/.rels")
+	assert.Contains(t, parts, "xl/_rels/workbook.xml.rels")
+	assert.Contains(t, string(parts["xl/workbook.xml"]), `<sheet name="Sales &amp; tips"`)
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet))
+	require.Len(t, sheet.Rows, 3)
+	assert.Equal(t, "inlineStr", sheet.Rows[1].Cells[0].Type)
+	assert.Equal(t, "2025-03-03", sheet.Rows[1].Cells[0].Inline)
+	assert.Empty(t, sheet.Rows[1].Cells[1].Type, "numbers are written as numbers")
+	assert.Equal(t, "12.5", sheet.Rows[1].Cells[1].Value)
+	assert.Equal(t, "<b>", sheet.Rows[2].Cells[0].Inline)
+	assert.Len(t, sheet.Rows[2].Cells, 2)
+}
+
+func TestNewWriterUnsupportedFormat(t *testing.T) {
+	_, err := exports.NewWriter(io.Discard, exports.Format("pdf"), "Orders")
+	assert.Error(t, err)
+}
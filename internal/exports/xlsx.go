@@ -0,0 +1,110 @@
+package exports
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// The parts of a minimal XLSX package with a single worksheet, see ECMA-376.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`
+	xlsxWorkbookEnd = `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxSheetStart  = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxWriter writes the static parts of the package first and then streams the rows into the worksheet,
+// which is the last entry of the zip archive.
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+}
+
+func newXLSXWriter(w io.Writer, sheet string) (*xlsxWriter, error) {
+	archive := zip.NewWriter(w)
+	parts := []struct {
+		name    string
+		content []string
+	}{
+		{name: "[Content_Types].xml", content: []string{xlsxContentTypes}},
+		{name: "_rels/.rels", content: []string{xlsxRelationships}},
+		{name: "xl/_rels/workbook.xml.rels", content: []string{xlsxWorkbookRelationships}},
+		{name: "xl/workbook.xml", content: []string{xlsxWorkbookStart, escapeXML(sheet), xlsxWorkbookEnd}},
+	}
+
+	for _, part := range parts {
+		partWriter, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range part.content {
+			if _, err = io.WriteString(partWriter, content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sheetWriter, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.WriteString(sheetWriter, xlsxSheetStart); err != nil {
+		return nil, err
+	}
+
+	return &xlsxWriter{archive: archive, sheet: sheetWriter}, nil
+}
+
+// WriteRow writes the cells as a row of the worksheet, text is written as inline strings.
+func (xw *xlsxWriter) WriteRow(cells ...any) error {
+	var row strings.Builder
+	row.WriteString("<row>")
+	for _, cell := range cells {
+		text, number := formatCell(cell)
+		switch {
+		case number:
+			row.WriteString("<c><v>" + text + "</v></c>")
+		case text == "":
+			row.WriteString("<c/>")
+		default:
+			row.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">` + escapeXML(text) + "</t></is></c>")
+		}
+	}
+	row.WriteString("</row>")
+
+	_, err := io.WriteString(xw.sheet, row.String())
+	return err
+}
+
+func (xw *xlsxWriter) Close() error {
+	if _, err := io.WriteString(xw.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	return xw.archive.Close()
+}
+
+// escapeXML escapes the text for XML character data, replacing characters XML does not allow.
+func escapeXML(text string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
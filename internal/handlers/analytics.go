@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// AnalyticsHandler handles HTTP requests for sales and kitchen performance analytics.
+// Every request covers the days between the from and to query parameters, see dtos.DateRangeQuery.
+type AnalyticsHandler struct {
+	analyticsService services.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// GetRevenueByDay handles HTTP GET requests to retrieve the revenue per day.
+func (ah *AnalyticsHandler) GetRevenueByDay() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, ok := bindDateRange(c)
+		if !ok {
+			return
+		}
+
+		revenues, err := ah.analyticsService.RevenueByDay(from, to)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToDayRevenueResponses(revenues))
+	}
+}
+
+// GetRevenueByHour handles HTTP GET requests to retrieve the revenue per hour of the day.
+func (ah *AnalyticsHandler) GetRevenueByHour() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, ok := bindDateRange(c)
+		if !ok {
+			return
+		}
+
+		revenues, err := ah.analyticsService.RevenueByHour(from, to)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToHourRevenueResponses(revenues))
+	}
+}
+
+// GetRevenueByCategory handles HTTP GET requests to retrieve the revenue per meal category.
+func (ah *AnalyticsHandler) GetRevenueByCategory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, ok := bindDateRange(c)
+		if !ok {
+			return
+		}
+
+		revenues, err := ah.analyticsService.RevenueByCategory(from, to)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToCategoryRevenueResponses(revenues))
+	}
+}
+
+// GetTopMeals handles HTTP GET requests to retrieve the top selling meals, limited by the limit query parameter.
+func (ah *AnalyticsHandler) GetTopMeals() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query dtos.TopMealsQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid query", err))
+			return
+		}
+
+		from, to := query.ToRange()
+		sales, err := ah.analyticsService.TopMeals(from, to, query.Limit)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMealSalesResponses(sales))
+	}
+}
+
+// GetTicketSize handles HTTP GET requests to retrieve the number of orders and their average revenue.
+func (ah *AnalyticsHandler) GetTicketSize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, ok := bindDateRange(c)
+		if !ok {
+			return
+		}
+
+		ticketSize, err := ah.analyticsService.TicketSize(from, to)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToTicketSizeResponse(ticketSize))
+	}
+}
+
+// GetPrepTimes handles HTTP GET requests to retrieve the average time from placing orders to completing their meals.
+func (ah *AnalyticsHandler) GetPrepTimes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, ok := bindDateRange(c)
+		if !ok {
+			return
+		}
+
+		report, err := ah.analyticsService.PrepTimes(from, to)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToPrepTimeReportResponse(report))
+	}
+}
+
+// GetTableTurnover handles HTTP GET requests to retrieve how often every table was seated.
+func (ah *AnalyticsHandler) GetTableTurnover() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, ok := bindDateRange(c)
+		if !ok {
+			return
+		}
+
+		turnovers, err := ah.analyticsService.TableTurnover(from, to)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToTableTurnoverResponses(turnovers))
+	}
+}
+
+// bindDateRange binds the date range query parameters of the request.
+// It records a validation error and reports false if they are invalid.
+func bindDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	var query dtos.DateRangeQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperrors.NewValidationErr("Invalid date range", err))
+		return time.Time{}, time.Time{}, false
+	}
+
+	from, to := query.ToRange()
+	return from, to, true
+}
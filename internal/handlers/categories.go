@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// CategoriesHandler handles HTTP requests related to the categories of the menu.
+type CategoriesHandler struct {
+	categoryService services.CategoryService
+}
+
+func NewCategoriesHandler(categoryService services.CategoryService) *CategoriesHandler {
+	return &CategoriesHandler{categoryService: categoryService}
+}
+
+// GetCategories handles HTTP GET requests to retrieve the active categories of the menu in their display order.
+func (ch *CategoriesHandler) GetCategories() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categories, err := ch.categoryService.GetAll(true)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToCategoryResponses(categories))
+	}
+}
+
+// GetAllCategories handles HTTP GET requests to retrieve all categories including the inactive ones.
+func (ch *CategoriesHandler) GetAllCategories() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categories, err := ch.categoryService.GetAll(false)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToCategoryResponses(categories))
+	}
+}
+
+// PostCategory handles HTTP POST requests to create a new category with an optional icon.
+func (ch *CategoriesHandler) PostCategory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.CategoryRequest
+		if err := c.ShouldBind(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		icon, err := optionalFormFile(c, "icon")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		category := request.ToModel()
+
+		if err = ch.categoryService.Create(c, category, icon); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, dtos.ToCategoryResponse(category))
+	}
+}
+
+// PutCategory handles HTTP PUT requests to update an existing category identified by its ID.
+// The icon of the category is replaced only if a new icon is provided.
+func (ch *CategoriesHandler) PutCategory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categoryID, err := strconv.ParseUint(c.Param("categoryID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid category id", err))
+			return
+		}
+
+		var request dtos.CategoryRequest
+		if err = c.ShouldBind(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		icon, err := optionalFormFile(c, "icon")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		category := request.ToModel()
+		category.ID = uint(categoryID)
+
+		if err = ch.categoryService.Update(c, category, icon); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToCategoryResponse(category))
+	}
+}
+
+// PutCategoryAvailabilityWindows handles HTTP PUT requests to replace all availability windows of a category
+// identified by its ID.
+func (ch *CategoriesHandler) PutCategoryAvailabilityWindows() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categoryID, err := strconv.ParseUint(c.Param("categoryID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid category id", err))
+			return
+		}
+
+		var request dtos.SetAvailabilityWindowsRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		category, err := ch.categoryService.SetAvailabilityWindows(uint(categoryID), request.ToModel())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToCategoryResponse(category))
+	}
+}
+
+// optionalFormFile returns the uploaded file with the given name, or nil if no file was uploaded.
+func optionalFormFile(c *gin.Context, name string) (*multipart.FileHeader, error) {
+	file, err := c.FormFile(name)
+	if err == nil {
+		return file, nil
+	}
+
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+
+	return nil, apperrors.NewValidationErr("error processing the "+name, err)
+}
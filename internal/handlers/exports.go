@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/exports"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ExportsHandler handles HTTP requests to download orders, reviews and daily sales as CSV or XLSX files.
+// Exports are streamed to the client while the records are read, see dtos.ExportQuery for the query parameters.
+type ExportsHandler struct {
+	exportService services.ExportService
+}
+
+func NewExportsHandler(exportService services.ExportService) *ExportsHandler {
+	return &ExportsHandler{exportService: exportService}
+}
+
+// GetOrdersExport handles HTTP GET requests to export the meals of the orders created in the date range.
+func (eh *ExportsHandler) GetOrdersExport() gin.HandlerFunc {
+	return eh.export("orders", eh.exportService.ExportOrders)
+}
+
+// GetReviewsExport handles HTTP GET requests to export the reviews of the orders created in the date range.
+func (eh *ExportsHandler) GetReviewsExport() gin.HandlerFunc {
+	return eh.export("reviews", eh.exportService.ExportReviews)
+}
+
+// GetDailySalesExport handles HTTP GET requests to export the sales of every day in the date range.
+func (eh *ExportsHandler) GetDailySalesExport() gin.HandlerFunc {
+	return eh.export("sales", eh.exportService.ExportDailySales)
+}
+
+// export streams the export as an attachment named after the exported records and the date range.
+// Errors which occur once the file has started streaming cannot be reported to the client anymore, so they are logged.
+func (eh *ExportsHandler) export(name string, export func(from, to time.Time, writer exports.Writer) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query dtos.ExportQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid query", err))
+			return
+		}
+
+		format := exports.CSV
+		if query.Format != "" {
+			format = exports.Format(query.Format)
+		}
+
+		from, to := query.ToRange()
+		if !from.Before(to) {
+			c.Error(apperrors.NewValidationErr("Export has to end after it starts", nil))
+			return
+		}
+
+		filename := fmt.Sprintf("%s-%s-%s.%s", name, from.Format(time.DateOnly),
+			to.AddDate(0, 0, -1).Format(time.DateOnly), format)
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+
+		writer, err := exports.NewWriter(c.Writer, format, name)
+		if err == nil {
+			err = export(from, to, writer)
+		}
+		if err != nil {
+			log.Printf("Failed to export %s: %v", name, err)
+		}
+	}
+}
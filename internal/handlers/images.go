@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// ImagesHandler handles HTTP requests for images which are not publicly readable in their storage
+// and for the orphaned images in the image storage.
+// The image signer is nil unless images are stored in a private bucket.
+type ImagesHandler struct {
+	imageService services.ImageService
+	imageSigner  storage.ImageSigner
+}
+
+func NewImagesHandler(imageService services.ImageService, imageSigner storage.ImageSigner) *ImagesHandler {
+	return &ImagesHandler{imageService: imageService, imageSigner: imageSigner}
+}
+
+// GetImage handles HTTP GET requests for an image by redirecting them to a short lived url of the image.
+// The redirect may be cached for a minute, well within the lifetime of the url.
+func (ih *ImagesHandler) GetImage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		imageURL, err := ih.imageSigner.PresignedURL(c, strings.TrimPrefix(c.Param("key"), "/"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Header("Cache-Control", "private, max-age=60")
+		c.Redirect(http.StatusFound, imageURL)
+	}
+}
+
+// GetOrphanedImages handles HTTP GET requests to list the orphaned images which the cleanup would delete.
+// Nothing is deleted.
+func (ih *ImagesHandler) GetOrphanedImages() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orphans, err := ih.imageService.GetOrphans(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToOrphanedImageResponses(orphans))
+	}
+}
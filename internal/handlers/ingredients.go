@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// IngredientsHandler handles HTTP requests related to the ingredients kept in stock.
+type IngredientsHandler struct {
+	ingredientService services.IngredientService
+}
+
+func NewIngredientsHandler(ingredientService services.IngredientService) *IngredientsHandler {
+	return &IngredientsHandler{ingredientService: ingredientService}
+}
+
+// GetIngredients handles HTTP GET requests to retrieve all ingredients with their stock.
+func (ih *IngredientsHandler) GetIngredients() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ingredients, err := ih.ingredientService.GetAll()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToIngredientResponses(ingredients))
+	}
+}
+
+// PostIngredient handles HTTP POST requests to create a new ingredient.
+func (ih *IngredientsHandler) PostIngredient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.IngredientRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		ingredient := request.ToModel()
+
+		if err := ih.ingredientService.Create(ingredient); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, dtos.ToIngredientResponse(ingredient))
+	}
+}
+
+// PutIngredient handles HTTP PUT requests to update an existing ingredient identified by its ID.
+func (ih *IngredientsHandler) PutIngredient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ingredientID, err := strconv.ParseUint(c.Param("ingredientID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid ingredient id", err))
+			return
+		}
+
+		var request dtos.IngredientRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		ingredient := request.ToModel()
+		ingredient.ID = uint(ingredientID)
+
+		if err = ih.ingredientService.Update(ingredient); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToIngredientResponse(ingredient))
+	}
+}
+
+// PostIngredientRestock handles HTTP POST requests to add a delivered quantity to the stock of an ingredient.
+func (ih *IngredientsHandler) PostIngredientRestock() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ingredientID, err := strconv.ParseUint(c.Param("ingredientID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid ingredient id", err))
+			return
+		}
+
+		var request dtos.RestockRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		ingredient, err := ih.ingredientService.Restock(uint(ingredientID), request.Quantity)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToIngredientResponse(ingredient))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/Ruclo/MyMeals/internal/apperrors"
 	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/models"
 	"github.com/Ruclo/MyMeals/internal/services"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -19,10 +20,18 @@ func NewMealsHandler(mealService services.MealService) *MealsHandler {
 	return &MealsHandler{mealService: mealService}
 }
 
-// GetMeals handles the HTTP GET request to retrieve all meals and returns them as a JSON response.
+// GetMeals handles the HTTP GET request to retrieve the meals of the currently active menu, priced and ordered by the menu,
+// and returns them as a JSON response. All meals are returned if no menu is active.
+// Supports filtering by allergens the meals must not contain, dietary tags they must have and their spicy level.
 func (mh *MealsHandler) GetMeals() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		meals, err := mh.mealService.GetAll()
+		var query dtos.MealFilterQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid filter", err))
+			return
+		}
+
+		meals, err := mh.mealService.GetAll(query.ToModel())
 		if err != nil {
 			c.Error(err)
 			return
@@ -45,7 +54,8 @@ func (mh *MealsHandler) GetMealsWithDeleted() gin.HandlerFunc {
 	}
 }
 
-// PostMeal handles the HTTP POST request to create a new meal with the provided details and photo.
+// PostMeal handles the HTTP POST request to create a new meal with the provided details and either a photo
+// or the ID of a photo uploaded into an upload session of the staff member.
 func (mh *MealsHandler) PostMeal() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var createMealRequest dtos.CreateMealRequest
@@ -55,14 +65,15 @@ func (mh *MealsHandler) PostMeal() gin.HandlerFunc {
 		}
 
 		photo, err := c.FormFile("photo")
-		if err != nil {
-			c.Error(apperrors.NewValidationErr("photo not provided", err))
+		if err != nil && !errors.Is(err, http.ErrMissingFile) {
+			c.Error(apperrors.NewValidationErr("error processing the photo", err))
 			return
 		}
 
 		meal := createMealRequest.ToModel()
+		upload := createMealRequest.PhotoUpload(models.StaffUploadOwner(c.MustGet("username").(string)))
 
-		if err = mh.mealService.Create(c, meal, photo); err != nil {
+		if err = mh.mealService.Create(c, meal, photo, upload); err != nil {
 			c.Error(err)
 			return
 		}
@@ -71,9 +82,9 @@ func (mh *MealsHandler) PostMeal() gin.HandlerFunc {
 	}
 }
 
-// PostMealReplace handles the HTTP POST request to replace an existing meal
-// by its ID with new details and an optional photo.
-func (mh *MealsHandler) PostMealReplace() gin.HandlerFunc {
+// PutMeal handles the HTTP request to edit an existing meal identified by its ID
+// with new details and an optional photo or ID of an uploaded photo. The meal keeps its ID, the edit is recorded as a new version of the meal.
+func (mh *MealsHandler) PutMeal() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var mealRequest dtos.CreateMealRequest
 		if err := c.ShouldBind(&mealRequest); err != nil {
@@ -96,8 +107,136 @@ func (mh *MealsHandler) PostMealReplace() gin.HandlerFunc {
 
 		meal := mealRequest.ToModel()
 		meal.ID = uint(idUint)
+		upload := mealRequest.PhotoUpload(models.StaffUploadOwner(c.MustGet("username").(string)))
+
+		err = mh.mealService.Update(c, meal, photo, upload)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMealResponse(meal))
+	}
+}
+
+// GetMealVersions handles the HTTP GET request to retrieve the version history of a meal identified by its ID,
+// along with the changes every version made to the previous one.
+func (mh *MealsHandler) GetMealVersions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("mealID")
+		idUint, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid meal id", err))
+			return
+		}
+
+		versions, err := mh.mealService.GetVersions(uint(idUint))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMealVersionResponses(versions))
+	}
+}
+
+// PutMealOptions handles the HTTP PUT request to replace all option groups of a meal identified by its ID.
+func (mh *MealsHandler) PutMealOptions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("mealID")
+		idUint, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid meal id", err))
+			return
+		}
+
+		var request dtos.SetOptionGroupsRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		meal, err := mh.mealService.SetOptionGroups(uint(idUint), request.ToModel())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMealResponse(meal))
+	}
+}
+
+// PutMealSoldOut handles the HTTP PUT request from staff members to mark a meal identified by its ID
+// as sold out or back in stock.
+func (mh *MealsHandler) PutMealSoldOut() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("mealID")
+		idUint, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid meal id", err))
+			return
+		}
+
+		var request dtos.SoldOutRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		meal, err := mh.mealService.SetSoldOut(uint(idUint), *request.SoldOut)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMealResponse(meal))
+	}
+}
+
+// PutMealAvailabilityWindows handles the HTTP PUT request to replace all availability windows of a meal
+// identified by its ID.
+func (mh *MealsHandler) PutMealAvailabilityWindows() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("mealID")
+		idUint, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid meal id", err))
+			return
+		}
+
+		var request dtos.SetAvailabilityWindowsRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		meal, err := mh.mealService.SetAvailabilityWindows(uint(idUint), request.ToModel())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMealResponse(meal))
+	}
+}
+
+// PutMealRecipe handles the HTTP PUT request to replace the recipe of a meal identified by its ID.
+func (mh *MealsHandler) PutMealRecipe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("mealID")
+		idUint, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid meal id", err))
+			return
+		}
+
+		var request dtos.SetRecipeRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
 
-		err = mh.mealService.Replace(c, meal, photo)
+		meal, err := mh.mealService.SetRecipe(uint(idUint), request.ToModel())
 		if err != nil {
 			c.Error(err)
 			return
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/config"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MenusHandler handles HTTP requests related to menus and their schedules.
+type MenusHandler struct {
+	menuService services.MenuService
+}
+
+func NewMenusHandler(menuService services.MenuService) *MenusHandler {
+	return &MenusHandler{menuService: menuService}
+}
+
+// GetMenus handles HTTP GET requests to retrieve all menus with their items and schedules.
+func (mh *MenusHandler) GetMenus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		menus, err := mh.menuService.GetAll()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMenuResponses(menus))
+	}
+}
+
+// PostMenu handles HTTP POST requests to create a new menu. Meals and schedules are added to the menu separately.
+func (mh *MenusHandler) PostMenu() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.MenuRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		menu := request.ToModel()
+
+		if err := mh.menuService.Create(menu); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, dtos.ToMenuResponse(menu))
+	}
+}
+
+// PutMenu handles HTTP PUT requests to update the details of an existing menu identified by its ID.
+func (mh *MenusHandler) PutMenu() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		menuID, err := strconv.ParseUint(c.Param("menuID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid menu id", err))
+			return
+		}
+
+		var request dtos.MenuRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		menu := request.ToModel()
+		menu.ID = uint(menuID)
+
+		updatedMenu, err := mh.menuService.Update(menu)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMenuResponse(updatedMenu))
+	}
+}
+
+// DeleteMenu handles HTTP DELETE requests to remove a menu identified by its ID.
+func (mh *MenusHandler) DeleteMenu() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		menuID, err := strconv.ParseUint(c.Param("menuID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid menu id", err))
+			return
+		}
+
+		if err = mh.menuService.Delete(uint(menuID)); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// PutMenuItems handles HTTP PUT requests to replace all meals of a menu identified by its ID
+// along with their prices and display order on the menu.
+func (mh *MenusHandler) PutMenuItems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		menuID, err := strconv.ParseUint(c.Param("menuID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid menu id", err))
+			return
+		}
+
+		var request dtos.SetMenuItemsRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		menu, err := mh.menuService.SetItems(uint(menuID), request.ToModel())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMenuResponse(menu))
+	}
+}
+
+// PutMenuSchedules handles HTTP PUT requests to replace all schedules of a menu identified by its ID.
+func (mh *MenusHandler) PutMenuSchedules() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		menuID, err := strconv.ParseUint(c.Param("menuID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid menu id", err))
+			return
+		}
+
+		var request dtos.SetAvailabilityWindowsRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		menu, err := mh.menuService.SetSchedules(uint(menuID), request.ToModel())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMenuResponse(menu))
+	}
+}
+
+// GetMenuPreview handles HTTP GET requests to preview the menu active at the RFC3339 timestamp
+// given by the at query parameter, or right now if it is missing.
+// The timestamp is converted to the time zone of the restaurant the schedules are defined in.
+func (mh *MenusHandler) GetMenuPreview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		at := time.Now()
+		if atStr := c.Query("at"); atStr != "" {
+			var err error
+			at, err = time.Parse(time.RFC3339, atStr)
+			if err != nil {
+				c.Error(apperrors.NewValidationErr("Invalid at argument", err))
+				return
+			}
+		}
+		at = at.In(config.ConfigInstance.TimeZone())
+
+		menu, meals, err := mh.menuService.Preview(at)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMenuPreviewResponse(at, menu, meals))
+	}
+}
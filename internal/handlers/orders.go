@@ -7,6 +7,7 @@ import (
 	"github.com/Ruclo/MyMeals/internal/models"
 	"github.com/Ruclo/MyMeals/internal/services"
 	"github.com/gin-gonic/gin"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"time"
@@ -50,24 +51,12 @@ func (oh *OrdersHandler) GetMyOrder() gin.HandlerFunc {
 // supports cursor based pagination based on the createdAt timestamp.
 func (oh *OrdersHandler) GetOrders() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		pageSizeStr := c.DefaultQuery("pageSize", "10")
-		pageSize, err := strconv.ParseUint(pageSizeStr, 10, 32)
-		if err != nil {
-			c.Error(apperrors.NewValidationErr("Invalid page size", err))
+		olderThan, pageSize, ok := bindPage(c)
+		if !ok {
 			return
 		}
 
-		olderThanStr := c.Query("olderThan")
-		olderThan := time.Time{}
-		if olderThanStr != "" {
-			olderThan, err = time.Parse(time.RFC3339, olderThanStr)
-			if err != nil {
-				c.Error(apperrors.NewValidationErr("Invalid older than argument", err))
-				return
-			}
-		}
-
-		orders, err := oh.orderService.GetOrders(olderThan, uint(pageSize))
+		orders, err := oh.orderService.GetOrders(olderThan, pageSize)
 		if err != nil {
 			c.Error(err)
 			return
@@ -93,6 +82,7 @@ func (oh *OrdersHandler) GetPendingOrders() gin.HandlerFunc {
 }
 
 // PostOrder handles HTTP POST requests to create a new order.
+// The table of the order is taken from the table token scanned by the customer.
 // Includes a cookie in the response, which is used to further authorize the creator of the order.
 func (oh *OrdersHandler) PostOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -104,9 +94,15 @@ func (oh *OrdersHandler) PostOrder() gin.HandlerFunc {
 			return
 		}
 
-		order := request.ToModel()
+		tableClaims, err := auth.ParseTableJWT(request.TableToken)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		order := request.ToModel(tableClaims.TableNo)
 
-		err = oh.orderService.Create(order)
+		err = oh.orderService.Create(order, tableClaims.Version)
 		if err != nil {
 			c.Error(err)
 			return
@@ -149,11 +145,9 @@ func (oh *OrdersHandler) PostOrderItems() gin.HandlerFunc {
 		var orderMeals []models.OrderMeal
 
 		for _, orderItem := range orderItems {
-			orderMeals = append(orderMeals, models.OrderMeal{
-				OrderID:  uint(orderId),
-				MealID:   orderItem.MealID,
-				Quantity: orderItem.Quantity,
-			})
+			orderMeal := orderItem.ToModel()
+			orderMeal.OrderID = uint(orderId)
+			orderMeals = append(orderMeals, orderMeal)
 		}
 
 		order, err := oh.orderService.AddMealsToOrder(&orderMeals)
@@ -166,7 +160,8 @@ func (oh *OrdersHandler) PostOrderItems() gin.HandlerFunc {
 	}
 }
 
-// PostOrderReview handles HTTP POST requests for submitting a review for a specific order with optional photo uploads.
+// PostOrderReview handles HTTP POST requests for submitting a review for a specific order with optional photo uploads
+// and IDs of photos uploaded into upload sessions of the order.
 func (oh *OrdersHandler) PostOrderReview() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		orderIdStr := c.Param("orderID")
@@ -186,9 +181,12 @@ func (oh *OrdersHandler) PostOrderReview() gin.HandlerFunc {
 		review := reviewRequest.ToModel()
 		review.OrderID = uint(orderId)
 
-		photos := c.Request.MultipartForm.File["photos"]
+		var photos []*multipart.FileHeader
+		if c.Request.MultipartForm != nil {
+			photos = c.Request.MultipartForm.File["photos"]
+		}
 
-		if err = oh.orderService.CreateReview(c, review, photos); err != nil {
+		if err = oh.orderService.CreateReview(c, review, photos, reviewRequest.PhotoIDs); err != nil {
 			c.Error(err)
 			return
 		}
@@ -197,8 +195,8 @@ func (oh *OrdersHandler) PostOrderReview() gin.HandlerFunc {
 	}
 }
 
-// UpdateStatus handles HTTP POST requests to mark a specific meal within an order
-// as completed based on order and meal ID.
+// UpdateStatus handles HTTP POST requests to move units of a specific line of an order
+// to another kitchen status based on order and order meal ID. The acting staff member gets recorded.
 func (oh *OrdersHandler) UpdateStatus() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		orderIDStr := c.Param("orderID")
@@ -209,15 +207,115 @@ func (oh *OrdersHandler) UpdateStatus() gin.HandlerFunc {
 			return
 		}
 
-		mealIDStr := c.Param("mealID")
+		orderMealIDStr := c.Param("orderMealID")
+
+		orderMealID, err := strconv.ParseUint(orderMealIDStr, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid order meal id", err))
+			return
+		}
+
+		var request dtos.UpdateStatusRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		statusChange := request.ToModel()
+		statusChange.OrderID = uint(orderID)
+		statusChange.OrderMealID = uint(orderMealID)
+		statusChange.ChangedBy = c.MustGet("username").(string)
+
+		order, err := oh.orderService.UpdateStatus(statusChange)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToOrderResponse(order))
+	}
+}
+
+// PostOrderCancel handles HTTP POST requests from the creator of an order to cancel it
+// before the kitchen starts preparing it.
+func (oh *OrdersHandler) PostOrderCancel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderIDStr := c.Param("orderID")
+
+		orderID, err := strconv.ParseUint(orderIDStr, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid order id", err))
+			return
+		}
 
-		mealID, err := strconv.ParseUint(mealIDStr, 10, 64)
+		order, err := oh.orderService.Cancel(uint(orderID))
 		if err != nil {
-			c.Error(apperrors.NewValidationErr("Invalid meal id", err))
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToOrderResponse(order))
+	}
+}
+
+// PostOrderItemVoid handles HTTP POST requests from staff members to void units of a specific line
+// of an order with a mandatory reason.
+func (oh *OrdersHandler) PostOrderItemVoid() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderIDStr := c.Param("orderID")
+
+		orderID, err := strconv.ParseUint(orderIDStr, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid order id", err))
+			return
+		}
+
+		orderMealIDStr := c.Param("orderMealID")
+
+		orderMealID, err := strconv.ParseUint(orderMealIDStr, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid order meal id", err))
+			return
+		}
+
+		var request dtos.VoidRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		void := request.ToModel()
+		void.OrderID = uint(orderID)
+		void.OrderMealID = uint(orderMealID)
+		void.VoidedBy = c.MustGet("username").(string)
+
+		order, err := oh.orderService.VoidOrderMeal(void)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToOrderResponse(order))
+	}
+}
+
+// PutOrderTip handles HTTP PUT requests from the creator of an order to set the tip added to its bill,
+// either a fixed amount or a percentage of the bill. The tip can be changed until the order is paid.
+func (oh *OrdersHandler) PutOrderTip() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := strconv.ParseUint(c.Param("orderID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid order id", err))
+			return
+		}
+
+		var request dtos.TipRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
 			return
 		}
 
-		order, err := oh.orderService.MarkCompleted(uint(orderID), uint(mealID))
+		order, err := oh.orderService.SetTip(uint(orderID), request.Amount, request.Percent)
 		if err != nil {
 			c.Error(err)
 			return
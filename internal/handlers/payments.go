@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// PaymentsHandler handles HTTP requests related to paying the bills of orders.
+type PaymentsHandler struct {
+	paymentService services.PaymentService
+}
+
+func NewPaymentsHandler(paymentService services.PaymentService) *PaymentsHandler {
+	return &PaymentsHandler{paymentService: paymentService}
+}
+
+// GetOrderPayments handles HTTP GET requests to retrieve the payments and the outstanding balance of an order.
+func (ph *PaymentsHandler) GetOrderPayments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := strconv.ParseUint(c.Param("orderID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid order id", err))
+			return
+		}
+
+		payments, balance, err := ph.paymentService.GetPayments(uint(orderID))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToOrderPaymentsResponse(payments, balance))
+	}
+}
+
+// PostOrderPayment handles HTTP POST requests to record a payment against an order.
+// The staff member recording the payment gets stored with it.
+func (ph *PaymentsHandler) PostOrderPayment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := strconv.ParseUint(c.Param("orderID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid order id", err))
+			return
+		}
+
+		var request dtos.PaymentRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		payment := request.ToModel()
+		payment.OrderID = uint(orderID)
+		payment.RecordedBy = c.MustGet("username").(string)
+
+		balance, err := ph.paymentService.RecordPayment(c, payment)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"payment": dtos.ToPaymentResponse(payment),
+			"balance": dtos.ToBalanceResponse(balance),
+		})
+	}
+}
+
+// PostSplitEvenly handles HTTP POST requests to split the outstanding balance of an order
+// evenly between a number of payers.
+func (ph *PaymentsHandler) PostSplitEvenly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := strconv.ParseUint(c.Param("orderID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid order id", err))
+			return
+		}
+
+		var request dtos.SplitEvenlyRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		shares, err := ph.paymentService.SplitEvenly(uint(orderID), request.Payers)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToSplitShareResponses(shares))
+	}
+}
+
+// PostSplitByLines handles HTTP POST requests to split the bill of an order
+// between payers by the ordered meals assigned to each of them.
+func (ph *PaymentsHandler) PostSplitByLines() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := strconv.ParseUint(c.Param("orderID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid order id", err))
+			return
+		}
+
+		var request dtos.SplitByLinesRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		shares, err := ph.paymentService.SplitByLines(uint(orderID), request.ToModel())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToSplitShareResponses(shares))
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// PromotionsHandler handles HTTP requests related to promotions.
+type PromotionsHandler struct {
+	promotionService services.PromotionService
+}
+
+func NewPromotionsHandler(promotionService services.PromotionService) *PromotionsHandler {
+	return &PromotionsHandler{promotionService: promotionService}
+}
+
+// GetPromotions handles HTTP GET requests to retrieve all promotions along with the usage of their promo codes.
+func (ph *PromotionsHandler) GetPromotions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		promotions, err := ph.promotionService.GetAll()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToPromotionResponses(promotions))
+	}
+}
+
+// PostPromotion handles HTTP POST requests to create a new promotion.
+func (ph *PromotionsHandler) PostPromotion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.PromotionRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		promotion := request.ToModel()
+
+		if err := ph.promotionService.Create(promotion); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, dtos.ToPromotionResponse(promotion))
+	}
+}
+
+// PutPromotion handles HTTP PUT requests to replace the settings of an existing promotion identified by its ID.
+func (ph *PromotionsHandler) PutPromotion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		promotionID, err := strconv.ParseUint(c.Param("promotionID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid promotion id", err))
+			return
+		}
+
+		var request dtos.PromotionRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		promotion := request.ToModel()
+		promotion.ID = uint(promotionID)
+
+		updatedPromotion, err := ph.promotionService.Update(promotion)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToPromotionResponse(updatedPromotion))
+	}
+}
+
+// DeletePromotion handles HTTP DELETE requests to remove a promotion identified by its ID.
+func (ph *PromotionsHandler) DeletePromotion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		promotionID, err := strconv.ParseUint(c.Param("promotionID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid promotion id", err))
+			return
+		}
+
+		if err = ph.promotionService.Delete(uint(promotionID)); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ReviewsHandler handles HTTP requests related to the reviews of orders.
+type ReviewsHandler struct {
+	reviewService services.ReviewService
+}
+
+func NewReviewsHandler(reviewService services.ReviewService) *ReviewsHandler {
+	return &ReviewsHandler{reviewService: reviewService}
+}
+
+// GetReviewFeed handles HTTP GET requests to retrieve the public reviews along with the meals of the reviewed orders,
+// supports cursor based pagination based on the createdAt timestamp.
+func (rh *ReviewsHandler) GetReviewFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		olderThan, pageSize, ok := bindPage(c)
+		if !ok {
+			return
+		}
+
+		items, err := rh.reviewService.GetFeed(olderThan, pageSize)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToReviewFeedResponseList(items))
+	}
+}
+
+// GetReviews handles HTTP GET requests to retrieve the reviews in any status or in the status query parameter,
+// supports cursor based pagination based on the createdAt timestamp.
+func (rh *ReviewsHandler) GetReviews() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		olderThan, pageSize, ok := bindPage(c)
+		if !ok {
+			return
+		}
+
+		status := models.ReviewStatus(c.Query("status"))
+		items, err := rh.reviewService.GetReviews(status, olderThan, pageSize)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToReviewFeedResponseList(items))
+	}
+}
+
+// PutReviewStatus handles HTTP PUT requests to hide, flag or publish a review.
+func (rh *ReviewsHandler) PutReviewStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reviewID, err := strconv.ParseUint(c.Param("reviewID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid review id", err))
+			return
+		}
+
+		var request dtos.ReviewStatusRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		review, err := rh.reviewService.Moderate(uint(reviewID), request.Status, c.MustGet("username").(string))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ModelToReviewResponse(review))
+	}
+}
+
+// DeleteReviewPhoto handles HTTP DELETE requests to remove a single photo from a review.
+// The photo is identified by its index in the photo URLs of the review.
+func (rh *ReviewsHandler) DeleteReviewPhoto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reviewID, err := strconv.ParseUint(c.Param("reviewID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid review id", err))
+			return
+		}
+
+		photoIndex, err := strconv.Atoi(c.Param("photoIndex"))
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid photo index", err))
+			return
+		}
+
+		review, err := rh.reviewService.RemovePhoto(c, uint(reviewID), photoIndex)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ModelToReviewResponse(review))
+	}
+}
+
+// PostReviewReply handles HTTP POST requests of staff members to reply to a review.
+func (rh *ReviewsHandler) PostReviewReply() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reviewID, err := strconv.ParseUint(c.Param("reviewID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid review id", err))
+			return
+		}
+
+		var request dtos.ReviewReplyRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		review, err := rh.reviewService.Reply(request.ToModel(uint(reviewID), c.MustGet("username").(string)))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, dtos.ModelToReviewResponse(review))
+	}
+}
+
+// GetMealRating handles HTTP GET requests to retrieve the average of the ratings a meal got in reviews,
+// the same rating the meal is listed with.
+func (rh *ReviewsHandler) GetMealRating() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mealID, err := strconv.ParseUint(c.Param("mealID"), 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid meal id", err))
+			return
+		}
+
+		rating, err := rh.reviewService.GetMealRating(uint(mealID))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToMealRatingResponse(rating))
+	}
+}
+
+// bindPage binds the pageSize and olderThan cursor pagination query parameters of the request.
+// It records a validation error and reports false if they are invalid.
+func bindPage(c *gin.Context) (time.Time, uint, bool) {
+	pageSize, err := strconv.ParseUint(c.DefaultQuery("pageSize", "10"), 10, 32)
+	if err != nil {
+		c.Error(apperrors.NewValidationErr("Invalid page size", err))
+		return time.Time{}, 0, false
+	}
+
+	olderThan := time.Time{}
+	if olderThanStr := c.Query("olderThan"); olderThanStr != "" {
+		olderThan, err = time.Parse(time.RFC3339, olderThanStr)
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid older than argument", err))
+			return time.Time{}, 0, false
+		}
+	}
+
+	return olderThan, uint(pageSize), true
+}
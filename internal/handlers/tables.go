@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/auth"
+	"github.com/Ruclo/MyMeals/internal/config"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/qrcodes"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	defaultQRCodeSize = 512
+	maxQRCodeSize     = 2048
+)
+
+// TablesHandler handles HTTP requests related to the tables of the restaurant and their sessions.
+type TablesHandler struct {
+	tableService services.TableService
+}
+
+func NewTablesHandler(tableService services.TableService) *TablesHandler {
+	return &TablesHandler{tableService: tableService}
+}
+
+// GetTables handles HTTP GET requests to retrieve all tables.
+func (th *TablesHandler) GetTables() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tables, err := th.tableService.GetAll()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToTableResponses(tables))
+	}
+}
+
+// GetTablesOverview handles HTTP GET requests to retrieve the live state of every table,
+// including its open session, unserved meals and outstanding balance.
+func (th *TablesHandler) GetTablesOverview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		overview, err := th.tableService.GetOverview()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToTableOverviewResponses(overview))
+	}
+}
+
+// PostTable handles HTTP POST requests to create a new table.
+func (th *TablesHandler) PostTable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.CreateTableRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		table := request.ToModel()
+		if err := th.tableService.Create(table); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, dtos.ToTableResponse(table))
+	}
+}
+
+// PutTable handles HTTP PUT requests to update an existing table.
+func (th *TablesHandler) PutTable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tableNo, err := strconv.Atoi(c.Param("tableNo"))
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid table number", err))
+			return
+		}
+
+		var request dtos.UpdateTableRequest
+		if err = c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		table := request.ToModel(tableNo)
+		if err = th.tableService.Update(table); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToTableResponse(table))
+	}
+}
+
+// PostCloseSession handles HTTP POST requests to close the open session of a table.
+func (th *TablesHandler) PostCloseSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tableNo, err := strconv.Atoi(c.Param("tableNo"))
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid table number", err))
+			return
+		}
+
+		if err = th.tableService.CloseSession(tableNo); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetTableQRCode handles HTTP GET requests to render the current token of a table as a QR code.
+// The format query parameter selects a png or svg image, the size query parameter its width in pixels.
+func (th *TablesHandler) GetTableQRCode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tableNo, err := strconv.Atoi(c.Param("tableNo"))
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid table number", err))
+			return
+		}
+
+		format := qrcodes.Format(c.DefaultQuery("format", string(qrcodes.PNG)))
+		if !format.Valid() {
+			c.Error(apperrors.NewValidationErr("Invalid format, use png or svg", nil))
+			return
+		}
+
+		size, err := strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(defaultQRCodeSize)))
+		if err != nil || size < 1 || size > maxQRCodeSize {
+			c.Error(apperrors.NewValidationErr("Invalid size", err))
+			return
+		}
+
+		table, err := th.tableService.IssueToken(tableNo)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		token, err := auth.GenerateTableJWT(table.Number, table.TokenVersion)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		image, err := qrcodes.Render(tableOrderContent(token), format, size)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Data(http.StatusOK, format.ContentType(), image)
+	}
+}
+
+// PostRotateToken handles HTTP POST requests to invalidate all issued tokens of a table and issue a new one.
+func (th *TablesHandler) PostRotateToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tableNo, err := strconv.Atoi(c.Param("tableNo"))
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid table number", err))
+			return
+		}
+
+		table, err := th.tableService.RotateToken(tableNo)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		token, err := auth.GenerateTableJWT(table.Number, table.TokenVersion)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.TableTokenResponse{
+			TableNo: table.Number,
+			Version: table.TokenVersion,
+			Token:   token,
+		})
+	}
+}
+
+// DeleteToken handles HTTP DELETE requests to revoke the token of a table until it gets rotated.
+func (th *TablesHandler) DeleteToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tableNo, err := strconv.Atoi(c.Param("tableNo"))
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid table number", err))
+			return
+		}
+
+		if err = th.tableService.RevokeToken(tableNo); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// tableOrderContent returns the content of a table QR code.
+// It is the ordering page with the token as a query parameter, or the bare token if no page is configured.
+func tableOrderContent(token string) string {
+	orderUrl, err := url.Parse(config.ConfigInstance.TableOrderUrl())
+	if err != nil || orderUrl.String() == "" {
+		return token
+	}
+
+	query := orderUrl.Query()
+	query.Set("table_token", token)
+	orderUrl.RawQuery = query.Encode()
+	return orderUrl.String()
+}
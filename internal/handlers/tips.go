@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// TipsHandler handles HTTP requests related to the tips received for orders.
+type TipsHandler struct {
+	tipService services.TipService
+}
+
+func NewTipsHandler(tipService services.TipService) *TipsHandler {
+	return &TipsHandler{tipService: tipService}
+}
+
+// GetTipReport handles HTTP GET requests to report the tips received between the from and to days,
+// split per shift and per staff member who completed the meals of the tipped orders.
+func (th *TipsHandler) GetTipReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, ok := bindDateRange(c)
+		if !ok {
+			return
+		}
+
+		report, err := th.tipService.Report(from, to)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dtos.ToTipReportResponse(report))
+	}
+}
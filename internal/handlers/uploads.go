@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/auth"
+	"github.com/Ruclo/MyMeals/internal/dtos"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// UploadsHandler handles HTTP requests for upload sessions, which photos of reviews and meals are uploaded into
+// before the review or the meal is posted.
+type UploadsHandler struct {
+	uploadService services.UploadService
+}
+
+func NewUploadsHandler(uploadService services.UploadService) *UploadsHandler {
+	return &UploadsHandler{uploadService: uploadService}
+}
+
+// PostUploadSession handles HTTP POST requests to start an upload session.
+// Customers start sessions for the photos of the review of their order, admins for the photos of meals.
+func (uh *UploadsHandler) PostUploadSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.UploadSessionRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.NewValidationErr("Invalid request", err))
+			return
+		}
+
+		tokenType, _ := c.Get("tokenType")
+		role, _ := c.Get("role")
+		if request.Purpose == models.ReviewUpload && tokenType != auth.CustomerJWT {
+			c.Error(apperrors.NewForbiddenErr("Only customers can upload photos of reviews", nil))
+			return
+		}
+		if request.Purpose == models.MealUpload && role != models.AdminRole {
+			c.Error(apperrors.NewForbiddenErr("Only admins can upload photos of meals", nil))
+			return
+		}
+
+		owner, err := uploadOwner(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		session, err := uh.uploadService.CreateSession(request.Purpose, owner)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, dtos.ToUploadSessionResponse(session))
+	}
+}
+
+// PostUpload handles HTTP POST requests to upload a photo into an upload session.
+// The response holds the ID the photo is attached by.
+func (uh *UploadsHandler) PostUpload() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		photo, err := c.FormFile("photo")
+		if err != nil {
+			c.Error(apperrors.NewValidationErr("photo not provided", err))
+			return
+		}
+
+		owner, err := uploadOwner(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		upload, err := uh.uploadService.Upload(c, c.Param("sessionID"), owner, photo)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, dtos.ToUploadResponse(upload))
+	}
+}
+
+// DeleteUpload handles HTTP DELETE requests to remove a photo which is no longer going to be attached
+// from its upload session.
+func (uh *UploadsHandler) DeleteUpload() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		owner, err := uploadOwner(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		if err = uh.uploadService.DeleteUpload(c, c.Param("sessionID"), c.Param("uploadID"), owner); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// uploadOwner returns the owner of the upload sessions of the authenticated customer or staff member.
+func uploadOwner(c *gin.Context) (string, error) {
+	if username, exists := c.Get("username"); exists {
+		return models.StaffUploadOwner(username.(string)), nil
+	}
+
+	orderIDStr, exists := c.Get("orderID")
+	if !exists {
+		return "", apperrors.NewUnauthorizedErr("You are not authenticated", nil)
+	}
+
+	orderID, err := strconv.ParseUint(orderIDStr.(string), 10, 64)
+	if err != nil {
+		return "", apperrors.NewValidationErr("Invalid order id", err)
+	}
+
+	return models.OrderUploadOwner(uint(orderID)), nil
+}
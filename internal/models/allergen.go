@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"slices"
+)
+
+// Allergen represents one of the 14 allergens which have to be declared in the EU.
+type Allergen string
+
+const (
+	Gluten      Allergen = "gluten"
+	Crustaceans Allergen = "crustaceans"
+	Eggs        Allergen = "eggs"
+	Fish        Allergen = "fish"
+	Peanuts     Allergen = "peanuts"
+	Soybeans    Allergen = "soybeans"
+	Milk        Allergen = "milk"
+	Nuts        Allergen = "nuts"
+	Celery      Allergen = "celery"
+	Mustard     Allergen = "mustard"
+	Sesame      Allergen = "sesame"
+	Sulphites   Allergen = "sulphites"
+	Lupin       Allergen = "lupin"
+	Molluscs    Allergen = "molluscs"
+)
+
+// Valid checks if the Allergen is one of the predefined valid allergens, returning an error if invalid.
+func (a Allergen) Valid() error {
+	switch a {
+	case Gluten, Crustaceans, Eggs, Fish, Peanuts, Soybeans, Milk, Nuts, Celery, Mustard, Sesame, Sulphites, Lupin,
+		Molluscs:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Invalid allergen %s", a))
+	}
+}
+
+// Allergens is a set of allergens stored as a text array.
+type Allergens []Allergen
+
+// Valid checks if all allergens are valid and none of them is listed twice.
+func (a Allergens) Valid() error {
+	for i, allergen := range a {
+		if err := allergen.Valid(); err != nil {
+			return err
+		}
+		if slices.Contains(a[:i], allergen) {
+			return errors.New(fmt.Sprintf("Allergen %s listed twice", allergen))
+		}
+	}
+	return nil
+}
+
+// Equal reports whether both sets contain the same allergens regardless of their order.
+func (a Allergens) Equal(other Allergens) bool {
+	return len(a) == len(other) && !slices.ContainsFunc(a, func(allergen Allergen) bool {
+		return !slices.Contains(other, allergen)
+	})
+}
+
+// Scan implements the sql.Scanner interface, allowing Allergens to be scanned from database text arrays.
+func (a *Allergens) Scan(value interface{}) error {
+	var strs pq.StringArray
+	if err := strs.Scan(value); err != nil {
+		return err
+	}
+
+	*a = make(Allergens, len(strs))
+	for i, str := range strs {
+		(*a)[i] = Allergen(str)
+	}
+	return a.Valid()
+}
+
+// Value converts the Allergens to a driver.Value for database storage, returning an error if the value is invalid.
+// A nil set is stored as an empty array.
+func (a Allergens) Value() (driver.Value, error) {
+	if err := a.Valid(); err != nil {
+		return nil, err
+	}
+
+	strs := make(pq.StringArray, len(a))
+	for i, allergen := range a {
+		strs[i] = string(allergen)
+	}
+	return strs.Value()
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// Revenue in analytics is the price of the billable units of the meals of orders which were not cancelled,
+// without tax, discounts, service charges and tips. Orders count towards the time they were created at.
+
+// DayRevenue is the revenue of the orders created on a day, Day is in the YYYY-MM-DD format.
+type DayRevenue struct {
+	Day     string
+	Orders  uint
+	Revenue decimal.Decimal
+}
+
+// DailySales summarizes the sales of the orders created on a day for accounting. Units counts the billable units sold,
+// Sales is the revenue and Discounts the discounts granted when the meals were ordered.
+type DailySales struct {
+	Day       string
+	Orders    uint
+	Units     uint
+	Sales     decimal.Decimal
+	Discounts decimal.Decimal
+}
+
+// HourRevenue is the revenue of the orders created during an hour of the day, summed over all days.
+type HourRevenue struct {
+	Hour    uint
+	Orders  uint
+	Revenue decimal.Decimal
+}
+
+// CategoryRevenue is the number of billable units sold of the meals of a category and their revenue.
+type CategoryRevenue struct {
+	CategoryID uint
+	Category   string
+	Quantity   uint
+	Revenue    decimal.Decimal
+}
+
+// MealSales is the number of billable units sold of a meal and their revenue.
+type MealSales struct {
+	MealID   uint
+	MealName string
+	Quantity uint
+	Revenue  decimal.Decimal
+}
+
+// TicketSize is the number of orders and their revenue.
+type TicketSize struct {
+	Orders  uint
+	Revenue decimal.Decimal
+}
+
+// Average returns the average revenue of an order rounded to cents, or zero if there are no orders.
+func (t *TicketSize) Average() decimal.Decimal {
+	if t.Orders == 0 {
+		return decimal.Zero
+	}
+	return t.Revenue.Div(decimal.NewFromInt(int64(t.Orders))).Round(2)
+}
+
+// PrepTime is the average time in seconds from the creation of an order until the kitchen completed its meals,
+// weighted by the Units completed. Overall prep times have no MealID.
+type PrepTime struct {
+	MealID         uint
+	MealName       string
+	Units          uint
+	AverageSeconds float64
+}
+
+// PrepTimeReport holds the overall prep time along with the prep times of the meals, slowest first.
+type PrepTimeReport struct {
+	Overall PrepTime
+	Meals   []PrepTime
+}
+
+// TableTurnover describes how often a table was seated. Sessions counts the closed sessions of the table
+// and AverageSeconds is how long they lasted on average. TurnsPerDay is the number of sessions per day.
+type TableTurnover struct {
+	TableNo        int
+	Sessions       uint
+	AverageSeconds float64
+	TurnsPerDay    float64 `gorm:"-"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MinutesPerDay is the number of minutes in a day, the end of an availability window is always before it.
+const MinutesPerDay = 24 * 60
+
+// AvailabilityWindow is a recurring time window a meal or all meals of a category can be ordered in,
+// a menu is offered in or a promotion applies in.
+// Days is a bit set of the days of the week the window starts on, bit n stands for time.Weekday(n).
+// StartMinute and EndMinute are minutes since midnight, the window ends before EndMinute.
+// Windows with EndMinute before StartMinute span midnight and end on the following day.
+type AvailabilityWindow struct {
+	ID          uint  `gorm:"primaryKey;autoIncrement"`
+	MealID      *uint `gorm:"index"`
+	CategoryID  *uint `gorm:"index"`
+	MenuID      *uint `gorm:"index"`
+	PromotionID *uint `gorm:"index"`
+	Days        uint8 `gorm:"not null; check: days > 0 AND days < 128"`
+	StartMinute uint  `gorm:"not null; check: start_minute < 1440"`
+	EndMinute   uint  `gorm:"not null; check: end_minute < 1440"`
+}
+
+// NewAvailabilityWindow creates a window starting on the given days of the week.
+func NewAvailabilityWindow(days []time.Weekday, startMinute, endMinute uint) AvailabilityWindow {
+	window := AvailabilityWindow{StartMinute: startMinute, EndMinute: endMinute}
+	for _, day := range days {
+		window.Days |= 1 << day
+	}
+	return window
+}
+
+// Weekdays returns the days of the week the window starts on.
+func (w *AvailabilityWindow) Weekdays() []time.Weekday {
+	var days []time.Weekday
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if w.startsOn(day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// Validate checks that the window starts on at least one day and its start and end are valid times of day.
+func (w *AvailabilityWindow) Validate() error {
+	if w.Days == 0 || w.Days >= 1<<7 {
+		return errors.New("Availability window has to start on at least one day of the week")
+	}
+
+	if w.StartMinute >= MinutesPerDay || w.EndMinute >= MinutesPerDay {
+		return errors.New("Availability window has to start and end within a day")
+	}
+
+	if w.StartMinute == w.EndMinute {
+		return errors.New(fmt.Sprintf("Availability window cannot start and end at %s", FormatMinute(w.StartMinute)))
+	}
+
+	return nil
+}
+
+// TimeZone is the time zone of the restaurant the windows are defined in, set from the config on startup.
+// Times are converted to it before they are checked against the windows.
+var TimeZone = time.Local
+
+// Contains reports whether the given time falls into the window.
+func (w *AvailabilityWindow) Contains(t time.Time) bool {
+	t = t.In(TimeZone)
+	minute := uint(t.Hour()*60 + t.Minute())
+
+	if w.StartMinute < w.EndMinute {
+		return w.startsOn(t.Weekday()) && minute >= w.StartMinute && minute < w.EndMinute
+	}
+
+	// The window spans midnight, so it either started today or the day before.
+	previousDay := (t.Weekday() + 6) % 7
+	return (w.startsOn(t.Weekday()) && minute >= w.StartMinute) || (w.startsOn(previousDay) && minute < w.EndMinute)
+}
+
+// startsOn reports whether the window starts on the given day of the week.
+func (w *AvailabilityWindow) startsOn(day time.Weekday) bool {
+	return w.Days&(1<<day) != 0
+}
+
+// FormatMinute formats minutes since midnight as a time of day, e.g. 07:30.
+func FormatMinute(minute uint) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// withinWindows reports whether the given time falls into any of the windows. No windows means no restriction.
+func withinWindows(windows []AvailabilityWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+
+	for i := range windows {
+		if windows[i].Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckAvailable returns an error if the meal cannot be ordered at the given time,
+// either because it is sold out, one of its ingredients ran out, its category is not active
+// or because the time is outside the availability windows of the meal or its category.
+func (m *Meal) CheckAvailable(t time.Time) error {
+	if m.SoldOut || !m.InStock() {
+		return errors.New(fmt.Sprintf("%s is sold out", m.Name))
+	}
+
+	if m.Category != nil && !m.Category.Active {
+		return errors.New(fmt.Sprintf("%s is not available", m.Name))
+	}
+
+	if !withinWindows(m.AvailabilityWindows, t) ||
+		(m.Category != nil && !withinWindows(m.Category.AvailabilityWindows, t)) {
+		return errors.New(fmt.Sprintf("%s is not available at this time", m.Name))
+	}
+
+	return nil
+}
+
+// AvailableAt reports whether the meal can be ordered at the given time.
+func (m *Meal) AvailableAt(t time.Time) bool {
+	return m.CheckAvailable(t) == nil
+}
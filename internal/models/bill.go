@@ -0,0 +1,132 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"sort"
+)
+
+// TaxLine is the tax charged at a single rate. Base is the total price of the meals taxed at the rate.
+type TaxLine struct {
+	Rate   decimal.Decimal
+	Base   decimal.Decimal
+	Amount decimal.Decimal
+}
+
+// DiscountLine is the total discount granted by a single promotion.
+type DiscountLine struct {
+	Name   string
+	Amount decimal.Decimal
+}
+
+// Bill holds what the customer owes for an order. Prices of meals do not include tax.
+// Subtotal is the price of the meals before discounts, discounts lower the taxed base.
+// ServiceCharge is charged on the discounted price of the meals of orders made at large tables and is not taxed.
+// Tip is the tip the customer added to the bill, a percentage tip is calculated from the rest of the total.
+type Bill struct {
+	Subtotal      decimal.Decimal
+	Discounts     []DiscountLine
+	ServiceCharge decimal.Decimal
+	TaxLines      []TaxLine
+	Tip           decimal.Decimal
+	Total         decimal.Decimal
+}
+
+// Bill calculates the bill of the order from the prices, tax rates and discounts snapshotted on its meals.
+// Tax, the service charge and a percentage tip are rounded to cents, tax per tax rate.
+// Tax lines are ordered by rate, discount lines by the promotion first applied.
+func (o *Order) Bill() *Bill {
+	bill := &Bill{
+		Subtotal:  decimal.Zero,
+		Discounts: []DiscountLine{},
+		TaxLines:  []TaxLine{},
+	}
+
+	discountLines := make(map[string]int)
+	for _, discount := range o.billableDiscounts() {
+		index, ok := discountLines[discount.Name]
+		if !ok {
+			index = len(bill.Discounts)
+			discountLines[discount.Name] = index
+			bill.Discounts = append(bill.Discounts, DiscountLine{Name: discount.Name, Amount: decimal.Zero})
+		}
+		bill.Discounts[index].Amount = bill.Discounts[index].Amount.Add(discount.Total())
+	}
+
+	bases := make(map[string]*TaxLine)
+	for _, orderMeal := range o.OrderMeals {
+		bill.Subtotal = bill.Subtotal.Add(orderMeal.LineTotal())
+		lineTotal := o.DiscountedLineTotal(&orderMeal)
+
+		if orderMeal.TaxRate.IsZero() {
+			continue
+		}
+
+		key := orderMeal.TaxRate.String()
+		taxLine, ok := bases[key]
+		if !ok {
+			taxLine = &TaxLine{Rate: orderMeal.TaxRate, Base: decimal.Zero}
+			bases[key] = taxLine
+		}
+		taxLine.Base = taxLine.Base.Add(lineTotal)
+	}
+
+	bill.Total = bill.Subtotal
+	for _, discountLine := range bill.Discounts {
+		bill.Total = bill.Total.Sub(discountLine.Amount)
+	}
+
+	bill.ServiceCharge = bill.Total.Mul(o.ServiceChargeRate).Round(2)
+	bill.Total = bill.Total.Add(bill.ServiceCharge)
+
+	for _, taxLine := range bases {
+		taxLine.Amount = taxLine.Base.Mul(taxLine.Rate).Round(2)
+		bill.Total = bill.Total.Add(taxLine.Amount)
+		bill.TaxLines = append(bill.TaxLines, *taxLine)
+	}
+
+	sort.Slice(bill.TaxLines, func(i, j int) bool {
+		return bill.TaxLines[i].Rate.LessThan(bill.TaxLines[j].Rate)
+	})
+
+	bill.Tip = o.TipAmount.Add(bill.Total.Mul(o.TipPercent).Div(decimal.NewFromInt(100)).Round(2))
+	bill.Total = bill.Total.Add(bill.Tip)
+
+	return bill
+}
+
+// Total returns the discount of all units the discount was granted for.
+func (d *OrderDiscount) Total() decimal.Decimal {
+	return d.UnitAmount.Mul(decimal.NewFromInt(int64(d.Quantity)))
+}
+
+// DiscountedLineTotal returns the price of the billable units of the order meal without tax, less their discounts.
+func (o *Order) DiscountedLineTotal(orderMeal *OrderMeal) decimal.Decimal {
+	lineTotal := orderMeal.LineTotal()
+	for _, discount := range o.billableDiscounts() {
+		if discount.OrderMealID == orderMeal.ID {
+			lineTotal = lineTotal.Sub(discount.Total())
+		}
+	}
+	return lineTotal
+}
+
+// billableDiscounts returns the discounts of the order limited to the billable units of their order meals,
+// so discounts of cancelled or voided units do not count. The units discounted first keep their discount.
+func (o *Order) billableDiscounts() []OrderDiscount {
+	remaining := make(map[uint]uint, len(o.OrderMeals))
+	for _, orderMeal := range o.OrderMeals {
+		remaining[orderMeal.ID] = orderMeal.BillableQuantity()
+	}
+
+	discounts := make([]OrderDiscount, 0, len(o.Discounts))
+	for _, discount := range o.Discounts {
+		discount.Quantity = min(discount.Quantity, remaining[discount.OrderMealID])
+		if discount.Quantity == 0 {
+			continue
+		}
+
+		remaining[discount.OrderMealID] -= discount.Quantity
+		discounts = append(discounts, discount)
+	}
+	return discounts
+}
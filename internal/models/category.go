@@ -0,0 +1,16 @@
+package models
+
+// Category groups meals on the menu. Categories are managed by admins,
+// inactive categories and their meals are hidden from the menu.
+// Categories are ordered on the menu by DisplayOrder.
+// IconPublicID identifies the icon in the image storage.
+// Meals of the category can only be ordered within the AvailabilityWindows of the category, if it has any.
+type Category struct {
+	ID                  uint                 `gorm:"primaryKey;autoIncrement"`
+	Name                string               `gorm:"not null; uniqueIndex; check: name <> ''"`
+	DisplayOrder        int                  `gorm:"not null; default: 0"`
+	IconURL             string               `gorm:"not null; default: ''"`
+	IconPublicID        string               `gorm:"not null; default: ''"`
+	Active              bool                 `gorm:"not null; default: true"`
+	AvailabilityWindows []AvailabilityWindow `gorm:"foreignKey:CategoryID; constraint:OnDelete:CASCADE"`
+}
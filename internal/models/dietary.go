@@ -0,0 +1,157 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"slices"
+	"time"
+)
+
+// DietaryTag marks a meal as suitable for a diet.
+type DietaryTag string
+
+const (
+	Vegan      DietaryTag = "vegan"
+	Vegetarian DietaryTag = "vegetarian"
+	GlutenFree DietaryTag = "gluten-free"
+	Halal      DietaryTag = "halal"
+)
+
+// MaxSpicyLevel is the spicy level of the hottest meals, meals with spicy level 0 are not spicy at all.
+const MaxSpicyLevel = 3
+
+// animalAllergens lists the allergens vegan meals cannot contain.
+var animalAllergens = Allergens{Eggs, Milk, Fish, Crustaceans, Molluscs}
+
+// Valid checks if the DietaryTag is one of the predefined valid tags, returning an error if invalid.
+func (t DietaryTag) Valid() error {
+	switch t {
+	case Vegan, Vegetarian, GlutenFree, Halal:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Invalid dietary tag %s", t))
+	}
+}
+
+// DietaryTags is a set of dietary tags stored as a text array.
+type DietaryTags []DietaryTag
+
+// Valid checks if all tags are valid and none of them is listed twice.
+func (t DietaryTags) Valid() error {
+	for i, tag := range t {
+		if err := tag.Valid(); err != nil {
+			return err
+		}
+		if slices.Contains(t[:i], tag) {
+			return errors.New(fmt.Sprintf("Dietary tag %s listed twice", tag))
+		}
+	}
+	return nil
+}
+
+// Equal reports whether both sets contain the same tags regardless of their order.
+func (t DietaryTags) Equal(other DietaryTags) bool {
+	return len(t) == len(other) && !slices.ContainsFunc(t, func(tag DietaryTag) bool {
+		return !slices.Contains(other, tag)
+	})
+}
+
+// Scan implements the sql.Scanner interface, allowing DietaryTags to be scanned from database text arrays.
+func (t *DietaryTags) Scan(value interface{}) error {
+	var strs pq.StringArray
+	if err := strs.Scan(value); err != nil {
+		return err
+	}
+
+	*t = make(DietaryTags, len(strs))
+	for i, str := range strs {
+		(*t)[i] = DietaryTag(str)
+	}
+	return t.Valid()
+}
+
+// Value converts the DietaryTags to a driver.Value for database storage, returning an error if the value is invalid.
+// A nil set is stored as an empty array.
+func (t DietaryTags) Value() (driver.Value, error) {
+	if err := t.Valid(); err != nil {
+		return nil, err
+	}
+
+	strs := make(pq.StringArray, len(t))
+	for i, tag := range t {
+		strs[i] = string(tag)
+	}
+	return strs.Value()
+}
+
+// ValidateDietaryInfo checks the allergens, dietary tags and spicy level of the meal
+// and that the tags do not contradict the allergens of the meal.
+func (m *Meal) ValidateDietaryInfo() error {
+	if err := m.Allergens.Valid(); err != nil {
+		return err
+	}
+
+	if err := m.DietaryTags.Valid(); err != nil {
+		return err
+	}
+
+	if m.SpicyLevel > MaxSpicyLevel {
+		return errors.New(fmt.Sprintf("Spicy level has to be between 0 and %d", MaxSpicyLevel))
+	}
+
+	if slices.Contains(m.DietaryTags, GlutenFree) && slices.Contains(m.Allergens, Gluten) {
+		return errors.New("Gluten-free meal cannot contain gluten")
+	}
+
+	if slices.Contains(m.DietaryTags, Vegan) {
+		for _, allergen := range animalAllergens {
+			if slices.Contains(m.Allergens, allergen) {
+				return errors.New(fmt.Sprintf("Vegan meal cannot contain %s", allergen))
+			}
+		}
+	}
+
+	return nil
+}
+
+// MealFilter narrows down the menu to meals a customer can eat.
+// ExcludeAllergens lists allergens the meals must not contain, Tags lists dietary tags the meals must all have.
+// MaxSpicyLevel limits the spicy level of the meals if set.
+// AvailableAt limits the meals to those which can be ordered at the given time if set.
+type MealFilter struct {
+	ExcludeAllergens Allergens
+	Tags             DietaryTags
+	MaxSpicyLevel    *uint
+	AvailableAt      *time.Time
+}
+
+// Valid checks the allergens and tags of the filter.
+func (f *MealFilter) Valid() error {
+	if err := f.ExcludeAllergens.Valid(); err != nil {
+		return err
+	}
+	return f.Tags.Valid()
+}
+
+// Matches reports whether the meal passes the filter.
+func (f *MealFilter) Matches(meal *Meal) bool {
+	for _, allergen := range f.ExcludeAllergens {
+		if slices.Contains(meal.Allergens, allergen) {
+			return false
+		}
+	}
+
+	for _, tag := range f.Tags {
+		if !slices.Contains(meal.DietaryTags, tag) {
+			return false
+		}
+	}
+
+	if f.AvailableAt != nil && !meal.AvailableAt(*f.AvailableAt) {
+		return false
+	}
+
+	return f.MaxSpicyLevel == nil || meal.SpicyLevel <= *f.MaxSpicyLevel
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// ImageVariant is a copy of an image in one of the sizes and formats images are stored in.
+// PublicID identifies the variant in the image storage.
+type ImageVariant struct {
+	Size        string `json:"size"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	URL         string `json:"url"`
+	PublicID    string `json:"public_id"`
+}
+
+// ImageVariants are the variants of one image, stored as JSON.
+// Images uploaded before variants were stored have none.
+type ImageVariants []ImageVariant
+
+// PublicIDs returns the public IDs of the variants.
+func (v ImageVariants) PublicIDs() []string {
+	publicIDs := make([]string, len(v))
+	for i, variant := range v {
+		publicIDs[i] = variant.PublicID
+	}
+	return publicIDs
+}
+
+// Scan implements the sql.Scanner interface, allowing ImageVariants to be scanned from JSON.
+func (v *ImageVariants) Scan(value interface{}) error {
+	return scanJSON(value, v)
+}
+
+// Value converts the ImageVariants to JSON for database storage. Nil variants are stored as an empty array.
+func (v ImageVariants) Value() (driver.Value, error) {
+	if v == nil {
+		v = ImageVariants{}
+	}
+	return json.Marshal(v)
+}
+
+// PhotoVariants are the variants of each photo of a review, stored as JSON.
+type PhotoVariants []ImageVariants
+
+// Scan implements the sql.Scanner interface, allowing PhotoVariants to be scanned from JSON.
+func (p *PhotoVariants) Scan(value interface{}) error {
+	return scanJSON(value, p)
+}
+
+// Value converts the PhotoVariants to JSON for database storage. Nil variants are stored as an empty array.
+func (p PhotoVariants) Value() (driver.Value, error) {
+	if p == nil {
+		p = PhotoVariants{}
+	}
+	return json.Marshal(p)
+}
+
+// scanJSON unmarshals a JSON database value into dest.
+func scanJSON(value interface{}, dest any) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, dest)
+	case string:
+		return json.Unmarshal([]byte(data), dest)
+	default:
+		return errors.New("invalid scan source for JSON")
+	}
+}
+
+// ImageReferences are the public IDs and URLs of the images which are referenced by meals, meal versions, reviews,
+// categories or upload sessions. Images stored before their public IDs were kept are only referenced by their URLs.
+type ImageReferences struct {
+	PublicIDs map[string]bool
+	URLs      map[string]bool
+}
+
+func NewImageReferences() *ImageReferences {
+	return &ImageReferences{PublicIDs: map[string]bool{}, URLs: map[string]bool{}}
+}
+
+// Add references an image by its public ID and URL, either of which may be empty.
+func (r *ImageReferences) Add(publicID, url string) {
+	if publicID != "" {
+		r.PublicIDs[publicID] = true
+	}
+	if url != "" {
+		r.URLs[url] = true
+	}
+}
+
+// AddVariants references all the variants.
+func (r *ImageReferences) AddVariants(variants ImageVariants) {
+	for _, variant := range variants {
+		r.Add(variant.PublicID, variant.URL)
+	}
+}
+
+// References reports whether the image with the public ID and URL is referenced by either of them.
+func (r *ImageReferences) References(publicID, url string) bool {
+	return r.PublicIDs[publicID] || r.URLs[url]
+}
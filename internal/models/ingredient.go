@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"strings"
+)
+
+// Ingredient is an ingredient kept in stock, measured in Unit, e.g. g, ml or pcs.
+// Staff get notified once Stock drops to LowStockThreshold.
+type Ingredient struct {
+	ID                uint            `gorm:"primaryKey;autoIncrement"`
+	Name              string          `gorm:"not null; uniqueIndex; check: name <> ''"`
+	Unit              string          `gorm:"not null; check: unit <> ''"`
+	Stock             decimal.Decimal `gorm:"type:numeric(12,3); not null; default: 0; check: stock >= 0"`
+	LowStockThreshold decimal.Decimal `gorm:"type:numeric(12,3); not null; default: 0; check: low_stock_threshold >= 0"`
+}
+
+// RecipeItem is the quantity of an ingredient, in the unit of the ingredient, used by one portion of a meal.
+type RecipeItem struct {
+	ID           uint            `gorm:"primaryKey;autoIncrement"`
+	MealID       uint            `gorm:"not null; uniqueIndex:idx_recipe_items_meal_ingredient"`
+	IngredientID uint            `gorm:"not null; uniqueIndex:idx_recipe_items_meal_ingredient; index"`
+	Ingredient   *Ingredient     `gorm:"foreignKey:IngredientID"`
+	Quantity     decimal.Decimal `gorm:"type:numeric(12,3); not null; check: quantity > 0"`
+}
+
+// IngredientUsage maps ingredient ids to the quantity of the ingredient used.
+type IngredientUsage map[uint]decimal.Decimal
+
+// Validate checks that the ingredient has a name and a unit and that its stock and threshold are not negative.
+func (i *Ingredient) Validate() error {
+	if strings.TrimSpace(i.Name) == "" {
+		return errors.New("Ingredient name is required")
+	}
+
+	if strings.TrimSpace(i.Unit) == "" {
+		return errors.New(fmt.Sprintf("Unit of ingredient %s is required", i.Name))
+	}
+
+	if i.Stock.IsNegative() || i.LowStockThreshold.IsNegative() {
+		return errors.New(fmt.Sprintf("Stock and low stock threshold of ingredient %s cannot be negative", i.Name))
+	}
+
+	return nil
+}
+
+// IsLowStock reports whether the stock of the ingredient is at or below its low stock threshold.
+func (i *Ingredient) IsLowStock() bool {
+	return i.Stock.LessThanOrEqual(i.LowStockThreshold)
+}
+
+// BecameLowStock reports whether using the given quantity brought the stock of the ingredient
+// from above its low stock threshold to or below it. The stock has to be the stock after the usage.
+func (i *Ingredient) BecameLowStock(used decimal.Decimal) bool {
+	return i.IsLowStock() && i.Stock.Add(used).GreaterThan(i.LowStockThreshold)
+}
+
+// ValidateRecipe checks that every ingredient is used at most once and in a positive quantity.
+func ValidateRecipe(recipe []RecipeItem) error {
+	seen := make(map[uint]bool, len(recipe))
+	for _, item := range recipe {
+		if seen[item.IngredientID] {
+			return errors.New(fmt.Sprintf("Ingredient %d is used twice in the recipe", item.IngredientID))
+		}
+		seen[item.IngredientID] = true
+
+		if !item.Quantity.IsPositive() {
+			return errors.New(fmt.Sprintf("Quantity of ingredient %d has to be positive", item.IngredientID))
+		}
+	}
+	return nil
+}
+
+// InStock reports whether there is enough of every ingredient in stock for one more portion of the meal.
+// Ingredients of the recipe which are not loaded are assumed to be in stock.
+func (m *Meal) InStock() bool {
+	for _, item := range m.Recipe {
+		if item.Ingredient != nil && item.Ingredient.Stock.LessThan(item.Quantity) {
+			return false
+		}
+	}
+	return true
+}
+
+// Add adds the ingredients used by the given number of portions of a meal made by the recipe.
+func (u IngredientUsage) Add(recipe []RecipeItem, portions uint) {
+	for _, item := range recipe {
+		used := item.Quantity.Mul(decimal.NewFromInt(int64(portions)))
+		u[item.IngredientID] = u[item.IngredientID].Add(used)
+	}
+}
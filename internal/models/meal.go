@@ -1,69 +1,48 @@
 package models
 
 import (
-	"database/sql/driver"
-	"errors"
-	"fmt"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
-// MealCategory represents one of the 5 categories of meals.
-type MealCategory string
-
-// DB Initialisation
-const (
-	Drinks      MealCategory = "Drinks"
-	Starters    MealCategory = "Starters"
-	MainCourses MealCategory = "Main Courses"
-	SideDishes  MealCategory = "Side Dishes"
-	Desserts    MealCategory = "Desserts"
-)
-
-// Valid checks if the MealCategory is one of the predefined valid categories, returning an error if invalid.
-func (c MealCategory) Valid() error {
-	switch c {
-	case Drinks, Starters, MainCourses, SideDishes, Desserts:
-		return nil
-	default:
-		return errors.New(fmt.Sprintf("Invalid meal category %s", c))
-	}
-}
-
-// Scan implements the sql.Scanner interface, allowing MealCategory to be scanned from database values.
-func (c *MealCategory) Scan(value interface{}) error {
-	if value == nil {
-		*c = ""
-		return nil
-	}
-
-	str, ok := value.(string)
-	if !ok {
-		bytes, ok := value.([]byte)
-		if !ok {
-			return errors.New("invalid scan source for MealCategory")
-		}
-		str = string(bytes)
-	}
-
-	*c = MealCategory(str)
-	return c.Valid()
+type Meal struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	Name        string    `gorm:"not null; check: name <> ''"`
+	CategoryID  uint      `gorm:"not null; index"`
+	Category    *Category `gorm:"foreignKey:CategoryID"`
+	Description string    `gorm:"not null; check: description <> ''"`
+	ImageURL    string    `gorm:"not null; check: image_url <> ''"`
+	// ImagePublicID is the public ID of the image in the image storage, empty for meals created before it was stored.
+	ImagePublicID string `gorm:"not null; default: ''"`
+	// ImageVariants are the scaled copies of the image, ImageURL is the largest one in the format of the upload.
+	ImageVariants ImageVariants   `gorm:"type:json; not null; default: '[]'"`
+	Price         decimal.Decimal `gorm:"type:numeric(10,2); check: price > 0"`
+	DeletedAt     gorm.DeletedAt  `json:"-"`
+	// Version is the number of the current MealVersion of the meal, it gets incremented by every edit.
+	Version  uint          `gorm:"not null; default: 1"`
+	Versions []MealVersion `gorm:"foreignKey:MealID"`
+	// Allergens are the EU allergens the meal contains.
+	Allergens   Allergens   `gorm:"type:text[]; not null; default: '{}'"`
+	DietaryTags DietaryTags `gorm:"type:text[]; not null; default: '{}'"`
+	SpicyLevel  uint        `gorm:"not null; default: 0; check: spicy_level <= 3"`
+	// SoldOut is set by staff when the meal runs out, sold out meals cannot be ordered.
+	SoldOut bool `gorm:"not null; default: false"`
+	// AvailabilityWindows limit the times the meal can be ordered at, in addition to the windows of its category.
+	AvailabilityWindows []AvailabilityWindow `gorm:"foreignKey:MealID; constraint:OnDelete:CASCADE"`
+	// Recipe lists the ingredients used by one portion of the meal, meals run out once one of them does.
+	Recipe []RecipeItem `gorm:"foreignKey:MealID; constraint:OnDelete:CASCADE"`
+	// OptionGroups are the groups of options customers choose from when ordering the meal.
+	OptionGroups []OptionGroup `gorm:"foreignKey:MealID; constraint:OnDelete:CASCADE"`
+	// RatingCount and AverageRating summarise the ratings of the meal in reviews which are not hidden.
+	// They are not stored with the meal but loaded along with it.
+	RatingCount   uint    `gorm:"-"`
+	AverageRating float64 `gorm:"-"`
 }
 
-// Value converts the MealCategory to a driver.Value for database storage, returning an error if the value is invalid.
-func (c MealCategory) Value() (driver.Value, error) {
-	if err := c.Valid(); err != nil {
-		return nil, err
+// CategoryName returns the name of the category of the meal, or an empty string if the category is not loaded.
+func (m *Meal) CategoryName() string {
+	if m.Category == nil {
+		return ""
 	}
-	return string(c), nil
-}
-
-type Meal struct {
-	ID          uint            `gorm:"primaryKey;autoIncrement"`
-	Name        string          `gorm:"not null; check: name <> ''"`
-	Category    MealCategory    `gorm:"not null" json:"category"`
-	Description string          `gorm:"not null; check: description <> ''"`
-	ImageURL    string          `gorm:"not null; check: image_url <> ''"`
-	Price       decimal.Decimal `gorm:"type:numeric(10,2); check: price > 0"`
-	DeletedAt   gorm.DeletedAt  `json:"-"`
+	return m.Category.Name
 }
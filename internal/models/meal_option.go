@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"slices"
+	"strings"
+)
+
+// OptionGroup is a group of options customers choose from when ordering a meal, e.g. sizes, extras or removals.
+// Customers have to choose at least MinSelected and at most MaxSelected options of the group.
+// Single select groups allow at most one option to be chosen.
+type OptionGroup struct {
+	ID          uint         `gorm:"primaryKey;autoIncrement"`
+	MealID      uint         `gorm:"not null; index"`
+	Name        string       `gorm:"not null; check: name <> ''"`
+	MultiSelect bool         `gorm:"not null"`
+	MinSelected uint         `gorm:"not null; default: 0"`
+	MaxSelected uint         `gorm:"not null; default: 1"`
+	Options     []MealOption `gorm:"foreignKey:OptionGroupID; constraint:OnDelete:CASCADE"`
+}
+
+// MealOption is a single option of an option group. PriceDelta gets added to the price of the meal
+// when the option is chosen and may be negative.
+type MealOption struct {
+	ID            uint            `gorm:"primaryKey;autoIncrement"`
+	OptionGroupID uint            `gorm:"not null; index"`
+	Name          string          `gorm:"not null; check: name <> ''"`
+	PriceDelta    decimal.Decimal `gorm:"type:numeric(10,2); not null; default: 0"`
+}
+
+// Validate checks that the option group has a name, at least one option and selection limits
+// which can be satisfied by its options.
+func (g *OptionGroup) Validate() error {
+	if strings.TrimSpace(g.Name) == "" {
+		return errors.New("Option group name is required")
+	}
+
+	if len(g.Options) == 0 {
+		return errors.New(fmt.Sprintf("Option group %s has no options", g.Name))
+	}
+
+	for _, option := range g.Options {
+		if strings.TrimSpace(option.Name) == "" {
+			return errors.New(fmt.Sprintf("Option group %s has an option without a name", g.Name))
+		}
+	}
+
+	if !g.MultiSelect && g.MaxSelected > 1 {
+		return errors.New(fmt.Sprintf("Single select option group %s allows at most one option", g.Name))
+	}
+
+	if g.MaxSelected == 0 || g.MinSelected > g.MaxSelected || int(g.MaxSelected) > len(g.Options) {
+		return errors.New(fmt.Sprintf("Invalid selection limits of option group %s", g.Name))
+	}
+
+	return nil
+}
+
+// ResolveOptions looks up the chosen options of the meal and checks them against the selection limits
+// of every option group. Returns snapshots of the chosen options ordered by option id.
+func (m *Meal) ResolveOptions(optionIDs []uint) ([]OrderMealOption, error) {
+	chosen := make(map[uint]bool, len(optionIDs))
+	for _, optionID := range optionIDs {
+		if chosen[optionID] {
+			return nil, errors.New(fmt.Sprintf("Option %d chosen more than once", optionID))
+		}
+		chosen[optionID] = true
+	}
+
+	var resolved []OrderMealOption
+	for _, group := range m.OptionGroups {
+		var selected uint
+		for _, option := range group.Options {
+			if !chosen[option.ID] {
+				continue
+			}
+
+			selected++
+			delete(chosen, option.ID)
+			resolved = append(resolved, OrderMealOption{
+				MealOptionID: option.ID,
+				GroupName:    group.Name,
+				Name:         option.Name,
+				PriceDelta:   option.PriceDelta,
+			})
+		}
+
+		if selected < group.MinSelected || selected > group.MaxSelected {
+			return nil, errors.New(fmt.Sprintf("Choose between %d and %d options of %s",
+				group.MinSelected, group.MaxSelected, group.Name))
+		}
+	}
+
+	for optionID := range chosen {
+		return nil, errors.New(fmt.Sprintf("Option %d is not available for meal %s", optionID, m.Name))
+	}
+
+	slices.SortFunc(resolved, func(a, b OrderMealOption) int {
+		return int(a.MealOptionID) - int(b.MealOptionID)
+	})
+
+	return resolved, nil
+}
+
+// OrderMealOption is a snapshot of an option chosen for an order meal, taken when the meal was ordered.
+type OrderMealOption struct {
+	ID           uint            `gorm:"primaryKey;autoIncrement"`
+	OrderMealID  uint            `gorm:"not null; index"`
+	MealOptionID uint            `gorm:"not null"`
+	GroupName    string          `gorm:"not null"`
+	Name         string          `gorm:"not null"`
+	PriceDelta   decimal.Decimal `gorm:"type:numeric(10,2); not null; default: 0"`
+}
+
+// SameSnapshot reports whether both chosen options are of the same option with the same name and price.
+func (o OrderMealOption) SameSnapshot(other OrderMealOption) bool {
+	return o.MealOptionID == other.MealOptionID && o.GroupName == other.GroupName && o.Name == other.Name &&
+		o.PriceDelta.Equal(other.PriceDelta)
+}
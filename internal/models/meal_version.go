@@ -0,0 +1,77 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// MealVersion is an immutable snapshot of the menu details of a meal. Every edit of a meal creates a new version,
+// order meals keep pointing at the version which was ordered.
+// Availability, option groups and the recipe of a meal are not versioned.
+// ImagePublicID keeps the image of the version in the image storage, it is empty for versions created before
+// public IDs were stored.
+type MealVersion struct {
+	ID            uint            `gorm:"primaryKey;autoIncrement"`
+	MealID        uint            `gorm:"not null; uniqueIndex:idx_meal_versions_meal_version"`
+	Version       uint            `gorm:"not null; uniqueIndex:idx_meal_versions_meal_version"`
+	Name          string          `gorm:"not null"`
+	CategoryID    uint            `gorm:"not null"`
+	Description   string          `gorm:"not null"`
+	ImageURL      string          `gorm:"not null"`
+	ImagePublicID string          `gorm:"not null; default: ''"`
+	Price         decimal.Decimal `gorm:"type:numeric(10,2); not null"`
+	Allergens     Allergens       `gorm:"type:text[]; not null; default: '{}'"`
+	DietaryTags   DietaryTags     `gorm:"type:text[]; not null; default: '{}'"`
+	SpicyLevel    uint            `gorm:"not null; default: 0"`
+	CreatedAt     time.Time       `gorm:"not null"`
+}
+
+// MealChange is a change of a single field between two versions of a meal.
+type MealChange struct {
+	Field string
+	From  any
+	To    any
+}
+
+// NewVersion snapshots the current menu details of the meal as its version Meal.Version.
+func (m *Meal) NewVersion() *MealVersion {
+	return &MealVersion{
+		MealID:        m.ID,
+		Version:       m.Version,
+		Name:          m.Name,
+		CategoryID:    m.CategoryID,
+		Description:   m.Description,
+		ImageURL:      m.ImageURL,
+		ImagePublicID: m.ImagePublicID,
+		Price:         m.Price,
+		Allergens:     m.Allergens,
+		DietaryTags:   m.DietaryTags,
+		SpicyLevel:    m.SpicyLevel,
+	}
+}
+
+// Diff returns the fields which changed from the previous version to this one.
+// All fields are reported as changed if there is no previous version.
+func (v *MealVersion) Diff(previous *MealVersion) []MealChange {
+	if previous == nil {
+		previous = &MealVersion{}
+	}
+
+	var changes []MealChange
+	addChange := func(field string, changed bool, from, to any) {
+		if changed {
+			changes = append(changes, MealChange{Field: field, From: from, To: to})
+		}
+	}
+
+	addChange("name", previous.Name != v.Name, previous.Name, v.Name)
+	addChange("category_id", previous.CategoryID != v.CategoryID, previous.CategoryID, v.CategoryID)
+	addChange("description", previous.Description != v.Description, previous.Description, v.Description)
+	addChange("image_url", previous.ImageURL != v.ImageURL, previous.ImageURL, v.ImageURL)
+	addChange("price", !previous.Price.Equal(v.Price), previous.Price, v.Price)
+	addChange("allergens", !previous.Allergens.Equal(v.Allergens), previous.Allergens, v.Allergens)
+	addChange("dietary_tags", !previous.DietaryTags.Equal(v.DietaryTags), previous.DietaryTags, v.DietaryTags)
+	addChange("spicy_level", previous.SpicyLevel != v.SpicyLevel, previous.SpicyLevel, v.SpicyLevel)
+
+	return changes
+}
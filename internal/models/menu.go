@@ -0,0 +1,131 @@
+package models
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"slices"
+	"strings"
+	"time"
+)
+
+// dateLayout formats the dates seasonal menus start and end on.
+const dateLayout = "2006-01-02"
+
+// Menu groups meals which are offered together, e.g. a lunch, dinner or seasonal menu, at prices of the menu.
+// A menu is active while it is Active, within its date range and within one of its Schedules.
+// StartDate and EndDate are inclusive and optional, menus without Schedules are active all day.
+// If several menus are active at the same time, the one with the highest Priority wins.
+type Menu struct {
+	ID        uint                 `gorm:"primaryKey;autoIncrement"`
+	Name      string               `gorm:"not null; uniqueIndex; check: name <> ''"`
+	Priority  int                  `gorm:"not null; default: 0"`
+	Active    bool                 `gorm:"not null; default: true"`
+	StartDate *time.Time           `gorm:"type:date"`
+	EndDate   *time.Time           `gorm:"type:date"`
+	Items     []MenuItem           `gorm:"foreignKey:MenuID; constraint:OnDelete:CASCADE"`
+	Schedules []AvailabilityWindow `gorm:"foreignKey:MenuID; constraint:OnDelete:CASCADE"`
+}
+
+// MenuItem is a meal offered on a menu for the price of the menu. Items are displayed ordered by DisplayOrder.
+type MenuItem struct {
+	ID           uint            `gorm:"primaryKey;autoIncrement"`
+	MenuID       uint            `gorm:"not null; uniqueIndex:idx_menu_items_menu_meal"`
+	MealID       uint            `gorm:"not null; uniqueIndex:idx_menu_items_menu_meal; index"`
+	Meal         *Meal           `gorm:"foreignKey:MealID"`
+	Price        decimal.Decimal `gorm:"type:numeric(10,2); not null; check: price > 0"`
+	DisplayOrder int             `gorm:"not null; default: 0"`
+}
+
+// Validate checks that the menu has a name and that its date range does not end before it starts.
+func (m *Menu) Validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return errors.New("Menu name is required")
+	}
+
+	if m.StartDate != nil && m.EndDate != nil && m.EndDate.Before(*m.StartDate) {
+		return errors.New(fmt.Sprintf("Menu %s cannot end before it starts", m.Name))
+	}
+
+	return nil
+}
+
+// ValidateMenuItems checks that every meal is on the menu at most once and for a positive price.
+func ValidateMenuItems(items []MenuItem) error {
+	seen := make(map[uint]bool, len(items))
+	for _, item := range items {
+		if seen[item.MealID] {
+			return errors.New(fmt.Sprintf("Meal %d is on the menu twice", item.MealID))
+		}
+		seen[item.MealID] = true
+
+		if !item.Price.IsPositive() {
+			return errors.New(fmt.Sprintf("Price of meal %d has to be positive", item.MealID))
+		}
+	}
+	return nil
+}
+
+// ActiveAt reports whether the menu is offered at the given time.
+func (m *Menu) ActiveAt(t time.Time) bool {
+	day := t.In(TimeZone).Format(dateLayout)
+	if m.StartDate != nil && day < m.StartDate.Format(dateLayout) {
+		return false
+	}
+
+	if m.EndDate != nil && day > m.EndDate.Format(dateLayout) {
+		return false
+	}
+
+	return m.Active && withinWindows(m.Schedules, t)
+}
+
+// Item returns the item of the menu for the given meal, or nil if the meal is not on the menu.
+func (m *Menu) Item(mealID uint) *MenuItem {
+	for i := range m.Items {
+		if m.Items[i].MealID == mealID {
+			return &m.Items[i]
+		}
+	}
+	return nil
+}
+
+// PriceMeal sets the price of the meal to its price on the menu,
+// returning an error if the meal is not on the menu.
+func (m *Menu) PriceMeal(meal *Meal) error {
+	item := m.Item(meal.ID)
+	if item == nil {
+		return errors.New(fmt.Sprintf("%s is not on the %s menu", meal.Name, m.Name))
+	}
+
+	meal.Price = item.Price
+	return nil
+}
+
+// Apply keeps only the meals on the menu, prices them by the menu and orders them by their display order on the menu.
+func (m *Menu) Apply(meals []*Meal) []*Meal {
+	menuMeals := make([]*Meal, 0, len(m.Items))
+	for _, meal := range meals {
+		if m.PriceMeal(meal) == nil {
+			menuMeals = append(menuMeals, meal)
+		}
+	}
+
+	slices.SortStableFunc(menuMeals, func(a, b *Meal) int {
+		return cmp.Compare(m.Item(a.ID).DisplayOrder, m.Item(b.ID).DisplayOrder)
+	})
+	return menuMeals
+}
+
+// ActiveMenu returns the menu with the highest priority of the menus active at the given time,
+// the first one of them on a tie. Returns nil if no menu is active.
+func ActiveMenu(menus []*Menu, t time.Time) *Menu {
+	var active *Menu
+	for _, menu := range menus {
+		if menu.ActiveAt(t) && (active == nil || menu.Priority > active.Priority) {
+			active = menu
+		}
+	}
+	return active
+}
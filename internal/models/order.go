@@ -1,8 +1,12 @@
 package models
 
 import (
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"slices"
 	"time"
 )
 
@@ -11,8 +15,33 @@ type Order struct {
 	TableNo    int         `gorm:"check:table_no >= 1"`
 	Notes      string      `gorm:"not null"`
 	OrderMeals []OrderMeal `gorm:"foreignKey:OrderID; preload:true"`
-	CreatedAt  time.Time
-	Review     *Review `gorm:"foreignKey:OrderID"`
+	// TableSessionID is the session of the table the order belongs to. Orders made before sessions existed have none.
+	TableSessionID *uint `gorm:"index"`
+	CreatedAt      time.Time
+	// CancelledAt is set when the customer cancels the order before the kitchen started working on it.
+	CancelledAt *time.Time
+	// PaidAt is set once the whole bill of the order has been paid, which closes the order.
+	PaidAt   *time.Time
+	Payments []*Payment `gorm:"foreignKey:OrderID"`
+	Review   *Review    `gorm:"foreignKey:OrderID"`
+	// PromoCode is the promo code the customer entered when placing the order, it applies to meals added later as well.
+	PromoCode string          `gorm:"not null; default: ''"`
+	Discounts []OrderDiscount `gorm:"foreignKey:OrderID"`
+	// ServiceChargeRate is the rate of the service charge of the table the order was made at, e.g. 0.1 for 10%.
+	ServiceChargeRate decimal.Decimal `gorm:"type:numeric(5,4); not null; default: 0"`
+	// TipAmount and TipPercent are the tip the customer added to the bill, at most one of them is set, see SetTip.
+	TipAmount  decimal.Decimal `gorm:"type:numeric(10,2); not null; default: 0"`
+	TipPercent decimal.Decimal `gorm:"type:numeric(5,2); not null; default: 0"`
+}
+
+// Started reports whether the kitchen has already started working on any meal of the order.
+func (o *Order) Started() bool {
+	for _, orderMeal := range o.OrderMeals {
+		if orderMeal.Accepted > 0 || orderMeal.Cooking > 0 || orderMeal.Completed > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 // BeforeCreate is a GORM hook that validates and resets fields before creating an Order record in the database.
@@ -28,10 +57,302 @@ func (o *Order) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// OrderMealStatus represents the kitchen state of a single unit of an ordered meal.
+type OrderMealStatus string
+
+const (
+	PendingStatus   OrderMealStatus = "pending"
+	AcceptedStatus  OrderMealStatus = "accepted"
+	CookingStatus   OrderMealStatus = "cooking"
+	ReadyStatus     OrderMealStatus = "ready"
+	ServedStatus    OrderMealStatus = "served"
+	CancelledStatus OrderMealStatus = "cancelled"
+	VoidedStatus    OrderMealStatus = "voided"
+)
+
+// CustomerActor is recorded as the author of status changes made by the anonymous customer who created the order.
+const CustomerActor = "customer"
+
+// orderMealTransitions lists the statuses every status is allowed to move to.
+var orderMealTransitions = map[OrderMealStatus][]OrderMealStatus{
+	PendingStatus:  {AcceptedStatus, CancelledStatus},
+	AcceptedStatus: {CookingStatus, CancelledStatus},
+	CookingStatus:  {ReadyStatus, CancelledStatus},
+	ReadyStatus:    {ServedStatus},
+}
+
+// Valid checks if the OrderMealStatus is one of the predefined valid statuses, returning an error if invalid.
+func (s OrderMealStatus) Valid() error {
+	switch s {
+	case PendingStatus, AcceptedStatus, CookingStatus, ReadyStatus, ServedStatus, CancelledStatus, VoidedStatus:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Invalid order meal status %s", s))
+	}
+}
+
+// CanTransitionTo reports whether a unit in this status may be moved to the next status.
+func (s OrderMealStatus) CanTransitionTo(next OrderMealStatus) bool {
+	for _, allowed := range orderMealTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Previous returns the status a unit normally comes from before reaching this status.
+// Cancelled units can come from several statuses, so pending is returned for them.
+func (s OrderMealStatus) Previous() OrderMealStatus {
+	switch s {
+	case AcceptedStatus, CancelledStatus:
+		return PendingStatus
+	case CookingStatus:
+		return AcceptedStatus
+	case ReadyStatus:
+		return CookingStatus
+	case ServedStatus:
+		return ReadyStatus
+	default:
+		return ""
+	}
+}
+
+// Scan implements the sql.Scanner interface, allowing OrderMealStatus to be scanned from database values.
+func (s *OrderMealStatus) Scan(value interface{}) error {
+	if value == nil {
+		*s = ""
+		return nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		bytes, ok := value.([]byte)
+		if !ok {
+			return errors.New("invalid scan source for OrderMealStatus")
+		}
+		str = string(bytes)
+	}
+
+	*s = OrderMealStatus(str)
+	return s.Valid()
+}
+
+// Value converts the OrderMealStatus to a driver.Value for database storage, returning an error if the value is invalid.
+func (s OrderMealStatus) Value() (driver.Value, error) {
+	if err := s.Valid(); err != nil {
+		return nil, err
+	}
+	return string(s), nil
+}
+
+// HasAllergies reports whether any line of the order has allergies flagged.
+func (o *Order) HasAllergies() bool {
+	return slices.ContainsFunc(o.OrderMeals, func(orderMeal OrderMeal) bool {
+		return orderMeal.HasAllergies()
+	})
+}
+
+// SortAllergiesFirst moves the lines with allergies flagged to the front of the order,
+// keeping the relative order of the lines otherwise.
+func (o *Order) SortAllergiesFirst() {
+	slices.SortStableFunc(o.OrderMeals, func(a, b OrderMeal) int {
+		switch {
+		case a.HasAllergies() && !b.HasAllergies():
+			return -1
+		case !a.HasAllergies() && b.HasAllergies():
+			return 1
+		default:
+			return 0
+		}
+	})
+}
+
+// FindOrderMeal returns the line of the order with the same configuration
+// as the given order meal, or nil if the order has no such line.
+func (o *Order) FindOrderMeal(orderMeal *OrderMeal) *OrderMeal {
+	for i := range o.OrderMeals {
+		if o.OrderMeals[i].SameConfiguration(orderMeal) {
+			return &o.OrderMeals[i]
+		}
+	}
+	return nil
+}
+
+// OrderMeal is a line of an order. A meal can be ordered on several lines with different options.
+// Every unit of the ordered quantity goes through the kitchen workflow
+// on its own, so the line keeps a counter of units per status.
+// Completed holds the units finished by the kitchen, that is units which are either ready or already served.
+// Voided units were taken off the order by staff, see OrderMealVoid.
+// Units which are not accepted, cooking, completed, cancelled or voided are pending.
+// MealID and MealVersion identify the MealVersion which was ordered.
+// MealName, UnitPrice, TaxRate and Options are snapshots of the meal taken when the meal was ordered,
+// so later changes to the meal do not change what the customer owes.
+// UnitPrice includes the price deltas of the chosen options.
+// Note and Allergens are instructions of the customer for the kitchen about this line only.
 type OrderMeal struct {
-	OrderID   uint `gorm:"primaryKey"`
-	MealID    uint `gorm:"primaryKey"`
-	Quantity  uint
-	Completed uint
-	Meal      *Meal `gorm:"foreignKey:MealID"`
+	ID            uint            `gorm:"primaryKey;autoIncrement"`
+	OrderID       uint            `gorm:"not null; index"`
+	MealID        uint            `gorm:"not null"`
+	MealVersion   uint            `gorm:"not null; default: 1"`
+	MealName      string          `gorm:"not null; default: ''"`
+	UnitPrice     decimal.Decimal `gorm:"type:numeric(10,2); not null; default: 0"`
+	TaxRate       decimal.Decimal `gorm:"type:numeric(5,4); not null; default: 0"`
+	Note          string          `gorm:"not null; default: ''"`
+	Allergens     Allergens       `gorm:"type:text[]; not null; default: '{}'"`
+	Quantity      uint
+	Accepted      uint `gorm:"not null; default: 0"`
+	Cooking       uint `gorm:"not null; default: 0"`
+	Completed     uint
+	Served        uint                    `gorm:"not null; default: 0"`
+	Cancelled     uint                    `gorm:"not null; default: 0"`
+	Voided        uint                    `gorm:"not null; default: 0"`
+	Meal          *Meal                   `gorm:"foreignKey:MealID"`
+	Options       []OrderMealOption       `gorm:"foreignKey:OrderMealID"`
+	StatusChanges []OrderMealStatusChange `gorm:"foreignKey:OrderMealID"`
+	Voids         []OrderMealVoid         `gorm:"foreignKey:OrderMealID"`
+}
+
+// SnapshotMeal copies the version, name, price and tax rate of the ordered meal and the chosen options
+// onto the order meal. The chosen options have to be resolved by Meal.ResolveOptions.
+func (om *OrderMeal) SnapshotMeal(meal *Meal, options []OrderMealOption, taxRate decimal.Decimal) {
+	om.MealVersion = meal.Version
+	om.MealName = meal.Name
+	om.UnitPrice = meal.Price
+	om.TaxRate = taxRate
+	om.Options = options
+
+	for _, option := range options {
+		om.UnitPrice = om.UnitPrice.Add(option.PriceDelta)
+	}
+}
+
+// OptionIDs returns the ids of the options chosen for the order meal.
+func (om *OrderMeal) OptionIDs() []uint {
+	optionIDs := make([]uint, len(om.Options))
+	for i, option := range om.Options {
+		optionIDs[i] = option.MealOptionID
+	}
+	return optionIDs
+}
+
+// SameConfiguration reports whether both order meals are of the same version of a meal with the same chosen options,
+// note and allergies, priced and taxed the same. The options of both order meals have to be ordered by option id.
+func (om *OrderMeal) SameConfiguration(other *OrderMeal) bool {
+	return om.MealID == other.MealID && om.MealVersion == other.MealVersion &&
+		slices.EqualFunc(om.Options, other.Options, OrderMealOption.SameSnapshot) &&
+		om.UnitPrice.Equal(other.UnitPrice) && om.TaxRate.Equal(other.TaxRate) &&
+		om.Note == other.Note && om.Allergens.Equal(other.Allergens)
+}
+
+// HasAllergies reports whether the customer flagged any allergies for the order meal.
+func (om *OrderMeal) HasAllergies() bool {
+	return len(om.Allergens) > 0
+}
+
+// BillableQuantity returns the number of units the customer pays for, which excludes cancelled and voided units.
+func (om *OrderMeal) BillableQuantity() uint {
+	return om.Quantity - om.Cancelled - om.Voided
+}
+
+// LineTotal returns the price of the billable units of the order meal without tax.
+func (om *OrderMeal) LineTotal() decimal.Decimal {
+	return om.UnitPrice.Mul(decimal.NewFromInt(int64(om.BillableQuantity())))
+}
+
+// CountIn returns the number of units of the order meal which are currently in the given status.
+func (om *OrderMeal) CountIn(status OrderMealStatus) uint {
+	switch status {
+	case PendingStatus:
+		return om.Quantity - om.Accepted - om.Cooking - om.Completed - om.Cancelled - om.Voided
+	case AcceptedStatus:
+		return om.Accepted
+	case CookingStatus:
+		return om.Cooking
+	case ReadyStatus:
+		return om.Completed - om.Served
+	case ServedStatus:
+		return om.Served
+	case CancelledStatus:
+		return om.Cancelled
+	case VoidedStatus:
+		return om.Voided
+	default:
+		return 0
+	}
+}
+
+// Transition moves quantity units of the order meal from one status to another.
+// It returns an error if the transition is not allowed or there are not enough units in the source status.
+func (om *OrderMeal) Transition(from, to OrderMealStatus, quantity uint) error {
+	if !from.CanTransitionTo(to) {
+		return errors.New(fmt.Sprintf("Cannot move meals from %s to %s", from, to))
+	}
+
+	if quantity == 0 || om.CountIn(from) < quantity {
+		return errors.New(fmt.Sprintf("Only %d meals are %s", om.CountIn(from), from))
+	}
+
+	om.adjust(from, -int(quantity))
+	om.adjust(to, int(quantity))
+	return nil
+}
+
+// Void takes quantity units of the order meal in the given status off the order.
+// Units can be voided from any status apart from cancelled and voided.
+func (om *OrderMeal) Void(from OrderMealStatus, quantity uint) error {
+	if from == CancelledStatus || from == VoidedStatus {
+		return errors.New(fmt.Sprintf("Cannot void %s meals", from))
+	}
+
+	if quantity == 0 || om.CountIn(from) < quantity {
+		return errors.New(fmt.Sprintf("Only %d meals are %s", om.CountIn(from), from))
+	}
+
+	om.adjust(from, -int(quantity))
+	om.adjust(VoidedStatus, int(quantity))
+	return nil
+}
+
+// adjust changes the counters backing the given status by delta.
+func (om *OrderMeal) adjust(status OrderMealStatus, delta int) {
+	switch status {
+	case AcceptedStatus:
+		om.Accepted = uint(int(om.Accepted) + delta)
+	case CookingStatus:
+		om.Cooking = uint(int(om.Cooking) + delta)
+	case ReadyStatus:
+		om.Completed = uint(int(om.Completed) + delta)
+	case ServedStatus:
+		om.Completed = uint(int(om.Completed) + delta)
+		om.Served = uint(int(om.Served) + delta)
+	case CancelledStatus:
+		om.Cancelled = uint(int(om.Cancelled) + delta)
+	case VoidedStatus:
+		om.Voided = uint(int(om.Voided) + delta)
+	}
+}
+
+// OrderMealStatusChange records a single transition of some units of an order meal, made by a staff member.
+type OrderMealStatusChange struct {
+	ID          uint            `gorm:"primaryKey;autoIncrement"`
+	OrderID     uint            `gorm:"not null; index"`
+	OrderMealID uint            `gorm:"not null; index"`
+	FromStatus  OrderMealStatus `gorm:"not null"`
+	ToStatus    OrderMealStatus `gorm:"not null"`
+	Quantity    uint            `gorm:"check:quantity >= 1"`
+	ChangedBy   string          `gorm:"not null"`
+	ChangedAt   time.Time       `gorm:"not null"`
+}
+
+// OrderMealVoid is an entry of the append-only log of order meal units voided by staff members.
+type OrderMealVoid struct {
+	ID          uint            `gorm:"primaryKey;autoIncrement"`
+	OrderID     uint            `gorm:"not null; index"`
+	OrderMealID uint            `gorm:"not null; index"`
+	FromStatus  OrderMealStatus `gorm:"not null"`
+	Quantity    uint            `gorm:"check:quantity >= 1"`
+	Reason      string          `gorm:"not null; check: reason <> ''"`
+	VoidedBy    string          `gorm:"not null"`
+	VoidedAt    time.Time       `gorm:"not null"`
 }
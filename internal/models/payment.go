@@ -0,0 +1,202 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// PaymentMethod represents the way a payment was made.
+type PaymentMethod string
+
+const (
+	CashPayment    PaymentMethod = "cash"
+	CardPayment    PaymentMethod = "card"
+	VoucherPayment PaymentMethod = "voucher"
+)
+
+// Valid checks if the PaymentMethod is one of the predefined valid methods, returning an error if invalid.
+func (m PaymentMethod) Valid() error {
+	switch m {
+	case CashPayment, CardPayment, VoucherPayment:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Invalid payment method %s", m))
+	}
+}
+
+// Scan implements the sql.Scanner interface, allowing PaymentMethod to be scanned from database values.
+func (m *PaymentMethod) Scan(value interface{}) error {
+	if value == nil {
+		*m = ""
+		return nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		bytes, ok := value.([]byte)
+		if !ok {
+			return errors.New("invalid scan source for PaymentMethod")
+		}
+		str = string(bytes)
+	}
+
+	*m = PaymentMethod(str)
+	return m.Valid()
+}
+
+// Value converts the PaymentMethod to a driver.Value for database storage, returning an error if the value is invalid.
+func (m PaymentMethod) Value() (driver.Value, error) {
+	if err := m.Valid(); err != nil {
+		return nil, err
+	}
+	return string(m), nil
+}
+
+// Payment is a payment made by one of the payers of an order, recorded by a staff member.
+// Reference identifies the voucher or the card terminal transaction, TransactionID is assigned by the payment provider.
+// Tip is given on top of Amount and does not count towards the bill of the order.
+type Payment struct {
+	ID            uint            `gorm:"primaryKey;autoIncrement"`
+	OrderID       uint            `gorm:"not null; index"`
+	Method        PaymentMethod   `gorm:"not null"`
+	Amount        decimal.Decimal `gorm:"type:numeric(10,2); check: amount > 0"`
+	Tip           decimal.Decimal `gorm:"type:numeric(10,2); not null; default: 0; check: tip >= 0"`
+	Payer         string          `gorm:"not null"`
+	Reference     string          `gorm:"not null"`
+	TransactionID string          `gorm:"not null"`
+	RecordedBy    string          `gorm:"not null"`
+	CreatedAt     time.Time
+}
+
+// Balance holds how much of the bill of an order has been paid.
+type Balance struct {
+	Total       decimal.Decimal
+	Paid        decimal.Decimal
+	Outstanding decimal.Decimal
+}
+
+// Balance calculates the balance of the order from its bill and the given payments.
+func (o *Order) Balance(payments []*Payment) *Balance {
+	balance := &Balance{
+		Total: o.Bill().Total,
+		Paid:  decimal.Zero,
+	}
+
+	for _, payment := range payments {
+		balance.Paid = balance.Paid.Add(payment.Amount)
+	}
+
+	balance.Outstanding = balance.Total.Sub(balance.Paid)
+	return balance
+}
+
+// SplitShare is the amount a single payer owes.
+type SplitShare struct {
+	Payer  string
+	Amount decimal.Decimal
+}
+
+// PayerLines assigns units of order meals to a payer.
+type PayerLines struct {
+	Payer string
+	Lines []LineShare
+}
+
+// LineShare is a number of units of an order meal.
+type LineShare struct {
+	OrderMealID uint
+	Quantity    uint
+}
+
+// SplitEvenly splits the amount between the given number of payers.
+// Cents which cannot be split evenly are added to the first payers.
+func SplitEvenly(amount decimal.Decimal, payers uint) ([]SplitShare, error) {
+	if payers == 0 {
+		return nil, errors.New("At least one payer is required")
+	}
+
+	cents := amount.Shift(2).IntPart()
+	share := cents / int64(payers)
+	remainder := cents % int64(payers)
+
+	shares := make([]SplitShare, payers)
+	for i := range shares {
+		payerCents := share
+		if int64(i) < remainder {
+			payerCents++
+		}
+		shares[i] = SplitShare{
+			Payer:  fmt.Sprintf("Payer %d", i+1),
+			Amount: decimal.New(payerCents, -2),
+		}
+	}
+
+	return shares, nil
+}
+
+// SplitByLines splits the bill of the order between payers based on the order meals assigned to them.
+// Every billable unit of the order has to be assigned to exactly one payer.
+// Each payer pays the discounted price of their units including tax, the service charge and the tip of the bill
+// are split in proportion to it. The cents lost to rounding are added to the last payer so the shares add up to the bill total.
+func (o *Order) SplitByLines(assignments []PayerLines) ([]SplitShare, error) {
+	if len(assignments) == 0 {
+		return nil, errors.New("At least one payer is required")
+	}
+
+	orderMeals := make(map[uint]*OrderMeal)
+	remaining := make(map[uint]uint)
+	for i := range o.OrderMeals {
+		orderMeals[o.OrderMeals[i].ID] = &o.OrderMeals[i]
+		remaining[o.OrderMeals[i].ID] = o.OrderMeals[i].BillableQuantity()
+	}
+
+	amounts := make([]decimal.Decimal, len(assignments))
+	linesTotal := decimal.Zero
+	for i, assignment := range assignments {
+		amount := decimal.Zero
+		for _, line := range assignment.Lines {
+			orderMeal, ok := orderMeals[line.OrderMealID]
+			if !ok {
+				return nil, errors.New(fmt.Sprintf("Order meal %d is not part of the order", line.OrderMealID))
+			}
+
+			if line.Quantity == 0 || remaining[line.OrderMealID] < line.Quantity {
+				return nil, errors.New(fmt.Sprintf("Too many units of order meal %d assigned", line.OrderMealID))
+			}
+			remaining[line.OrderMealID] -= line.Quantity
+
+			price := o.DiscountedLineTotal(orderMeal).Mul(decimal.NewFromInt(int64(line.Quantity))).
+				Div(decimal.NewFromInt(int64(orderMeal.BillableQuantity())))
+			amount = amount.Add(price.Add(price.Mul(orderMeal.TaxRate)))
+		}
+
+		amounts[i] = amount
+		linesTotal = linesTotal.Add(amount)
+	}
+
+	for orderMealID, quantity := range remaining {
+		if quantity > 0 {
+			return nil, errors.New(fmt.Sprintf("%d units of order meal %d are not assigned to any payer", quantity, orderMealID))
+		}
+	}
+
+	bill := o.Bill()
+	extras := bill.ServiceCharge.Add(bill.Tip)
+	shares := make([]SplitShare, len(assignments))
+	assigned := decimal.Zero
+	for i, amount := range amounts {
+		if linesTotal.IsPositive() {
+			amount = amount.Add(extras.Mul(amount).Div(linesTotal))
+		}
+
+		shares[i] = SplitShare{Payer: assignments[i].Payer, Amount: amount.Round(2)}
+		assigned = assigned.Add(shares[i].Amount)
+	}
+
+	last := &shares[len(shares)-1]
+	last.Amount = last.Amount.Add(bill.Total.Sub(assigned))
+	return shares, nil
+}
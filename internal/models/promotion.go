@@ -0,0 +1,368 @@
+package models
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PromotionKind represents the rule a promotion discounts ordered meals by.
+type PromotionKind string
+
+const (
+	// PercentageOffPromotion takes Percent off the price of every meal of the category.
+	PercentageOffPromotion PromotionKind = "percentage_off"
+	// FixedOffPromotion takes Amount off the price of every meal of the category.
+	FixedOffPromotion PromotionKind = "fixed_off"
+	// BuyXGetYPromotion makes FreeQuantity meals of the category free for every BuyQuantity meals bought,
+	// the cheapest meals are the free ones.
+	BuyXGetYPromotion PromotionKind = "buy_x_get_y"
+	// ComboPromotion sells one of each of the ComboMeals together for Amount.
+	ComboPromotion PromotionKind = "combo"
+)
+
+// Valid checks if the PromotionKind is one of the predefined valid kinds, returning an error if invalid.
+func (k PromotionKind) Valid() error {
+	switch k {
+	case PercentageOffPromotion, FixedOffPromotion, BuyXGetYPromotion, ComboPromotion:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Invalid promotion kind %s", k))
+	}
+}
+
+// Scan implements the sql.Scanner interface, allowing PromotionKind to be scanned from database values.
+func (k *PromotionKind) Scan(value interface{}) error {
+	if value == nil {
+		*k = ""
+		return nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		bytes, ok := value.([]byte)
+		if !ok {
+			return errors.New("invalid scan source for PromotionKind")
+		}
+		str = string(bytes)
+	}
+
+	*k = PromotionKind(str)
+	return k.Valid()
+}
+
+// Value converts the PromotionKind to a driver.Value for database storage, returning an error if the value is invalid.
+func (k PromotionKind) Value() (driver.Value, error) {
+	if err := k.Valid(); err != nil {
+		return nil, err
+	}
+	return string(k), nil
+}
+
+// Promotion is a rule discounting ordered meals. Promotions apply while they are Active and within one of their
+// Windows, promotions without windows apply at any time. CategoryID limits percentage off, fixed off and
+// buy X get Y promotions to meals of the category, they apply to all meals if it is not set.
+// Promotions with a Code only apply to orders the customer entered the code for,
+// UsageLimit limits the number of orders the code can be used for.
+// Promotions are applied in the order of their Priority, highest first, and every unit of a meal
+// is discounted by at most one promotion.
+type Promotion struct {
+	ID           uint            `gorm:"primaryKey;autoIncrement"`
+	Name         string          `gorm:"not null; uniqueIndex; check: name <> ''"`
+	Kind         PromotionKind   `gorm:"type:varchar(20); not null"`
+	Active       bool            `gorm:"not null"`
+	Priority     int             `gorm:"not null; default: 0"`
+	CategoryID   *uint           `gorm:"index"`
+	Category     *Category       `gorm:"foreignKey:CategoryID"`
+	Percent      decimal.Decimal `gorm:"type:numeric(5,2); not null; default: 0"`
+	Amount       decimal.Decimal `gorm:"type:numeric(10,2); not null; default: 0"`
+	BuyQuantity  uint            `gorm:"not null; default: 0"`
+	FreeQuantity uint            `gorm:"not null; default: 0"`
+	Code         *string         `gorm:"uniqueIndex"`
+	UsageLimit   *uint
+	UsageCount   uint                 `gorm:"not null; default: 0"`
+	ComboMeals   []PromotionComboMeal `gorm:"foreignKey:PromotionID; constraint:OnDelete:CASCADE"`
+	Windows      []AvailabilityWindow `gorm:"foreignKey:PromotionID; constraint:OnDelete:CASCADE"`
+}
+
+// PromotionComboMeal is a meal which is part of a combo promotion.
+type PromotionComboMeal struct {
+	ID          uint  `gorm:"primaryKey;autoIncrement"`
+	PromotionID uint  `gorm:"not null; uniqueIndex:idx_promotion_combo_meals_promotion_meal"`
+	MealID      uint  `gorm:"not null; uniqueIndex:idx_promotion_combo_meals_promotion_meal"`
+	Meal        *Meal `gorm:"foreignKey:MealID"`
+}
+
+// OrderDiscount is a discount of Quantity units of an order meal by UnitAmount each, granted by a promotion.
+// Name is a snapshot of the name of the promotion, so later changes to the promotion do not change the bill.
+// Discounts of cancelled or voided units do not count.
+type OrderDiscount struct {
+	ID          uint            `gorm:"primaryKey;autoIncrement"`
+	OrderID     uint            `gorm:"not null; index"`
+	OrderMealID uint            `gorm:"not null; index"`
+	PromotionID *uint           `gorm:"index"`
+	Promotion   *Promotion      `gorm:"foreignKey:PromotionID; constraint:OnDelete:SET NULL"`
+	Name        string          `gorm:"not null"`
+	UnitAmount  decimal.Decimal `gorm:"type:numeric(10,2); not null; check: unit_amount > 0"`
+	Quantity    uint            `gorm:"not null; check: quantity > 0"`
+}
+
+// NormalizePromoCode returns the promo code the way it is stored, promo codes are not case-sensitive.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks that the promotion has a name, a valid kind and the settings its kind needs.
+func (p *Promotion) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("Promotion name is required")
+	}
+
+	if err := p.Kind.Valid(); err != nil {
+		return err
+	}
+
+	switch p.Kind {
+	case PercentageOffPromotion:
+		if !p.Percent.IsPositive() || p.Percent.GreaterThan(decimal.NewFromInt(100)) {
+			return errors.New(fmt.Sprintf("Percent off of promotion %s has to be between 0 and 100", p.Name))
+		}
+	case FixedOffPromotion:
+		if !p.Amount.IsPositive() {
+			return errors.New(fmt.Sprintf("Amount off of promotion %s has to be positive", p.Name))
+		}
+	case BuyXGetYPromotion:
+		if p.BuyQuantity == 0 || p.FreeQuantity == 0 {
+			return errors.New(fmt.Sprintf("Promotion %s has to have both a bought and a free quantity", p.Name))
+		}
+	case ComboPromotion:
+		if len(p.ComboMeals) < 2 {
+			return errors.New(fmt.Sprintf("Combo %s has to consist of at least two meals", p.Name))
+		}
+		if !p.Amount.IsPositive() {
+			return errors.New(fmt.Sprintf("Price of combo %s has to be positive", p.Name))
+		}
+		seen := make(map[uint]bool, len(p.ComboMeals))
+		for _, comboMeal := range p.ComboMeals {
+			if seen[comboMeal.MealID] {
+				return errors.New(fmt.Sprintf("Meal %d is part of combo %s twice", comboMeal.MealID, p.Name))
+			}
+			seen[comboMeal.MealID] = true
+		}
+	}
+
+	if p.Code != nil && *p.Code == "" {
+		return errors.New("Promo code cannot be empty")
+	}
+
+	if p.UsageLimit != nil && p.Code == nil {
+		return errors.New(fmt.Sprintf("Promotion %s needs a promo code to limit its usage", p.Name))
+	}
+
+	for i := range p.Windows {
+		if err := p.Windows[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AppliesAt reports whether the promotion applies at the given time to an order the customer entered the code for.
+func (p *Promotion) AppliesAt(t time.Time, code string) bool {
+	if p.Code != nil && *p.Code != code {
+		return false
+	}
+
+	return p.Active && withinWindows(p.Windows, t)
+}
+
+// UsedUp reports whether the promo code of the promotion reached its usage limit.
+func (p *Promotion) UsedUp() bool {
+	return p.UsageLimit != nil && p.UsageCount >= *p.UsageLimit
+}
+
+// matches reports whether the promotion discounts the meal of the item.
+func (p *Promotion) matches(item *PromotionItem) bool {
+	return p.CategoryID == nil || *p.CategoryID == item.CategoryID
+}
+
+// PromotionItem is a number of units of an order meal ordered at the same time, which promotions can discount.
+type PromotionItem struct {
+	OrderMealID uint
+	MealID      uint
+	CategoryID  uint
+	UnitPrice   decimal.Decimal
+	Quantity    uint
+}
+
+// promotionEngine applies promotions to the items of an order, keeping track of the units already discounted.
+type promotionEngine struct {
+	remaining map[*PromotionItem]uint
+	discounts []OrderDiscount
+}
+
+// ApplyPromotions evaluates the promotions against the items ordered at the given time with the given promo code
+// and returns the discounts granted. Promotions are applied in the order of their priority
+// and every unit is discounted by at most one promotion.
+func ApplyPromotions(promotions []*Promotion, items []PromotionItem, code string, at time.Time) []OrderDiscount {
+	engine := &promotionEngine{remaining: make(map[*PromotionItem]uint, len(items))}
+	for i := range items {
+		engine.remaining[&items[i]] = items[i].Quantity
+	}
+
+	sorted := slices.Clone(promotions)
+	slices.SortStableFunc(sorted, func(a, b *Promotion) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+
+	for _, promotion := range sorted {
+		if !promotion.AppliesAt(at, code) {
+			continue
+		}
+
+		switch promotion.Kind {
+		case PercentageOffPromotion:
+			engine.applyPerUnit(promotion, items, func(unitPrice decimal.Decimal) decimal.Decimal {
+				return unitPrice.Mul(promotion.Percent).Div(decimal.NewFromInt(100)).Round(2)
+			})
+		case FixedOffPromotion:
+			engine.applyPerUnit(promotion, items, func(unitPrice decimal.Decimal) decimal.Decimal {
+				return decimal.Min(promotion.Amount, unitPrice)
+			})
+		case BuyXGetYPromotion:
+			engine.applyBuyXGetY(promotion, items)
+		case ComboPromotion:
+			engine.applyCombo(promotion, items)
+		}
+	}
+
+	return engine.discounts
+}
+
+// applyPerUnit discounts every remaining unit the promotion matches by the amount off its unit price.
+func (e *promotionEngine) applyPerUnit(promotion *Promotion, items []PromotionItem, amountOff func(decimal.Decimal) decimal.Decimal) {
+	for i := range items {
+		item := &items[i]
+		if !promotion.matches(item) || e.remaining[item] == 0 {
+			continue
+		}
+
+		e.discount(promotion, item, amountOff(item.UnitPrice), e.remaining[item])
+	}
+}
+
+// applyBuyXGetY groups the remaining units the promotion matches and makes the cheapest units of every group free.
+// Units which are left over after grouping stay available to other promotions.
+func (e *promotionEngine) applyBuyXGetY(promotion *Promotion, items []PromotionItem) {
+	// Every unit is represented by its item
+	var units []*PromotionItem
+	for i := range items {
+		item := &items[i]
+		if !promotion.matches(item) {
+			continue
+		}
+		for range e.remaining[item] {
+			units = append(units, item)
+		}
+	}
+
+	groupSize := promotion.BuyQuantity + promotion.FreeQuantity
+	groups := uint(len(units)) / groupSize
+	if groups == 0 {
+		return
+	}
+
+	slices.SortStableFunc(units, func(a, b *PromotionItem) int {
+		return a.UnitPrice.Cmp(b.UnitPrice)
+	})
+
+	free := groups * promotion.FreeQuantity
+	for _, unit := range units[:free] {
+		e.discount(promotion, unit, unit.UnitPrice, 1)
+	}
+
+	// The bought units of the groups are used up by the promotion as well
+	for _, unit := range units[free : groups*groupSize] {
+		e.remaining[unit]--
+	}
+}
+
+// applyCombo sells as many combos as there are remaining units of every meal of the combo.
+// The difference between the regular prices of the meals and the price of the combo is split between the meals
+// by their price, the cents lost to rounding are taken off the last meal of the combo.
+func (e *promotionEngine) applyCombo(promotion *Promotion, items []PromotionItem) {
+	for {
+		units := make([]*PromotionItem, len(promotion.ComboMeals))
+		regularPrice := decimal.Zero
+		for i, comboMeal := range promotion.ComboMeals {
+			units[i] = e.remainingItemOf(items, comboMeal.MealID)
+			if units[i] == nil {
+				return
+			}
+			regularPrice = regularPrice.Add(units[i].UnitPrice)
+		}
+
+		saving := regularPrice.Sub(promotion.Amount)
+		if !saving.IsPositive() {
+			return
+		}
+
+		allocated := decimal.Zero
+		for i, item := range units {
+			amount := saving.Sub(allocated)
+			if i < len(units)-1 {
+				amount = saving.Mul(item.UnitPrice).Div(regularPrice).Round(2)
+			}
+			allocated = allocated.Add(amount)
+
+			if amount.IsPositive() {
+				e.discount(promotion, item, amount, 1)
+			} else {
+				e.remaining[item]--
+			}
+		}
+	}
+}
+
+// remainingItemOf returns an item of the meal with remaining units, or nil if there is none.
+func (e *promotionEngine) remainingItemOf(items []PromotionItem, mealID uint) *PromotionItem {
+	for i := range items {
+		if items[i].MealID == mealID && e.remaining[&items[i]] > 0 {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+// discount records a discount of quantity units of the item by the promotion, merging it into an earlier discount
+// of the same units by the same amount.
+func (e *promotionEngine) discount(promotion *Promotion, item *PromotionItem, unitAmount decimal.Decimal, quantity uint) {
+	e.remaining[item] -= quantity
+	if !unitAmount.IsPositive() {
+		return
+	}
+
+	for i := range e.discounts {
+		discount := &e.discounts[i]
+		if discount.OrderMealID == item.OrderMealID && *discount.PromotionID == promotion.ID &&
+			discount.UnitAmount.Equal(unitAmount) {
+			discount.Quantity += quantity
+			return
+		}
+	}
+
+	promotionID := promotion.ID
+	e.discounts = append(e.discounts, OrderDiscount{
+		OrderMealID: item.OrderMealID,
+		PromotionID: &promotionID,
+		Name:        promotion.Name,
+		UnitAmount:  unitAmount,
+		Quantity:    quantity,
+	})
+}
@@ -4,17 +4,185 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// ReviewStatus is the moderation status of a review.
+// Flagged reviews stay public until a moderator hides them, hidden reviews are not public and do not count towards ratings.
+type ReviewStatus string
+
+const (
+	PublishedReview ReviewStatus = "published"
+	FlaggedReview   ReviewStatus = "flagged"
+	HiddenReview    ReviewStatus = "hidden"
+)
+
+// Valid checks if the ReviewStatus is one of the predefined valid statuses, returning an error if invalid.
+func (s ReviewStatus) Valid() error {
+	switch s {
+	case PublishedReview, FlaggedReview, HiddenReview:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Invalid review status %s", s))
+	}
+}
+
+// Scan implements the sql.Scanner interface, allowing ReviewStatus to be scanned from database values.
+func (s *ReviewStatus) Scan(value interface{}) error {
+	if value == nil {
+		*s = ""
+		return nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		bytes, ok := value.([]byte)
+		if !ok {
+			return errors.New("invalid scan source for ReviewStatus")
+		}
+		str = string(bytes)
+	}
+
+	*s = ReviewStatus(str)
+	return s.Valid()
+}
+
+// Value converts the ReviewStatus to a driver.Value for database storage, returning an error if the value is invalid.
+func (s ReviewStatus) Value() (driver.Value, error) {
+	if err := s.Valid(); err != nil {
+		return nil, err
+	}
+	return string(s), nil
+}
+
+// Review is the review a customer left for an order.
+// PhotoPublicIDs are the storage public IDs of the photos, in the same order as PhotoURLs.
+// Reviews created before public IDs were kept have none, their photos can be removed from the review only.
+// PhotoVariants are the scaled copies of each photo, in the same order as PhotoURLs.
+// Reviews created before variants were stored have none.
+// ModeratedBy and ModeratedAt record the staff member who last changed the status.
+// MealRatings optionally rate single meals of the order in addition to the order as a whole.
 type Review struct {
-	ID        uint `gorm:"primaryKey"`
-	OrderID   uint `gorm:"unique; not null; constraint: OnDelete:CASCADE, OnUpdate:CASCADE; references:orders(ID)"`
-	Rating    int  `gorm:"check:rating >= 1 AND rating <= 5"`
-	Comment   *string
-	PhotoURLs pq.StringArray `gorm:"type:text[]; not null"`
+	ID             uint `gorm:"primaryKey"`
+	OrderID        uint `gorm:"unique; not null; constraint: OnDelete:CASCADE, OnUpdate:CASCADE; references:orders(ID)"`
+	Rating         int  `gorm:"check:rating >= 1 AND rating <= 5"`
+	Comment        *string
+	PhotoURLs      pq.StringArray `gorm:"type:text[]; not null"`
+	PhotoPublicIDs pq.StringArray `gorm:"type:text[]; not null; default: '{}'"`
+	PhotoVariants  PhotoVariants  `gorm:"type:json; not null; default: '[]'"`
+	Status         ReviewStatus   `gorm:"not null; default: 'published'; index"`
+	ModeratedBy    string         `gorm:"not null; default: ''"`
+	ModeratedAt    *time.Time
+	CreatedAt      time.Time          `gorm:"not null; default: CURRENT_TIMESTAMP; index"`
+	Replies        []ReviewReply      `gorm:"foreignKey:ReviewID; constraint:OnDelete:CASCADE"`
+	MealRatings    []ReviewMealRating `gorm:"foreignKey:ReviewID; constraint:OnDelete:CASCADE"`
+}
+
+// MaxMealRatingCommentLength is the maximum number of characters of the comment on a single meal.
+const MaxMealRatingCommentLength = 280
+
+// ReviewMealRating is the rating of a single meal of the reviewed order.
+// MealID is copied from the order meal, so meals can be rated without joining their orders.
+type ReviewMealRating struct {
+	ID          uint `gorm:"primaryKey;autoIncrement"`
+	ReviewID    uint `gorm:"not null; uniqueIndex:idx_review_meal_ratings_order_meal"`
+	OrderMealID uint `gorm:"not null; uniqueIndex:idx_review_meal_ratings_order_meal"`
+	MealID      uint `gorm:"not null; index"`
+	Rating      int  `gorm:"not null; check: rating >= 1 AND rating <= 5"`
+	Comment     *string
+}
+
+// ReviewReply is a public reply of a staff member to a review.
+type ReviewReply struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ReviewID  uint      `gorm:"not null; index"`
+	Message   string    `gorm:"not null; check: message <> ''"`
+	RepliedBy string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// ReviewFeedItem is a review along with the names of the meals of the reviewed order.
+type ReviewFeedItem struct {
+	Review
+	MealNames []string
+}
+
+// MealRating is the average of the ratings a meal got in reviews, the same rating meals are listed with.
+// Reviews is the number of reviews which rated the meal, hidden reviews do not count.
+type MealRating struct {
+	MealID  uint
+	Reviews uint
+	Average float64
+}
+
+// RemovePhoto removes the photo at the index from the review and returns the public IDs of all its variants,
+// which are empty if the review does not know them.
+func (r *Review) RemovePhoto(index int) ([]string, error) {
+	if index < 0 || index >= len(r.PhotoURLs) {
+		return nil, errors.New(fmt.Sprintf("Review has no photo %d", index))
+	}
+
+	var publicIDs []string
+	if len(r.PhotoVariants) == len(r.PhotoURLs) {
+		publicIDs = r.PhotoVariants[index].PublicIDs()
+		r.PhotoVariants = append(r.PhotoVariants[:index:index], r.PhotoVariants[index+1:]...)
+	}
+	if len(r.PhotoPublicIDs) == len(r.PhotoURLs) {
+		if len(publicIDs) == 0 {
+			publicIDs = []string{r.PhotoPublicIDs[index]}
+		}
+		r.PhotoPublicIDs = append(r.PhotoPublicIDs[:index:index], r.PhotoPublicIDs[index+1:]...)
+	}
+	r.PhotoURLs = append(r.PhotoURLs[:index:index], r.PhotoURLs[index+1:]...)
+
+	return publicIDs, nil
+}
+
+// ValidateMealRatings checks that the meal ratings rate meals of the order, each one at most once,
+// and copies the meals of the rated order meals to the ratings.
+// Only meals the customer was billed for can be rated.
+func (r *Review) ValidateMealRatings(orderMeals []OrderMeal) error {
+	rated := make(map[uint]bool, len(r.MealRatings))
+	for i := range r.MealRatings {
+		mealRating := &r.MealRatings[i]
+
+		if mealRating.Rating < 1 || mealRating.Rating > 5 {
+			return errors.New(fmt.Sprintf("Invalid rating %d of order meal %d", mealRating.Rating, mealRating.OrderMealID))
+		}
+
+		if mealRating.Comment != nil && utf8.RuneCountInString(*mealRating.Comment) > MaxMealRatingCommentLength {
+			return errors.New(fmt.Sprintf("Comment on order meal %d is longer than %d characters",
+				mealRating.OrderMealID, MaxMealRatingCommentLength))
+		}
+
+		if rated[mealRating.OrderMealID] {
+			return errors.New(fmt.Sprintf("Order meal %d is rated more than once", mealRating.OrderMealID))
+		}
+		rated[mealRating.OrderMealID] = true
+
+		index := slices.IndexFunc(orderMeals, func(orderMeal OrderMeal) bool {
+			return orderMeal.ID == mealRating.OrderMealID
+		})
+		if index < 0 || orderMeals[index].BillableQuantity() == 0 {
+			return errors.New(fmt.Sprintf("Order meal %d is not part of the order", mealRating.OrderMealID))
+		}
+		mealRating.MealID = orderMeals[index].MealID
+	}
+
+	return nil
+}
+
+// ReviewExport is a review along with the time and table of the order it was left for.
+type ReviewExport struct {
+	Review
+	OrderedAt time.Time
+	TableNo   int
 }
 
 func (r *Review) BeforeCreate(db *gorm.DB) error {
@@ -22,6 +190,25 @@ func (r *Review) BeforeCreate(db *gorm.DB) error {
 		r.PhotoURLs = pq.StringArray{}
 	}
 
+	if r.PhotoPublicIDs == nil {
+		r.PhotoPublicIDs = pq.StringArray{}
+	}
+
+	if r.PhotoVariants == nil {
+		r.PhotoVariants = PhotoVariants{}
+	}
+
+	if r.Status == "" {
+		r.Status = PublishedReview
+	}
+
+	if r.Comment != nil && len(strings.TrimSpace(*r.Comment)) == 0 {
+		r.Comment = nil
+	}
+	return nil
+}
+
+func (r *ReviewMealRating) BeforeCreate(db *gorm.DB) error {
 	if r.Comment != nil && len(strings.TrimSpace(*r.Comment)) == 0 {
 		r.Comment = nil
 	}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// Table is a table of the restaurant customers can order to.
+// Inactive tables cannot be ordered to.
+// Customers order using a signed token of the table. Only tokens of the current TokenVersion are accepted,
+// rotating the token invalidates all previously issued ones. Revoked tokens are rejected until the next rotation.
+type Table struct {
+	Number       int    `gorm:"primaryKey;autoIncrement:false;check:number >= 1"`
+	Seats        uint   `gorm:"check:seats >= 1"`
+	Area         string `gorm:"not null"`
+	Active       bool   `gorm:"not null"`
+	TokenVersion uint   `gorm:"not null;default:1"`
+	TokenRevoked bool   `gorm:"not null;default:false"`
+}
+
+// RotateToken invalidates all previously issued tokens of the table and reinstates a revoked token.
+func (t *Table) RotateToken() {
+	t.TokenVersion++
+	t.TokenRevoked = false
+}
+
+// TableOverview is the current state of a table shown on the staff dashboard.
+// Session is nil if the table has no open session.
+type TableOverview struct {
+	Table   *Table
+	Session *TableSession
+}
+
+// TableSession groups all orders made on a table from the first order until the table is paid.
+// A table has at most one open session at a time.
+type TableSession struct {
+	ID       uint       `gorm:"primaryKey;autoIncrement"`
+	TableNo  int        `gorm:"not null; uniqueIndex:idx_open_table_session,where:closed_at IS NULL"`
+	OpenedAt time.Time  `gorm:"not null"`
+	ClosedAt *time.Time `gorm:"index"`
+	Orders   []Order    `gorm:"foreignKey:TableSessionID"`
+}
+
+// Balance calculates the balance of all orders of the session which were not cancelled.
+// The orders need to have their payments loaded.
+func (s *TableSession) Balance() *Balance {
+	balance := &Balance{
+		Total:       decimal.Zero,
+		Paid:        decimal.Zero,
+		Outstanding: decimal.Zero,
+	}
+
+	for _, order := range s.Orders {
+		if order.CancelledAt != nil {
+			continue
+		}
+
+		orderBalance := order.Balance(order.Payments)
+		balance.Total = balance.Total.Add(orderBalance.Total)
+		balance.Paid = balance.Paid.Add(orderBalance.Paid)
+		balance.Outstanding = balance.Outstanding.Add(orderBalance.Outstanding)
+	}
+
+	return balance
+}
+
+// UnservedMeals returns the number of meal units ordered in the session which have not been served yet.
+func (s *TableSession) UnservedMeals() uint {
+	var unserved uint
+	for _, order := range s.Orders {
+		for _, orderMeal := range order.OrderMeals {
+			unserved += orderMeal.CountIn(PendingStatus) + orderMeal.CountIn(AcceptedStatus) +
+				orderMeal.CountIn(CookingStatus) + orderMeal.CountIn(ReadyStatus)
+		}
+	}
+	return unserved
+}
+
+// Settled reports whether every order of the session is either paid or cancelled.
+func (s *TableSession) Settled() bool {
+	for _, order := range s.Orders {
+		if order.PaidAt == nil && order.CancelledAt == nil {
+			return false
+		}
+	}
+	return true
+}
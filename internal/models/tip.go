@@ -0,0 +1,214 @@
+package models
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"slices"
+	"strings"
+	"time"
+)
+
+// UnassignedStaff collects the tips of orders none of whose meals were completed by a staff member.
+const UnassignedStaff = "unassigned"
+
+// Tip is a tip received for an order. It is either the tip the customer added to the bill of the order,
+// received once the order is paid in full, or a tip given on top of the payment with PaymentID.
+// ReceivedAt decides the shift the tip counts towards.
+type Tip struct {
+	ID         uint            `gorm:"primaryKey;autoIncrement"`
+	OrderID    uint            `gorm:"not null; index"`
+	PaymentID  *uint           `gorm:"index"`
+	Amount     decimal.Decimal `gorm:"type:numeric(10,2); not null; check: amount > 0"`
+	ReceivedAt time.Time       `gorm:"not null; index"`
+}
+
+// SetTip sets the tip the customer adds to the bill of the order, which is either a fixed amount
+// or a percentage of the bill. Setting both to zero removes the tip.
+func (o *Order) SetTip(amount, percent decimal.Decimal) error {
+	if amount.IsNegative() || percent.IsNegative() {
+		return errors.New("Tip cannot be negative")
+	}
+
+	if amount.IsPositive() && percent.IsPositive() {
+		return errors.New("Tip has to be either a fixed amount or a percentage")
+	}
+
+	if percent.GreaterThan(decimal.NewFromInt(100)) {
+		return errors.New("Tip cannot be more than 100 percent")
+	}
+
+	o.TipAmount = amount.Round(2)
+	o.TipPercent = percent
+	return nil
+}
+
+// Shift is a recurring part of the day staff work in. StartMinute and EndMinute are minutes since midnight,
+// shifts ending before they start span midnight and shifts starting and ending at the same time last the whole day.
+type Shift struct {
+	Name        string
+	StartMinute uint
+	EndMinute   uint
+}
+
+// contains reports whether the minute of the day falls into the shift.
+func (s *Shift) contains(minute uint) bool {
+	switch {
+	case s.StartMinute == s.EndMinute:
+		return true
+	case s.StartMinute < s.EndMinute:
+		return minute >= s.StartMinute && minute < s.EndMinute
+	default:
+		return minute >= s.StartMinute || minute < s.EndMinute
+	}
+}
+
+// OutsideShifts is the shift of the times which do not fall into any of the configured shifts.
+var OutsideShifts = Shift{Name: "Outside shifts"}
+
+// ShiftAt returns the first of the shifts the time falls into along with the date the shift started on.
+// Shifts spanning midnight started on the previous day for times after midnight.
+func ShiftAt(shifts []Shift, t time.Time) (Shift, time.Time) {
+	minute := uint(t.Hour()*60 + t.Minute())
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	for _, shift := range shifts {
+		if !shift.contains(minute) {
+			continue
+		}
+
+		if shift.StartMinute > shift.EndMinute && minute < shift.EndMinute {
+			date = date.AddDate(0, 0, -1)
+		}
+		return shift, date
+	}
+
+	return OutsideShifts, date
+}
+
+// StaffTips is the share of tips of a staff member.
+type StaffTips struct {
+	Username string
+	Amount   decimal.Decimal
+}
+
+// ShiftTips holds the tips received during a shift which started on Date.
+type ShiftTips struct {
+	Date  time.Time
+	Shift Shift
+	Total decimal.Decimal
+	Staff []StaffTips
+}
+
+// TipReport holds the tips received between From and To, split per shift and per staff member.
+type TipReport struct {
+	From   time.Time
+	To     time.Time
+	Total  decimal.Decimal
+	Shifts []ShiftTips
+	Staff  []StaffTips
+}
+
+// NewTipReport splits the tips between the shifts they were received in and between the staff members
+// who completed the meals of their orders, by the number of units each of them completed.
+// completions are the transitions of the meals of the orders to ready. Tips of orders without completed meals
+// go to UnassignedStaff. Shifts are ordered by the time they started, staff members by their username.
+func NewTipReport(from, to time.Time, tips []*Tip, completions []OrderMealStatusChange, shifts []Shift) *TipReport {
+	completedBy := make(map[uint]map[string]uint)
+	for _, completion := range completions {
+		if completion.ToStatus != ReadyStatus {
+			continue
+		}
+		if completedBy[completion.OrderID] == nil {
+			completedBy[completion.OrderID] = make(map[string]uint)
+		}
+		completedBy[completion.OrderID][completion.ChangedBy] += completion.Quantity
+	}
+
+	report := &TipReport{From: from, To: to, Total: decimal.Zero, Shifts: []ShiftTips{}}
+	staffTotals := make(map[string]decimal.Decimal)
+	for _, tip := range tips {
+		shift, date := ShiftAt(shifts, tip.ReceivedAt)
+		index := slices.IndexFunc(report.Shifts, func(shiftTips ShiftTips) bool {
+			return shiftTips.Date.Equal(date) && shiftTips.Shift.Name == shift.Name
+		})
+		if index == -1 {
+			index = len(report.Shifts)
+			report.Shifts = append(report.Shifts, ShiftTips{Date: date, Shift: shift, Total: decimal.Zero})
+		}
+		shiftTips := &report.Shifts[index]
+
+		report.Total = report.Total.Add(tip.Amount)
+		shiftTips.Total = shiftTips.Total.Add(tip.Amount)
+
+		for _, share := range splitTip(tip.Amount, completedBy[tip.OrderID]) {
+			staffTotals[share.Username] = staffTotals[share.Username].Add(share.Amount)
+			shiftTips.Staff = addStaffTips(shiftTips.Staff, share)
+		}
+	}
+
+	slices.SortStableFunc(report.Shifts, func(a, b ShiftTips) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+		return int(a.Shift.StartMinute) - int(b.Shift.StartMinute)
+	})
+
+	report.Staff = make([]StaffTips, 0, len(staffTotals))
+	for username, amount := range staffTotals {
+		report.Staff = append(report.Staff, StaffTips{Username: username, Amount: amount})
+	}
+	sortStaffTips(report.Staff)
+
+	return report
+}
+
+// splitTip splits the tip between the staff members by the number of units each of them completed.
+// Cents which cannot be split by the units go to the staff members in the order of their username.
+func splitTip(amount decimal.Decimal, completedBy map[string]uint) []StaffTips {
+	var units uint
+	for _, quantity := range completedBy {
+		units += quantity
+	}
+
+	if units == 0 {
+		return []StaffTips{{Username: UnassignedStaff, Amount: amount}}
+	}
+
+	cents := amount.Shift(2).IntPart()
+	shares := make([]StaffTips, 0, len(completedBy))
+	var assigned int64
+	for username, quantity := range completedBy {
+		share := cents * int64(quantity) / int64(units)
+		assigned += share
+		shares = append(shares, StaffTips{Username: username, Amount: decimal.New(share, -2)})
+	}
+	sortStaffTips(shares)
+
+	for i := 0; assigned < cents; i++ {
+		shares[i%len(shares)].Amount = shares[i%len(shares)].Amount.Add(decimal.New(1, -2))
+		assigned++
+	}
+
+	return shares
+}
+
+// addStaffTips adds the share to the tips of its staff member, keeping the tips ordered by username.
+func addStaffTips(staffTips []StaffTips, share StaffTips) []StaffTips {
+	for i := range staffTips {
+		if staffTips[i].Username == share.Username {
+			staffTips[i].Amount = staffTips[i].Amount.Add(share.Amount)
+			return staffTips
+		}
+	}
+
+	staffTips = append(staffTips, share)
+	sortStaffTips(staffTips)
+	return staffTips
+}
+
+// sortStaffTips orders the tips by the username of their staff member.
+func sortStaffTips(staffTips []StaffTips) {
+	slices.SortFunc(staffTips, func(a, b StaffTips) int {
+		return strings.Compare(a.Username, b.Username)
+	})
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxReviewPhotos is the maximum number of photos attached to a review.
+const MaxReviewPhotos = 3
+
+// UploadPurpose is what the images of an upload session are attached to once they are uploaded.
+// Meal photos are cropped to a square while they are uploaded, review photos keep their aspect ratio.
+type UploadPurpose string
+
+const (
+	ReviewUpload UploadPurpose = "review"
+	MealUpload   UploadPurpose = "meal"
+)
+
+// Valid checks if the UploadPurpose is one of the predefined valid purposes, returning an error if invalid.
+func (p UploadPurpose) Valid() error {
+	switch p {
+	case ReviewUpload, MealUpload:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Invalid upload purpose %s", p))
+	}
+}
+
+// MaxUploads returns the maximum number of images which can be uploaded into a session for the purpose.
+func (p UploadPurpose) MaxUploads() int {
+	if p == MealUpload {
+		return 1
+	}
+	return MaxReviewPhotos
+}
+
+// Scan implements the sql.Scanner interface, allowing UploadPurpose to be scanned from database values.
+func (p *UploadPurpose) Scan(value interface{}) error {
+	if value == nil {
+		*p = ""
+		return nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		bytes, ok := value.([]byte)
+		if !ok {
+			return errors.New("invalid scan source for UploadPurpose")
+		}
+		str = string(bytes)
+	}
+
+	*p = UploadPurpose(str)
+	return p.Valid()
+}
+
+// Value converts the UploadPurpose to a driver.Value for database storage, returning an error if the value is invalid.
+func (p UploadPurpose) Value() (driver.Value, error) {
+	if err := p.Valid(); err != nil {
+		return nil, err
+	}
+	return string(p), nil
+}
+
+// UploadSession is a temporary session images are uploaded into before they are attached to a review or a meal.
+// Owner is the customer order or the staff member who started the session, only the owner can upload into
+// the session and attach its uploads. Uploads which are not attached before the session expires are deleted
+// along with their images.
+type UploadSession struct {
+	ID        string        `gorm:"primaryKey"`
+	Purpose   UploadPurpose `gorm:"not null"`
+	Owner     string        `gorm:"not null"`
+	ExpiresAt time.Time     `gorm:"not null; index"`
+	CreatedAt time.Time     `gorm:"not null"`
+	Uploads   []Upload      `gorm:"foreignKey:UploadSessionID; constraint:OnDelete:CASCADE"`
+}
+
+// Expired reports whether the session expired at the time.
+func (s *UploadSession) Expired(at time.Time) bool {
+	return !at.Before(s.ExpiresAt)
+}
+
+// PublicIDs returns the public IDs of all images uploaded into the session, including their variants.
+func (s *UploadSession) PublicIDs() []string {
+	var publicIDs []string
+	for _, upload := range s.Uploads {
+		publicIDs = append(publicIDs, upload.PublicIDs()...)
+	}
+	return publicIDs
+}
+
+// Upload is an image uploaded into an upload session along with its variants.
+type Upload struct {
+	ID              string        `gorm:"primaryKey"`
+	UploadSessionID string        `gorm:"not null; index"`
+	ImageURL        string        `gorm:"not null"`
+	ImagePublicID   string        `gorm:"not null"`
+	ImageVariants   ImageVariants `gorm:"type:json; not null; default: '[]'"`
+	CreatedAt       time.Time     `gorm:"not null"`
+}
+
+// PublicIDs returns the public IDs of all variants of the uploaded image, which include the image itself.
+func (u *Upload) PublicIDs() []string {
+	if len(u.ImageVariants) == 0 {
+		return []string{u.ImagePublicID}
+	}
+	return u.ImageVariants.PublicIDs()
+}
+
+// OrderUploadOwner returns the owner of the upload sessions started by the customer of the order.
+func OrderUploadOwner(orderID uint) string {
+	return fmt.Sprintf("order:%d", orderID)
+}
+
+// StaffUploadOwner returns the owner of the upload sessions started by the staff member.
+func StaffUploadOwner(username string) string {
+	return "staff:" + username
+}
+
+// UploadRef references an upload to attach by its ID along with the owner of its upload session.
+type UploadRef struct {
+	ID    string
+	Owner string
+}
@@ -0,0 +1,85 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// Charge is a charge accepted by the InMemoryProvider.
+type Charge struct {
+	TransactionID string
+	Method        models.PaymentMethod
+	Amount        decimal.Decimal
+	Reference     string
+	Refunded      bool
+}
+
+// InMemoryProvider implements PaymentProvider without talking to any payment processor.
+// It accepts every valid charge and keeps it in memory, which makes it suitable for offline use and tests.
+type InMemoryProvider struct {
+	mu      sync.Mutex
+	charges []Charge
+}
+
+// NewInMemoryProvider creates a new in-memory payment provider.
+func NewInMemoryProvider() *InMemoryProvider {
+	return &InMemoryProvider{}
+}
+
+// Charge records the charge. Voucher payments require a reference.
+func (p *InMemoryProvider) Charge(_ context.Context,
+	method models.PaymentMethod, amount decimal.Decimal, reference string) (*ChargeResult, error) {
+	if !amount.IsPositive() {
+		return nil, apperrors.NewValidationErr("Charged amount must be positive", nil)
+	}
+
+	if method == models.VoucherPayment && reference == "" {
+		return nil, apperrors.NewValidationErr("Voucher code is required", nil)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge := Charge{
+		TransactionID: fmt.Sprintf("mem-%d", len(p.charges)+1),
+		Method:        method,
+		Amount:        amount,
+		Reference:     reference,
+	}
+	p.charges = append(p.charges, charge)
+
+	return &ChargeResult{TransactionID: charge.TransactionID}, nil
+}
+
+// Refund marks the charge with the transaction ID as refunded.
+func (p *InMemoryProvider) Refund(_ context.Context, transactionID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := range p.charges {
+		if p.charges[i].TransactionID != transactionID {
+			continue
+		}
+
+		if p.charges[i].Refunded {
+			return apperrors.NewValidationErr(fmt.Sprintf("Charge %s has already been refunded", transactionID), nil)
+		}
+		p.charges[i].Refunded = true
+		return nil
+	}
+
+	return apperrors.NewNotFoundErr(fmt.Sprintf("Charge %s not found", transactionID), nil)
+}
+
+// Charges returns all charges accepted so far, including the refunded ones.
+func (p *InMemoryProvider) Charges() []Charge {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Charge(nil), p.charges...)
+}
@@ -0,0 +1,21 @@
+package payments
+
+import (
+	"context"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// ChargeResult represents the result of a charge made through a payment provider.
+type ChargeResult struct {
+	TransactionID string
+}
+
+// PaymentProvider defines an interface for charging payments and refunding them.
+type PaymentProvider interface {
+	// Charge charges the amount using the given payment method.
+	// The reference identifies the voucher or the card terminal transaction.
+	Charge(ctx context.Context, method models.PaymentMethod, amount decimal.Decimal, reference string) (*ChargeResult, error)
+	// Refund refunds the whole charge with the given transaction ID.
+	Refund(ctx context.Context, transactionID string) error
+}
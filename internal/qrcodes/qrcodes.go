@@ -0,0 +1,70 @@
+package qrcodes
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/skip2/go-qrcode"
+)
+
+// Format is the image format QR codes get rendered in.
+type Format string
+
+const (
+	PNG Format = "png"
+	SVG Format = "svg"
+)
+
+// Valid checks whether the format is one of the supported formats.
+func (f Format) Valid() bool {
+	return f == PNG || f == SVG
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == SVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Render encodes the content as a QR code and renders it in the given format.
+// PNG images are size pixels wide, SVG images scale to the given size.
+func Render(content string, format Format, size int) ([]byte, error) {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to encode QR code", err)
+	}
+
+	switch format {
+	case PNG:
+		image, err := code.PNG(size)
+		if err != nil {
+			return nil, apperrors.NewInternalServerErr("Failed to render QR code", err)
+		}
+		return image, nil
+	case SVG:
+		return renderSVG(code.Bitmap(), size), nil
+	default:
+		return nil, apperrors.NewValidationErr(fmt.Sprintf("Unsupported QR code format %s", format), nil)
+	}
+}
+
+// renderSVG draws every dark module of the bitmap, including the quiet zone, as a unit square of a single path.
+func renderSVG(bitmap [][]bool, size int) []byte {
+	var path bytes.Buffer
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	var svg bytes.Buffer
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, len(bitmap), len(bitmap))
+	svg.WriteString(`<rect width="100%" height="100%" fill="#fff"/>`)
+	fmt.Fprintf(&svg, `<path fill="#000" d="%s"/></svg>`, path.String())
+	return svg.Bytes()
+}
@@ -0,0 +1,261 @@
+package repositories
+
+import (
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// AnalyticsRepository provides an interface for aggregating sales and kitchen performance.
+// All methods consider the orders and table sessions started from the start of the time range until before its end.
+// Revenue is the price of the billable units of the order meals net of the discounts granted by promotions.
+// RevenueByDay retrieves the revenue per day, ordered by day.
+// RevenueByHour retrieves the revenue per hour of the day, ordered by hour.
+// RevenueByCategory retrieves the revenue per category of the ordered meals, highest revenue first.
+// TopMeals retrieves at most limit meals with the most billable units sold.
+// TicketSize retrieves the number of orders and their revenue.
+// AveragePrepTime retrieves the average time from the creation of orders until their meals were completed.
+// PrepTimesByMeal retrieves the average prep time per meal, slowest first.
+// TableTurnover retrieves the closed sessions per table, ordered by table number.
+type AnalyticsRepository interface {
+	RevenueByDay(from, to time.Time) ([]models.DayRevenue, error)
+	RevenueByHour(from, to time.Time) ([]models.HourRevenue, error)
+	RevenueByCategory(from, to time.Time) ([]models.CategoryRevenue, error)
+	TopMeals(from, to time.Time, limit int) ([]models.MealSales, error)
+	TicketSize(from, to time.Time) (*models.TicketSize, error)
+	AveragePrepTime(from, to time.Time) (*models.PrepTime, error)
+	PrepTimesByMeal(from, to time.Time) ([]models.PrepTime, error)
+	TableTurnover(from, to time.Time) ([]models.TableTurnover, error)
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepositoryImpl{db: db}
+}
+
+type analyticsRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// billableQuantity and sales are the SQL expressions of models.OrderMeal.BillableQuantity and LineTotal,
+// discounts is the sum of the discounts granted to the order meals and revenue the sales net of the discounts.
+const (
+	billableQuantity = "om.quantity - om.cancelled - om.voided"
+	sales            = "SUM(om.unit_price * (" + billableQuantity + "))"
+	discounts        = "COALESCE(SUM(d.amount), 0)"
+	revenue          = "(" + sales + " - " + discounts + ")"
+)
+
+// lineDiscounts is the SQL query of the total discount of every order meal. Like models.Order.billableDiscounts,
+// discounts only count for the billable units of the order meal, which the discounts granted first get.
+const lineDiscounts = "SELECT order_meal_id, SUM(unit_amount * CASE " +
+	"WHEN remaining <= 0 THEN 0 WHEN remaining < quantity THEN remaining ELSE quantity END) AS amount " +
+	"FROM (SELECT od.order_meal_id, od.unit_amount, od.quantity, " +
+	"m.quantity - m.cancelled - m.voided - SUM(od.quantity) OVER (PARTITION BY od.order_meal_id ORDER BY od.id) " +
+	"+ od.quantity AS remaining " +
+	"FROM order_discounts AS od JOIN order_meals AS m ON m.id = od.order_meal_id) AS od " +
+	"GROUP BY order_meal_id"
+
+// billedOrderMeals returns a query of the order meals of the orders created in the time range which were not cancelled,
+// each joined with the total of its discounts as d.amount.
+func billedOrderMeals(db *gorm.DB, from, to time.Time) *gorm.DB {
+	return db.Table("order_meals AS om").
+		Joins("JOIN orders AS o ON o.id = om.order_id").
+		Joins("LEFT JOIN ("+lineDiscounts+") AS d ON d.order_meal_id = om.id").
+		Where("o.created_at >= ? AND o.created_at < ? AND o.cancelled_at IS NULL", from, to)
+}
+
+func (r *analyticsRepositoryImpl) RevenueByDay(from, to time.Time) ([]models.DayRevenue, error) {
+	day, timeZone := dayOf(r.db, "o.created_at", from)
+	revenues := []models.DayRevenue{}
+
+	err := billedOrderMeals(r.db, from, to).
+		Select(day+" AS day, COUNT(DISTINCT o.id) AS orders, "+revenue+" AS revenue", timeZone).
+		Group("day").
+		Order("day").
+		Scan(&revenues).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the revenue per day", err)
+	}
+
+	return revenues, nil
+}
+
+func (r *analyticsRepositoryImpl) RevenueByHour(from, to time.Time) ([]models.HourRevenue, error) {
+	hour, timeZone := hourOf(r.db, "o.created_at", from)
+	revenues := []models.HourRevenue{}
+
+	err := billedOrderMeals(r.db, from, to).
+		Select(hour+" AS hour, COUNT(DISTINCT o.id) AS orders, "+revenue+" AS revenue", timeZone).
+		Group("hour").
+		Order("hour").
+		Scan(&revenues).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the revenue per hour", err)
+	}
+
+	return revenues, nil
+}
+
+func (r *analyticsRepositoryImpl) RevenueByCategory(from, to time.Time) ([]models.CategoryRevenue, error) {
+	revenues := []models.CategoryRevenue{}
+
+	err := billedOrderMeals(r.db, from, to).
+		Joins("JOIN meals AS m ON m.id = om.meal_id").
+		Joins("JOIN categories AS c ON c.id = m.category_id").
+		Select("c.id AS category_id, c.name AS category, SUM(" + billableQuantity + ") AS quantity, " +
+			revenue + " AS revenue").
+		Group("c.id, c.name").
+		Order("revenue DESC, c.name ASC").
+		Scan(&revenues).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the revenue per category", err)
+	}
+
+	return revenues, nil
+}
+
+func (r *analyticsRepositoryImpl) TopMeals(from, to time.Time, limit int) ([]models.MealSales, error) {
+	sales := []models.MealSales{}
+
+	err := billedOrderMeals(r.db, from, to).
+		Joins("JOIN meals AS m ON m.id = om.meal_id").
+		Select("m.id AS meal_id, m.name AS meal_name, SUM(" + billableQuantity + ") AS quantity, " +
+			revenue + " AS revenue").
+		Group("m.id, m.name").
+		Having("SUM(" + billableQuantity + ") > 0").
+		Order("quantity DESC, revenue DESC, m.name ASC").
+		Limit(limit).
+		Scan(&sales).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the top selling meals", err)
+	}
+
+	return sales, nil
+}
+
+func (r *analyticsRepositoryImpl) TicketSize(from, to time.Time) (*models.TicketSize, error) {
+	var ticketSize models.TicketSize
+
+	err := billedOrderMeals(r.db, from, to).
+		Select("COUNT(DISTINCT o.id) AS orders, COALESCE(" + revenue + ", 0) AS revenue").
+		Scan(&ticketSize).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the ticket size", err)
+	}
+
+	return &ticketSize, nil
+}
+
+// completions returns a query of the transitions to ready of the meals of orders created in the time range,
+// along with the prep time expression weighted by the completed units.
+func (r *analyticsRepositoryImpl) completions(from, to time.Time) (*gorm.DB, string) {
+	query := r.db.Table("order_meal_status_changes AS sc").
+		Joins("JOIN orders AS o ON o.id = sc.order_id").
+		Where("o.created_at >= ? AND o.created_at < ? AND sc.to_status = ?", from, to, models.ReadyStatus)
+
+	averageSeconds := "SUM(" + secondsBetween(r.db, "o.created_at", "sc.changed_at") + " * sc.quantity) / SUM(sc.quantity)"
+	return query, averageSeconds
+}
+
+func (r *analyticsRepositoryImpl) AveragePrepTime(from, to time.Time) (*models.PrepTime, error) {
+	var prepTime models.PrepTime
+
+	query, averageSeconds := r.completions(from, to)
+	err := query.
+		Select("COALESCE(SUM(sc.quantity), 0) AS units, COALESCE(" + averageSeconds + ", 0) AS average_seconds").
+		Scan(&prepTime).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the average prep time", err)
+	}
+
+	return &prepTime, nil
+}
+
+func (r *analyticsRepositoryImpl) PrepTimesByMeal(from, to time.Time) ([]models.PrepTime, error) {
+	prepTimes := []models.PrepTime{}
+
+	query, averageSeconds := r.completions(from, to)
+	err := query.
+		Joins("JOIN order_meals AS om ON om.id = sc.order_meal_id").
+		Joins("JOIN meals AS m ON m.id = om.meal_id").
+		Select("m.id AS meal_id, m.name AS meal_name, SUM(sc.quantity) AS units, " +
+			averageSeconds + " AS average_seconds").
+		Group("m.id, m.name").
+		Order("average_seconds DESC, m.name ASC").
+		Scan(&prepTimes).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the prep times per meal", err)
+	}
+
+	return prepTimes, nil
+}
+
+func (r *analyticsRepositoryImpl) TableTurnover(from, to time.Time) ([]models.TableTurnover, error) {
+	turnovers := []models.TableTurnover{}
+
+	err := r.db.Table("table_sessions AS ts").
+		Where("ts.opened_at >= ? AND ts.opened_at < ? AND ts.closed_at IS NOT NULL", from, to).
+		Select("ts.table_no AS table_no, COUNT(*) AS sessions, AVG(" +
+			secondsBetween(r.db, "ts.opened_at", "ts.closed_at") + ") AS average_seconds").
+		Group("ts.table_no").
+		Order("ts.table_no ASC").
+		Scan(&turnovers).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the table turnover", err)
+	}
+
+	return turnovers, nil
+}
+
+// isSQLite reports whether the database is SQLite, which lacks the date functions of PostgreSQL.
+// SQLite keeps times as text in the time zone they were written in.
+// Production runs on PostgreSQL, the SQLite expressions only exist for the repository tests.
+func isSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == "sqlite"
+}
+
+// restaurantTime returns the SQL expression of the time column converted to the time zone of the restaurant,
+// models.TimeZone, along with the argument of its placeholder. Time zones without an IANA name, like the local
+// time zone of the server, are converted by their UTC offset at the given time. So is every time zone on SQLite,
+// which does not know time zones, so days and hours across a daylight saving time change are off by an hour there.
+func restaurantTime(db *gorm.DB, column string, at time.Time) (string, any) {
+	_, offset := at.In(models.TimeZone).Zone()
+	if isSQLite(db) {
+		return "datetime(" + column + ", ?)", fmt.Sprintf("%+d seconds", offset)
+	}
+
+	if name := models.TimeZone.String(); name != "Local" {
+		return "(" + column + " AT TIME ZONE ?)", name
+	}
+	return "(" + column + " AT TIME ZONE make_interval(secs => ?))", offset
+}
+
+// dayOf returns the SQL expression of the day of the time column in the YYYY-MM-DD format in the time zone
+// of the restaurant, along with the argument of its placeholder.
+func dayOf(db *gorm.DB, column string, at time.Time) (string, any) {
+	localTime, timeZone := restaurantTime(db, column, at)
+	if isSQLite(db) {
+		return "substr(" + localTime + ", 1, 10)", timeZone
+	}
+	return "to_char(" + localTime + ", 'YYYY-MM-DD')", timeZone
+}
+
+// hourOf returns the SQL expression of the hour of the day of the time column in the time zone of the restaurant,
+// along with the argument of its placeholder.
+func hourOf(db *gorm.DB, column string, at time.Time) (string, any) {
+	localTime, timeZone := restaurantTime(db, column, at)
+	if isSQLite(db) {
+		return "CAST(substr(" + localTime + ", 12, 2) AS INTEGER)", timeZone
+	}
+	return "CAST(EXTRACT(HOUR FROM " + localTime + ") AS INTEGER)", timeZone
+}
+
+// secondsBetween returns the SQL expression of the number of seconds between the start and end time columns.
+func secondsBetween(db *gorm.DB, start, end string) string {
+	if isSQLite(db) {
+		return "((julianday(" + end + ") - julianday(" + start + ")) * 86400)"
+	}
+	return "EXTRACT(EPOCH FROM (" + end + " - " + start + "))"
+}
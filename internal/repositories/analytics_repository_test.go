@@ -0,0 +1,201 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createAnalyticsOrder creates an order at the given time, which cannot be set on creation.
+func createAnalyticsOrder(t *testing.T, db *gorm.DB, createdAt time.Time, orderMeals ...models.OrderMeal) *models.Order {
+	order := &models.Order{TableNo: 1, OrderMeals: orderMeals}
+	require.NoError(t, db.Create(order).Error)
+	require.NoError(t, db.Model(order).UpdateColumn("created_at", createdAt).Error)
+	order.CreatedAt = createdAt
+	return order
+}
+
+func TestAnalyticsRepository(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewAnalyticsRepository(db)
+
+	burger := getTestMeal()
+	require.NoError(t, db.Create(burger).Error)
+	lemonade := getTestMeal()
+	lemonade.Name = "Lemonade"
+	lemonade.CategoryID = 0
+	lemonade.Category = &models.Category{Name: "Drinks", Active: true}
+	require.NoError(t, db.Create(lemonade).Error)
+
+	day := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.Local)
+	lunch := createAnalyticsOrder(t, db, day.Add(12*time.Hour),
+		models.OrderMeal{MealID: burger.ID, UnitPrice: decimal.RequireFromString("10.50"), Quantity: 2},
+		models.OrderMeal{MealID: lemonade.ID, UnitPrice: decimal.NewFromInt(3), Quantity: 3, Voided: 1})
+	createAnalyticsOrder(t, db, day.Add(12*time.Hour+30*time.Minute),
+		models.OrderMeal{MealID: lemonade.ID, UnitPrice: decimal.NewFromInt(3), Quantity: 4})
+	dinner := createAnalyticsOrder(t, db, day.Add(24*time.Hour+19*time.Hour),
+		models.OrderMeal{MealID: burger.ID, UnitPrice: decimal.RequireFromString("10.50"), Quantity: 1})
+	require.NoError(t, db.Create(&models.OrderDiscount{OrderID: dinner.ID, OrderMealID: dinner.OrderMeals[0].ID,
+		Name: "Happy hour", UnitAmount: decimal.NewFromInt(2), Quantity: 1}).Error)
+	cancelled := createAnalyticsOrder(t, db, day.Add(13*time.Hour),
+		models.OrderMeal{MealID: burger.ID, UnitPrice: decimal.RequireFromString("10.50"), Quantity: 5, Cancelled: 5})
+	require.NoError(t, db.Model(cancelled).UpdateColumn("cancelled_at", day.Add(13*time.Hour)).Error)
+	createAnalyticsOrder(t, db, day.AddDate(0, 0, 2),
+		models.OrderMeal{MealID: burger.ID, UnitPrice: decimal.RequireFromString("10.50"), Quantity: 1})
+
+	from, to := day, day.AddDate(0, 0, 2)
+
+	days, err := repo.RevenueByDay(from, to)
+	require.NoError(t, err)
+	require.Len(t, days, 2, "cancelled orders and orders after the range are left out")
+	assert.Equal(t, "2025-03-03", days[0].Day)
+	assert.Equal(t, uint(2), days[0].Orders)
+	assert.True(t, decimal.NewFromInt(39).Equal(days[0].Revenue))
+	assert.Equal(t, "2025-03-04", days[1].Day)
+
+	hours, err := repo.RevenueByHour(from, to)
+	require.NoError(t, err)
+	require.Len(t, hours, 2)
+	assert.Equal(t, uint(12), hours[0].Hour)
+	assert.Equal(t, uint(19), hours[1].Hour)
+	assert.True(t, decimal.RequireFromString("8.5").Equal(hours[1].Revenue), "discounts are not part of the revenue")
+
+	categories, err := repo.RevenueByCategory(from, to)
+	require.NoError(t, err)
+	require.Len(t, categories, 2)
+	assert.Equal(t, "Main Courses", categories[0].Category)
+	assert.Equal(t, uint(3), categories[0].Quantity)
+	assert.True(t, decimal.RequireFromString("29.5").Equal(categories[0].Revenue))
+	assert.Equal(t, lemonade.CategoryID, categories[1].CategoryID)
+	assert.Equal(t, uint(6), categories[1].Quantity)
+
+	meals, err := repo.TopMeals(from, to, 1)
+	require.NoError(t, err)
+	require.Len(t, meals, 1)
+	assert.Equal(t, "Lemonade", meals[0].MealName)
+	assert.Equal(t, uint(6), meals[0].Quantity)
+
+	ticketSize, err := repo.TicketSize(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), ticketSize.Orders)
+	assert.True(t, decimal.RequireFromString("47.5").Equal(ticketSize.Revenue))
+
+	ticketSize, err = repo.TicketSize(to.AddDate(1, 0, 0), to.AddDate(1, 0, 1))
+	require.NoError(t, err)
+	assert.Zero(t, ticketSize.Orders)
+	assert.True(t, ticketSize.Revenue.IsZero())
+
+	require.NoError(t, db.Create(&[]models.OrderMealStatusChange{
+		{OrderID: lunch.ID, OrderMealID: lunch.OrderMeals[0].ID, FromStatus: models.CookingStatus,
+			ToStatus: models.ReadyStatus, Quantity: 2, ChangedBy: "cook", ChangedAt: lunch.CreatedAt.Add(20 * time.Minute)},
+		{OrderID: lunch.ID, OrderMealID: lunch.OrderMeals[1].ID, FromStatus: models.CookingStatus,
+			ToStatus: models.ReadyStatus, Quantity: 1, ChangedBy: "cook", ChangedAt: lunch.CreatedAt.Add(5 * time.Minute)},
+		{OrderID: lunch.ID, OrderMealID: lunch.OrderMeals[1].ID, FromStatus: models.AcceptedStatus,
+			ToStatus: models.CookingStatus, Quantity: 1, ChangedBy: "cook", ChangedAt: lunch.CreatedAt.Add(time.Minute)},
+	}).Error)
+
+	prepTime, err := repo.AveragePrepTime(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), prepTime.Units)
+	assert.InDelta(t, 15*60, prepTime.AverageSeconds, 1)
+
+	prepTimes, err := repo.PrepTimesByMeal(from, to)
+	require.NoError(t, err)
+	require.Len(t, prepTimes, 2)
+	assert.Equal(t, burger.ID, prepTimes[0].MealID)
+	assert.InDelta(t, 20*60, prepTimes[0].AverageSeconds, 1)
+	assert.InDelta(t, 5*60, prepTimes[1].AverageSeconds, 1)
+
+	closedAt := day.Add(13 * time.Hour)
+	require.NoError(t, db.Create(&[]models.TableSession{
+		{TableNo: 4, OpenedAt: day.Add(12 * time.Hour), ClosedAt: &closedAt},
+		{TableNo: 2, OpenedAt: day.Add(11 * time.Hour), ClosedAt: &closedAt},
+		{TableNo: 2, OpenedAt: day.Add(18 * time.Hour)},
+	}).Error)
+
+	turnovers, err := repo.TableTurnover(from, to)
+	require.NoError(t, err)
+	require.Len(t, turnovers, 2, "open sessions are left out")
+	assert.Equal(t, 2, turnovers[0].TableNo)
+	assert.Equal(t, uint(1), turnovers[0].Sessions)
+	assert.InDelta(t, 2*60*60, turnovers[0].AverageSeconds, 1)
+}
+
+func TestAnalyticsRepository_VoidedDiscounts(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewAnalyticsRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+
+	burger := getTestMeal()
+	require.NoError(t, db.Create(burger).Error)
+
+	day := time.Date(2025, time.March, 3, 12, 0, 0, 0, time.Local)
+	partlyVoided := createAnalyticsOrder(t, db, day,
+		models.OrderMeal{MealID: burger.ID, UnitPrice: decimal.NewFromInt(10), Quantity: 3, Voided: 1})
+	voided := createAnalyticsOrder(t, db, day,
+		models.OrderMeal{MealID: burger.ID, UnitPrice: decimal.NewFromInt(10), Quantity: 2, Voided: 2})
+	require.NoError(t, db.Create(&[]models.OrderDiscount{
+		{OrderID: partlyVoided.ID, OrderMealID: partlyVoided.OrderMeals[0].ID,
+			Name: "Happy hour", UnitAmount: decimal.NewFromInt(1), Quantity: 2},
+		{OrderID: partlyVoided.ID, OrderMealID: partlyVoided.OrderMeals[0].ID,
+			Name: "Loyalty", UnitAmount: decimal.NewFromInt(2), Quantity: 1},
+		{OrderID: voided.ID, OrderMealID: voided.OrderMeals[0].ID,
+			Name: "Buy one get one free", UnitAmount: decimal.NewFromInt(10), Quantity: 1},
+	}).Error)
+
+	expected := decimal.Zero
+	for _, orderID := range []uint{partlyVoided.ID, voided.ID} {
+		order, err := orderRepo.GetByID(orderID)
+		require.NoError(t, err)
+		expected = expected.Add(order.DiscountedLineTotal(&order.OrderMeals[0]))
+	}
+
+	ticketSize, err := repo.TicketSize(day.Add(-time.Hour), day.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(18).Equal(expected))
+	assert.True(t, expected.Equal(ticketSize.Revenue),
+		"discounts of voided units do not count, the units discounted first keep their discount")
+}
+
+func TestAnalyticsRepository_TimeZone(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewAnalyticsRepository(db)
+
+	timeZone := models.TimeZone
+	defer func() { models.TimeZone = timeZone }()
+	models.TimeZone = time.FixedZone("UTC+2", 2*60*60)
+
+	burger := getTestMeal()
+	require.NoError(t, db.Create(burger).Error)
+
+	// 12:00 on March 3 and 01:30 on March 4 in the restaurant
+	createAnalyticsOrder(t, db, time.Date(2025, time.March, 3, 10, 0, 0, 0, time.UTC),
+		models.OrderMeal{MealID: burger.ID, UnitPrice: decimal.NewFromInt(10), Quantity: 1})
+	createAnalyticsOrder(t, db, time.Date(2025, time.March, 3, 23, 30, 0, 0, time.UTC),
+		models.OrderMeal{MealID: burger.ID, UnitPrice: decimal.NewFromInt(10), Quantity: 1})
+
+	from := time.Date(2025, time.March, 3, 0, 0, 0, 0, models.TimeZone).UTC()
+	to := from.AddDate(0, 0, 2)
+
+	days, err := repo.RevenueByDay(from, to)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, "2025-03-03", days[0].Day)
+	assert.Equal(t, "2025-03-04", days[1].Day, "orders are counted on the day of the restaurant")
+
+	hours, err := repo.RevenueByHour(from, to)
+	require.NoError(t, err)
+	require.Len(t, hours, 2)
+	assert.Equal(t, uint(1), hours[0].Hour)
+	assert.Equal(t, uint(12), hours[1].Hour)
+}
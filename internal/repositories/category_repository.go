@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+)
+
+// CategoryRepository provides an interface for CRUD operations on Category entities
+// and supports transactional operations.
+// WithTransaction executes a function within a database transaction and rolls back if an error occurs.
+// GetAll retrieves all categories ordered by their display order, optionally only the active ones.
+// GetByID retrieves a specific category by its ID.
+// GetByName retrieves a specific category by its name.
+// Create adds a new category to the database.
+// Update updates the name, display order, icon and active flag of an existing category.
+// ReplaceAvailabilityWindows replaces all availability windows of a category.
+// Categories are retrieved with their availability windows.
+type CategoryRepository interface {
+	WithTransaction(fn func(txRepo CategoryRepository) error) error
+	GetAll(onlyActive bool) ([]*models.Category, error)
+	GetByID(ID uint) (*models.Category, error)
+	GetByName(name string) (*models.Category, error)
+	Create(category *models.Category) error
+	Update(category *models.Category) error
+	ReplaceAvailabilityWindows(categoryID uint, windows []models.AvailabilityWindow) error
+}
+
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepositoryImpl{db: db}
+}
+
+type categoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *categoryRepositoryImpl) WithTransaction(fn func(txRepo CategoryRepository) error) error {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to start a transaction", tx.Error)
+	}
+	defer tx.Rollback()
+
+	txRepo := &categoryRepositoryImpl{db: tx}
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to commit transaction", err)
+	}
+	return nil
+}
+
+func (r *categoryRepositoryImpl) GetAll(onlyActive bool) ([]*models.Category, error) {
+	var categories []*models.Category
+
+	query := r.db.Preload("AvailabilityWindows").Order("display_order ASC, id ASC")
+	if onlyActive {
+		query = query.Where("active = ?", true)
+	}
+
+	if err := query.Find(&categories).Error; err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get all categories", err)
+	}
+
+	return categories, nil
+}
+
+func (r *categoryRepositoryImpl) GetByID(ID uint) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Preload("AvailabilityWindows").Where("id = ?", ID).First(&category).Error
+
+	if err == nil {
+		return &category, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Category with ID %d not found", ID), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get category %d", ID), err)
+}
+
+func (r *categoryRepositoryImpl) GetByName(name string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Preload("AvailabilityWindows").Where("name = ?", name).First(&category).Error
+
+	if err == nil {
+		return &category, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Category %s not found", name), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get category %s", name), err)
+}
+
+func (r *categoryRepositoryImpl) Create(category *models.Category) error {
+	if err := r.db.Create(category).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create category %s", category.Name), err)
+	}
+	return nil
+}
+
+func (r *categoryRepositoryImpl) Update(category *models.Category) error {
+	res := r.db.Model(category).Select("Name", "DisplayOrder", "IconURL", "IconPublicID", "Active").Updates(category)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update category %d", category.ID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Category with ID %d not found", category.ID), nil)
+	}
+
+	return nil
+}
+
+func (r *categoryRepositoryImpl) ReplaceAvailabilityWindows(categoryID uint, windows []models.AvailabilityWindow) error {
+	for i := range windows {
+		windows[i].CategoryID = &categoryID
+		windows[i].MealID = nil
+		windows[i].MenuID = nil
+		windows[i].PromotionID = nil
+	}
+
+	if err := replaceAvailabilityWindows(r.db, "category_id", categoryID, windows); err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to replace availability windows of category %d", categoryID), err)
+	}
+
+	return nil
+}
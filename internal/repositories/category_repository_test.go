@@ -0,0 +1,83 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryRepository_CRUD(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewCategoryRepository(db)
+
+	_, err := repo.GetByID(1)
+	assert.True(t, apperrors.IsNotFoundErr(err))
+
+	breakfast := &models.Category{Name: "Breakfast", DisplayOrder: 2, Active: true}
+	cocktails := &models.Category{Name: "Cocktails", DisplayOrder: 1, Active: true}
+	require.NoError(t, repo.Create(breakfast))
+	require.NoError(t, repo.Create(cocktails))
+	assert.Error(t, repo.Create(&models.Category{Name: "Breakfast"}), "category names are unique")
+	assert.Error(t, repo.Create(&models.Category{Name: ""}))
+
+	categories, err := repo.GetAll(false)
+	require.NoError(t, err)
+	require.Len(t, categories, 2)
+	assert.Equal(t, "Cocktails", categories[0].Name)
+	assert.Equal(t, "Breakfast", categories[1].Name)
+
+	cocktails.Active = false
+	cocktails.IconURL = "http://example.com/cocktail.png"
+	cocktails.IconPublicID = "cocktail"
+	require.NoError(t, repo.Update(cocktails))
+
+	categories, err = repo.GetAll(true)
+	require.NoError(t, err)
+	require.Len(t, categories, 1)
+	assert.Equal(t, breakfast.ID, categories[0].ID)
+
+	found, err := repo.GetByName("Cocktails")
+	require.NoError(t, err)
+	assert.False(t, found.Active)
+	assert.Equal(t, "cocktail", found.IconPublicID)
+
+	_, err = repo.GetByName("Brunch")
+	assert.True(t, apperrors.IsNotFoundErr(err))
+
+	assert.True(t, apperrors.IsNotFoundErr(repo.Update(&models.Category{ID: 99, Name: "Brunch"})))
+}
+
+func TestCategoryRepository_ReplaceAvailabilityWindows(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewCategoryRepository(db)
+
+	breakfast := &models.Category{Name: "Breakfast", Active: true}
+	require.NoError(t, repo.Create(breakfast))
+
+	err := repo.ReplaceAvailabilityWindows(breakfast.ID, []models.AvailabilityWindow{
+		models.NewAvailabilityWindow([]time.Weekday{time.Monday, time.Tuesday}, 7*60, 11*60),
+		models.NewAvailabilityWindow([]time.Weekday{time.Saturday, time.Sunday}, 8*60, 12*60),
+	})
+	require.NoError(t, err)
+
+	found, err := repo.GetByID(breakfast.ID)
+	require.NoError(t, err)
+	require.Len(t, found.AvailabilityWindows, 2)
+	assert.Equal(t, breakfast.ID, *found.AvailabilityWindows[0].CategoryID)
+	assert.Nil(t, found.AvailabilityWindows[0].MealID)
+
+	require.NoError(t, repo.ReplaceAvailabilityWindows(breakfast.ID, nil))
+
+	categories, err := repo.GetAll(false)
+	require.NoError(t, err)
+	require.Len(t, categories, 1)
+	assert.Empty(t, categories[0].AvailabilityWindows)
+}
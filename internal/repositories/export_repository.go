@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// exportBatchSize is the number of orders loaded at once while streaming them.
+const exportBatchSize = 100
+
+// ExportRepository provides an interface for streaming records to exports one by one,
+// so exports of long time ranges never load all records into memory.
+// Every method stops and returns the error of fn if it fails.
+// StreamOrders calls fn for every order created in the time range with its meals, options and discounts, oldest first.
+// StreamReviews calls fn for every review of the orders created in the time range, oldest order first.
+// StreamDailySales calls fn for the sales of every day of the time range orders were created on, ordered by day.
+type ExportRepository interface {
+	StreamOrders(from, to time.Time, fn func(order *models.Order) error) error
+	StreamReviews(from, to time.Time, fn func(review *models.ReviewExport) error) error
+	StreamDailySales(from, to time.Time, fn func(sales *models.DailySales) error) error
+}
+
+func NewExportRepository(db *gorm.DB) ExportRepository {
+	return &exportRepositoryImpl{db: db}
+}
+
+type exportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *exportRepositoryImpl) StreamOrders(from, to time.Time, fn func(order *models.Order) error) error {
+	var batch []*models.Order
+	var fnErr error
+
+	err := r.db.Model(&models.Order{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Preload("OrderMeals", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("OrderMeals.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("meal_option_id ASC")
+		}).
+		Preload("Discounts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, order := range batch {
+				if fnErr = fn(order); fnErr != nil {
+					return fnErr
+				}
+			}
+			return nil
+		}).Error
+
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return apperrors.NewInternalServerErr("Failed to export orders", err)
+	}
+	return nil
+}
+
+func (r *exportRepositoryImpl) StreamReviews(from, to time.Time, fn func(review *models.ReviewExport) error) error {
+	rows, err := r.db.Table("reviews AS r").
+		Joins("JOIN orders AS o ON o.id = r.order_id").
+		Where("o.created_at >= ? AND o.created_at < ?", from, to).
+		Select("r.*, o.created_at AS ordered_at, o.table_no AS table_no").
+		Order("o.created_at ASC, r.id ASC").
+		Rows()
+	if err != nil {
+		return apperrors.NewInternalServerErr("Failed to export reviews", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var review models.ReviewExport
+		if err = r.db.ScanRows(rows, &review); err != nil {
+			return apperrors.NewInternalServerErr("Failed to export reviews", err)
+		}
+		if err = fn(&review); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return apperrors.NewInternalServerErr("Failed to export reviews", err)
+	}
+	return nil
+}
+
+func (r *exportRepositoryImpl) StreamDailySales(from, to time.Time, fn func(sales *models.DailySales) error) error {
+	day, timeZone := dayOf(r.db, "o.created_at", from)
+
+	rows, err := billedOrderMeals(r.db, from, to).
+		Select(day+" AS day, COUNT(DISTINCT o.id) AS orders, SUM("+billableQuantity+") AS units, "+
+			sales+" AS sales, "+discounts+" AS discounts", timeZone).
+		Group("day").
+		Order("day").
+		Rows()
+	if err != nil {
+		return apperrors.NewInternalServerErr("Failed to export daily sales", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sales models.DailySales
+		if err = r.db.ScanRows(rows, &sales); err != nil {
+			return apperrors.NewInternalServerErr("Failed to export daily sales", err)
+		}
+		if err = fn(&sales); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return apperrors.NewInternalServerErr("Failed to export daily sales", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRepository(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewExportRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, db.Create(meal).Error)
+
+	day := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.Local)
+	var orders []*models.Order
+	for i := 0; i < 3; i++ {
+		orders = append(orders, createAnalyticsOrder(t, db, day.Add(time.Duration(10+i)*time.Hour),
+			models.OrderMeal{MealID: meal.ID, MealName: "Burger", UnitPrice: decimal.NewFromInt(10), Quantity: 2,
+				Options: []models.OrderMealOption{{MealOptionID: 1, GroupName: "Size", Name: "Large"}}}))
+	}
+	createAnalyticsOrder(t, db, day.AddDate(0, 0, 1),
+		models.OrderMeal{MealID: meal.ID, UnitPrice: decimal.NewFromInt(10), Quantity: 1})
+	createAnalyticsOrder(t, db, day.AddDate(0, 0, 2),
+		models.OrderMeal{MealID: meal.ID, UnitPrice: decimal.NewFromInt(10), Quantity: 1})
+
+	require.NoError(t, db.Create(&models.OrderDiscount{OrderID: orders[0].ID, OrderMealID: orders[0].OrderMeals[0].ID,
+		Name: "Happy hour", UnitAmount: decimal.NewFromInt(5), Quantity: 2}).Error)
+
+	comment := "Tasty"
+	require.NoError(t, db.Create(&models.Review{OrderID: orders[1].ID, Rating: 5, Comment: &comment,
+		PhotoURLs: pq.StringArray{"http://image.com/1.jpg"}}).Error)
+	require.NoError(t, db.Create(&models.Review{OrderID: orders[0].ID, Rating: 3}).Error)
+
+	from, to := day, day.AddDate(0, 0, 2)
+
+	var streamed []*models.Order
+	err := repo.StreamOrders(from, to, func(order *models.Order) error {
+		streamed = append(streamed, order)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, streamed, 4)
+	assert.Equal(t, orders[0].ID, streamed[0].ID)
+	require.Len(t, streamed[0].OrderMeals, 1)
+	require.Len(t, streamed[0].OrderMeals[0].Options, 1)
+	assert.Equal(t, "Large", streamed[0].OrderMeals[0].Options[0].Name)
+	require.Len(t, streamed[0].Discounts, 1)
+
+	failure := errors.New("client went away")
+	calls := 0
+	err = repo.StreamOrders(from, to, func(order *models.Order) error {
+		calls++
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, 1, calls, "streaming stops at the first error")
+
+	var reviews []*models.ReviewExport
+	err = repo.StreamReviews(from, to, func(review *models.ReviewExport) error {
+		reviews = append(reviews, review)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, reviews, 2)
+	assert.Equal(t, orders[0].ID, reviews[0].OrderID, "reviews are ordered by the time of their order")
+	assert.Equal(t, 3, reviews[0].Rating)
+	assert.Nil(t, reviews[0].Comment)
+	assert.True(t, orders[0].CreatedAt.Equal(reviews[0].OrderedAt))
+	assert.Equal(t, 1, reviews[1].TableNo)
+	assert.Equal(t, "Tasty", *reviews[1].Comment)
+	assert.Equal(t, pq.StringArray{"http://image.com/1.jpg"}, reviews[1].PhotoURLs)
+
+	var sales []*models.DailySales
+	err = repo.StreamDailySales(from, to, func(daySales *models.DailySales) error {
+		sales = append(sales, daySales)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, sales, 2)
+	assert.Equal(t, "2025-03-03", sales[0].Day)
+	assert.Equal(t, uint(3), sales[0].Orders)
+	assert.Equal(t, uint(6), sales[0].Units)
+	assert.True(t, decimal.NewFromInt(60).Equal(sales[0].Sales))
+	assert.True(t, decimal.NewFromInt(10).Equal(sales[0].Discounts))
+	assert.True(t, sales[1].Discounts.IsZero())
+}
+
+func TestExportRepository_VoidedDiscounts(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewExportRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, db.Create(meal).Error)
+
+	day := time.Date(2025, time.March, 3, 12, 0, 0, 0, time.Local)
+	voided := createAnalyticsOrder(t, db, day,
+		models.OrderMeal{MealID: meal.ID, UnitPrice: decimal.NewFromInt(10), Quantity: 2, Voided: 2})
+	partlyVoided := createAnalyticsOrder(t, db, day,
+		models.OrderMeal{MealID: meal.ID, UnitPrice: decimal.NewFromInt(10), Quantity: 2, Voided: 1})
+	require.NoError(t, db.Create(&[]models.OrderDiscount{
+		{OrderID: voided.ID, OrderMealID: voided.OrderMeals[0].ID,
+			Name: "Buy one get one free", UnitAmount: decimal.NewFromInt(10), Quantity: 1},
+		{OrderID: partlyVoided.ID, OrderMealID: partlyVoided.OrderMeals[0].ID,
+			Name: "Happy hour", UnitAmount: decimal.NewFromInt(5), Quantity: 2},
+	}).Error)
+
+	var sales []*models.DailySales
+	err := repo.StreamDailySales(day.Add(-time.Hour), day.Add(time.Hour), func(daySales *models.DailySales) error {
+		sales = append(sales, daySales)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, sales, 1)
+	assert.Equal(t, uint(1), sales[0].Units)
+	assert.True(t, decimal.NewFromInt(10).Equal(sales[0].Sales))
+	assert.True(t, decimal.NewFromInt(5).Equal(sales[0].Discounts), "discounts of voided units do not count")
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+)
+
+// imageReferenceBatchSize is the number of reviews loaded at once while collecting image references.
+const imageReferenceBatchSize = 500
+
+// ImageRepository provides an interface for finding the images the data store references.
+// GetReferences retrieves the images referenced by meals, including deleted ones, meal versions, reviews, categories
+// and upload sessions.
+type ImageRepository interface {
+	GetReferences() (*models.ImageReferences, error)
+}
+
+func NewImageRepository(db *gorm.DB) ImageRepository {
+	return &imageRepositoryImpl{db: db}
+}
+
+type imageRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *imageRepositoryImpl) GetReferences() (*models.ImageReferences, error) {
+	references := models.NewImageReferences()
+
+	var meals []struct {
+		ImageURL      string
+		ImagePublicID string
+		ImageVariants models.ImageVariants
+	}
+	err := r.db.Unscoped().Model(&models.Meal{}).
+		Select("image_url", "image_public_id", "image_variants").
+		Find(&meals).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the images of meals", err)
+	}
+	for _, meal := range meals {
+		references.Add(meal.ImagePublicID, meal.ImageURL)
+		references.AddVariants(meal.ImageVariants)
+	}
+
+	var versions []struct {
+		ImageURL      string
+		ImagePublicID string
+	}
+	err = r.db.Model(&models.MealVersion{}).
+		Select("image_url", "image_public_id").
+		Find(&versions).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the images of meal versions", err)
+	}
+	for _, version := range versions {
+		references.Add(version.ImagePublicID, version.ImageURL)
+	}
+
+	var categories []struct {
+		IconURL      string
+		IconPublicID string
+	}
+	err = r.db.Model(&models.Category{}).
+		Select("icon_url", "icon_public_id").
+		Find(&categories).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the icons of categories", err)
+	}
+	for _, category := range categories {
+		references.Add(category.IconPublicID, category.IconURL)
+	}
+
+	var reviews []*models.Review
+	err = r.db.Select("id", "photo_urls", "photo_public_ids", "photo_variants").
+		FindInBatches(&reviews, imageReferenceBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, review := range reviews {
+				for _, url := range review.PhotoURLs {
+					references.Add("", url)
+				}
+				for _, publicID := range review.PhotoPublicIDs {
+					references.Add(publicID, "")
+				}
+				for _, variants := range review.PhotoVariants {
+					references.AddVariants(variants)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the photos of reviews", err)
+	}
+
+	// Images waiting in upload sessions are referenced until their sessions expire
+	var uploads []struct {
+		ImageURL      string
+		ImagePublicID string
+		ImageVariants models.ImageVariants
+	}
+	err = r.db.Model(&models.Upload{}).
+		Select("image_url", "image_public_id", "image_variants").
+		Find(&uploads).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the images of upload sessions", err)
+	}
+	for _, upload := range uploads {
+		references.Add(upload.ImagePublicID, upload.ImageURL)
+		references.AddVariants(upload.ImageVariants)
+	}
+
+	return references, nil
+}
@@ -0,0 +1,78 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageRepository_GetReferences(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewImageRepository(db)
+
+	burger := getTestMeal()
+	burger.ImageURL = "/images/burger-large.jpg"
+	burger.ImagePublicID = "burger-large"
+	burger.ImageVariants = models.ImageVariants{
+		{Size: "thumbnail", URL: "/images/burger-thumbnail.webp", PublicID: "burger-thumbnail"},
+	}
+	burger.Category.IconURL = "/images/main-courses.png"
+	burger.Category.IconPublicID = "main-courses"
+	require.NoError(t, db.Create(burger).Error)
+
+	// The first version of the burger still shows its former image
+	firstVersion := burger.NewVersion()
+	firstVersion.Version = 0
+	firstVersion.ImageURL = "/images/old-burger.jpg"
+	firstVersion.ImagePublicID = "old-burger"
+	require.NoError(t, db.Create(firstVersion).Error)
+
+	// Meals created before public IDs were kept are referenced by their URL, even once deleted
+	legacy := getTestMeal()
+	legacy.Name = "Legacy Meal"
+	legacy.CategoryID = 1
+	legacy.Category = nil
+	legacy.ImageURL = "https://res.cloudinary.com/legacy.jpg"
+	require.NoError(t, db.Create(legacy).Error)
+	require.NoError(t, db.Delete(legacy).Error)
+
+	order := createAnalyticsOrder(t, db, time.Now(), models.OrderMeal{MealID: burger.ID, Quantity: 1})
+	review := &models.Review{OrderID: order.ID, Rating: 5,
+		PhotoURLs:      pq.StringArray{"/images/photo.jpg"},
+		PhotoPublicIDs: pq.StringArray{"photo"},
+		PhotoVariants: models.PhotoVariants{{
+			{Size: "thumbnail", URL: "/images/photo-thumbnail.jpg", PublicID: "photo-thumbnail"},
+			{Size: "thumbnail", URL: "/images/photo-thumbnail.webp", PublicID: "photo-thumbnail-webp"},
+		}}}
+	require.NoError(t, db.Create(review).Error)
+
+	// Photos waiting in upload sessions are referenced as well
+	uploads := repositories.NewUploadRepository(db)
+	require.NoError(t, uploads.CreateSession(&models.UploadSession{ID: "session", Purpose: models.ReviewUpload,
+		Owner: "order:1", ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, uploads.CreateUpload(&models.Upload{ID: "upload", UploadSessionID: "session",
+		ImageURL: "/images/upload.jpg", ImagePublicID: "upload",
+		ImageVariants: models.ImageVariants{{Size: "thumbnail", URL: "/images/upload.webp", PublicID: "upload-webp"}}}))
+
+	references, err := repo.GetReferences()
+	require.NoError(t, err)
+
+	for _, publicID := range []string{"burger-large", "burger-thumbnail", "main-courses", "old-burger",
+		"photo", "photo-thumbnail", "photo-thumbnail-webp", "upload", "upload-webp"} {
+		assert.True(t, references.PublicIDs[publicID], publicID)
+	}
+	for _, url := range []string{"/images/burger-large.jpg", "/images/old-burger.jpg",
+		"https://res.cloudinary.com/legacy.jpg", "/images/photo.jpg", "/images/photo-thumbnail.webp"} {
+		assert.True(t, references.URLs[url], url)
+	}
+
+	assert.False(t, references.References("orphan", "/images/orphan.jpg"))
+	assert.True(t, references.References("unknown", "https://res.cloudinary.com/legacy.jpg"))
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"maps"
+	"slices"
+)
+
+// IngredientRepository provides an interface for CRUD operations on Ingredient entities
+// and supports transactional operations.
+// WithTransaction executes a function within a database transaction and rolls back if an error occurs.
+// GetAll retrieves all ingredients ordered by their name.
+// GetByID retrieves a specific ingredient by its ID.
+// GetByName retrieves a specific ingredient by its name.
+// Create adds a new ingredient to the database.
+// Update updates the name, unit, stock and low stock threshold of an existing ingredient.
+// AddStock adds the given quantity to the stock of an ingredient.
+type IngredientRepository interface {
+	WithTransaction(fn func(txRepo IngredientRepository) error) error
+	GetAll() ([]*models.Ingredient, error)
+	GetByID(ID uint) (*models.Ingredient, error)
+	GetByName(name string) (*models.Ingredient, error)
+	Create(ingredient *models.Ingredient) error
+	Update(ingredient *models.Ingredient) error
+	AddStock(ingredientID uint, quantity decimal.Decimal) error
+}
+
+func NewIngredientRepository(db *gorm.DB) IngredientRepository {
+	return &ingredientRepositoryImpl{db: db}
+}
+
+type ingredientRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *ingredientRepositoryImpl) WithTransaction(fn func(txRepo IngredientRepository) error) error {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to start a transaction", tx.Error)
+	}
+	defer tx.Rollback()
+
+	txRepo := &ingredientRepositoryImpl{db: tx}
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to commit transaction", err)
+	}
+	return nil
+}
+
+func (r *ingredientRepositoryImpl) GetAll() ([]*models.Ingredient, error) {
+	var ingredients []*models.Ingredient
+
+	if err := r.db.Order("name ASC").Find(&ingredients).Error; err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get all ingredients", err)
+	}
+
+	return ingredients, nil
+}
+
+func (r *ingredientRepositoryImpl) GetByID(ID uint) (*models.Ingredient, error) {
+	var ingredient models.Ingredient
+	err := r.db.Where("id = ?", ID).First(&ingredient).Error
+
+	if err == nil {
+		return &ingredient, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Ingredient with ID %d not found", ID), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get ingredient %d", ID), err)
+}
+
+func (r *ingredientRepositoryImpl) GetByName(name string) (*models.Ingredient, error) {
+	var ingredient models.Ingredient
+	err := r.db.Where("name = ?", name).First(&ingredient).Error
+
+	if err == nil {
+		return &ingredient, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Ingredient %s not found", name), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get ingredient %s", name), err)
+}
+
+func (r *ingredientRepositoryImpl) Create(ingredient *models.Ingredient) error {
+	if err := r.db.Create(ingredient).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create ingredient %s", ingredient.Name), err)
+	}
+	return nil
+}
+
+func (r *ingredientRepositoryImpl) Update(ingredient *models.Ingredient) error {
+	res := r.db.Model(ingredient).Select("Name", "Unit", "Stock", "LowStockThreshold").Updates(ingredient)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update ingredient %d", ingredient.ID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Ingredient with ID %d not found", ingredient.ID), nil)
+	}
+
+	return nil
+}
+
+func (r *ingredientRepositoryImpl) AddStock(ingredientID uint, quantity decimal.Decimal) error {
+	res := r.db.Model(&models.Ingredient{}).Where("id = ?", ingredientID).
+		Update("stock", gorm.Expr("stock + ?", quantity))
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to add stock of ingredient %d", ingredientID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Ingredient with ID %d not found", ingredientID), nil)
+	}
+
+	return nil
+}
+
+// consumeIngredients takes the used quantities off the stock of the ingredients and returns the updated ingredients.
+// It fails with a validation error if there is not enough of an ingredient in stock.
+// Ingredients are updated in the order of their ids, so concurrent orders lock them in the same order.
+func consumeIngredients(db *gorm.DB, usage models.IngredientUsage) ([]*models.Ingredient, error) {
+	ids := slices.Sorted(maps.Keys(usage))
+
+	for _, id := range ids {
+		res := db.Model(&models.Ingredient{}).Where("id = ? AND stock >= ?", id, usage[id]).
+			Update("stock", gorm.Expr("stock - ?", usage[id]))
+		if res.Error != nil {
+			return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update stock of ingredient %d", id), res.Error)
+		}
+
+		if res.RowsAffected == 0 {
+			var ingredient models.Ingredient
+			if err := db.Where("id = ?", id).First(&ingredient).Error; err != nil {
+				return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Ingredient with ID %d not found", id), err)
+			}
+			return nil, apperrors.NewValidationErr(fmt.Sprintf("Not enough %s in stock", ingredient.Name), nil)
+		}
+	}
+
+	var ingredients []*models.Ingredient
+	if err := db.Where("id IN ?", ids).Order("id ASC").Find(&ingredients).Error; err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get consumed ingredients", err)
+	}
+
+	return ingredients, nil
+}
+
+// returnIngredients puts the quantities of ingredients which were not used after all back on the stock.
+// Ingredients are updated in the order of their ids, the same way consumeIngredients does.
+func returnIngredients(db *gorm.DB, usage models.IngredientUsage) error {
+	for _, id := range slices.Sorted(maps.Keys(usage)) {
+		err := db.Model(&models.Ingredient{}).Where("id = ?", id).
+			Update("stock", gorm.Expr("stock + ?", usage[id])).Error
+		if err != nil {
+			return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to return stock of ingredient %d", id), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngredientRepository_CRUD(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewIngredientRepository(db)
+
+	_, err := repo.GetByID(1)
+	assert.True(t, apperrors.IsNotFoundErr(err))
+
+	eggs := &models.Ingredient{Name: "Eggs", Unit: "pcs", Stock: decimal.NewFromInt(30), LowStockThreshold: decimal.NewFromInt(12)}
+	beef := &models.Ingredient{Name: "Beef", Unit: "kg", Stock: decimal.RequireFromString("4.5")}
+	require.NoError(t, repo.Create(eggs))
+	require.NoError(t, repo.Create(beef))
+	assert.Error(t, repo.Create(&models.Ingredient{Name: "Eggs", Unit: "pcs"}), "ingredient names are unique")
+
+	ingredients, err := repo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, ingredients, 2)
+	assert.Equal(t, "Beef", ingredients[0].Name)
+	assert.True(t, decimal.RequireFromString("4.5").Equal(ingredients[0].Stock))
+
+	eggs.LowStockThreshold = decimal.NewFromInt(24)
+	require.NoError(t, repo.Update(eggs))
+	require.NoError(t, repo.AddStock(eggs.ID, decimal.NewFromInt(6)))
+	assert.True(t, apperrors.IsNotFoundErr(repo.AddStock(99, decimal.NewFromInt(6))))
+
+	found, err := repo.GetByName("Eggs")
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(36).Equal(found.Stock))
+	assert.True(t, decimal.NewFromInt(24).Equal(found.LowStockThreshold))
+
+	_, err = repo.GetByName("Milk")
+	assert.True(t, apperrors.IsNotFoundErr(err))
+
+	assert.True(t, apperrors.IsNotFoundErr(repo.Update(&models.Ingredient{ID: 99, Name: "Milk", Unit: "l"})))
+}
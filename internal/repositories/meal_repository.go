@@ -10,18 +10,36 @@ import (
 
 // MealRepository provides an interface for CRUD operations on Meal entities and supports transactional operations.
 // WithTransaction executes a function within a database transaction and rolls back if an error occurs.
-// GetAll retrieves allMeal records from the database.
+// GetAll retrieves all Meal records of active categories from the database, ordered by the display order of their category.
 // GetAllWithDeleted retrieves all Meal records, including soft-deleted ones, from the database.
 // GetByID retrieves a specific Meal by its ID from the database.
 // Create adds a new Meal record to the database.
+// Update updates the versioned menu details of an existing Meal and increments its version, which is set on the Meal.
+// CreateVersion adds a version to the history of a Meal.
+// GetVersions retrieves the version history of a Meal ordered by version, including versions of soft-deleted meals.
 // Delete performs a soft delete on a Meal record in the database.
+// ReplaceOptionGroups replaces all option groups and their options of a Meal.
+// SetSoldOut marks a Meal as sold out or back in stock.
+// ReplaceAvailabilityWindows replaces all availability windows of a Meal.
+// ReplaceRecipe replaces all recipe items of a Meal.
+// Uploads returns the upload repository sharing the transaction of the meal repository.
+// Meals are retrieved with their category, availability windows of the meal and the category, recipe with its ingredients,
+// option groups and options, and the summary of their ratings in reviews.
 type MealRepository interface {
 	WithTransaction(fn func(txRepo MealRepository) error) error
 	GetAll() ([]*models.Meal, error)
 	GetAllWithDeleted() ([]*models.Meal, error)
 	GetByID(ID uint) (*models.Meal, error)
 	Create(meal *models.Meal) error
+	Update(meal *models.Meal) error
+	CreateVersion(version *models.MealVersion) error
+	GetVersions(mealID uint) ([]*models.MealVersion, error)
 	Delete(meal *models.Meal) error
+	ReplaceOptionGroups(mealID uint, optionGroups []models.OptionGroup) error
+	SetSoldOut(mealID uint, soldOut bool) error
+	ReplaceAvailabilityWindows(mealID uint, windows []models.AvailabilityWindow) error
+	ReplaceRecipe(mealID uint, recipe []models.RecipeItem) error
+	Uploads() UploadRepository
 }
 
 func NewMealRepository(db *gorm.DB) MealRepository {
@@ -54,28 +72,42 @@ func (r *mealRepositoryImpl) WithTransaction(fn func(txRepo MealRepository) erro
 func (r *mealRepositoryImpl) GetAll() ([]*models.Meal, error) {
 	var meals []*models.Meal
 
-	if err := r.db.Find(&meals).Error; err != nil {
+	err := r.db.Joins("JOIN categories ON categories.id = meals.category_id AND categories.active = ?", true).
+		Order("categories.display_order ASC, meals.id ASC").
+		Preload("Category.AvailabilityWindows").Preload("AvailabilityWindows").Preload("Recipe.Ingredient").Preload("OptionGroups.Options").Find(&meals).Error
+	if err != nil {
 		return nil, apperrors.NewInternalServerErr("Failed to get all meals", err)
 	}
 
+	if err = r.loadRatings(meals...); err != nil {
+		return nil, err
+	}
+
 	return meals, nil
 }
 
 func (r *mealRepositoryImpl) GetAllWithDeleted() ([]*models.Meal, error) {
 	var meals []*models.Meal
 
-	if err := r.db.Unscoped().Find(&meals).Error; err != nil {
+	if err := r.db.Unscoped().Preload("Category.AvailabilityWindows").Preload("AvailabilityWindows").Preload("Recipe.Ingredient").Preload("OptionGroups.Options").Find(&meals).Error; err != nil {
 		return nil, apperrors.NewInternalServerErr("Failed to get all meals including deleted", err)
 	}
 
+	if err := r.loadRatings(meals...); err != nil {
+		return nil, err
+	}
+
 	return meals, nil
 }
 
 func (r *mealRepositoryImpl) GetByID(ID uint) (*models.Meal, error) {
 	var meal models.Meal
-	err := r.db.Model(&models.Meal{}).Where("ID = ?", ID).First(&meal).Error
+	err := r.db.Model(&models.Meal{}).Where("ID = ?", ID).Preload("Category.AvailabilityWindows").Preload("AvailabilityWindows").Preload("Recipe.Ingredient").Preload("OptionGroups.Options").First(&meal).Error
 
 	if err == nil {
+		if err = r.loadRatings(&meal); err != nil {
+			return nil, err
+		}
 		return &meal, nil
 
 	}
@@ -88,6 +120,42 @@ func (r *mealRepositoryImpl) GetByID(ID uint) (*models.Meal, error) {
 
 }
 
+// loadRatings loads the number and average of the ratings of the meals in reviews which are not hidden.
+func (r *mealRepositoryImpl) loadRatings(meals ...*models.Meal) error {
+	if len(meals) == 0 {
+		return nil
+	}
+
+	mealIDs := make([]uint, len(meals))
+	mealsByID := make(map[uint]*models.Meal, len(meals))
+	for i, meal := range meals {
+		mealIDs[i] = meal.ID
+		mealsByID[meal.ID] = meal
+	}
+
+	var ratings []struct {
+		MealID        uint
+		RatingCount   uint
+		AverageRating float64
+	}
+	err := r.db.Table("review_meal_ratings AS rmr").
+		Joins("JOIN reviews AS r ON r.id = rmr.review_id").
+		Where("rmr.meal_id IN ? AND r.status <> ?", mealIDs, models.HiddenReview).
+		Select("rmr.meal_id AS meal_id, COUNT(*) AS rating_count, AVG(rmr.rating) AS average_rating").
+		Group("rmr.meal_id").
+		Scan(&ratings).Error
+	if err != nil {
+		return apperrors.NewInternalServerErr("Failed to get meal ratings", err)
+	}
+
+	for _, rating := range ratings {
+		mealsByID[rating.MealID].RatingCount = rating.RatingCount
+		mealsByID[rating.MealID].AverageRating = rating.AverageRating
+	}
+
+	return nil
+}
+
 func (r *mealRepositoryImpl) Create(meal *models.Meal) error {
 	if err := r.db.Create(meal).Error; err != nil {
 		return apperrors.NewInternalServerErr("Failed to create meal", err)
@@ -95,6 +163,47 @@ func (r *mealRepositoryImpl) Create(meal *models.Meal) error {
 	return nil
 }
 
+func (r *mealRepositoryImpl) Update(meal *models.Meal) error {
+	res := r.db.Model(meal).
+		Select("Name", "CategoryID", "Description", "ImageURL", "ImagePublicID", "ImageVariants", "Price", "Allergens", "DietaryTags", "SpicyLevel").
+		Updates(meal)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update meal %d", meal.ID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Meal with ID %d not found", meal.ID), nil)
+	}
+
+	// The version is incremented by the database, so concurrent updates of the meal get versions of their own
+	err := r.db.Model(meal).UpdateColumn("version", gorm.Expr("version + 1")).Error
+	if err == nil {
+		err = r.db.Model(&models.Meal{}).Where("id = ?", meal.ID).Select("version").Scan(&meal.Version).Error
+	}
+	if err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update the version of meal %d", meal.ID), err)
+	}
+
+	return nil
+}
+
+func (r *mealRepositoryImpl) CreateVersion(version *models.MealVersion) error {
+	if err := r.db.Create(version).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create version %d of meal %d", version.Version, version.MealID), err)
+	}
+	return nil
+}
+
+func (r *mealRepositoryImpl) GetVersions(mealID uint) ([]*models.MealVersion, error) {
+	var versions []*models.MealVersion
+
+	if err := r.db.Where("meal_id = ?", mealID).Order("version ASC").Find(&versions).Error; err != nil {
+		return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get versions of meal %d", mealID), err)
+	}
+
+	return versions, nil
+}
+
 func (r *mealRepositoryImpl) Delete(meal *models.Meal) error {
 	result := r.db.Delete(meal)
 	if err := result.Error; err != nil {
@@ -107,3 +216,95 @@ func (r *mealRepositoryImpl) Delete(meal *models.Meal) error {
 
 	return nil
 }
+
+func (r *mealRepositoryImpl) ReplaceOptionGroups(mealID uint, optionGroups []models.OptionGroup) error {
+	existingGroups := r.db.Model(&models.OptionGroup{}).Select("id").Where("meal_id = ?", mealID)
+
+	if err := r.db.Where("option_group_id IN (?)", existingGroups).Delete(&models.MealOption{}).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to delete options of meal %d", mealID), err)
+	}
+
+	if err := r.db.Where("meal_id = ?", mealID).Delete(&models.OptionGroup{}).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to delete option groups of meal %d", mealID), err)
+	}
+
+	if len(optionGroups) == 0 {
+		return nil
+	}
+
+	for i := range optionGroups {
+		optionGroups[i].MealID = mealID
+	}
+
+	if err := r.db.Create(&optionGroups).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create option groups of meal %d", mealID), err)
+	}
+
+	return nil
+}
+
+func (r *mealRepositoryImpl) SetSoldOut(mealID uint, soldOut bool) error {
+	res := r.db.Model(&models.Meal{}).Where("id = ?", mealID).Update("sold_out", soldOut)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update availability of meal %d", mealID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Meal with ID %d not found", mealID), nil)
+	}
+
+	return nil
+}
+
+func (r *mealRepositoryImpl) ReplaceAvailabilityWindows(mealID uint, windows []models.AvailabilityWindow) error {
+	for i := range windows {
+		windows[i].MealID = &mealID
+		windows[i].CategoryID = nil
+		windows[i].MenuID = nil
+		windows[i].PromotionID = nil
+	}
+
+	if err := replaceAvailabilityWindows(r.db, "meal_id", mealID, windows); err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to replace availability windows of meal %d", mealID), err)
+	}
+
+	return nil
+}
+
+func (r *mealRepositoryImpl) ReplaceRecipe(mealID uint, recipe []models.RecipeItem) error {
+	if err := r.db.Where("meal_id = ?", mealID).Delete(&models.RecipeItem{}).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to delete recipe of meal %d", mealID), err)
+	}
+
+	if len(recipe) == 0 {
+		return nil
+	}
+
+	for i := range recipe {
+		recipe[i].MealID = mealID
+	}
+
+	if err := r.db.Omit("Ingredient").Create(&recipe).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create recipe of meal %d", mealID), err)
+	}
+
+	return nil
+}
+
+// replaceAvailabilityWindows deletes the availability windows of the meal, category or menu identified by ownerColumn
+// and ownerID and creates the given windows instead. The windows have to reference their owner already.
+func replaceAvailabilityWindows(db *gorm.DB, ownerColumn string, ownerID uint, windows []models.AvailabilityWindow) error {
+	if err := db.Where(ownerColumn+" = ?", ownerID).Delete(&models.AvailabilityWindow{}).Error; err != nil {
+		return err
+	}
+
+	if len(windows) == 0 {
+		return nil
+	}
+
+	return db.Create(&windows).Error
+}
+
+func (r *mealRepositoryImpl) Uploads() UploadRepository {
+	return &uploadRepositoryImpl{db: r.db}
+}
@@ -7,8 +7,10 @@ import (
 	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestMealRepository_GetAll(t *testing.T) {
@@ -23,21 +25,34 @@ func TestMealRepository_GetAll(t *testing.T) {
 	assert.Len(t, meals, 0)
 
 	// Create test data
+	categories := []models.Category{
+		{Name: "Main Courses", DisplayOrder: 2, Active: true},
+		{Name: "Desserts", DisplayOrder: 1, Active: true},
+		{Name: "Cocktails", DisplayOrder: 3, Active: false},
+	}
+	require.NoError(t, db.Create(&categories).Error)
+	require.NoError(t, db.Model(&categories[2]).Update("active", false).Error)
+
 	testMeals := []models.Meal{
-		{Name: "Test Meal 1", Price: decimal.NewFromFloat(10.99), Category: "Main Courses", Description: "Test Description 1", ImageURL: "http://example.com/1.jpg"},
-		{Name: "Test Meal 2", Price: decimal.NewFromFloat(7.99), Category: "Desserts", Description: "Test Description 2", ImageURL: "http://example.com/2.jpg"},
+		{Name: "Test Meal 1", Price: decimal.NewFromFloat(10.99), CategoryID: categories[0].ID, Description: "Test Description 1", ImageURL: "http://example.com/1.jpg"},
+		{Name: "Test Meal 2", Price: decimal.NewFromFloat(7.99), CategoryID: categories[1].ID, Description: "Test Description 2", ImageURL: "http://example.com/2.jpg"},
 	}
 
 	for i := range testMeals {
 		assert.NoError(t, db.Create(&testMeals[i]).Error)
 	}
 
+	hiddenMeal := models.Meal{Name: "Mojito", Price: decimal.NewFromFloat(8.50), CategoryID: categories[2].ID, Description: "Hidden", ImageURL: "http://example.com/3.jpg"}
+	require.NoError(t, db.Create(&hiddenMeal).Error)
+
 	// Execute
 	meals, err = repo.GetAll()
 
 	// Verify
 	assert.NoError(t, err)
-	assert.Len(t, meals, 2)
+	require.Len(t, meals, 2)
+	assert.Equal(t, "Desserts", meals[0].CategoryName(), "meals are ordered by the display order of their category")
+	assert.Equal(t, "Main Courses", meals[1].CategoryName())
 
 	for _, meal := range meals {
 		found := false
@@ -61,7 +76,7 @@ func TestMealRepository_GetByID(t *testing.T) {
 	testMeal := models.Meal{
 		Name:        "Test Meal",
 		Price:       decimal.NewFromFloat(9.99),
-		Category:    "Main Courses",
+		Category:    &models.Category{Name: "Main Courses"},
 		Description: "Test Description",
 		ImageURL:    "http://example.com/image.jpg",
 	}
@@ -76,6 +91,7 @@ func TestMealRepository_GetByID(t *testing.T) {
 	assert.NotNil(t, meal)
 	assert.Equal(t, testMeal.ID, meal.ID)
 	assert.Equal(t, testMeal.Name, meal.Name)
+	assert.Equal(t, "Main Courses", meal.CategoryName())
 
 	// Non existant meal
 	meal, err = repo.GetByID(999)
@@ -108,6 +124,28 @@ func TestMealRepository_Create(t *testing.T) {
 		assertMealEquals(t, meal, meals[0])
 	})
 
+	t.Run("dietary information", func(t *testing.T) {
+		db := testinghelpers.NewTestDB(t)
+		defer testinghelpers.CleanupTestDB(t, db)
+
+		repo := repositories.NewMealRepository(db)
+		meal := getTestMeal()
+		meal.Allergens = models.Allergens{models.Gluten, models.Sesame}
+		meal.DietaryTags = models.DietaryTags{models.Vegan, models.Halal}
+		meal.SpicyLevel = 2
+		require.NoError(t, repo.Create(meal))
+
+		foundMeal, err := repo.GetByID(meal.ID)
+		require.NoError(t, err)
+		assert.Equal(t, meal.Allergens, foundMeal.Allergens)
+		assert.Equal(t, meal.DietaryTags, foundMeal.DietaryTags)
+		assert.Equal(t, uint(2), foundMeal.SpicyLevel)
+
+		meal = getTestMeal()
+		meal.SpicyLevel = 4
+		assert.Error(t, repo.Create(meal))
+	})
+
 	t.Run("invalid meal", func(t *testing.T) {
 		db := testinghelpers.NewTestDB(t)
 		defer testinghelpers.CleanupTestDB(t, db)
@@ -160,11 +198,170 @@ func TestMealRepository_Delete(t *testing.T) {
 	})
 }
 
+func TestMealRepository_ReplaceOptionGroups(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewMealRepository(db)
+
+	meal := getTestMeal()
+	meal.OptionGroups = []models.OptionGroup{
+		{Name: "Size", MinSelected: 1, MaxSelected: 1, Options: []models.MealOption{
+			{Name: "Small"},
+			{Name: "Large", PriceDelta: decimal.RequireFromString("2.00")},
+		}},
+	}
+	require.NoError(t, repo.Create(meal))
+
+	foundMeal, err := repo.GetByID(meal.ID)
+	require.NoError(t, err)
+	require.Len(t, foundMeal.OptionGroups, 1)
+	assert.Len(t, foundMeal.OptionGroups[0].Options, 2)
+
+	err = repo.ReplaceOptionGroups(meal.ID, []models.OptionGroup{
+		{Name: "Extras", MultiSelect: true, MaxSelected: 2, Options: []models.MealOption{
+			{Name: "Cheese", PriceDelta: decimal.RequireFromString("0.50")},
+			{Name: "Bacon", PriceDelta: decimal.RequireFromString("1.00")},
+		}},
+		{Name: "Remove", MultiSelect: true, MaxSelected: 1, Options: []models.MealOption{
+			{Name: "No onions"},
+		}},
+	})
+	require.NoError(t, err)
+
+	meals, err := repo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, meals, 1)
+	require.Len(t, meals[0].OptionGroups, 2)
+	assert.Equal(t, "Extras", meals[0].OptionGroups[0].Name)
+	assert.Len(t, meals[0].OptionGroups[0].Options, 2)
+
+	var optionCount int64
+	require.NoError(t, db.Model(&models.MealOption{}).Count(&optionCount).Error)
+	assert.Equal(t, int64(3), optionCount, "options of replaced groups must be deleted")
+}
+
+func TestMealRepository_Availability(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewMealRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, repo.Create(meal))
+
+	require.NoError(t, repo.SetSoldOut(meal.ID, true))
+	assert.True(t, apperrors.IsNotFoundErr(repo.SetSoldOut(99, true)))
+
+	err := repo.ReplaceAvailabilityWindows(meal.ID, []models.AvailabilityWindow{
+		models.NewAvailabilityWindow([]time.Weekday{time.Saturday, time.Sunday}, 8*60, 11*60),
+	})
+	require.NoError(t, err)
+
+	err = repo.ReplaceAvailabilityWindows(meal.ID, []models.AvailabilityWindow{
+		models.NewAvailabilityWindow([]time.Weekday{time.Friday}, 22*60, 2*60),
+	})
+	require.NoError(t, err)
+
+	foundMeal, err := repo.GetByID(meal.ID)
+	require.NoError(t, err)
+	assert.True(t, foundMeal.SoldOut)
+	require.Len(t, foundMeal.AvailabilityWindows, 1, "replaced windows must be deleted")
+	assert.Equal(t, []time.Weekday{time.Friday}, foundMeal.AvailabilityWindows[0].Weekdays())
+	assert.Equal(t, uint(22*60), foundMeal.AvailabilityWindows[0].StartMinute)
+
+	require.NoError(t, repo.SetSoldOut(meal.ID, false))
+	require.NoError(t, repo.ReplaceAvailabilityWindows(meal.ID, nil))
+
+	foundMeal, err = repo.GetByID(meal.ID)
+	require.NoError(t, err)
+	assert.False(t, foundMeal.SoldOut)
+	assert.Empty(t, foundMeal.AvailabilityWindows)
+}
+
+func TestMealRepository_ReplaceRecipe(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewMealRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, repo.Create(meal))
+
+	cod := &models.Ingredient{Name: "Cod", Unit: "kg", Stock: decimal.RequireFromString("0.1")}
+	potatoes := &models.Ingredient{Name: "Potatoes", Unit: "kg", Stock: decimal.NewFromInt(20)}
+	require.NoError(t, db.Create(cod).Error)
+	require.NoError(t, db.Create(potatoes).Error)
+
+	assert.Error(t, repo.ReplaceRecipe(meal.ID, []models.RecipeItem{
+		{IngredientID: 99, Quantity: decimal.NewFromInt(1)},
+	}), "recipes can only use existing ingredients")
+	require.NoError(t, repo.ReplaceRecipe(meal.ID, []models.RecipeItem{
+		{IngredientID: potatoes.ID, Quantity: decimal.RequireFromString("0.3")},
+	}))
+	require.NoError(t, repo.ReplaceRecipe(meal.ID, []models.RecipeItem{
+		{IngredientID: cod.ID, Quantity: decimal.RequireFromString("0.2")},
+		{IngredientID: potatoes.ID, Quantity: decimal.RequireFromString("0.3")},
+	}))
+
+	foundMeal, err := repo.GetByID(meal.ID)
+	require.NoError(t, err)
+	require.Len(t, foundMeal.Recipe, 2, "replaced recipe items must be deleted")
+	require.NotNil(t, foundMeal.Recipe[0].Ingredient)
+	assert.Equal(t, "Cod", foundMeal.Recipe[0].Ingredient.Name)
+	assert.False(t, foundMeal.InStock(), "there is not enough cod for a portion")
+
+	require.NoError(t, db.Model(cod).Update("stock", decimal.NewFromInt(5)).Error)
+
+	meals, err := repo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, meals, 1)
+	assert.True(t, meals[0].InStock())
+}
+
+func TestMealRepository_Versions(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewMealRepository(db)
+
+	meal := getTestMeal()
+	meal.Version = 1
+	require.NoError(t, repo.Create(meal))
+	require.NoError(t, repo.CreateVersion(meal.NewVersion()))
+
+	meal.Name = "Renamed Meal"
+	meal.Price = decimal.RequireFromString("11.50")
+	meal.Allergens = models.Allergens{models.Celery}
+	meal.Version = 1
+	require.NoError(t, repo.Update(meal))
+	assert.Equal(t, uint(2), meal.Version, "the version is incremented by the database")
+	require.NoError(t, repo.CreateVersion(meal.NewVersion()))
+	assert.Error(t, repo.CreateVersion(meal.NewVersion()), "versions of a meal are unique")
+
+	foundMeal, err := repo.GetByID(meal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed Meal", foundMeal.Name)
+	assert.Equal(t, uint(2), foundMeal.Version)
+	assert.Equal(t, models.Allergens{models.Celery}, foundMeal.Allergens)
+
+	require.NoError(t, repo.Delete(foundMeal))
+
+	versions, err := repo.GetVersions(meal.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2, "versions of deleted meals are kept")
+	assert.Equal(t, "Test Meal", versions[0].Name)
+	assert.Equal(t, []models.MealChange{
+		{Field: "name", From: "Test Meal", To: "Renamed Meal"},
+		{Field: "price", From: versions[0].Price, To: versions[1].Price},
+		{Field: "allergens", From: versions[0].Allergens, To: versions[1].Allergens},
+	}, versions[1].Diff(versions[0]))
+
+	assert.True(t, apperrors.IsNotFoundErr(repo.Update(&models.Meal{ID: 99, Name: "Missing"})))
+}
+
 func getTestMeal() *models.Meal {
 	return &models.Meal{
 		Name:        "Test Meal",
 		Description: "Test Description",
-		Category:    models.MainCourses,
+		CategoryID:  1,
+		Category:    &models.Category{ID: 1, Name: "Main Courses", Active: true},
 		ImageURL:    "http://image.com",
 		Price:       decimal.NewFromFloat(9.99),
 	}
@@ -240,7 +437,7 @@ func assertMealEquals(t *testing.T, expected, actual *models.Meal) {
 	assert.Equal(t, expected.ID, actual.ID)
 	assert.Equal(t, expected.Name, actual.Name)
 	assert.Equal(t, expected.Description, actual.Description)
-	assert.Equal(t, expected.Category, actual.Category)
+	assert.Equal(t, expected.CategoryID, actual.CategoryID)
 	assert.Equal(t, expected.Price, actual.Price)
 	assert.Equal(t, expected.ImageURL, actual.ImageURL)
 }
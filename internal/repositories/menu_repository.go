@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+)
+
+// MenuRepository provides an interface for CRUD operations on Menu entities
+// and supports transactional operations.
+// WithTransaction executes a function within a database transaction and rolls back if an error occurs.
+// GetAll retrieves all menus ordered by their ID.
+// GetByID retrieves a specific menu by its ID.
+// GetByName retrieves a specific menu by its name.
+// Create adds a new menu without items and schedules to the database.
+// Update updates the name, priority, active flag and date range of an existing menu.
+// Delete removes a menu along with its items and schedules.
+// ReplaceItems replaces all items of a menu.
+// ReplaceSchedules replaces all schedules of a menu.
+// Menus are retrieved with their items ordered by display order and their schedules.
+type MenuRepository interface {
+	WithTransaction(fn func(txRepo MenuRepository) error) error
+	GetAll() ([]*models.Menu, error)
+	GetByID(ID uint) (*models.Menu, error)
+	GetByName(name string) (*models.Menu, error)
+	Create(menu *models.Menu) error
+	Update(menu *models.Menu) error
+	Delete(ID uint) error
+	ReplaceItems(menuID uint, items []models.MenuItem) error
+	ReplaceSchedules(menuID uint, schedules []models.AvailabilityWindow) error
+}
+
+func NewMenuRepository(db *gorm.DB) MenuRepository {
+	return &menuRepositoryImpl{db: db}
+}
+
+type menuRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *menuRepositoryImpl) WithTransaction(fn func(txRepo MenuRepository) error) error {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to start a transaction", tx.Error)
+	}
+	defer tx.Rollback()
+
+	txRepo := &menuRepositoryImpl{db: tx}
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to commit transaction", err)
+	}
+	return nil
+}
+
+// preloaded returns a query which loads menus with their items and schedules.
+func (r *menuRepositoryImpl) preloaded() *gorm.DB {
+	return r.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("display_order ASC, id ASC")
+		}).
+		Preload("Schedules")
+}
+
+func (r *menuRepositoryImpl) GetAll() ([]*models.Menu, error) {
+	var menus []*models.Menu
+
+	if err := r.preloaded().Order("id ASC").Find(&menus).Error; err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get all menus", err)
+	}
+
+	return menus, nil
+}
+
+func (r *menuRepositoryImpl) GetByID(ID uint) (*models.Menu, error) {
+	var menu models.Menu
+	err := r.preloaded().Where("id = ?", ID).First(&menu).Error
+
+	if err == nil {
+		return &menu, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Menu with ID %d not found", ID), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get menu %d", ID), err)
+}
+
+func (r *menuRepositoryImpl) GetByName(name string) (*models.Menu, error) {
+	var menu models.Menu
+	err := r.preloaded().Where("name = ?", name).First(&menu).Error
+
+	if err == nil {
+		return &menu, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Menu %s not found", name), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get menu %s", name), err)
+}
+
+func (r *menuRepositoryImpl) Create(menu *models.Menu) error {
+	if err := r.db.Omit("Items", "Schedules").Create(menu).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create menu %s", menu.Name), err)
+	}
+	return nil
+}
+
+func (r *menuRepositoryImpl) Update(menu *models.Menu) error {
+	res := r.db.Model(menu).Select("Name", "Priority", "Active", "StartDate", "EndDate").Updates(menu)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update menu %d", menu.ID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Menu with ID %d not found", menu.ID), nil)
+	}
+
+	return nil
+}
+
+func (r *menuRepositoryImpl) Delete(ID uint) error {
+	res := r.db.Delete(&models.Menu{}, ID)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to delete menu %d", ID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Menu with ID %d not found", ID), nil)
+	}
+
+	return nil
+}
+
+func (r *menuRepositoryImpl) ReplaceItems(menuID uint, items []models.MenuItem) error {
+	for i := range items {
+		items[i].ID = 0
+		items[i].MenuID = menuID
+	}
+
+	if err := r.db.Where("menu_id = ?", menuID).Delete(&models.MenuItem{}).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to replace items of menu %d", menuID), err)
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	if err := r.db.Omit("Meal").Create(&items).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to replace items of menu %d", menuID), err)
+	}
+
+	return nil
+}
+
+func (r *menuRepositoryImpl) ReplaceSchedules(menuID uint, schedules []models.AvailabilityWindow) error {
+	for i := range schedules {
+		schedules[i].MenuID = &menuID
+		schedules[i].MealID = nil
+		schedules[i].CategoryID = nil
+		schedules[i].PromotionID = nil
+	}
+
+	if err := replaceAvailabilityWindows(r.db, "menu_id", menuID, schedules); err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to replace schedules of menu %d", menuID), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMenuRepository_CRUD(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewMenuRepository(db)
+
+	_, err := repo.GetByID(1)
+	assert.True(t, apperrors.IsNotFoundErr(err))
+
+	christmasEve := time.Date(2026, time.December, 24, 0, 0, 0, 0, time.UTC)
+	lunch := &models.Menu{Name: "Lunch", Active: true}
+	christmas := &models.Menu{Name: "Christmas", Priority: 10, Active: true, StartDate: &christmasEve}
+	require.NoError(t, repo.Create(lunch))
+	require.NoError(t, repo.Create(christmas))
+	assert.Error(t, repo.Create(&models.Menu{Name: "Lunch"}), "menu names are unique")
+
+	menus, err := repo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, menus, 2)
+	assert.Equal(t, "Lunch", menus[0].Name)
+	require.NotNil(t, menus[1].StartDate)
+	assert.Equal(t, "2026-12-24", menus[1].StartDate.Format("2006-01-02"))
+	assert.Nil(t, menus[1].EndDate)
+
+	lunch.Name = "Weekday lunch"
+	lunch.Active = false
+	require.NoError(t, repo.Update(lunch))
+
+	found, err := repo.GetByName("Weekday lunch")
+	require.NoError(t, err)
+	assert.False(t, found.Active)
+
+	_, err = repo.GetByName("Lunch")
+	assert.True(t, apperrors.IsNotFoundErr(err))
+	assert.True(t, apperrors.IsNotFoundErr(repo.Update(&models.Menu{ID: 99, Name: "Brunch"})))
+
+	require.NoError(t, repo.Delete(christmas.ID))
+	assert.True(t, apperrors.IsNotFoundErr(repo.Delete(christmas.ID)))
+}
+
+func TestMenuRepository_ItemsAndSchedules(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewMenuRepository(db)
+	mealRepo := repositories.NewMealRepository(db)
+
+	soup := getTestMeal()
+	require.NoError(t, mealRepo.Create(soup))
+	salad := getTestMeal()
+	salad.Category = nil
+	salad.Name = "Salad"
+	require.NoError(t, mealRepo.Create(salad))
+
+	lunch := &models.Menu{Name: "Lunch", Active: true}
+	require.NoError(t, repo.Create(lunch))
+
+	assert.Error(t, repo.ReplaceItems(lunch.ID, []models.MenuItem{{MealID: 99, Price: decimal.NewFromInt(5)}}),
+		"menu items have to reference existing meals")
+
+	err := repo.ReplaceItems(lunch.ID, []models.MenuItem{
+		{MealID: salad.ID, Price: decimal.RequireFromString("7.50"), DisplayOrder: 2},
+		{MealID: soup.ID, Price: decimal.RequireFromString("4.90"), DisplayOrder: 1},
+	})
+	require.NoError(t, err)
+
+	err = repo.ReplaceSchedules(lunch.ID, []models.AvailabilityWindow{
+		models.NewAvailabilityWindow([]time.Weekday{time.Monday, time.Friday}, 11*60, 15*60),
+	})
+	require.NoError(t, err)
+
+	found, err := repo.GetByID(lunch.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Items, 2)
+	assert.Equal(t, soup.ID, found.Items[0].MealID, "items are ordered by their display order")
+	assert.True(t, decimal.RequireFromString("4.90").Equal(found.Items[0].Price))
+	require.Len(t, found.Schedules, 1)
+	assert.Equal(t, lunch.ID, *found.Schedules[0].MenuID)
+	assert.Nil(t, found.Schedules[0].MealID)
+
+	require.NoError(t, repo.ReplaceItems(lunch.ID, []models.MenuItem{{MealID: soup.ID, Price: decimal.NewFromInt(5)}}))
+
+	found, err = repo.GetByID(lunch.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Items, 1)
+
+	require.NoError(t, repo.Delete(lunch.ID))
+
+	var remaining int64
+	require.NoError(t, db.Model(&models.AvailabilityWindow{}).Count(&remaining).Error)
+	assert.Zero(t, remaining, "schedules are deleted along with their menu")
+	require.NoError(t, db.Model(&models.MenuItem{}).Count(&remaining).Error)
+	assert.Zero(t, remaining, "items are deleted along with their menu")
+}
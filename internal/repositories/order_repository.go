@@ -16,18 +16,46 @@ type OrderQueryParams struct {
 // WithTransaction executes a function within a database transaction.
 // GetOrders retrieves a list of orders based on the specified query parameters.
 // GetByID fetches a single order by its unique identifier.
+// GetByIDForUpdate fetches a single order and locks it until the transaction ends, so it cannot be paid meanwhile.
 // Create adds a new order to the data store.
-// GetOrderMeal retrieves a specific meal associated with an order.
-// CreateOrderMeal adds a new meal to an order in the data store.
-// UpdateOrderMeal updates an existing meal tied to an order.
+// Cancel marks an order which is not cancelled yet as cancelled at the given time.
+// GetOrOpenTableSession retrieves the open session of a table, opening a new one if the table has none.
+// CloseSettledSession closes the table session if all of its orders are paid or cancelled.
+// GetOrderMeal retrieves a specific line of an order.
+// CreateOrderMeal adds a new line with its chosen options to an order in the data store.
+// UpdateOrderMeal updates the quantity and status counters of an existing meal tied to an order.
+// CreateStatusChange records a status transition of an order meal.
+// CreateVoid appends an entry to the log of voided order meals.
 // CreateReview creates a new review associated with an order.
+// ConsumeIngredients takes the ingredients used by ordered meals off the stock and returns the updated ingredients.
+// GetRecipe retrieves the ingredients used by one portion of a meal, including meals which were deleted since.
+// ReturnIngredients puts the ingredients of ordered meals which are not going to be prepared back on the stock.
+// CreateDiscounts adds discounts granted by promotions to the meals of an order.
+// RedeemPromotion counts an order towards the usage limit of a promotion's promo code.
+// ReleasePromoCode stops counting a cancelled order towards the usage limit of its promo code.
+// UpdateTip updates the tip the customer added to the bill of an order which is not paid yet.
+// Uploads returns the upload repository sharing the transaction of the order repository.
 type OrderRepository interface {
 	WithTransaction(fn func(tx OrderRepository) error) error
 	GetOrders(params OrderQueryParams) ([]*models.Order, error)
 	GetByID(orderID uint) (*models.Order, error)
+	GetByIDForUpdate(orderID uint) (*models.Order, error)
 	Create(order *models.Order) error
-	GetOrderMeal(orderID, mealID uint) (*models.OrderMeal, error)
+	Cancel(orderID uint, cancelledAt time.Time) error
+	GetOrOpenTableSession(tableNo int, openedAt time.Time) (*models.TableSession, error)
+	CloseSettledSession(sessionID uint, closedAt time.Time) error
+	GetOrderMeal(orderID, orderMealID uint) (*models.OrderMeal, error)
 	CreateOrderMeal(orderMeal *models.OrderMeal) error
 	UpdateOrderMeal(orderMeal *models.OrderMeal) error
+	CreateStatusChange(statusChange *models.OrderMealStatusChange) error
+	CreateVoid(void *models.OrderMealVoid) error
 	CreateReview(review *models.Review) error
+	ConsumeIngredients(usage models.IngredientUsage) ([]*models.Ingredient, error)
+	GetRecipe(mealID uint) ([]models.RecipeItem, error)
+	ReturnIngredients(usage models.IngredientUsage) error
+	CreateDiscounts(discounts []models.OrderDiscount) error
+	RedeemPromotion(promotionID uint) error
+	ReleasePromoCode(code string) error
+	UpdateTip(order *models.Order) error
+	Uploads() UploadRepository
 }
@@ -6,6 +6,8 @@ import (
 	"github.com/Ruclo/MyMeals/internal/apperrors"
 	"github.com/Ruclo/MyMeals/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 func NewOrderRepository(db *gorm.DB) OrderRepository {
@@ -37,10 +39,31 @@ func (r *orderRepositoryImpl) WithTransaction(fn func(txRepo OrderRepository) er
 }
 
 func (r *orderRepositoryImpl) GetByID(orderID uint) (*models.Order, error) {
+	return getOrder(r.db, orderID)
+}
+
+func (r *orderRepositoryImpl) GetByIDForUpdate(orderID uint) (*models.Order, error) {
+	return getOrder(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), orderID)
+}
+
+// getOrder retrieves an order along with its meals, discounts and review.
+func getOrder(db *gorm.DB, orderID uint) (*models.Order, error) {
 	var order models.Order
 
-	err := r.db.Model(&models.Order{}).Where("ID = ?", orderID).
+	err := db.Model(&models.Order{}).Where("ID = ?", orderID).
 		Preload("OrderMeals.Meal").
+		Preload("OrderMeals.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("meal_option_id ASC")
+		}).
+		Preload("OrderMeals.StatusChanges", func(db *gorm.DB) *gorm.DB {
+			return db.Order("changed_at ASC")
+		}).
+		Preload("OrderMeals.Voids", func(db *gorm.DB) *gorm.DB {
+			return db.Order("voided_at ASC")
+		}).
+		Preload("Discounts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
 		Preload("Review").First(&order).Error
 
 	if err == nil {
@@ -62,20 +85,25 @@ func (r *orderRepositoryImpl) GetOrders(params OrderQueryParams) ([]*models.Orde
 	if params.OnlyPending {
 		query = query.Distinct("orders.*").
 			Joins("JOIN order_meals ON orders.id = order_meals.order_id").
-			Where("order_meals.completed < order_meals.quantity")
+			Where("orders.cancelled_at IS NULL").
+			Where("order_meals.completed + order_meals.cancelled + order_meals.voided < order_meals.quantity")
 	}
 
 	if !params.OlderThan.IsZero() {
-		query = query.Where("created_at < ?", params.OlderThan)
+		query = query.Where("orders.created_at < ?", params.OlderThan)
 	}
 
 	if params.PageSize > 0 {
 		query = query.Limit(int(params.PageSize))
 	}
 
-	query = query.Order("created_at DESC")
+	query = query.Order("orders.created_at DESC")
 
-	query = query.Preload("OrderMeals").Preload("Review")
+	query = query.Preload("OrderMeals.Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("meal_option_id ASC")
+	}).Preload("Discounts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("Review")
 
 	if err := query.Find(&orders).Error; err != nil {
 		return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get orders with params %+v", params), nil)
@@ -93,19 +121,56 @@ func (r *orderRepositoryImpl) Create(order *models.Order) error {
 	return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create order %+v", order), nil)
 }
 
-func (r *orderRepositoryImpl) GetOrderMeal(orderID, mealID uint) (*models.OrderMeal, error) {
+func (r *orderRepositoryImpl) Cancel(orderID uint, cancelledAt time.Time) error {
+	res := r.db.Model(&models.Order{}).Where("id = ? AND cancelled_at IS NULL", orderID).
+		Update("cancelled_at", cancelledAt)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to cancel order %d", orderID), res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Order with id %d not found or already cancelled", orderID), nil)
+	}
+
+	return nil
+}
+
+func (r *orderRepositoryImpl) GetOrOpenTableSession(tableNo int, openedAt time.Time) (*models.TableSession, error) {
+	session := models.TableSession{TableNo: tableNo, OpenedAt: openedAt}
+
+	// Orders made at the table at the same time may open its session concurrently. Only one of them inserts it,
+	// the inserts of the others do nothing instead of violating the index of open sessions and they look it up.
+	err := r.db.Where("table_no = ? AND closed_at IS NULL", tableNo).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&session)
+		err = result.Error
+		if err == nil && result.RowsAffected == 0 {
+			err = r.db.Where("table_no = ? AND closed_at IS NULL", tableNo).First(&session).Error
+		}
+	}
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to open session of table %d", tableNo), err)
+	}
+
+	return &session, nil
+}
+
+func (r *orderRepositoryImpl) CloseSettledSession(sessionID uint, closedAt time.Time) error {
+	return closeSettledSession(r.db, sessionID, closedAt)
+}
+
+func (r *orderRepositoryImpl) GetOrderMeal(orderID, orderMealID uint) (*models.OrderMeal, error) {
 	var orderMeal models.OrderMeal
 
-	err := r.db.Model(&models.OrderMeal{}).Where("order_id = ? AND meal_id = ?", orderID, mealID).First(&orderMeal).Error
+	err := r.db.Model(&models.OrderMeal{}).Where("id = ? AND order_id = ?", orderMealID, orderID).First(&orderMeal).Error
 	if err == nil {
 		return &orderMeal, nil
 	}
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Order %d does not have meal with id %d", orderID, mealID), err)
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Order %d does not have order meal with id %d", orderID, orderMealID), err)
 	}
 
-	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get order meal order id: %d meal id: %d", orderID, mealID), err)
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get order meal order id: %d order meal id: %d", orderID, orderMealID), err)
 }
 
 func (r *orderRepositoryImpl) CreateOrderMeal(orderMeal *models.OrderMeal) error {
@@ -119,7 +184,9 @@ func (r *orderRepositoryImpl) CreateOrderMeal(orderMeal *models.OrderMeal) error
 }
 
 func (r *orderRepositoryImpl) UpdateOrderMeal(orderMeal *models.OrderMeal) error {
-	res := r.db.Model(orderMeal).Updates(orderMeal)
+	res := r.db.Model(orderMeal).
+		Select("Quantity", "Accepted", "Cooking", "Completed", "Served", "Cancelled", "Voided").
+		Updates(orderMeal)
 	if res.Error != nil {
 		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update order meal %+v", orderMeal), res.Error)
 	}
@@ -130,6 +197,24 @@ func (r *orderRepositoryImpl) UpdateOrderMeal(orderMeal *models.OrderMeal) error
 	return nil
 }
 
+func (r *orderRepositoryImpl) CreateStatusChange(statusChange *models.OrderMealStatusChange) error {
+	err := r.db.Create(statusChange).Error
+	if err == nil {
+		return nil
+	}
+
+	return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create status change %+v", statusChange), err)
+}
+
+func (r *orderRepositoryImpl) CreateVoid(void *models.OrderMealVoid) error {
+	err := r.db.Create(void).Error
+	if err == nil {
+		return nil
+	}
+
+	return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create void %+v", void), err)
+}
+
 func (r *orderRepositoryImpl) CreateReview(review *models.Review) error {
 	err := r.db.Create(review).Error
 
@@ -139,3 +224,57 @@ func (r *orderRepositoryImpl) CreateReview(review *models.Review) error {
 
 	return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create a review %+v", review), nil)
 }
+
+func (r *orderRepositoryImpl) ConsumeIngredients(usage models.IngredientUsage) ([]*models.Ingredient, error) {
+	return consumeIngredients(r.db, usage)
+}
+
+func (r *orderRepositoryImpl) GetRecipe(mealID uint) ([]models.RecipeItem, error) {
+	var recipe []models.RecipeItem
+
+	if err := r.db.Where("meal_id = ?", mealID).Order("ingredient_id ASC").Find(&recipe).Error; err != nil {
+		return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get recipe of meal %d", mealID), err)
+	}
+
+	return recipe, nil
+}
+
+func (r *orderRepositoryImpl) ReturnIngredients(usage models.IngredientUsage) error {
+	return returnIngredients(r.db, usage)
+}
+
+func (r *orderRepositoryImpl) CreateDiscounts(discounts []models.OrderDiscount) error {
+	if len(discounts) == 0 {
+		return nil
+	}
+
+	if err := r.db.Omit("Promotion").Create(&discounts).Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to create order discounts", err)
+	}
+
+	return nil
+}
+
+func (r *orderRepositoryImpl) RedeemPromotion(promotionID uint) error {
+	return redeemPromotion(r.db, promotionID)
+}
+
+func (r *orderRepositoryImpl) ReleasePromoCode(code string) error {
+	return releasePromoCode(r.db, code)
+}
+
+func (r *orderRepositoryImpl) UpdateTip(order *models.Order) error {
+	res := r.db.Model(order).Where("paid_at IS NULL").Select("TipAmount", "TipPercent").Updates(order)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update the tip of order %d", order.ID), res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Order with id %d not found or already paid", order.ID), nil)
+	}
+
+	return nil
+}
+
+func (r *orderRepositoryImpl) Uploads() UploadRepository {
+	return &uploadRepositoryImpl{db: r.db}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/Ruclo/MyMeals/internal/models"
 	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,6 +77,16 @@ func TestOrderRepository_GetByID(t *testing.T) {
 	assert.NotNil(t, foundOrder.OrderMeals)
 	assert.NotZero(t, len(foundOrder.OrderMeals))
 	assert.NotNil(t, foundOrder.OrderMeals[0].Meal)
+
+	err = repo.WithTransaction(func(tx repositories.OrderRepository) error {
+		lockedOrder, err := tx.GetByIDForUpdate(order.ID)
+		if err != nil {
+			return err
+		}
+		assertEqualOrders(t, order, lockedOrder)
+		return nil
+	})
+	assert.NoError(t, err)
 }
 
 func TestOrderRepository_Create(t *testing.T) {
@@ -133,13 +144,15 @@ func TestOrderRepository_GetOrderMeal(t *testing.T) {
 	}
 	require.NoError(t, db.Create(order).Error)
 
+	orderMealID := order.OrderMeals[0].ID
+
 	_, err := repo.GetOrderMeal(order.ID, 999)
 	assert.Error(t, err)
 
-	_, err = repo.GetOrderMeal(999, meal.ID)
+	_, err = repo.GetOrderMeal(999, orderMealID)
 	assert.Error(t, err)
 
-	orderMeal, err := repo.GetOrderMeal(order.ID, meal.ID)
+	orderMeal, err := repo.GetOrderMeal(order.ID, orderMealID)
 	assert.NoError(t, err)
 
 	assert.Equal(t, om.MealID, orderMeal.MealID)
@@ -173,33 +186,55 @@ func TestOrderRepository_CreateOrderMeal(t *testing.T) {
 
 	orderMeal := models.OrderMeal{
 		OrderID:  order.ID,
-		MealID:   meal.ID,
+		MealID:   999,
 		Quantity: 2,
 	}
 
 	err := repo.CreateOrderMeal(&orderMeal)
 	assert.Error(t, err)
 
-	orderMeal.MealID = 999
-	err = repo.CreateOrderMeal(&orderMeal)
-	assert.Error(t, err)
-
-	meal2 := getTestMeal()
-	require.NoError(t, db.Create(&meal2).Error)
-
-	orderMeal.MealID = meal2.ID
+	// The same meal can be ordered again on another line with different options
+	orderMeal.MealID = meal.ID
+	orderMeal.Options = []models.OrderMealOption{
+		{MealOptionID: 4, GroupName: "Size", Name: "Large", PriceDelta: decimal.RequireFromString("1.50")},
+	}
 	err = repo.CreateOrderMeal(&orderMeal)
 	assert.NoError(t, err)
+	assert.NotEqual(t, order.OrderMeals[0].ID, orderMeal.ID)
 
-	var foundOrderMeal models.OrderMeal
-
-	assert.NoError(t, db.Model(&models.OrderMeal{}).Where("order_id = ? AND meal_id = ?", order.ID, meal2.ID).First(&foundOrderMeal).Error)
+	foundOrder, err := repo.GetByID(order.ID)
+	require.NoError(t, err)
+	require.Len(t, foundOrder.OrderMeals, 2)
 
-	assert.Equal(t, orderMeal.MealID, foundOrderMeal.MealID)
+	foundOrderMeal := foundOrder.FindOrderMeal(&orderMeal)
+	require.NotNil(t, foundOrderMeal)
+	assert.Equal(t, orderMeal.ID, foundOrderMeal.ID)
 	assert.Equal(t, orderMeal.Quantity, foundOrderMeal.Quantity)
 	assert.Zero(t, foundOrderMeal.Completed)
-	assert.Equal(t, order.ID, foundOrderMeal.OrderID)
+	require.Len(t, foundOrderMeal.Options, 1)
+	assert.Equal(t, "Large", foundOrderMeal.Options[0].Name)
+	assert.Empty(t, foundOrderMeal.Allergens)
 
+	// Notes and allergies are kept per line
+	allergyOrderMeal := models.OrderMeal{
+		OrderID:   order.ID,
+		MealID:    meal.ID,
+		Quantity:  1,
+		Note:      "Sauce on the side",
+		Allergens: models.Allergens{models.Nuts, models.Gluten},
+	}
+	require.NoError(t, repo.CreateOrderMeal(&allergyOrderMeal))
+
+	foundOrder, err = repo.GetByID(order.ID)
+	require.NoError(t, err)
+	foundOrderMeal = foundOrder.FindOrderMeal(&allergyOrderMeal)
+	require.NotNil(t, foundOrderMeal)
+	assert.Equal(t, "Sauce on the side", foundOrderMeal.Note)
+	assert.Equal(t, models.Allergens{models.Nuts, models.Gluten}, foundOrderMeal.Allergens)
+
+	allergyOrderMeal.ID = 0
+	allergyOrderMeal.Allergens = models.Allergens{"shellfish"}
+	assert.Error(t, repo.CreateOrderMeal(&allergyOrderMeal))
 }
 
 func TestOrderRepository_UpdateOrderMeal(t *testing.T) {
@@ -229,17 +264,121 @@ func TestOrderRepository_UpdateOrderMeal(t *testing.T) {
 
 	require.NoError(t, db.Create(order).Error)
 
-	om.OrderID = order.ID
+	om = order.OrderMeals[0]
 	om.Completed = 3
 	err := repo.UpdateOrderMeal(&om)
 	assert.NoError(t, err)
 
 	var foundOrderMeal models.OrderMeal
-	assert.NoError(t, db.Model(&models.OrderMeal{}).Where("order_id = ? AND meal_id = ?", order.ID, meal.ID).First(&foundOrderMeal).Error)
+	assert.NoError(t, db.Model(&models.OrderMeal{}).Where("id = ?", om.ID).First(&foundOrderMeal).Error)
 	assert.Equal(t, om.Completed, foundOrderMeal.Completed)
 
 }
 
+func TestOrderRepository_CreateStatusChange(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewOrderRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, db.Create(&meal).Error)
+
+	order := &models.Order{
+		TableNo: 5,
+		Notes:   "Test notes",
+		OrderMeals: []models.OrderMeal{
+			{
+				MealID:   meal.ID,
+				Quantity: 3,
+				Accepted: 3,
+			},
+		},
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	orderMeal := order.OrderMeals[0]
+	require.NoError(t, orderMeal.Transition(models.AcceptedStatus, models.CookingStatus, 3))
+	require.NoError(t, repo.UpdateOrderMeal(&orderMeal))
+
+	statusChange := &models.OrderMealStatusChange{
+		OrderID:     order.ID,
+		OrderMealID: orderMeal.ID,
+		FromStatus:  models.AcceptedStatus,
+		ToStatus:    models.CookingStatus,
+		Quantity:    3,
+		ChangedBy:   "cook",
+		ChangedAt:   time.Now(),
+	}
+	assert.NoError(t, repo.CreateStatusChange(statusChange))
+	assert.NotZero(t, statusChange.ID)
+
+	foundOrder, err := repo.GetByID(order.ID)
+	require.NoError(t, err)
+	foundOrderMeal := foundOrder.OrderMeals[0]
+	assert.Zero(t, foundOrderMeal.Accepted)
+	assert.Equal(t, uint(3), foundOrderMeal.Cooking)
+	require.Len(t, foundOrderMeal.StatusChanges, 1)
+	assert.Equal(t, models.CookingStatus, foundOrderMeal.StatusChanges[0].ToStatus)
+	assert.Equal(t, "cook", foundOrderMeal.StatusChanges[0].ChangedBy)
+
+	statusChange.ID = 0
+	statusChange.Quantity = 0
+	assert.Error(t, repo.CreateStatusChange(statusChange))
+}
+
+func TestOrderRepository_CancelAndVoid(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewOrderRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, db.Create(&meal).Error)
+
+	order := &models.Order{
+		TableNo: 5,
+		Notes:   "Test notes",
+		OrderMeals: []models.OrderMeal{
+			{
+				MealID:   meal.ID,
+				Quantity: 2,
+			},
+		},
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	assert.True(t, apperrors.IsNotFoundErr(repo.Cancel(999, time.Now())))
+
+	void := &models.OrderMealVoid{
+		OrderID:     order.ID,
+		OrderMealID: order.OrderMeals[0].ID,
+		FromStatus:  models.PendingStatus,
+		Quantity:    1,
+		VoidedBy:    "waiter",
+		VoidedAt:    time.Now(),
+	}
+	assert.Error(t, repo.CreateVoid(void), "void without a reason must be rejected")
+
+	void.Reason = "Customer changed their mind"
+	assert.NoError(t, repo.CreateVoid(void))
+
+	pending, err := repo.GetOrders(repositories.OrderQueryParams{OnlyPending: true})
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	assert.NoError(t, repo.Cancel(order.ID, time.Now()))
+	assert.True(t, apperrors.IsNotFoundErr(repo.Cancel(order.ID, time.Now())), "order must not be cancelled twice")
+
+	pending, err = repo.GetOrders(repositories.OrderQueryParams{OnlyPending: true})
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	foundOrder, err := repo.GetByID(order.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, foundOrder.CancelledAt)
+	require.Len(t, foundOrder.OrderMeals[0].Voids, 1)
+	assert.Equal(t, "Customer changed their mind", foundOrder.OrderMeals[0].Voids[0].Reason)
+}
+
 func TestOrderRepository_CreateReview(t *testing.T) {
 	db := testinghelpers.NewTestDB(t)
 	defer testinghelpers.CleanupTestDB(t, db)
@@ -401,6 +540,24 @@ func TestOrderRepository_GetOrders(t *testing.T) {
 	order5.Review = createReview(t, db, order5.ID, 5, "Excellent service!")
 
 	orderedOrders = append(orderedOrders, order5)
+
+	// Order 6: Partially completed order with the remaining meal cancelled
+	order6 := &models.Order{
+		TableNo: 6,
+		Notes:   "Test notes",
+		OrderMeals: []models.OrderMeal{
+			{
+				MealID:    meals[0].ID,
+				Quantity:  2,
+				Completed: 1,
+				Cancelled: 1,
+			},
+		},
+	}
+	require.NoError(t, db.Create(order6).Error)
+	assert.NotZero(t, order6.CreatedAt)
+
+	orderedOrders = append(orderedOrders, order6)
 	slices.Reverse(orderedOrders)
 	// Test cases
 	testCases := []struct {
@@ -429,7 +586,7 @@ func TestOrderRepository_GetOrders(t *testing.T) {
 			params: repositories.OrderQueryParams{
 				PageSize: 2, // Limit to 2 orders
 			},
-			expectedOrders: []*models.Order{order5, order4},
+			expectedOrders: []*models.Order{order6, order5},
 		},
 		{
 			name: "Get orders older than order3",
@@ -522,6 +679,69 @@ func createReview(t *testing.T, db *gorm.DB, orderID uint, rating int, comment s
 	return review
 }
 
+func TestOrderRepository_ConsumeIngredients(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewOrderRepository(db)
+
+	eggs := &models.Ingredient{Name: "Eggs", Unit: "pcs", Stock: decimal.NewFromInt(10), LowStockThreshold: decimal.NewFromInt(4)}
+	milk := &models.Ingredient{Name: "Milk", Unit: "l", Stock: decimal.RequireFromString("2.5")}
+	require.NoError(t, db.Create(eggs).Error)
+	require.NoError(t, db.Create(milk).Error)
+
+	ingredients, err := repo.ConsumeIngredients(models.IngredientUsage{
+		eggs.ID: decimal.NewFromInt(6),
+		milk.ID: decimal.RequireFromString("0.4"),
+	})
+	require.NoError(t, err)
+	require.Len(t, ingredients, 2)
+	assert.True(t, decimal.NewFromInt(4).Equal(ingredients[0].Stock))
+	assert.True(t, ingredients[0].IsLowStock())
+	assert.True(t, decimal.RequireFromString("2.1").Equal(ingredients[1].Stock))
+
+	err = repo.WithTransaction(func(tx repositories.OrderRepository) error {
+		_, err := tx.ConsumeIngredients(models.IngredientUsage{
+			milk.ID: decimal.NewFromInt(1),
+			eggs.ID: decimal.NewFromInt(5),
+		})
+		return err
+	})
+	assert.True(t, apperrors.IsValidationErr(err))
+
+	var foundMilk models.Ingredient
+	require.NoError(t, db.First(&foundMilk, milk.ID).Error)
+	assert.True(t, decimal.RequireFromString("2.1").Equal(foundMilk.Stock), "stock must not change if an ingredient runs out")
+
+	_, err = repo.ConsumeIngredients(models.IngredientUsage{99: decimal.NewFromInt(1)})
+	assert.True(t, apperrors.IsNotFoundErr(err))
+}
+
+func TestOrderRepository_ReturnIngredients(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewOrderRepository(db)
+
+	eggs := &models.Ingredient{Name: "Eggs", Unit: "pcs", Stock: decimal.NewFromInt(4)}
+	require.NoError(t, db.Create(eggs).Error)
+
+	meal := getTestMeal()
+	meal.Recipe = []models.RecipeItem{{IngredientID: eggs.ID, Quantity: decimal.NewFromInt(3)}}
+	require.NoError(t, db.Create(meal).Error)
+	require.NoError(t, db.Delete(meal).Error)
+
+	recipe, err := repo.GetRecipe(meal.ID)
+	require.NoError(t, err)
+	require.Len(t, recipe, 1, "recipes of deleted meals are still found")
+
+	usage := models.IngredientUsage{}
+	usage.Add(recipe, 2)
+	require.NoError(t, repo.ReturnIngredients(usage))
+
+	var foundEggs models.Ingredient
+	require.NoError(t, db.First(&foundEggs, eggs.ID).Error)
+	assert.True(t, decimal.NewFromInt(10).Equal(foundEggs.Stock))
+}
+
 func TestOrderRepository_WithTransaction(t *testing.T) {
 	// Setup test database
 	db := testinghelpers.NewTestDB(t)
@@ -616,3 +836,27 @@ func TestOrderRepository_WithTransaction(t *testing.T) {
 		assert.Equal(t, int64(1), count, "Transaction should have rolled back, no order meal should exist")
 	})
 }
+
+func TestOrderRepository_GetOrOpenTableSession(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewOrderRepository(db)
+
+	// The session another order opened concurrently
+	existing := &models.TableSession{TableNo: 3, OpenedAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, db.Create(existing).Error)
+
+	session, err := repo.GetOrOpenTableSession(3, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, session.ID, "the open session of the table is returned")
+
+	var openSessions int64
+	require.NoError(t, db.Model(&models.TableSession{}).Where("table_no = ? AND closed_at IS NULL", 3).
+		Count(&openSessions).Error)
+	assert.Equal(t, int64(1), openSessions, "no second session is opened")
+
+	session, err = repo.GetOrOpenTableSession(4, time.Now())
+	require.NoError(t, err)
+	assert.NotZero(t, session.ID)
+	assert.NotEqual(t, existing.ID, session.ID, "tables without an open session get a new one")
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// PaymentRepository provides an interface for operations on Payment entities and supports transactional operations.
+// WithTransaction executes a function within a database transaction and rolls back if an error occurs.
+// GetOrderForUpdate retrieves an order and locks it until the transaction ends, so its balance cannot change meanwhile.
+// GetByOrderID retrieves all payments of an order, oldest first.
+// Create adds a new Payment record to the database.
+// MarkOrderPaid closes an order which is not paid yet as paid at the given time.
+// CloseSettledSession closes the table session if all of its orders are paid or cancelled.
+// CreateTip records a tip received for an order.
+type PaymentRepository interface {
+	WithTransaction(fn func(txRepo PaymentRepository) error) error
+	GetOrderForUpdate(orderID uint) (*models.Order, error)
+	GetByOrderID(orderID uint) ([]*models.Payment, error)
+	Create(payment *models.Payment) error
+	MarkOrderPaid(orderID uint, paidAt time.Time) error
+	CloseSettledSession(sessionID uint, closedAt time.Time) error
+	CreateTip(tip *models.Tip) error
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepositoryImpl{db: db}
+}
+
+type paymentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *paymentRepositoryImpl) WithTransaction(fn func(txRepo PaymentRepository) error) error {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to start a transaction", tx.Error)
+	}
+	defer tx.Rollback()
+
+	txRepo := &paymentRepositoryImpl{db: tx}
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to commit transaction", err)
+	}
+	return nil
+}
+
+func (r *paymentRepositoryImpl) GetOrderForUpdate(orderID uint) (*models.Order, error) {
+	return getOrder(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), orderID)
+}
+
+func (r *paymentRepositoryImpl) GetByOrderID(orderID uint) ([]*models.Payment, error) {
+	var payments []*models.Payment
+
+	err := r.db.Where("order_id = ?", orderID).Order("created_at ASC").Find(&payments).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get payments of order %d", orderID), err)
+	}
+
+	return payments, nil
+}
+
+func (r *paymentRepositoryImpl) Create(payment *models.Payment) error {
+	if err := r.db.Create(payment).Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to create payment", err)
+	}
+	return nil
+}
+
+func (r *paymentRepositoryImpl) MarkOrderPaid(orderID uint, paidAt time.Time) error {
+	res := r.db.Model(&models.Order{}).Where("id = ? AND paid_at IS NULL", orderID).
+		Update("paid_at", paidAt)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to mark order %d as paid", orderID), res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Order with id %d not found or already paid", orderID), nil)
+	}
+
+	return nil
+}
+
+func (r *paymentRepositoryImpl) CloseSettledSession(sessionID uint, closedAt time.Time) error {
+	return closeSettledSession(r.db, sessionID, closedAt)
+}
+
+func (r *paymentRepositoryImpl) CreateTip(tip *models.Tip) error {
+	if err := r.db.Create(tip).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to record tip for order %d", tip.OrderID), err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentRepository(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewPaymentRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, db.Create(meal).Error)
+
+	order := &models.Order{
+		TableNo:    3,
+		OrderMeals: []models.OrderMeal{{MealID: meal.ID, Quantity: 2}},
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	payments, err := repo.GetByOrderID(order.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, payments)
+
+	invalid := &models.Payment{OrderID: order.ID, Method: "bitcoin", Amount: decimal.NewFromInt(5)}
+	assert.Error(t, repo.Create(invalid))
+
+	err = repo.WithTransaction(func(tx repositories.PaymentRepository) error {
+		lockedOrder, err := tx.GetOrderForUpdate(order.ID)
+		if err != nil {
+			return err
+		}
+		assert.Len(t, lockedOrder.OrderMeals, 1, "the order is locked along with its meals")
+
+		for _, method := range []models.PaymentMethod{models.CashPayment, models.CardPayment} {
+			payment := &models.Payment{
+				OrderID:       order.ID,
+				Method:        method,
+				Amount:        decimal.RequireFromString("9.99"),
+				Payer:         "Guest",
+				TransactionID: "tx",
+				RecordedBy:    "waiter",
+			}
+			if err := tx.Create(payment); err != nil {
+				return err
+			}
+		}
+		return tx.MarkOrderPaid(order.ID, time.Now())
+	})
+	require.NoError(t, err)
+
+	payments, err = repo.GetByOrderID(order.ID)
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+	assert.Equal(t, models.CashPayment, payments[0].Method)
+	assert.True(t, decimal.RequireFromString("9.99").Equal(payments[1].Amount))
+
+	var foundOrder models.Order
+	require.NoError(t, db.First(&foundOrder, order.ID).Error)
+	assert.NotNil(t, foundOrder.PaidAt)
+
+	assert.True(t, apperrors.IsNotFoundErr(repo.MarkOrderPaid(order.ID, time.Now())))
+
+	_, err = repo.GetOrderForUpdate(order.ID + 1)
+	assert.True(t, apperrors.IsNotFoundErr(err))
+}
@@ -0,0 +1,215 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+)
+
+// PromotionRepository provides an interface for CRUD operations on Promotion entities
+// and supports transactional operations.
+// WithTransaction executes a function within a database transaction and rolls back if an error occurs.
+// GetAll retrieves all promotions ordered by their ID, optionally only the active ones.
+// GetByID retrieves a specific promotion by its ID.
+// GetByName retrieves a specific promotion by its name.
+// GetByCode retrieves a specific promotion by its promo code.
+// Create adds a new promotion to the database along with its combo meals and windows.
+// Update updates the settings of an existing promotion, its usage count is kept.
+// Delete removes a promotion along with its combo meals and windows, discounts it granted keep their name.
+// ReplaceComboMeals replaces all combo meals of a promotion.
+// ReplaceWindows replaces all windows of a promotion.
+// Promotions are retrieved with their combo meals and windows.
+type PromotionRepository interface {
+	WithTransaction(fn func(txRepo PromotionRepository) error) error
+	GetAll(onlyActive bool) ([]*models.Promotion, error)
+	GetByID(ID uint) (*models.Promotion, error)
+	GetByName(name string) (*models.Promotion, error)
+	GetByCode(code string) (*models.Promotion, error)
+	Create(promotion *models.Promotion) error
+	Update(promotion *models.Promotion) error
+	Delete(ID uint) error
+	ReplaceComboMeals(promotionID uint, comboMeals []models.PromotionComboMeal) error
+	ReplaceWindows(promotionID uint, windows []models.AvailabilityWindow) error
+}
+
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepositoryImpl{db: db}
+}
+
+type promotionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *promotionRepositoryImpl) WithTransaction(fn func(txRepo PromotionRepository) error) error {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to start a transaction", tx.Error)
+	}
+	defer tx.Rollback()
+
+	txRepo := &promotionRepositoryImpl{db: tx}
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to commit transaction", err)
+	}
+	return nil
+}
+
+// preloaded returns a query which loads promotions with their combo meals and windows.
+func (r *promotionRepositoryImpl) preloaded() *gorm.DB {
+	return r.db.
+		Preload("ComboMeals", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("Windows")
+}
+
+func (r *promotionRepositoryImpl) GetAll(onlyActive bool) ([]*models.Promotion, error) {
+	var promotions []*models.Promotion
+
+	query := r.preloaded().Order("id ASC")
+	if onlyActive {
+		query = query.Where("active = ?", true)
+	}
+
+	if err := query.Find(&promotions).Error; err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get all promotions", err)
+	}
+
+	return promotions, nil
+}
+
+func (r *promotionRepositoryImpl) GetByID(ID uint) (*models.Promotion, error) {
+	return r.getWhere(fmt.Sprintf("Promotion with ID %d", ID), "id = ?", ID)
+}
+
+func (r *promotionRepositoryImpl) GetByName(name string) (*models.Promotion, error) {
+	return r.getWhere(fmt.Sprintf("Promotion %s", name), "name = ?", name)
+}
+
+func (r *promotionRepositoryImpl) GetByCode(code string) (*models.Promotion, error) {
+	return r.getWhere(fmt.Sprintf("Promo code %s", code), "code = ?", code)
+}
+
+// getWhere retrieves the promotion matching the condition, description names the promotion in errors.
+func (r *promotionRepositoryImpl) getWhere(description string, query string, args ...any) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := r.preloaded().Where(query, args...).First(&promotion).Error
+
+	if err == nil {
+		return &promotion, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("%s not found", description), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get %s", description), err)
+}
+
+func (r *promotionRepositoryImpl) Create(promotion *models.Promotion) error {
+	if err := r.db.Omit("Category", "ComboMeals.Meal").Create(promotion).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create promotion %s", promotion.Name), err)
+	}
+	return nil
+}
+
+func (r *promotionRepositoryImpl) Update(promotion *models.Promotion) error {
+	res := r.db.Model(promotion).
+		Select("Name", "Kind", "Active", "Priority", "CategoryID", "Percent", "Amount",
+			"BuyQuantity", "FreeQuantity", "Code", "UsageLimit").
+		Updates(promotion)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update promotion %d", promotion.ID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Promotion with ID %d not found", promotion.ID), nil)
+	}
+
+	return nil
+}
+
+func (r *promotionRepositoryImpl) Delete(ID uint) error {
+	res := r.db.Delete(&models.Promotion{}, ID)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to delete promotion %d", ID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Promotion with ID %d not found", ID), nil)
+	}
+
+	return nil
+}
+
+func (r *promotionRepositoryImpl) ReplaceComboMeals(promotionID uint, comboMeals []models.PromotionComboMeal) error {
+	for i := range comboMeals {
+		comboMeals[i].ID = 0
+		comboMeals[i].PromotionID = promotionID
+	}
+
+	if err := r.db.Where("promotion_id = ?", promotionID).Delete(&models.PromotionComboMeal{}).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to replace combo meals of promotion %d", promotionID), err)
+	}
+
+	if len(comboMeals) == 0 {
+		return nil
+	}
+
+	if err := r.db.Omit("Meal").Create(&comboMeals).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to replace combo meals of promotion %d", promotionID), err)
+	}
+
+	return nil
+}
+
+func (r *promotionRepositoryImpl) ReplaceWindows(promotionID uint, windows []models.AvailabilityWindow) error {
+	for i := range windows {
+		windows[i].PromotionID = &promotionID
+		windows[i].MealID = nil
+		windows[i].CategoryID = nil
+		windows[i].MenuID = nil
+	}
+
+	if err := replaceAvailabilityWindows(r.db, "promotion_id", promotionID, windows); err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to replace windows of promotion %d", promotionID), err)
+	}
+
+	return nil
+}
+
+// redeemPromotion counts an order towards the usage limit of the promotion's promo code.
+// It fails with a validation error if the promo code is used up.
+func redeemPromotion(db *gorm.DB, promotionID uint) error {
+	res := db.Model(&models.Promotion{}).
+		Where("id = ? AND (usage_limit IS NULL OR usage_count < usage_limit)", promotionID).
+		Update("usage_count", gorm.Expr("usage_count + 1"))
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to redeem promotion %d", promotionID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewValidationErr("Promo code has been used up", nil)
+	}
+
+	return nil
+}
+
+// releasePromoCode stops counting an order towards the usage limit of the promo code, undoing redeemPromotion.
+// Promo codes which no longer exist are skipped.
+func releasePromoCode(db *gorm.DB, code string) error {
+	err := db.Model(&models.Promotion{}).Where("code = ? AND usage_count > 0", code).
+		Update("usage_count", gorm.Expr("usage_count - 1")).Error
+	if err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to release promo code %s", code), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromotionRepository_CRUD(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewPromotionRepository(db)
+	mealRepo := repositories.NewMealRepository(db)
+
+	_, err := repo.GetByID(1)
+	assert.True(t, apperrors.IsNotFoundErr(err))
+
+	burger := getTestMeal()
+	require.NoError(t, mealRepo.Create(burger))
+	lemonade := getTestMeal()
+	lemonade.Category = nil
+	lemonade.Name = "Lemonade"
+	require.NoError(t, mealRepo.Create(lemonade))
+
+	categoryID := burger.CategoryID
+	happyHour := &models.Promotion{Name: "Happy hour", Kind: models.PercentageOffPromotion, Active: true,
+		CategoryID: &categoryID, Percent: decimal.NewFromInt(50),
+		Windows: []models.AvailabilityWindow{models.NewAvailabilityWindow([]time.Weekday{time.Friday}, 17*60, 19*60)}}
+	require.NoError(t, repo.Create(happyHour))
+
+	code := "SPRING"
+	limit := uint(10)
+	combo := &models.Promotion{Name: "Burger menu", Kind: models.ComboPromotion, Active: false,
+		Amount: decimal.NewFromInt(14), Code: &code, UsageLimit: &limit,
+		ComboMeals: []models.PromotionComboMeal{{MealID: burger.ID}, {MealID: lemonade.ID}}}
+	require.NoError(t, repo.Create(combo))
+	assert.Error(t, repo.Create(&models.Promotion{Name: "Happy hour", Kind: models.FixedOffPromotion}),
+		"promotion names are unique")
+
+	promotions, err := repo.GetAll(false)
+	require.NoError(t, err)
+	require.Len(t, promotions, 2)
+	assert.Equal(t, "Happy hour", promotions[0].Name)
+	require.Len(t, promotions[0].Windows, 1)
+	assert.Equal(t, happyHour.ID, *promotions[0].Windows[0].PromotionID)
+	require.Len(t, promotions[1].ComboMeals, 2)
+
+	promotions, err = repo.GetAll(true)
+	require.NoError(t, err)
+	require.Len(t, promotions, 1, "inactive promotions are left out")
+
+	found, err := repo.GetByCode("SPRING")
+	require.NoError(t, err)
+	assert.Equal(t, combo.ID, found.ID)
+	assert.Equal(t, limit, *found.UsageLimit)
+
+	combo.Name = "Burger and lemonade"
+	combo.Code = nil
+	combo.UsageLimit = nil
+	require.NoError(t, repo.Update(combo))
+	require.NoError(t, repo.ReplaceComboMeals(combo.ID, []models.PromotionComboMeal{{MealID: lemonade.ID}, {MealID: burger.ID}}))
+
+	found, err = repo.GetByName("Burger and lemonade")
+	require.NoError(t, err)
+	assert.Nil(t, found.Code)
+	assert.Nil(t, found.UsageLimit)
+	require.Len(t, found.ComboMeals, 2)
+	assert.Equal(t, lemonade.ID, found.ComboMeals[0].MealID)
+
+	_, err = repo.GetByCode("SPRING")
+	assert.True(t, apperrors.IsNotFoundErr(err))
+	assert.True(t, apperrors.IsNotFoundErr(repo.Update(&models.Promotion{ID: 99, Name: "Brunch", Kind: models.FixedOffPromotion})))
+
+	require.NoError(t, repo.ReplaceWindows(happyHour.ID, nil))
+	found, err = repo.GetByID(happyHour.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.Windows)
+
+	require.NoError(t, repo.Delete(combo.ID))
+	assert.True(t, apperrors.IsNotFoundErr(repo.Delete(combo.ID)))
+
+	var remaining int64
+	require.NoError(t, db.Model(&models.PromotionComboMeal{}).Count(&remaining).Error)
+	assert.Zero(t, remaining, "combo meals are deleted along with their promotion")
+}
+
+func TestPromotionRepository_Discounts(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewPromotionRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, db.Create(&meal).Error)
+
+	code := "WELCOME"
+	limit := uint(1)
+	welcome := &models.Promotion{Name: "Welcome", Kind: models.FixedOffPromotion, Active: true,
+		Amount: decimal.NewFromInt(2), Code: &code, UsageLimit: &limit}
+	require.NoError(t, repo.Create(welcome))
+
+	order := &models.Order{TableNo: 5, PromoCode: code, OrderMeals: []models.OrderMeal{
+		{MealID: meal.ID, Quantity: 3, UnitPrice: decimal.NewFromInt(10)},
+	}}
+	require.NoError(t, db.Create(order).Error)
+
+	require.NoError(t, orderRepo.CreateDiscounts([]models.OrderDiscount{
+		{OrderID: order.ID, OrderMealID: order.OrderMeals[0].ID, PromotionID: &welcome.ID, Name: "Welcome",
+			UnitAmount: decimal.NewFromInt(2), Quantity: 3},
+	}))
+	require.NoError(t, orderRepo.CreateDiscounts(nil))
+
+	require.NoError(t, orderRepo.RedeemPromotion(welcome.ID))
+	assert.True(t, apperrors.IsValidationErr(orderRepo.RedeemPromotion(welcome.ID)), "the promo code is used up")
+
+	found, err := repo.GetByID(welcome.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), found.UsageCount)
+	assert.True(t, found.UsedUp())
+
+	require.NoError(t, orderRepo.ReleasePromoCode(code))
+	require.NoError(t, orderRepo.ReleasePromoCode(code), "usage counts do not drop below zero")
+	found, err = repo.GetByID(welcome.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), found.UsageCount)
+	require.NoError(t, orderRepo.RedeemPromotion(welcome.ID), "released promo codes can be redeemed again")
+
+	foundOrder, err := orderRepo.GetByID(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "WELCOME", foundOrder.PromoCode)
+	require.Len(t, foundOrder.Discounts, 1)
+	assert.True(t, decimal.NewFromInt(6).Equal(foundOrder.Discounts[0].Total()))
+
+	require.NoError(t, repo.Delete(welcome.ID))
+
+	foundOrder, err = orderRepo.GetByID(order.ID)
+	require.NoError(t, err)
+	require.Len(t, foundOrder.Discounts, 1, "discounts are kept when their promotion is deleted")
+	assert.Nil(t, foundOrder.Discounts[0].PromotionID)
+	assert.Equal(t, "Welcome", foundOrder.Discounts[0].Name)
+}
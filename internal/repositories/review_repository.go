@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ReviewQueryParams defines parameters for querying reviews in the data store.
+// Reviews in any of the Statuses are returned, all reviews if there are none.
+type ReviewQueryParams struct {
+	Statuses  []models.ReviewStatus
+	OlderThan time.Time
+	PageSize  uint
+}
+
+// ReviewRepository provides an interface for reading and moderating reviews and supports transactional operations.
+// Reviews are created along with their orders, see OrderRepository.CreateReview.
+// WithTransaction executes a function within a database transaction and rolls back if an error occurs.
+// GetReviews retrieves the newest reviews with their replies and meal ratings based on the specified query parameters.
+// GetByID retrieves a specific review with its replies and meal ratings.
+// GetMealNames retrieves the names of the billable meals of the given orders, keyed by order id.
+// UpdateStatus updates the status of an existing review along with who moderated it and when.
+// UpdatePhotos updates the photo URLs, public IDs and variants of an existing review.
+// CreateReply adds a new reply to a review.
+// GetMealRating retrieves the average of the ratings the meal got in reviews which are not hidden.
+type ReviewRepository interface {
+	WithTransaction(fn func(txRepo ReviewRepository) error) error
+	GetReviews(params ReviewQueryParams) ([]*models.Review, error)
+	GetByID(reviewID uint) (*models.Review, error)
+	GetMealNames(orderIDs []uint) (map[uint][]string, error)
+	UpdateStatus(review *models.Review) error
+	UpdatePhotos(review *models.Review) error
+	CreateReply(reply *models.ReviewReply) error
+	GetMealRating(mealID uint) (*models.MealRating, error)
+}
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepositoryImpl{db: db}
+}
+
+type reviewRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *reviewRepositoryImpl) WithTransaction(fn func(txRepo ReviewRepository) error) error {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to start a transaction", tx.Error)
+	}
+	defer tx.Rollback()
+
+	txRepo := &reviewRepositoryImpl{db: tx}
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to commit transaction", err)
+	}
+	return nil
+}
+
+func (r *reviewRepositoryImpl) GetReviews(params ReviewQueryParams) ([]*models.Review, error) {
+	reviews := []*models.Review{}
+
+	query := r.db.Model(&models.Review{})
+
+	if len(params.Statuses) > 0 {
+		query = query.Where("status IN ?", params.Statuses)
+	}
+
+	if !params.OlderThan.IsZero() {
+		query = query.Where("created_at < ?", params.OlderThan)
+	}
+
+	if params.PageSize > 0 {
+		query = query.Limit(int(params.PageSize))
+	}
+
+	err := query.Order("created_at DESC, id DESC").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("MealRatings", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_meal_id ASC")
+		}).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get reviews with params %+v", params), err)
+	}
+
+	return reviews, nil
+}
+
+func (r *reviewRepositoryImpl) GetByID(reviewID uint) (*models.Review, error) {
+	var review models.Review
+
+	err := r.db.Where("id = ?", reviewID).
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("MealRatings", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_meal_id ASC")
+		}).
+		First(&review).Error
+
+	if err == nil {
+		return &review, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Review with id %d not found", reviewID), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get review with id %d", reviewID), err)
+}
+
+func (r *reviewRepositoryImpl) GetMealNames(orderIDs []uint) (map[uint][]string, error) {
+	mealNames := make(map[uint][]string)
+	if len(orderIDs) == 0 {
+		return mealNames, nil
+	}
+
+	// Order meals created before meal names were snapshotted fall back to the current name of the meal.
+	var rows []struct {
+		OrderID  uint
+		MealName string
+	}
+	err := r.db.Table("order_meals AS om").
+		Joins("JOIN meals AS m ON m.id = om.meal_id").
+		Where("om.order_id IN ? AND om.quantity > om.cancelled + om.voided", orderIDs).
+		Select("om.order_id AS order_id, COALESCE(NULLIF(om.meal_name, ''), m.name) AS meal_name").
+		Order("om.id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get the meal names of reviewed orders", err)
+	}
+
+	for _, row := range rows {
+		mealNames[row.OrderID] = append(mealNames[row.OrderID], row.MealName)
+	}
+
+	return mealNames, nil
+}
+
+func (r *reviewRepositoryImpl) UpdateStatus(review *models.Review) error {
+	res := r.db.Model(review).Select("Status", "ModeratedBy", "ModeratedAt").Updates(review)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to update review status", res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Review with id %d not found", review.ID), nil)
+	}
+
+	return nil
+}
+
+func (r *reviewRepositoryImpl) UpdatePhotos(review *models.Review) error {
+	res := r.db.Model(review).Select("PhotoURLs", "PhotoPublicIDs", "PhotoVariants").Updates(review)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to update review photos", res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Review with id %d not found", review.ID), nil)
+	}
+
+	return nil
+}
+
+func (r *reviewRepositoryImpl) CreateReply(reply *models.ReviewReply) error {
+	if err := r.db.Create(reply).Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to create review reply", err)
+	}
+
+	return nil
+}
+
+func (r *reviewRepositoryImpl) GetMealRating(mealID uint) (*models.MealRating, error) {
+	rating := models.MealRating{MealID: mealID}
+
+	err := r.db.Table("review_meal_ratings AS rmr").
+		Joins("JOIN reviews AS r ON r.id = rmr.review_id").
+		Where("rmr.meal_id = ? AND r.status <> ?", mealID, models.HiddenReview).
+		Select("COUNT(*) AS reviews, COALESCE(AVG(rmr.rating), 0) AS average").
+		Scan(&rating).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get the rating of meal with id %d", mealID), err)
+	}
+
+	return &rating, nil
+}
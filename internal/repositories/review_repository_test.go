@@ -0,0 +1,129 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewRepository(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewReviewRepository(db)
+	mealRepo := repositories.NewMealRepository(db)
+
+	burger := getTestMeal()
+	require.NoError(t, db.Create(burger).Error)
+	lemonade := getTestMeal()
+	lemonade.Name = "Lemonade"
+	lemonade.CategoryID = 0
+	lemonade.Category = &models.Category{Name: "Drinks", Active: true}
+	require.NoError(t, db.Create(lemonade).Error)
+
+	day := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.Local)
+	lunch := createAnalyticsOrder(t, db, day.Add(12*time.Hour),
+		models.OrderMeal{MealID: burger.ID, MealName: "Cheeseburger", Quantity: 2},
+		models.OrderMeal{MealID: lemonade.ID, Quantity: 1, Voided: 1})
+	dinner := createAnalyticsOrder(t, db, day.Add(19*time.Hour),
+		models.OrderMeal{MealID: burger.ID, Quantity: 1})
+	late := createAnalyticsOrder(t, db, day.Add(22*time.Hour),
+		models.OrderMeal{MealID: lemonade.ID, Quantity: 1})
+
+	lunchReview := &models.Review{OrderID: lunch.ID, Rating: 5, CreatedAt: day.Add(13 * time.Hour),
+		PhotoURLs: pq.StringArray{"a.jpg", "b.jpg"}, PhotoPublicIDs: pq.StringArray{"a", "b"},
+		MealRatings: []models.ReviewMealRating{{OrderMealID: lunch.OrderMeals[0].ID, MealID: burger.ID, Rating: 4}}}
+	dinnerReview := &models.Review{OrderID: dinner.ID, Rating: 2, CreatedAt: day.Add(20 * time.Hour),
+		MealRatings: []models.ReviewMealRating{{OrderMealID: dinner.OrderMeals[0].ID, MealID: burger.ID, Rating: 1}}}
+	lateReview := &models.Review{OrderID: late.ID, Rating: 4, CreatedAt: day.Add(23 * time.Hour),
+		Status: models.HiddenReview}
+	require.NoError(t, db.Create(&[]*models.Review{lunchReview, dinnerReview, lateReview}).Error)
+
+	reviews, err := repo.GetReviews(repositories.ReviewQueryParams{
+		Statuses: []models.ReviewStatus{models.PublishedReview, models.FlaggedReview},
+	})
+	require.NoError(t, err)
+	require.Len(t, reviews, 2, "hidden reviews are filtered out")
+	assert.Equal(t, dinnerReview.ID, reviews[0].ID, "newest reviews come first")
+
+	reviews, err = repo.GetReviews(repositories.ReviewQueryParams{OlderThan: day.Add(20 * time.Hour), PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.Equal(t, lunchReview.ID, reviews[0].ID)
+	assert.Equal(t, models.PublishedReview, reviews[0].Status)
+	require.Len(t, reviews[0].MealRatings, 1)
+	assert.Equal(t, 4, reviews[0].MealRatings[0].Rating)
+
+	meal, err := mealRepo.GetByID(burger.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), meal.RatingCount)
+	assert.InDelta(t, 2.5, meal.AverageRating, 0.001)
+
+	mealNames, err := repo.GetMealNames([]uint{lunch.ID, dinner.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Cheeseburger"}, mealNames[lunch.ID], "voided meals are left out")
+	assert.Equal(t, []string{burger.Name}, mealNames[dinner.ID], "meals without a snapshotted name use the meal name")
+
+	now := time.Now()
+	dinnerReview.Status = models.FlaggedReview
+	dinnerReview.ModeratedBy = "admin"
+	dinnerReview.ModeratedAt = &now
+	require.NoError(t, repo.UpdateStatus(dinnerReview))
+
+	require.NoError(t, repo.CreateReply(&models.ReviewReply{ReviewID: dinnerReview.ID, Message: "Sorry to hear that",
+		RepliedBy: "waiter", CreatedAt: now}))
+	assert.Error(t, repo.CreateReply(&models.ReviewReply{ReviewID: dinnerReview.ID, RepliedBy: "waiter", CreatedAt: now}))
+
+	found, err := repo.GetByID(dinnerReview.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.FlaggedReview, found.Status)
+	assert.Equal(t, "admin", found.ModeratedBy)
+	require.Len(t, found.Replies, 1)
+	assert.Equal(t, "Sorry to hear that", found.Replies[0].Message)
+
+	_, err = lunchReview.RemovePhoto(0)
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdatePhotos(lunchReview))
+	found, err = repo.GetByID(lunchReview.ID)
+	require.NoError(t, err)
+	assert.Equal(t, pq.StringArray{"b.jpg"}, found.PhotoURLs)
+	assert.Equal(t, pq.StringArray{"b"}, found.PhotoPublicIDs)
+
+	rating, err := repo.GetMealRating(burger.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), rating.Reviews)
+	assert.InDelta(t, 2.5, rating.Average, 0.001, "the meal is rated by its own ratings, not those of the orders")
+
+	meal, err = mealRepo.GetByID(burger.ID)
+	require.NoError(t, err)
+	assert.Equal(t, meal.RatingCount, rating.Reviews, "the rating of the meal matches the rating it is listed with")
+	assert.InDelta(t, meal.AverageRating, rating.Average, 0.001)
+
+	rating, err = repo.GetMealRating(lemonade.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), rating.Reviews, "reviews which do not rate the meal do not count")
+	assert.Zero(t, rating.Average)
+
+	lunchReview.Status = models.HiddenReview
+	require.NoError(t, repo.UpdateStatus(lunchReview))
+	meals, err := mealRepo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, meals, 2)
+	assert.Equal(t, uint(1), meals[0].RatingCount, "ratings in hidden reviews do not count")
+	assert.InDelta(t, 1, meals[0].AverageRating, 0.001)
+	assert.Zero(t, meals[1].RatingCount)
+	rating, err = repo.GetMealRating(burger.ID)
+	require.NoError(t, err)
+	assert.Equal(t, meals[0].RatingCount, rating.Reviews)
+	assert.InDelta(t, meals[0].AverageRating, rating.Average, 0.001)
+
+	_, err = repo.GetByID(999)
+	assert.True(t, apperrors.IsNotFoundErr(err))
+	assert.True(t, apperrors.IsNotFoundErr(repo.UpdateStatus(&models.Review{ID: 999, Status: models.HiddenReview})))
+}
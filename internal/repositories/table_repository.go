@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// TableRepository provides an interface for CRUD operations on Table and TableSession entities
+// and supports transactional operations.
+// WithTransaction executes a function within a database transaction and rolls back if an error occurs.
+// GetAll retrieves all tables ordered by their number.
+// GetByNumber retrieves a specific table by its number.
+// Create adds a new table to the database.
+// Update updates the seats, area and active flag of an existing table.
+// UpdateToken updates the token version and the revoked flag of an existing table.
+// GetOpenSessions retrieves all open table sessions with their orders, order meals and payments.
+// GetOpenSession retrieves the open session of a table with its orders.
+// CloseSession closes an open table session at the given time.
+type TableRepository interface {
+	WithTransaction(fn func(txRepo TableRepository) error) error
+	GetAll() ([]*models.Table, error)
+	GetByNumber(number int) (*models.Table, error)
+	Create(table *models.Table) error
+	Update(table *models.Table) error
+	UpdateToken(table *models.Table) error
+	GetOpenSessions() ([]*models.TableSession, error)
+	GetOpenSession(tableNo int) (*models.TableSession, error)
+	CloseSession(sessionID uint, closedAt time.Time) error
+}
+
+func NewTableRepository(db *gorm.DB) TableRepository {
+	return &tableRepositoryImpl{db: db}
+}
+
+type tableRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *tableRepositoryImpl) WithTransaction(fn func(txRepo TableRepository) error) error {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to start a transaction", tx.Error)
+	}
+	defer tx.Rollback()
+
+	txRepo := &tableRepositoryImpl{db: tx}
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to commit transaction", err)
+	}
+	return nil
+}
+
+func (r *tableRepositoryImpl) GetAll() ([]*models.Table, error) {
+	var tables []*models.Table
+
+	if err := r.db.Order("number ASC").Find(&tables).Error; err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get all tables", err)
+	}
+
+	return tables, nil
+}
+
+func (r *tableRepositoryImpl) GetByNumber(number int) (*models.Table, error) {
+	var table models.Table
+	err := r.db.Where("number = ?", number).First(&table).Error
+
+	if err == nil {
+		return &table, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Table %d not found", number), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get table %d", number), err)
+}
+
+func (r *tableRepositoryImpl) Create(table *models.Table) error {
+	if err := r.db.Create(table).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to create table %d", table.Number), err)
+	}
+	return nil
+}
+
+func (r *tableRepositoryImpl) Update(table *models.Table) error {
+	res := r.db.Model(table).Select("Seats", "Area", "Active").Updates(table)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update table %d", table.Number), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Table %d not found", table.Number), nil)
+	}
+
+	return nil
+}
+
+func (r *tableRepositoryImpl) UpdateToken(table *models.Table) error {
+	res := r.db.Model(table).Select("TokenVersion", "TokenRevoked").Updates(table)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to update token of table %d", table.Number), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Table %d not found", table.Number), nil)
+	}
+
+	return nil
+}
+
+func (r *tableRepositoryImpl) GetOpenSessions() ([]*models.TableSession, error) {
+	var sessions []*models.TableSession
+
+	err := r.db.Where("closed_at IS NULL").
+		Preload("Orders.OrderMeals").
+		Preload("Orders.Discounts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("Orders.Payments").
+		Order("table_no ASC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get open table sessions", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *tableRepositoryImpl) GetOpenSession(tableNo int) (*models.TableSession, error) {
+	var session models.TableSession
+
+	err := r.db.Where("table_no = ? AND closed_at IS NULL", tableNo).
+		Preload("Orders").
+		First(&session).Error
+
+	if err == nil {
+		return &session, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Table %d has no open session", tableNo), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get open session of table %d", tableNo), err)
+}
+
+func (r *tableRepositoryImpl) CloseSession(sessionID uint, closedAt time.Time) error {
+	res := r.db.Model(&models.TableSession{}).
+		Where("id = ? AND closed_at IS NULL", sessionID).
+		Update("closed_at", closedAt)
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to close table session %d", sessionID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Open table session %d not found", sessionID), nil)
+	}
+
+	return nil
+}
+
+// closeSettledSession closes the table session if all of its orders are paid or cancelled.
+// Sessions which still have open orders are left untouched.
+func closeSettledSession(db *gorm.DB, sessionID uint, closedAt time.Time) error {
+	openOrders := db.Model(&models.Order{}).Select("1").
+		Where("table_session_id = ? AND paid_at IS NULL AND cancelled_at IS NULL", sessionID)
+
+	err := db.Model(&models.TableSession{}).
+		Where("id = ? AND closed_at IS NULL AND NOT EXISTS (?)", sessionID, openOrders).
+		Update("closed_at", closedAt).Error
+	if err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to close table session %d", sessionID), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableRepository_CRUD(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewTableRepository(db)
+
+	_, err := repo.GetByNumber(1)
+	assert.True(t, apperrors.IsNotFoundErr(err))
+
+	require.NoError(t, repo.Create(&models.Table{Number: 2, Seats: 4, Area: "Terrace", Active: true}))
+	require.NoError(t, repo.Create(&models.Table{Number: 1, Seats: 2, Area: "Bar", Active: true}))
+	assert.Error(t, repo.Create(&models.Table{Number: 3, Seats: 0, Area: "Bar"}))
+
+	tables, err := repo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, tables, 2)
+	assert.Equal(t, 1, tables[0].Number)
+	assert.Equal(t, 2, tables[1].Number)
+
+	require.NoError(t, repo.Update(&models.Table{Number: 2, Seats: 6, Area: "Garden", Active: false}))
+	table, err := repo.GetByNumber(2)
+	require.NoError(t, err)
+	assert.Equal(t, uint(6), table.Seats)
+	assert.Equal(t, "Garden", table.Area)
+	assert.False(t, table.Active)
+
+	assert.True(t, apperrors.IsNotFoundErr(repo.Update(&models.Table{Number: 9, Seats: 1, Area: "Bar"})))
+
+	// Tokens start at the first version and are only changed by UpdateToken
+	assert.Equal(t, uint(1), table.TokenVersion)
+	table.RotateToken()
+	table.TokenRevoked = true
+	require.NoError(t, repo.UpdateToken(table))
+	require.NoError(t, repo.Update(&models.Table{Number: 2, Seats: 6, Area: "Garden", Active: true}))
+
+	table, err = repo.GetByNumber(2)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), table.TokenVersion)
+	assert.True(t, table.TokenRevoked)
+	assert.True(t, table.Active)
+}
+
+func TestTableRepository_Sessions(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	tableRepo := repositories.NewTableRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+	paymentRepo := repositories.NewPaymentRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, db.Create(meal).Error)
+	require.NoError(t, tableRepo.Create(&models.Table{Number: 5, Seats: 4, Area: "Bar", Active: true}))
+
+	session, err := orderRepo.GetOrOpenTableSession(5, time.Now())
+	require.NoError(t, err)
+	sameSession, err := orderRepo.GetOrOpenTableSession(5, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, sameSession.ID)
+
+	// Only one session per table can be open
+	assert.Error(t, db.Create(&models.TableSession{TableNo: 5, OpenedAt: time.Now()}).Error)
+
+	first := &models.Order{TableNo: 5, TableSessionID: &session.ID,
+		OrderMeals: []models.OrderMeal{{MealID: meal.ID, Quantity: 1}}}
+	second := &models.Order{TableNo: 5, TableSessionID: &session.ID,
+		OrderMeals: []models.OrderMeal{{MealID: meal.ID, Quantity: 2}}}
+	require.NoError(t, db.Create(first).Error)
+	require.NoError(t, db.Create(second).Error)
+
+	sessions, err := tableRepo.GetOpenSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Len(t, sessions[0].Orders, 2)
+	assert.Equal(t, uint(3), sessions[0].UnservedMeals())
+
+	// The session stays open while the second order is unpaid
+	require.NoError(t, paymentRepo.MarkOrderPaid(first.ID, time.Now()))
+	require.NoError(t, paymentRepo.CloseSettledSession(session.ID, time.Now()))
+	_, err = tableRepo.GetOpenSession(5)
+	require.NoError(t, err)
+
+	require.NoError(t, orderRepo.Cancel(second.ID, time.Now()))
+	require.NoError(t, orderRepo.CloseSettledSession(session.ID, time.Now()))
+	_, err = tableRepo.GetOpenSession(5)
+	assert.True(t, apperrors.IsNotFoundErr(err))
+
+	// A new session is opened for the next party
+	nextSession, err := orderRepo.GetOrOpenTableSession(5, time.Now())
+	require.NoError(t, err)
+	assert.NotEqual(t, session.ID, nextSession.ID)
+
+	require.NoError(t, tableRepo.CloseSession(nextSession.ID, time.Now()))
+	assert.True(t, apperrors.IsNotFoundErr(tableRepo.CloseSession(nextSession.ID, time.Now())))
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// TipRepository provides an interface for reading the tips received for orders.
+// GetReceived retrieves the tips received from the start of the time range until before its end, oldest first.
+// GetCompletions retrieves the transitions of the meals of the given orders to ready,
+// which record the staff members who completed them.
+type TipRepository interface {
+	GetReceived(from, to time.Time) ([]*models.Tip, error)
+	GetCompletions(orderIDs []uint) ([]models.OrderMealStatusChange, error)
+}
+
+func NewTipRepository(db *gorm.DB) TipRepository {
+	return &tipRepositoryImpl{db: db}
+}
+
+type tipRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *tipRepositoryImpl) GetReceived(from, to time.Time) ([]*models.Tip, error) {
+	var tips []*models.Tip
+
+	err := r.db.Where("received_at >= ? AND received_at < ?", from, to).
+		Order("received_at ASC, id ASC").
+		Find(&tips).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get received tips", err)
+	}
+
+	return tips, nil
+}
+
+func (r *tipRepositoryImpl) GetCompletions(orderIDs []uint) ([]models.OrderMealStatusChange, error) {
+	var completions []models.OrderMealStatusChange
+	if len(orderIDs) == 0 {
+		return completions, nil
+	}
+
+	err := r.db.Where("order_id IN ? AND to_status = ?", orderIDs, models.ReadyStatus).
+		Order("id ASC").
+		Find(&completions).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get completed order meals", err)
+	}
+
+	return completions, nil
+}
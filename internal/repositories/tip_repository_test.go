@@ -0,0 +1,73 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTipRepository(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewTipRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+	paymentRepo := repositories.NewPaymentRepository(db)
+
+	meal := getTestMeal()
+	require.NoError(t, db.Create(meal).Error)
+
+	order := &models.Order{TableNo: 2, OrderMeals: []models.OrderMeal{{MealID: meal.ID, Quantity: 2}}}
+	require.NoError(t, db.Create(order).Error)
+
+	require.NoError(t, order.SetTip(decimal.Zero, decimal.NewFromInt(10)))
+	require.NoError(t, orderRepo.UpdateTip(order))
+	found, err := orderRepo.GetByID(order.ID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(10).Equal(found.TipPercent))
+	assert.True(t, found.TipAmount.IsZero())
+
+	payment := &models.Payment{OrderID: order.ID, Method: models.CashPayment, Amount: decimal.NewFromInt(20),
+		Tip: decimal.NewFromInt(2), TransactionID: "tx"}
+	require.NoError(t, paymentRepo.Create(payment))
+
+	day := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.Local)
+	require.NoError(t, paymentRepo.CreateTip(&models.Tip{OrderID: order.ID, Amount: decimal.NewFromInt(3),
+		ReceivedAt: day.Add(20 * time.Hour)}))
+	require.NoError(t, paymentRepo.CreateTip(&models.Tip{OrderID: order.ID, PaymentID: &payment.ID,
+		Amount: decimal.NewFromInt(2), ReceivedAt: day.Add(12 * time.Hour)}))
+	require.NoError(t, paymentRepo.CreateTip(&models.Tip{OrderID: order.ID, Amount: decimal.NewFromInt(1),
+		ReceivedAt: day.AddDate(0, 0, 1)}))
+	assert.Error(t, paymentRepo.CreateTip(&models.Tip{OrderID: order.ID, Amount: decimal.Zero, ReceivedAt: day}))
+
+	tips, err := repo.GetReceived(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, tips, 2, "the end of the range is excluded")
+	assert.Equal(t, payment.ID, *tips[0].PaymentID)
+	assert.Nil(t, tips[1].PaymentID)
+
+	require.NoError(t, db.Create(&[]models.OrderMealStatusChange{
+		{OrderID: order.ID, OrderMealID: order.OrderMeals[0].ID, FromStatus: models.PendingStatus,
+			ToStatus: models.AcceptedStatus, Quantity: 2, ChangedBy: "cook", ChangedAt: day},
+		{OrderID: order.ID, OrderMealID: order.OrderMeals[0].ID, FromStatus: models.CookingStatus,
+			ToStatus: models.ReadyStatus, Quantity: 2, ChangedBy: "cook", ChangedAt: day},
+	}).Error)
+
+	completions, err := repo.GetCompletions([]uint{order.ID})
+	require.NoError(t, err)
+	require.Len(t, completions, 1)
+	assert.Equal(t, "cook", completions[0].ChangedBy)
+
+	completions, err = repo.GetCompletions(nil)
+	require.NoError(t, err)
+	assert.Empty(t, completions)
+
+	require.NoError(t, paymentRepo.MarkOrderPaid(order.ID, time.Now()))
+	assert.True(t, apperrors.IsNotFoundErr(orderRepo.UpdateTip(order)), "the tip of a paid order cannot be changed")
+}
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// UploadRepository provides an interface for managing upload sessions and the images uploaded into them
+// and supports transactional operations.
+// WithTransaction executes a function within a database transaction and rolls back if an error occurs.
+// CreateSession adds a new upload session to the data store.
+// GetSession retrieves a specific upload session with its uploads, oldest upload first.
+// CreateUpload adds an image uploaded into a session to the data store.
+// GetUploads retrieves the uploads with the given IDs from the sessions of the owner for the purpose
+// which have not expired at the given time.
+// DeleteUploads removes the uploads with the given IDs, failing if any of them was already removed.
+// GetExpiredSessions retrieves the sessions which expired at the given time with their uploads.
+// DeleteSession removes an upload session along with its uploads.
+type UploadRepository interface {
+	WithTransaction(fn func(txRepo UploadRepository) error) error
+	CreateSession(session *models.UploadSession) error
+	GetSession(sessionID string) (*models.UploadSession, error)
+	CreateUpload(upload *models.Upload) error
+	GetUploads(owner string, purpose models.UploadPurpose, uploadIDs []string, at time.Time) ([]*models.Upload, error)
+	DeleteUploads(uploadIDs []string) error
+	GetExpiredSessions(at time.Time) ([]*models.UploadSession, error)
+	DeleteSession(sessionID string) error
+}
+
+func NewUploadRepository(db *gorm.DB) UploadRepository {
+	return &uploadRepositoryImpl{db: db}
+}
+
+type uploadRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *uploadRepositoryImpl) WithTransaction(fn func(txRepo UploadRepository) error) error {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to start a transaction", tx.Error)
+	}
+	defer tx.Rollback()
+
+	txRepo := &uploadRepositoryImpl{db: tx}
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to commit transaction", err)
+	}
+	return nil
+}
+
+func (r *uploadRepositoryImpl) CreateSession(session *models.UploadSession) error {
+	if err := r.db.Create(session).Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to create upload session", err)
+	}
+
+	return nil
+}
+
+func (r *uploadRepositoryImpl) GetSession(sessionID string) (*models.UploadSession, error) {
+	var session models.UploadSession
+
+	err := r.db.Where("id = ?", sessionID).
+		Preload("Uploads", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		First(&session).Error
+
+	if err == nil {
+		return &session, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Upload session %s not found", sessionID), err)
+	}
+
+	return nil, apperrors.NewInternalServerErr(fmt.Sprintf("Failed to get upload session %s", sessionID), err)
+}
+
+func (r *uploadRepositoryImpl) CreateUpload(upload *models.Upload) error {
+	if err := r.db.Create(upload).Error; err != nil {
+		return apperrors.NewInternalServerErr("Failed to create upload", err)
+	}
+
+	return nil
+}
+
+func (r *uploadRepositoryImpl) GetUploads(owner string,
+	purpose models.UploadPurpose,
+	uploadIDs []string,
+	at time.Time) ([]*models.Upload, error) {
+	uploads := []*models.Upload{}
+	if len(uploadIDs) == 0 {
+		return uploads, nil
+	}
+
+	err := r.db.Joins("JOIN upload_sessions ON upload_sessions.id = uploads.upload_session_id").
+		Where("uploads.id IN ?", uploadIDs).
+		Where("upload_sessions.owner = ? AND upload_sessions.purpose = ? AND upload_sessions.expires_at > ?",
+			owner, purpose, at).
+		Find(&uploads).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get uploads", err)
+	}
+
+	return uploads, nil
+}
+
+func (r *uploadRepositoryImpl) DeleteUploads(uploadIDs []string) error {
+	if len(uploadIDs) == 0 {
+		return nil
+	}
+
+	res := r.db.Where("id IN ?", uploadIDs).Delete(&models.Upload{})
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr("Failed to delete uploads", res.Error)
+	}
+
+	if res.RowsAffected != int64(len(uploadIDs)) {
+		return apperrors.NewNotFoundErr("Uploads not found", nil)
+	}
+
+	return nil
+}
+
+func (r *uploadRepositoryImpl) GetExpiredSessions(at time.Time) ([]*models.UploadSession, error) {
+	sessions := []*models.UploadSession{}
+
+	err := r.db.Where("expires_at <= ?", at).
+		Preload("Uploads").
+		Order("expires_at ASC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to get expired upload sessions", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *uploadRepositoryImpl) DeleteSession(sessionID string) error {
+	// The uploads are deleted first, they reference the session
+	if err := r.db.Where("upload_session_id = ?", sessionID).Delete(&models.Upload{}).Error; err != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to delete the uploads of session %s", sessionID), err)
+	}
+
+	res := r.db.Where("id = ?", sessionID).Delete(&models.UploadSession{})
+	if res.Error != nil {
+		return apperrors.NewInternalServerErr(fmt.Sprintf("Failed to delete upload session %s", sessionID), res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return apperrors.NewNotFoundErr(fmt.Sprintf("Upload session %s not found", sessionID), nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	testinghelpers "github.com/Ruclo/MyMeals/internal/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createUploadSession(t *testing.T, repo repositories.UploadRepository, id string, purpose models.UploadPurpose,
+	owner string, expiresAt time.Time, uploadIDs ...string) *models.UploadSession {
+	session := &models.UploadSession{ID: id, Purpose: purpose, Owner: owner, ExpiresAt: expiresAt}
+	require.NoError(t, repo.CreateSession(session))
+
+	for _, uploadID := range uploadIDs {
+		upload := &models.Upload{
+			ID:              uploadID,
+			UploadSessionID: id,
+			ImageURL:        "/images/" + uploadID + ".jpg",
+			ImagePublicID:   uploadID,
+			ImageVariants: models.ImageVariants{
+				{Size: "thumbnail", URL: "/images/" + uploadID + ".webp", PublicID: uploadID + "-webp"},
+			},
+		}
+		require.NoError(t, repo.CreateUpload(upload))
+		session.Uploads = append(session.Uploads, *upload)
+	}
+
+	return session
+}
+
+func TestUploadRepository_GetSession(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewUploadRepository(db)
+
+	createUploadSession(t, repo, "session", models.ReviewUpload, "order:1", time.Now().Add(time.Hour), "first", "second")
+
+	session, err := repo.GetSession("session")
+	require.NoError(t, err)
+	assert.Equal(t, models.ReviewUpload, session.Purpose)
+	assert.Equal(t, "order:1", session.Owner)
+	require.Len(t, session.Uploads, 2)
+	assert.Equal(t, "/images/first.jpg", session.Uploads[0].ImageURL)
+	assert.Equal(t, []string{"first-webp"}, session.Uploads[0].ImageVariants.PublicIDs())
+
+	_, err = repo.GetSession("unknown")
+	assert.True(t, apperrors.IsNotFoundErr(err))
+}
+
+func TestUploadRepository_GetUploads(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewUploadRepository(db)
+
+	now := time.Now()
+	createUploadSession(t, repo, "review", models.ReviewUpload, "order:1", now.Add(time.Hour), "first", "second")
+	createUploadSession(t, repo, "other", models.ReviewUpload, "order:2", now.Add(time.Hour), "other")
+	createUploadSession(t, repo, "meal", models.MealUpload, "order:1", now.Add(time.Hour), "meal")
+	createUploadSession(t, repo, "expired", models.ReviewUpload, "order:1", now.Add(-time.Minute), "expired")
+
+	uploads, err := repo.GetUploads("order:1", models.ReviewUpload,
+		[]string{"first", "second", "other", "meal", "expired"}, now)
+	require.NoError(t, err)
+
+	uploadIDs := make([]string, len(uploads))
+	for i, upload := range uploads {
+		uploadIDs[i] = upload.ID
+	}
+	assert.ElementsMatch(t, []string{"first", "second"}, uploadIDs,
+		"uploads of other owners, purposes and expired sessions are not returned")
+}
+
+func TestUploadRepository_DeleteUploads(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewUploadRepository(db)
+
+	createUploadSession(t, repo, "session", models.ReviewUpload, "order:1", time.Now().Add(time.Hour), "first", "second")
+
+	require.NoError(t, repo.DeleteUploads([]string{"first"}))
+
+	err := repo.DeleteUploads([]string{"first", "second"})
+	assert.True(t, apperrors.IsNotFoundErr(err), "uploads which were already deleted cannot be claimed again")
+
+	session, err := repo.GetSession("session")
+	require.NoError(t, err)
+	assert.Len(t, session.Uploads, 0)
+}
+
+func TestUploadRepository_DeleteExpiredSessions(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewUploadRepository(db)
+
+	now := time.Now()
+	createUploadSession(t, repo, "expired", models.ReviewUpload, "order:1", now.Add(-time.Minute), "first")
+	createUploadSession(t, repo, "active", models.ReviewUpload, "order:1", now.Add(time.Hour), "second")
+
+	sessions, err := repo.GetExpiredSessions(now)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "expired", sessions[0].ID)
+	assert.Equal(t, []string{"first-webp"}, sessions[0].PublicIDs())
+
+	require.NoError(t, repo.DeleteSession("expired"))
+	assert.True(t, apperrors.IsNotFoundErr(repo.DeleteSession("expired")))
+
+	var uploads int64
+	require.NoError(t, db.Model(&models.Upload{}).Count(&uploads).Error)
+	assert.Equal(t, int64(1), uploads, "the uploads of the session are deleted along with it")
+}
+
+func TestUploadRepository_ClaimRolledBackWithTransaction(t *testing.T) {
+	db := testinghelpers.NewTestDB(t)
+	defer testinghelpers.CleanupTestDB(t, db)
+	repo := repositories.NewUploadRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+
+	createUploadSession(t, repo, "session", models.ReviewUpload, "order:1", time.Now().Add(time.Hour), "first")
+
+	err := orderRepo.WithTransaction(func(tx repositories.OrderRepository) error {
+		require.NoError(t, tx.Uploads().DeleteUploads([]string{"first"}))
+		return apperrors.NewInternalServerErr("Failed to create review", nil)
+	})
+	require.Error(t, err)
+
+	session, err := repo.GetSession("session")
+	require.NoError(t, err)
+	assert.Len(t, session.Uploads, 1, "uploads claimed in a failed transaction stay in their session")
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"time"
+)
+
+// DefaultTopMealsLimit is the number of top selling meals reported when no limit is given.
+const DefaultTopMealsLimit = 10
+
+// AnalyticsService defines operations for reporting sales and kitchen performance over a time range.
+type AnalyticsService interface {
+	RevenueByDay(from, to time.Time) ([]models.DayRevenue, error)
+	RevenueByHour(from, to time.Time) ([]models.HourRevenue, error)
+	RevenueByCategory(from, to time.Time) ([]models.CategoryRevenue, error)
+	TopMeals(from, to time.Time, limit int) ([]models.MealSales, error)
+	TicketSize(from, to time.Time) (*models.TicketSize, error)
+	PrepTimes(from, to time.Time) (*models.PrepTimeReport, error)
+	TableTurnover(from, to time.Time) ([]models.TableTurnover, error)
+}
+
+type analyticsService struct {
+	analyticsRepository repositories.AnalyticsRepository
+}
+
+func NewAnalyticsService(analyticsRepository repositories.AnalyticsRepository) AnalyticsService {
+	return &analyticsService{
+		analyticsRepository: analyticsRepository,
+	}
+}
+
+// RevenueByDay returns the revenue of every day of the time range orders were created on.
+func (as *analyticsService) RevenueByDay(from, to time.Time) ([]models.DayRevenue, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	return as.analyticsRepository.RevenueByDay(from, to)
+}
+
+// RevenueByHour returns the revenue of every hour of the day orders were created during, summed over the time range.
+func (as *analyticsService) RevenueByHour(from, to time.Time) ([]models.HourRevenue, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	return as.analyticsRepository.RevenueByHour(from, to)
+}
+
+// RevenueByCategory returns the revenue of every category of the meals ordered during the time range.
+func (as *analyticsService) RevenueByCategory(from, to time.Time) ([]models.CategoryRevenue, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	return as.analyticsRepository.RevenueByCategory(from, to)
+}
+
+// TopMeals returns at most limit meals with the most units sold during the time range.
+// A zero limit falls back to DefaultTopMealsLimit.
+func (as *analyticsService) TopMeals(from, to time.Time, limit int) ([]models.MealSales, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	if limit < 0 {
+		return nil, apperrors.NewValidationErr("Limit cannot be negative", nil)
+	}
+
+	if limit == 0 {
+		limit = DefaultTopMealsLimit
+	}
+
+	return as.analyticsRepository.TopMeals(from, to, limit)
+}
+
+// TicketSize returns the number of orders created during the time range and their revenue.
+func (as *analyticsService) TicketSize(from, to time.Time) (*models.TicketSize, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	return as.analyticsRepository.TicketSize(from, to)
+}
+
+// PrepTimes returns the average time it took the kitchen to complete the meals of the orders created
+// during the time range, overall and per meal.
+func (as *analyticsService) PrepTimes(from, to time.Time) (*models.PrepTimeReport, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	overall, err := as.analyticsRepository.AveragePrepTime(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	meals, err := as.analyticsRepository.PrepTimesByMeal(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PrepTimeReport{Overall: *overall, Meals: meals}, nil
+}
+
+// TableTurnover returns how often every table was seated during the time range.
+// Only sessions which were opened during the time range and are already closed count.
+func (as *analyticsService) TableTurnover(from, to time.Time) ([]models.TableTurnover, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	turnovers, err := as.analyticsRepository.TableTurnover(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	days := to.Sub(from).Hours() / 24
+	for i := range turnovers {
+		turnovers[i].TurnsPerDay = float64(turnovers[i].Sessions) / days
+	}
+
+	return turnovers, nil
+}
+
+// validateRange checks that the time range ends after it starts.
+func validateRange(from, to time.Time) error {
+	if !from.Before(to) {
+		return apperrors.NewValidationErr("Report has to end after it starts", nil)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// AnalyticsServiceTestSuite defines the test suite for AnalyticsService
+type AnalyticsServiceTestSuite struct {
+	suite.Suite
+	analyticsService  services.AnalyticsService
+	mockAnalyticsRepo *MockAnalyticsRepository
+	from              time.Time
+	to                time.Time
+}
+
+func (s *AnalyticsServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockAnalyticsRepo = new(MockAnalyticsRepository)
+	s.analyticsService = services.NewAnalyticsService(s.mockAnalyticsRepo)
+	s.from = time.Date(2025, time.March, 3, 0, 0, 0, 0, time.Local)
+	s.to = s.from.AddDate(0, 0, 7)
+}
+
+// TearDownTest runs after each test
+func (s *AnalyticsServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockAnalyticsRepo.AssertExpectations(s.T())
+}
+
+// TestInvalidRange tests that reports ending before they start are rejected
+func (s *AnalyticsServiceTestSuite) TestInvalidRange() {
+	_, err := s.analyticsService.RevenueByDay(s.to, s.from)
+	s.True(apperrors.IsValidationErr(err))
+
+	_, err = s.analyticsService.TicketSize(s.from, s.from)
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// TestTopMeals tests the TopMeals method
+func (s *AnalyticsServiceTestSuite) TestTopMeals() {
+	sales := []models.MealSales{{MealID: 1, MealName: "Burger", Quantity: 12, Revenue: decimal.NewFromInt(120)}}
+	s.mockAnalyticsRepo.On("TopMeals", s.from, s.to, services.DefaultTopMealsLimit).Return(sales, nil).Once()
+	s.mockAnalyticsRepo.On("TopMeals", s.from, s.to, 3).Return(sales, nil).Once()
+
+	found, err := s.analyticsService.TopMeals(s.from, s.to, 0)
+	s.NoError(err)
+	s.Equal(sales, found)
+
+	_, err = s.analyticsService.TopMeals(s.from, s.to, 3)
+	s.NoError(err)
+
+	_, err = s.analyticsService.TopMeals(s.from, s.to, -1)
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// TestPrepTimes tests the PrepTimes method
+func (s *AnalyticsServiceTestSuite) TestPrepTimes() {
+	s.mockAnalyticsRepo.On("AveragePrepTime", s.from, s.to).Return(&models.PrepTime{Units: 3, AverageSeconds: 900}, nil)
+	s.mockAnalyticsRepo.On("PrepTimesByMeal", s.from, s.to).Return([]models.PrepTime{
+		{MealID: 1, MealName: "Burger", Units: 2, AverageSeconds: 1200},
+		{MealID: 2, MealName: "Lemonade", Units: 1, AverageSeconds: 300},
+	}, nil)
+
+	report, err := s.analyticsService.PrepTimes(s.from, s.to)
+
+	s.NoError(err)
+	s.Equal(uint(3), report.Overall.Units)
+	s.Equal(900.0, report.Overall.AverageSeconds)
+	s.Len(report.Meals, 2)
+}
+
+// TestTableTurnover tests the TableTurnover method
+func (s *AnalyticsServiceTestSuite) TestTableTurnover() {
+	s.mockAnalyticsRepo.On("TableTurnover", s.from, s.to).Return([]models.TableTurnover{
+		{TableNo: 1, Sessions: 14, AverageSeconds: 3600},
+		{TableNo: 2, Sessions: 3, AverageSeconds: 5400},
+	}, nil)
+
+	turnovers, err := s.analyticsService.TableTurnover(s.from, s.to)
+
+	s.NoError(err)
+	s.Require().Len(turnovers, 2)
+	s.Equal(2.0, turnovers[0].TurnsPerDay)
+	s.InDelta(3.0/7, turnovers[1].TurnsPerDay, 0.0001)
+}
+
+// TestTicketSizeAverage tests the average ticket size
+func (s *AnalyticsServiceTestSuite) TestTicketSizeAverage() {
+	s.mockAnalyticsRepo.On("TicketSize", s.from, s.to).
+		Return(&models.TicketSize{Orders: 3, Revenue: decimal.NewFromInt(100)}, nil)
+
+	ticketSize, err := s.analyticsService.TicketSize(s.from, s.to)
+
+	s.NoError(err)
+	s.True(decimal.RequireFromString("33.33").Equal(ticketSize.Average()))
+	s.True((&models.TicketSize{}).Average().IsZero(), "no orders have no average")
+}
+
+// Run the test suite
+func TestAnalyticsServiceSuite(t *testing.T) {
+	suite.Run(t, new(AnalyticsServiceTestSuite))
+}
+
+// MockAnalyticsRepository implementation
+type MockAnalyticsRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsRepository) RevenueByDay(from, to time.Time) ([]models.DayRevenue, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DayRevenue), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) RevenueByHour(from, to time.Time) ([]models.HourRevenue, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.HourRevenue), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) RevenueByCategory(from, to time.Time) ([]models.CategoryRevenue, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CategoryRevenue), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) TopMeals(from, to time.Time, limit int) ([]models.MealSales, error) {
+	args := m.Called(from, to, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MealSales), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) TicketSize(from, to time.Time) (*models.TicketSize, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TicketSize), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) AveragePrepTime(from, to time.Time) (*models.PrepTime, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PrepTime), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) PrepTimesByMeal(from, to time.Time) ([]models.PrepTime, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PrepTime), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) TableTurnover(from, to time.Time) ([]models.TableTurnover, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TableTurnover), args.Error(1)
+}
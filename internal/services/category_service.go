@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"mime/multipart"
+)
+
+const CategoryIconSize = 256
+
+// CategoryService defines operations for managing the categories of the menu.
+type CategoryService interface {
+	GetAll(onlyActive bool) ([]*models.Category, error)
+	Create(c context.Context, category *models.Category, icon *multipart.FileHeader) error
+	Update(c context.Context, category *models.Category, icon *multipart.FileHeader) error
+	SetAvailabilityWindows(categoryID uint, windows []models.AvailabilityWindow) (*models.Category, error)
+}
+
+type categoryService struct {
+	categoryRepository repositories.CategoryRepository
+	imageStorage       storage.ImageStorage
+}
+
+func NewCategoryService(categoryRepository repositories.CategoryRepository,
+	imageStorage storage.ImageStorage) CategoryService {
+	return &categoryService{
+		categoryRepository: categoryRepository,
+		imageStorage:       imageStorage,
+	}
+}
+
+// GetAll retrieves all categories ordered by their display order, optionally only the active ones.
+func (cs *categoryService) GetAll(onlyActive bool) ([]*models.Category, error) {
+	return cs.categoryRepository.GetAll(onlyActive)
+}
+
+// Create adds a new category with an optional icon,
+// returning an error if a category with the same name already exists.
+func (cs *categoryService) Create(c context.Context, category *models.Category, icon *multipart.FileHeader) error {
+	if err := cs.checkNameAvailable(category); err != nil {
+		return err
+	}
+
+	if icon != nil {
+		if err := cs.uploadIcon(c, category, icon); err != nil {
+			return err
+		}
+	}
+
+	if err := cs.categoryRepository.Create(category); err != nil {
+		if icon != nil {
+			cs.imageStorage.Delete(c, category.IconPublicID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Update replaces the name, display order and active flag of an existing category.
+// The icon of the category gets replaced if a new icon is provided, otherwise the category keeps its icon.
+// The availability windows of the category are kept.
+func (cs *categoryService) Update(c context.Context, category *models.Category, icon *multipart.FileHeader) error {
+	existingCategory, err := cs.categoryRepository.GetByID(category.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = cs.checkNameAvailable(category); err != nil {
+		return err
+	}
+
+	category.IconURL = existingCategory.IconURL
+	category.IconPublicID = existingCategory.IconPublicID
+
+	if icon != nil {
+		if err = cs.uploadIcon(c, category, icon); err != nil {
+			return err
+		}
+	}
+
+	if err = cs.categoryRepository.Update(category); err != nil {
+		if icon != nil {
+			cs.imageStorage.Delete(c, category.IconPublicID)
+		}
+		return err
+	}
+
+	if icon != nil && existingCategory.IconPublicID != "" {
+		cs.imageStorage.Delete(c, existingCategory.IconPublicID)
+	}
+
+	category.AvailabilityWindows = existingCategory.AvailabilityWindows
+	return nil
+}
+
+// SetAvailabilityWindows validates the windows and replaces all availability windows of the category with them.
+// Returns the category with its new availability windows.
+func (cs *categoryService) SetAvailabilityWindows(categoryID uint,
+	windows []models.AvailabilityWindow) (*models.Category, error) {
+	if err := validateAvailabilityWindows(windows); err != nil {
+		return nil, err
+	}
+
+	var category *models.Category
+	err := cs.categoryRepository.WithTransaction(func(tx repositories.CategoryRepository) error {
+		if _, err := tx.GetByID(categoryID); err != nil {
+			return err
+		}
+
+		if err := tx.ReplaceAvailabilityWindows(categoryID, windows); err != nil {
+			return err
+		}
+
+		var err error
+		category, err = tx.GetByID(categoryID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// checkNameAvailable returns an error if another category already has the name of the category.
+func (cs *categoryService) checkNameAvailable(category *models.Category) error {
+	found, err := cs.categoryRepository.GetByName(category.Name)
+	if err == nil && found.ID != category.ID {
+		return apperrors.NewAlreadyExistsErr(fmt.Sprintf("Category %s already exists", category.Name), nil)
+	}
+
+	if err != nil && !apperrors.IsNotFoundErr(err) {
+		return err
+	}
+
+	return nil
+}
+
+// uploadIcon validates and uploads the icon and sets it on the category.
+func (cs *categoryService) uploadIcon(c context.Context, category *models.Category, icon *multipart.FileHeader) error {
+	if err := validateImageFile(icon); err != nil {
+		return err
+	}
+
+	result, err := cs.imageStorage.UploadCropped(c, icon, CategoryIconSize, CategoryIconSize)
+	if err != nil {
+		return apperrors.NewInternalServerErr("Failed to upload icon", err)
+	}
+
+	category.IconURL = result.URL
+	category.IconPublicID = result.PublicID
+	return nil
+}
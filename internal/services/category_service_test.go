@@ -0,0 +1,231 @@
+package services_test
+
+import (
+	"context"
+	"mime/multipart"
+	"testing"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"github.com/Ruclo/MyMeals/internal/testing/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// CategoryServiceTestSuite defines the test suite for CategoryService
+type CategoryServiceTestSuite struct {
+	suite.Suite
+	categoryService  services.CategoryService
+	mockCategoryRepo *MockCategoryRepository
+	mockImageStorage *mocks.MockImageStorage
+}
+
+func (s *CategoryServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockCategoryRepo = new(MockCategoryRepository)
+	s.mockImageStorage = new(mocks.MockImageStorage)
+	s.categoryService = services.NewCategoryService(s.mockCategoryRepo, s.mockImageStorage)
+}
+
+// TearDownTest runs after each test
+func (s *CategoryServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockCategoryRepo.AssertExpectations(s.T())
+	s.mockImageStorage.AssertExpectations(s.T())
+}
+
+// TestCreate tests the Create method
+func (s *CategoryServiceTestSuite) TestCreate() {
+	testCases := []struct {
+		name           string
+		withIcon       bool
+		setupMock      func(category *models.Category)
+		expectedError  bool
+		errorPredicate func(error) bool
+		expectedIcon   string
+	}{
+		{
+			name: "Success without icon",
+			setupMock: func(category *models.Category) {
+				s.mockCategoryRepo.On("GetByName", "Cocktails").Return(nil, apperrors.NewNotFoundErr("Category Cocktails not found", nil))
+				s.mockCategoryRepo.On("Create", category).Return(nil)
+			},
+		},
+		{
+			name:     "Success with icon",
+			withIcon: true,
+			setupMock: func(category *models.Category) {
+				s.mockCategoryRepo.On("GetByName", "Cocktails").Return(nil, apperrors.NewNotFoundErr("Category Cocktails not found", nil))
+				s.mockImageStorage.On("UploadCropped", mock.Anything, mock.AnythingOfType("*multipart.FileHeader"), 256, 256).
+					Return(&storage.ImageResult{URL: "cocktails.png", PublicID: "cocktails"}, nil)
+				s.mockCategoryRepo.On("Create", category).Return(nil)
+			},
+			expectedIcon: "cocktails.png",
+		},
+		{
+			name:     "Database error removes the icon",
+			withIcon: true,
+			setupMock: func(category *models.Category) {
+				s.mockCategoryRepo.On("GetByName", "Cocktails").Return(nil, apperrors.NewNotFoundErr("Category Cocktails not found", nil))
+				s.mockImageStorage.On("UploadCropped", mock.Anything, mock.AnythingOfType("*multipart.FileHeader"), 256, 256).
+					Return(&storage.ImageResult{URL: "cocktails.png", PublicID: "cocktails"}, nil)
+				s.mockCategoryRepo.On("Create", category).Return(apperrors.NewInternalServerErr("Database error", nil))
+				s.mockImageStorage.On("Delete", mock.Anything, "cocktails").Return(nil)
+			},
+			expectedError: true,
+		},
+		{
+			name: "Category already exists",
+			setupMock: func(category *models.Category) {
+				s.mockCategoryRepo.On("GetByName", "Cocktails").Return(&models.Category{ID: 4, Name: "Cocktails"}, nil)
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsAlreadyExistsErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			category := &models.Category{Name: "Cocktails", DisplayOrder: 3, Active: true}
+			tc.setupMock(category)
+
+			var icon *multipart.FileHeader
+			if tc.withIcon {
+				icon = newTestImageFileHeader(s.T(), "icon.png")
+			}
+
+			// Act
+			err := s.categoryService.Create(context.Background(), category, icon)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				if tc.errorPredicate != nil {
+					s.True(tc.errorPredicate(err))
+				}
+			} else {
+				s.NoError(err)
+				s.Equal(tc.expectedIcon, category.IconURL)
+			}
+		})
+	}
+}
+
+// TestUpdate tests the Update method
+func (s *CategoryServiceTestSuite) TestUpdate() {
+	existing := &models.Category{ID: 2, Name: "Drinks", DisplayOrder: 1, IconURL: "drinks.png", IconPublicID: "drinks", Active: true}
+
+	s.Run("Keeps the icon", func() {
+		s.SetupTest()
+
+		category := &models.Category{ID: 2, Name: "Beverages", DisplayOrder: 5, Active: false}
+		s.mockCategoryRepo.On("GetByID", uint(2)).Return(existing, nil)
+		s.mockCategoryRepo.On("GetByName", "Beverages").Return(nil, apperrors.NewNotFoundErr("Category Beverages not found", nil))
+		s.mockCategoryRepo.On("Update", category).Return(nil)
+
+		s.NoError(s.categoryService.Update(context.Background(), category, nil))
+		s.Equal("drinks.png", category.IconURL)
+		s.Equal("drinks", category.IconPublicID)
+	})
+
+	s.Run("Replaces the icon", func() {
+		s.SetupTest()
+
+		category := &models.Category{ID: 2, Name: "Drinks", DisplayOrder: 1, Active: true}
+		s.mockCategoryRepo.On("GetByID", uint(2)).Return(existing, nil)
+		s.mockCategoryRepo.On("GetByName", "Drinks").Return(existing, nil)
+		s.mockImageStorage.On("UploadCropped", mock.Anything, mock.AnythingOfType("*multipart.FileHeader"), 256, 256).
+			Return(&storage.ImageResult{URL: "new-drinks.png", PublicID: "new-drinks"}, nil)
+		s.mockCategoryRepo.On("Update", category).Return(nil)
+		s.mockImageStorage.On("Delete", mock.Anything, "drinks").Return(nil)
+
+		s.NoError(s.categoryService.Update(context.Background(), category, newTestImageFileHeader(s.T(), "icon.png")))
+		s.Equal("new-drinks.png", category.IconURL)
+	})
+
+	s.Run("Name taken by another category", func() {
+		s.SetupTest()
+
+		category := &models.Category{ID: 2, Name: "Desserts"}
+		s.mockCategoryRepo.On("GetByID", uint(2)).Return(existing, nil)
+		s.mockCategoryRepo.On("GetByName", "Desserts").Return(&models.Category{ID: 3, Name: "Desserts"}, nil)
+
+		err := s.categoryService.Update(context.Background(), category, nil)
+		s.True(apperrors.IsAlreadyExistsErr(err))
+	})
+
+	s.Run("Category not found", func() {
+		s.SetupTest()
+
+		s.mockCategoryRepo.On("GetByID", uint(9)).Return(nil, apperrors.NewNotFoundErr("Category with ID 9 not found", nil))
+
+		err := s.categoryService.Update(context.Background(), &models.Category{ID: 9, Name: "Brunch"}, nil)
+		s.True(apperrors.IsNotFoundErr(err))
+	})
+}
+
+// Run the test suite
+func TestCategoryServiceSuite(t *testing.T) {
+	suite.Run(t, new(CategoryServiceTestSuite))
+}
+
+// MockCategoryRepository implementation
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+// WithTransaction implementation for the mock repository
+func (m *MockCategoryRepository) WithTransaction(fn func(txRepo repositories.CategoryRepository) error) error {
+	args := m.Called(fn)
+
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+
+	return fn(m)
+}
+
+func (m *MockCategoryRepository) GetAll(onlyActive bool) ([]*models.Category, error) {
+	args := m.Called(onlyActive)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetByID(ID uint) (*models.Category, error) {
+	args := m.Called(ID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetByName(name string) (*models.Category, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) Create(category *models.Category) error {
+	args := m.Called(category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) Update(category *models.Category) error {
+	args := m.Called(category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) ReplaceAvailabilityWindows(categoryID uint, windows []models.AvailabilityWindow) error {
+	args := m.Called(categoryID, windows)
+	return args.Error(0)
+}
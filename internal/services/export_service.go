@@ -0,0 +1,144 @@
+package services
+
+import (
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/exports"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"strings"
+	"time"
+)
+
+// ExportService defines operations for exporting orders, reviews and daily sales of a time range
+// for accounting. Every export writes a header row followed by a row per record and closes the writer.
+type ExportService interface {
+	ExportOrders(from, to time.Time, writer exports.Writer) error
+	ExportReviews(from, to time.Time, writer exports.Writer) error
+	ExportDailySales(from, to time.Time, writer exports.Writer) error
+}
+
+type exportService struct {
+	exportRepository repositories.ExportRepository
+}
+
+func NewExportService(exportRepository repositories.ExportRepository) ExportService {
+	return &exportService{
+		exportRepository: exportRepository,
+	}
+}
+
+// ExportOrders exports a row per meal of every order created during the time range.
+// Prices do not include tax, the discounted total subtracts the discounts of the billable units.
+func (es *exportService) ExportOrders(from, to time.Time, writer exports.Writer) error {
+	if err := validateRange(from, to); err != nil {
+		return err
+	}
+
+	err := writeRow(writer, "Order ID", "Created at", "Table", "Status", "Paid at", "Promo code",
+		"Meal", "Options", "Quantity", "Cancelled", "Voided", "Unit price", "Tax rate", "Total", "Discounted total")
+	if err != nil {
+		return err
+	}
+
+	err = es.exportRepository.StreamOrders(from, to, func(order *models.Order) error {
+		for i := range order.OrderMeals {
+			orderMeal := &order.OrderMeals[i]
+			err := writeRow(writer, order.ID, order.CreatedAt, order.TableNo, orderStatus(order), order.PaidAt,
+				order.PromoCode, orderMeal.MealName, optionNames(orderMeal), orderMeal.Quantity, orderMeal.Cancelled,
+				orderMeal.Voided, orderMeal.UnitPrice, orderMeal.TaxRate, orderMeal.LineTotal(),
+				order.DiscountedLineTotal(orderMeal))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return closeWriter(writer)
+}
+
+// ExportReviews exports a row per review of the orders created during the time range.
+func (es *exportService) ExportReviews(from, to time.Time, writer exports.Writer) error {
+	if err := validateRange(from, to); err != nil {
+		return err
+	}
+
+	if err := writeRow(writer, "Review ID", "Order ID", "Ordered at", "Table", "Rating", "Comment", "Photos"); err != nil {
+		return err
+	}
+
+	err := es.exportRepository.StreamReviews(from, to, func(review *models.ReviewExport) error {
+		var comment string
+		if review.Comment != nil {
+			comment = *review.Comment
+		}
+
+		return writeRow(writer, review.ID, review.OrderID, review.OrderedAt, review.TableNo, review.Rating,
+			comment, strings.Join(review.PhotoURLs, " "))
+	})
+	if err != nil {
+		return err
+	}
+
+	return closeWriter(writer)
+}
+
+// ExportDailySales exports a row per day of the time range orders were created on.
+// Net sales are the sales less the discounts, neither includes tax.
+func (es *exportService) ExportDailySales(from, to time.Time, writer exports.Writer) error {
+	if err := validateRange(from, to); err != nil {
+		return err
+	}
+
+	if err := writeRow(writer, "Date", "Orders", "Units", "Sales", "Discounts", "Net sales"); err != nil {
+		return err
+	}
+
+	err := es.exportRepository.StreamDailySales(from, to, func(sales *models.DailySales) error {
+		return writeRow(writer, sales.Day, sales.Orders, sales.Units, sales.Sales.Round(2),
+			sales.Discounts.Round(2), sales.Sales.Sub(sales.Discounts).Round(2))
+	})
+	if err != nil {
+		return err
+	}
+
+	return closeWriter(writer)
+}
+
+// orderStatus describes whether the order is open, paid or cancelled.
+func orderStatus(order *models.Order) string {
+	switch {
+	case order.CancelledAt != nil:
+		return "cancelled"
+	case order.PaidAt != nil:
+		return "paid"
+	default:
+		return "open"
+	}
+}
+
+// optionNames lists the options chosen for the order meal along with their groups.
+func optionNames(orderMeal *models.OrderMeal) string {
+	names := make([]string, len(orderMeal.Options))
+	for i, option := range orderMeal.Options {
+		names[i] = option.GroupName + ": " + option.Name
+	}
+	return strings.Join(names, "; ")
+}
+
+func writeRow(writer exports.Writer, cells ...any) error {
+	if err := writer.WriteRow(cells...); err != nil {
+		return apperrors.NewInternalServerErr("Failed to write the export", err)
+	}
+	return nil
+}
+
+func closeWriter(writer exports.Writer) error {
+	if err := writer.Close(); err != nil {
+		return apperrors.NewInternalServerErr("Failed to write the export", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// ExportServiceTestSuite defines the test suite for ExportService
+type ExportServiceTestSuite struct {
+	suite.Suite
+	exportService  services.ExportService
+	mockExportRepo *MockExportRepository
+	writer         *recordingWriter
+	from           time.Time
+	to             time.Time
+}
+
+func (s *ExportServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockExportRepo = new(MockExportRepository)
+	s.exportService = services.NewExportService(s.mockExportRepo)
+	s.writer = &recordingWriter{}
+	s.from = time.Date(2025, time.March, 1, 0, 0, 0, 0, time.Local)
+	s.to = s.from.AddDate(0, 1, 0)
+}
+
+// TearDownTest runs after each test
+func (s *ExportServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockExportRepo.AssertExpectations(s.T())
+}
+
+// TestExportOrders tests the ExportOrders method
+func (s *ExportServiceTestSuite) TestExportOrders() {
+	paidAt := s.from.Add(13 * time.Hour)
+	order := &models.Order{ID: 4, TableNo: 2, CreatedAt: s.from.Add(12 * time.Hour), PaidAt: &paidAt,
+		OrderMeals: []models.OrderMeal{
+			{ID: 1, MealName: "Burger", UnitPrice: decimal.NewFromInt(10), TaxRate: decimal.RequireFromString("0.1"),
+				Quantity: 3, Voided: 1, Options: []models.OrderMealOption{
+					{GroupName: "Size", Name: "Large"}, {GroupName: "Sauce", Name: "BBQ"},
+				}},
+			{ID: 2, MealName: "Lemonade", UnitPrice: decimal.NewFromInt(3), Quantity: 1},
+		},
+		Discounts: []models.OrderDiscount{{OrderMealID: 1, Name: "Happy hour", UnitAmount: decimal.NewFromInt(2), Quantity: 3}},
+	}
+	s.mockExportRepo.On("StreamOrders", s.from, s.to, mock.Anything).Return([]any{order}, nil)
+
+	err := s.exportService.ExportOrders(s.from, s.to, s.writer)
+
+	s.NoError(err)
+	s.True(s.writer.closed)
+	s.Require().Len(s.writer.rows, 3)
+	s.Equal("Order ID", s.writer.rows[0][0])
+	burger := s.writer.rows[1]
+	s.Equal(uint(4), burger[0])
+	s.Equal("paid", burger[3])
+	s.Equal("Size: Large; Sauce: BBQ", burger[7])
+	s.True(decimal.NewFromInt(20).Equal(burger[13].(decimal.Decimal)))
+	s.True(decimal.NewFromInt(16).Equal(burger[14].(decimal.Decimal)), "discounts of voided units do not count")
+	s.Equal("", s.writer.rows[2][7])
+}
+
+// TestExportReviews tests the ExportReviews method
+func (s *ExportServiceTestSuite) TestExportReviews() {
+	comment := "Tasty"
+	s.mockExportRepo.On("StreamReviews", s.from, s.to, mock.Anything).Return([]any{
+		&models.ReviewExport{Review: models.Review{ID: 1, OrderID: 4, Rating: 5, Comment: &comment,
+			PhotoURLs: []string{"http://image.com/1.jpg", "http://image.com/2.jpg"}}, TableNo: 2},
+		&models.ReviewExport{Review: models.Review{ID: 2, OrderID: 5, Rating: 2}, TableNo: 3},
+	}, nil)
+
+	err := s.exportService.ExportReviews(s.from, s.to, s.writer)
+
+	s.NoError(err)
+	s.Require().Len(s.writer.rows, 3)
+	s.Equal("Tasty", s.writer.rows[1][5])
+	s.Equal("http://image.com/1.jpg http://image.com/2.jpg", s.writer.rows[1][6])
+	s.Equal("", s.writer.rows[2][5])
+}
+
+// TestExportDailySales tests the ExportDailySales method
+func (s *ExportServiceTestSuite) TestExportDailySales() {
+	s.mockExportRepo.On("StreamDailySales", s.from, s.to, mock.Anything).Return([]any{
+		&models.DailySales{Day: "2025-03-01", Orders: 2, Units: 5, Sales: decimal.NewFromInt(42),
+			Discounts: decimal.RequireFromString("4.5")},
+	}, nil)
+
+	err := s.exportService.ExportDailySales(s.from, s.to, s.writer)
+
+	s.NoError(err)
+	s.Require().Len(s.writer.rows, 2)
+	s.True(decimal.RequireFromString("37.5").Equal(s.writer.rows[1][5].(decimal.Decimal)))
+}
+
+// TestExportFailures tests that exports fail on invalid ranges and failing writers
+func (s *ExportServiceTestSuite) TestExportFailures() {
+	err := s.exportService.ExportOrders(s.to, s.from, s.writer)
+	s.True(apperrors.IsValidationErr(err))
+	s.Empty(s.writer.rows)
+
+	s.writer.err = errors.New("connection reset")
+	err = s.exportService.ExportDailySales(s.from, s.to, s.writer)
+	s.True(apperrors.IsInternalServerErr(err))
+	s.False(s.writer.closed)
+}
+
+// Run the test suite
+func TestExportServiceSuite(t *testing.T) {
+	suite.Run(t, new(ExportServiceTestSuite))
+}
+
+// recordingWriter records the rows written to it, or fails every write with err.
+type recordingWriter struct {
+	rows   [][]any
+	closed bool
+	err    error
+}
+
+func (w *recordingWriter) WriteRow(cells ...any) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.rows = append(w.rows, cells)
+	return nil
+}
+
+func (w *recordingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+// MockExportRepository implementation, the records to stream are returned by the expectations.
+type MockExportRepository struct {
+	mock.Mock
+}
+
+func (m *MockExportRepository) StreamOrders(from, to time.Time, fn func(order *models.Order) error) error {
+	args := m.Called(from, to, fn)
+	for _, record := range args.Get(0).([]any) {
+		if err := fn(record.(*models.Order)); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockExportRepository) StreamReviews(from, to time.Time, fn func(review *models.ReviewExport) error) error {
+	args := m.Called(from, to, fn)
+	for _, record := range args.Get(0).([]any) {
+		if err := fn(record.(*models.ReviewExport)); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockExportRepository) StreamDailySales(from, to time.Time, fn func(sales *models.DailySales) error) error {
+	args := m.Called(from, to, fn)
+	for _, record := range args.Get(0).([]any) {
+		if err := fn(record.(*models.DailySales)); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"log"
+	"time"
+)
+
+// ImageService defines operations for finding and deleting orphaned images, images in the image storage which
+// nothing in the database references, like the photos of reviews which failed to be created.
+// Only images older than the grace period can be orphaned, so images of meals and reviews
+// which are still being created are never deleted.
+type ImageService interface {
+	GetOrphans(c context.Context) ([]storage.StoredImage, error)
+	DeleteOrphans(c context.Context) ([]storage.StoredImage, error)
+	Run(c context.Context, interval time.Duration)
+}
+
+type imageService struct {
+	imageRepository repositories.ImageRepository
+	imageStorage    storage.ImageStorage
+	gracePeriod     time.Duration
+}
+
+func NewImageService(imageRepository repositories.ImageRepository,
+	imageStorage storage.ImageStorage,
+	gracePeriod time.Duration) ImageService {
+	return &imageService{
+		imageRepository: imageRepository,
+		imageStorage:    imageStorage,
+		gracePeriod:     gracePeriod,
+	}
+}
+
+// GetOrphans lists the images which DeleteOrphans would delete, without deleting them.
+func (is *imageService) GetOrphans(c context.Context) ([]storage.StoredImage, error) {
+	// Images are listed before the references are loaded, so images stored in between are never orphaned
+	images, err := is.imageStorage.List(c)
+	if err != nil {
+		return nil, err
+	}
+
+	references, err := is.imageRepository.GetReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-is.gracePeriod)
+	orphans := []storage.StoredImage{}
+	for _, image := range images {
+		if image.CreatedAt.Before(cutoff) && !references.References(image.PublicID, image.URL) {
+			orphans = append(orphans, image)
+		}
+	}
+
+	return orphans, nil
+}
+
+// DeleteOrphans deletes the orphaned images and returns the ones which were deleted.
+// Deleting the other orphans continues if deleting one of them fails, the errors are returned along with them.
+func (is *imageService) DeleteOrphans(c context.Context) ([]storage.StoredImage, error) {
+	orphans, err := is.GetOrphans(c)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []storage.StoredImage{}
+	var errs []error
+	for _, orphan := range orphans {
+		if err = is.imageStorage.Delete(c, orphan.PublicID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted = append(deleted, orphan)
+	}
+
+	return deleted, errors.Join(errs...)
+}
+
+// Run deletes the orphaned images every interval until the context is cancelled.
+func (is *imageService) Run(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			deleted, err := is.DeleteOrphans(c)
+			if err != nil {
+				log.Printf("Failed to delete orphaned images: %v", err)
+			}
+			if len(deleted) > 0 {
+				log.Printf("Deleted %d orphaned images", len(deleted))
+			}
+		}
+	}
+}
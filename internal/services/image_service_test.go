@@ -0,0 +1,106 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"github.com/Ruclo/MyMeals/internal/testing/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// ImageServiceTestSuite defines the test suite for ImageService
+type ImageServiceTestSuite struct {
+	suite.Suite
+	imageService     services.ImageService
+	mockImageRepo    *MockImageRepository
+	mockImageStorage *mocks.MockImageStorage
+	images           []storage.StoredImage
+}
+
+func (s *ImageServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockImageRepo = new(MockImageRepository)
+	s.mockImageStorage = new(mocks.MockImageStorage)
+	s.imageService = services.NewImageService(s.mockImageRepo, s.mockImageStorage, time.Hour)
+
+	old := time.Now().Add(-2 * time.Hour)
+	s.images = []storage.StoredImage{
+		{PublicID: "meal", URL: "/images/meal.jpg", CreatedAt: old},
+		{PublicID: "legacy", URL: "/images/legacy.jpg", CreatedAt: old},
+		{PublicID: "orphan", URL: "/images/orphan.jpg", CreatedAt: old},
+		{PublicID: "uploading", URL: "/images/uploading.jpg", CreatedAt: time.Now()},
+		{PublicID: "failed", URL: "/images/failed.jpg", CreatedAt: old},
+	}
+}
+
+// expectStoredImages mocks the stored images, of which the meal and legacy images are referenced.
+func (s *ImageServiceTestSuite) expectStoredImages() {
+	references := models.NewImageReferences()
+	references.Add("meal", "/images/meal.jpg")
+	references.Add("", "/images/legacy.jpg")
+	s.mockImageStorage.On("List", mock.Anything).Return(s.images, nil)
+	s.mockImageRepo.On("GetReferences").Return(references, nil)
+}
+
+// TearDownTest runs after each test
+func (s *ImageServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockImageRepo.AssertExpectations(s.T())
+	s.mockImageStorage.AssertExpectations(s.T())
+}
+
+// TestGetOrphans tests that only unreferenced images older than the grace period are orphaned
+func (s *ImageServiceTestSuite) TestGetOrphans() {
+	s.expectStoredImages()
+
+	orphans, err := s.imageService.GetOrphans(context.Background())
+
+	s.NoError(err)
+	s.Equal([]storage.StoredImage{s.images[2], s.images[4]}, orphans)
+}
+
+// TestDeleteOrphans tests that deleting orphans continues after a failed delete
+func (s *ImageServiceTestSuite) TestDeleteOrphans() {
+	s.expectStoredImages()
+	s.mockImageStorage.On("Delete", mock.Anything, "orphan").Return(errors.New("storage unavailable"))
+	s.mockImageStorage.On("Delete", mock.Anything, "failed").Return(nil)
+
+	deleted, err := s.imageService.DeleteOrphans(context.Background())
+
+	s.Error(err)
+	s.Equal([]storage.StoredImage{s.images[4]}, deleted)
+}
+
+// TestGetOrphansStorageError tests that no images are orphaned if the storage cannot be listed
+func (s *ImageServiceTestSuite) TestGetOrphansStorageError() {
+	s.mockImageStorage.On("List", mock.Anything).
+		Return(nil, apperrors.NewInternalServerErr("Failed to list images", nil))
+
+	_, err := s.imageService.GetOrphans(context.Background())
+
+	s.True(apperrors.IsInternalServerErr(err))
+}
+
+func TestImageServiceSuite(t *testing.T) {
+	suite.Run(t, new(ImageServiceTestSuite))
+}
+
+// MockImageRepository implementation
+type MockImageRepository struct {
+	mock.Mock
+}
+
+func (m *MockImageRepository) GetReferences() (*models.ImageReferences, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImageReferences), args.Error(1)
+}
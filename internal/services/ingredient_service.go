@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/shopspring/decimal"
+)
+
+// IngredientService defines operations for managing the ingredients kept in stock.
+type IngredientService interface {
+	GetAll() ([]*models.Ingredient, error)
+	Create(ingredient *models.Ingredient) error
+	Update(ingredient *models.Ingredient) error
+	Restock(ingredientID uint, quantity decimal.Decimal) (*models.Ingredient, error)
+}
+
+type ingredientService struct {
+	ingredientRepository repositories.IngredientRepository
+}
+
+func NewIngredientService(ingredientRepository repositories.IngredientRepository) IngredientService {
+	return &ingredientService{ingredientRepository: ingredientRepository}
+}
+
+// GetAll retrieves all ingredients ordered by their name.
+func (is *ingredientService) GetAll() ([]*models.Ingredient, error) {
+	return is.ingredientRepository.GetAll()
+}
+
+// Create validates and adds a new ingredient,
+// returning an error if an ingredient with the same name already exists.
+func (is *ingredientService) Create(ingredient *models.Ingredient) error {
+	if err := ingredient.Validate(); err != nil {
+		return apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	if err := is.checkNameAvailable(ingredient); err != nil {
+		return err
+	}
+
+	return is.ingredientRepository.Create(ingredient)
+}
+
+// Update validates and replaces the name, unit, stock and low stock threshold of an existing ingredient,
+// e.g. after a stock count.
+func (is *ingredientService) Update(ingredient *models.Ingredient) error {
+	if err := ingredient.Validate(); err != nil {
+		return apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	if err := is.checkNameAvailable(ingredient); err != nil {
+		return err
+	}
+
+	return is.ingredientRepository.Update(ingredient)
+}
+
+// Restock adds a delivered quantity to the stock of an ingredient and returns the updated ingredient.
+func (is *ingredientService) Restock(ingredientID uint, quantity decimal.Decimal) (*models.Ingredient, error) {
+	if !quantity.IsPositive() {
+		return nil, apperrors.NewValidationErr("Restocked quantity has to be positive", nil)
+	}
+
+	var ingredient *models.Ingredient
+	err := is.ingredientRepository.WithTransaction(func(tx repositories.IngredientRepository) error {
+		if err := tx.AddStock(ingredientID, quantity); err != nil {
+			return err
+		}
+
+		var err error
+		ingredient, err = tx.GetByID(ingredientID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ingredient, nil
+}
+
+// checkNameAvailable returns an error if another ingredient already has the name of the ingredient.
+func (is *ingredientService) checkNameAvailable(ingredient *models.Ingredient) error {
+	found, err := is.ingredientRepository.GetByName(ingredient.Name)
+	if err == nil && found.ID != ingredient.ID {
+		return apperrors.NewAlreadyExistsErr(fmt.Sprintf("Ingredient %s already exists", ingredient.Name), nil)
+	}
+
+	if err != nil && !apperrors.IsNotFoundErr(err) {
+		return err
+	}
+
+	return nil
+}
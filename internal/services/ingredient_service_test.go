@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// IngredientServiceTestSuite defines the test suite for IngredientService
+type IngredientServiceTestSuite struct {
+	suite.Suite
+	ingredientService  services.IngredientService
+	mockIngredientRepo *MockIngredientRepository
+}
+
+func (s *IngredientServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockIngredientRepo = new(MockIngredientRepository)
+	s.ingredientService = services.NewIngredientService(s.mockIngredientRepo)
+}
+
+// TearDownTest runs after each test
+func (s *IngredientServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockIngredientRepo.AssertExpectations(s.T())
+}
+
+// TestCreate tests the Create method
+func (s *IngredientServiceTestSuite) TestCreate() {
+	testCases := []struct {
+		name           string
+		ingredient     *models.Ingredient
+		setupMock      func(ingredient *models.Ingredient)
+		expectedError  bool
+		errorPredicate func(error) bool
+	}{
+		{
+			name:       "Success",
+			ingredient: &models.Ingredient{Name: "Rice noodles", Unit: "g", Stock: decimal.NewFromInt(5000), LowStockThreshold: decimal.NewFromInt(1000)},
+			setupMock: func(ingredient *models.Ingredient) {
+				s.mockIngredientRepo.On("GetByName", "Rice noodles").Return(nil, apperrors.NewNotFoundErr("Ingredient Rice noodles not found", nil))
+				s.mockIngredientRepo.On("Create", ingredient).Return(nil)
+			},
+		},
+		{
+			name:       "Name already exists",
+			ingredient: &models.Ingredient{Name: "Rice noodles", Unit: "g"},
+			setupMock: func(ingredient *models.Ingredient) {
+				s.mockIngredientRepo.On("GetByName", "Rice noodles").Return(&models.Ingredient{ID: 3, Name: "Rice noodles"}, nil)
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsAlreadyExistsErr,
+		},
+		{
+			name:           "Missing unit",
+			ingredient:     &models.Ingredient{Name: "Rice noodles"},
+			setupMock:      func(ingredient *models.Ingredient) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Negative stock",
+			ingredient:     &models.Ingredient{Name: "Rice noodles", Unit: "g", Stock: decimal.NewFromInt(-1)},
+			setupMock:      func(ingredient *models.Ingredient) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+			tc.setupMock(tc.ingredient)
+
+			// Act
+			err := s.ingredientService.Create(tc.ingredient)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				s.True(tc.errorPredicate(err))
+			} else {
+				s.NoError(err)
+			}
+		})
+	}
+}
+
+// TestRestock tests the Restock method
+func (s *IngredientServiceTestSuite) TestRestock() {
+	restocked := &models.Ingredient{ID: 1, Name: "Eggs", Unit: "pcs", Stock: decimal.NewFromInt(60), LowStockThreshold: decimal.NewFromInt(12)}
+	s.mockIngredientRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.IngredientRepository) error")).Return(nil)
+	s.mockIngredientRepo.On("AddStock", uint(1), decimal.NewFromInt(50)).Return(nil)
+	s.mockIngredientRepo.On("GetByID", uint(1)).Return(restocked, nil)
+
+	ingredient, err := s.ingredientService.Restock(1, decimal.NewFromInt(50))
+	s.NoError(err)
+	s.Equal(restocked, ingredient)
+	s.False(ingredient.IsLowStock())
+
+	_, err = s.ingredientService.Restock(1, decimal.Zero)
+	s.True(apperrors.IsValidationErr(err))
+
+	s.mockIngredientRepo.On("AddStock", uint(9), decimal.NewFromInt(5)).Return(apperrors.NewNotFoundErr("Ingredient with ID 9 not found", nil))
+	_, err = s.ingredientService.Restock(9, decimal.NewFromInt(5))
+	s.True(apperrors.IsNotFoundErr(err))
+}
+
+// Run the test suite
+func TestIngredientServiceSuite(t *testing.T) {
+	suite.Run(t, new(IngredientServiceTestSuite))
+}
+
+// MockIngredientRepository implementation
+type MockIngredientRepository struct {
+	mock.Mock
+}
+
+// WithTransaction implementation for the mock repository
+func (m *MockIngredientRepository) WithTransaction(fn func(txRepo repositories.IngredientRepository) error) error {
+	args := m.Called(fn)
+
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+
+	return fn(m)
+}
+
+func (m *MockIngredientRepository) GetAll() ([]*models.Ingredient, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Ingredient), args.Error(1)
+}
+
+func (m *MockIngredientRepository) GetByID(ID uint) (*models.Ingredient, error) {
+	args := m.Called(ID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Ingredient), args.Error(1)
+}
+
+func (m *MockIngredientRepository) GetByName(name string) (*models.Ingredient, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Ingredient), args.Error(1)
+}
+
+func (m *MockIngredientRepository) Create(ingredient *models.Ingredient) error {
+	args := m.Called(ingredient)
+	return args.Error(0)
+}
+
+func (m *MockIngredientRepository) Update(ingredient *models.Ingredient) error {
+	args := m.Called(ingredient)
+	return args.Error(0)
+}
+
+func (m *MockIngredientRepository) AddStock(ingredientID uint, quantity decimal.Decimal) error {
+	args := m.Called(ingredientID, quantity)
+	return args.Error(0)
+}
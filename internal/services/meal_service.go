@@ -2,30 +2,40 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"github.com/Ruclo/MyMeals/internal/apperrors"
 	"github.com/Ruclo/MyMeals/internal/models"
 	"github.com/Ruclo/MyMeals/internal/repositories"
 	"github.com/Ruclo/MyMeals/internal/storage"
 	"io"
-	"net/http"
 	"mime/multipart"
+	"net/http"
 	"strings"
+	"time"
 )
 
 const MealPhotoSize = 1000
 
 // MealService defines an interface for managing meal operations, including creation, updating, deletion, and retrieval.
 type MealService interface {
-	Create(context.Context, *models.Meal, *multipart.FileHeader) error
-	Replace(context.Context, *models.Meal, *multipart.FileHeader) error
+	Create(context.Context, *models.Meal, *multipart.FileHeader, *models.UploadRef) error
+	Update(context.Context, *models.Meal, *multipart.FileHeader, *models.UploadRef) error
+	GetVersions(mealID uint) ([]*models.MealVersion, error)
 	Delete(uint) error
-	GetAll() ([]*models.Meal, error)
+	GetAll(filter models.MealFilter) ([]*models.Meal, error)
 	GetAllWithDeleted() ([]*models.Meal, error)
+	SetOptionGroups(mealID uint, optionGroups []models.OptionGroup) (*models.Meal, error)
+	SetSoldOut(mealID uint, soldOut bool) (*models.Meal, error)
+	SetAvailabilityWindows(mealID uint, windows []models.AvailabilityWindow) (*models.Meal, error)
+	SetRecipe(mealID uint, recipe []models.RecipeItem) (*models.Meal, error)
 }
 
 type mealService struct {
-	mealRepository repositories.MealRepository
-	imageStorage   storage.ImageStorage
+	mealRepository       repositories.MealRepository
+	categoryRepository   repositories.CategoryRepository
+	menuRepository       repositories.MenuRepository
+	ingredientRepository repositories.IngredientRepository
+	imageProcessor       storage.ImageProcessor
 }
 
 func validateImageFile(photo *multipart.FileHeader) error {
@@ -49,40 +59,105 @@ func validateImageFile(photo *multipart.FileHeader) error {
 	return nil
 }
 
-func NewMealService(mealRepository repositories.MealRepository, imageStorage storage.ImageStorage) MealService {
+// toImageVariants converts the variants of a processed image to the variants stored with meals and reviews.
+func toImageVariants(image *storage.ProcessedImage) models.ImageVariants {
+	variants := make(models.ImageVariants, len(image.Variants))
+	for i, variant := range image.Variants {
+		variants[i] = models.ImageVariant{
+			Size:        variant.Size,
+			ContentType: variant.ContentType,
+			Width:       variant.Width,
+			Height:      variant.Height,
+			URL:         variant.URL,
+			PublicID:    variant.PublicID,
+		}
+	}
+	return variants
+}
+
+func NewMealService(mealRepository repositories.MealRepository,
+	categoryRepository repositories.CategoryRepository,
+	menuRepository repositories.MenuRepository,
+	ingredientRepository repositories.IngredientRepository,
+	imageProcessor storage.ImageProcessor) MealService {
 	return &mealService{
-		mealRepository: mealRepository,
-		imageStorage:   imageStorage,
+		mealRepository:       mealRepository,
+		categoryRepository:   categoryRepository,
+		menuRepository:       menuRepository,
+		ingredientRepository: ingredientRepository,
+		imageProcessor:       imageProcessor,
 	}
 }
 
-// Create uploads a meal photo, sets the meal's image URL, and stores the meal in the database.
+// Create validates the category and the dietary information of the meal, uploads the variants of a meal photo
+// or attaches a photo uploaded into an upload session beforehand, sets the meal's image URL and variants,
+// and stores the meal in the database along with its first version.
 func (ms *mealService) Create(c context.Context,
 	meal *models.Meal,
-	photo *multipart.FileHeader) error {
-	if err := validateImageFile(photo); err != nil {
+	photo *multipart.FileHeader,
+	upload *models.UploadRef) error {
+	if err := meal.ValidateDietaryInfo(); err != nil {
+		return apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	category, err := ms.getCategory(meal.CategoryID)
+	if err != nil {
 		return err
 	}
 
-	result, err := ms.imageStorage.UploadCropped(c, photo, MealPhotoSize, MealPhotoSize)
+	if photo == nil && upload == nil {
+		return apperrors.NewValidationErr("photo not provided", nil)
+	}
 
+	publicIDs, err := ms.processPhoto(c, meal, photo, upload)
 	if err != nil {
-		return apperrors.NewInternalServerErr("Failed to upload photo", err)
+		return err
 	}
+	meal.Version = 1
+
+	err = ms.mealRepository.WithTransaction(func(tx repositories.MealRepository) error {
+		if err := attachMealUpload(tx.Uploads(), meal, upload); err != nil {
+			return err
+		}
 
-	meal.ImageURL = result.URL
+		if err := tx.Create(meal); err != nil {
+			return err
+		}
+
+		return tx.CreateVersion(meal.NewVersion())
+	})
 
-	if err = ms.mealRepository.Create(meal); err != nil {
-		ms.imageStorage.Delete(c, result.PublicID)
+	if err != nil {
+		if len(publicIDs) > 0 {
+			ms.imageProcessor.Delete(c, publicIDs...)
+		}
 		return err
 	}
 
+	meal.Category = category
 	return nil
 }
 
-// GetAll retrieves all meal records from the repository and returns them along with any encountered apperrors.
-func (ms *mealService) GetAll() ([]*models.Meal, error) {
-	return ms.mealRepository.GetAll()
+// GetAll retrieves the meals of the currently active menu which pass the filter, priced and ordered by the menu,
+// and returns them along with any encountered apperrors. All meals pass to the filter if no menu is active.
+func (ms *mealService) GetAll(filter models.MealFilter) ([]*models.Meal, error) {
+	if err := filter.Valid(); err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	_, meals, err := menuMealsAt(ms.menuRepository, ms.mealRepository, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	filteredMeals := make([]*models.Meal, 0, len(meals))
+	for _, meal := range meals {
+		if filter.Matches(meal) {
+			filteredMeals = append(filteredMeals, meal)
+		}
+	}
+
+	return filteredMeals, nil
 }
 
 // GetAllWithDeleted retrieves all Meal records, including those that have been soft-deleted, from the repository.
@@ -90,53 +165,126 @@ func (ms *mealService) GetAllWithDeleted() ([]*models.Meal, error) {
 	return ms.mealRepository.GetAllWithDeleted()
 }
 
-// Replace modifies an existing meal, optionally updates its image, and replaces it in the repository
-// within a transaction context.
-// The old meal gets soft deleted. A new meal gets created.
-func (ms *mealService) Replace(c context.Context, meal *models.Meal, photo *multipart.FileHeader) error {
+// Update edits the menu details of an existing meal in place, optionally replacing its image with a photo
+// or a photo uploaded into an upload session beforehand, and records the edited details as a new version of the meal within a transaction.
+// The meal keeps its id, availability, option groups and recipe.
+// The previous image is kept, since earlier versions of the meal still show it.
+func (ms *mealService) Update(c context.Context,
+	meal *models.Meal,
+	photo *multipart.FileHeader,
+	upload *models.UploadRef) error {
+	if err := meal.ValidateDietaryInfo(); err != nil {
+		return apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	if _, err := ms.getCategory(meal.CategoryID); err != nil {
+		return err
+	}
 
 	existingMeal, err := ms.mealRepository.GetByID(meal.ID)
 	if err != nil {
 		return err
 	}
 
-	newMeal := models.Meal{
-		Name:        meal.Name,
-		Price:       meal.Price,
-		Description: meal.Description,
-		Category:    meal.Category,
-		ImageURL:    existingMeal.ImageURL,
+	meal.ImageURL = existingMeal.ImageURL
+	meal.ImagePublicID = existingMeal.ImagePublicID
+	meal.ImageVariants = existingMeal.ImageVariants
+
+	publicIDs, err := ms.processPhoto(c, meal, photo, upload)
+	if err != nil {
+		return err
 	}
 
+	var updatedMeal *models.Meal
 	err = ms.mealRepository.WithTransaction(func(tx repositories.MealRepository) error {
-		if photo != nil {
-			if err := validateImageFile(photo); err != nil {
-				return err
-			}
-
-			result, err := ms.imageStorage.UploadCropped(c, photo, MealPhotoSize, MealPhotoSize)
-			if err != nil {
-				return err
-			}
-
-			err = ms.imageStorage.Delete(c, meal.ImageURL)
-			if err != nil {
-				return err
-			}
-
-			newMeal.ImageURL = result.URL
+		if err := attachMealUpload(tx.Uploads(), meal, upload); err != nil {
+			return err
 		}
 
-		if err := tx.Create(&newMeal); err != nil {
+		if err := tx.Update(meal); err != nil {
 			return err
 		}
 
-		return tx.Delete(existingMeal)
+		if err := tx.CreateVersion(meal.NewVersion()); err != nil {
+			return err
+		}
 
+		var err error
+		updatedMeal, err = tx.GetByID(meal.ID)
+		return err
 	})
 
-	*meal = newMeal
-	return err
+	if err != nil {
+		if len(publicIDs) > 0 {
+			ms.imageProcessor.Delete(c, publicIDs...)
+		}
+		return err
+	}
+
+	*meal = *updatedMeal
+	return nil
+}
+
+// processPhoto sets the image of the meal to the variants of the photo and returns their public IDs,
+// which have to be deleted if the meal fails to be stored. Nothing is processed if there is no photo.
+// A meal gets either a photo or an upload, see attachMealUpload.
+func (ms *mealService) processPhoto(c context.Context,
+	meal *models.Meal,
+	photo *multipart.FileHeader,
+	upload *models.UploadRef) ([]string, error) {
+	if photo != nil && upload != nil {
+		return nil, apperrors.NewValidationErr("Attach either a photo or an uploaded photo, not both", nil)
+	}
+
+	if photo == nil {
+		return nil, nil
+	}
+
+	if err := validateImageFile(photo); err != nil {
+		return nil, err
+	}
+
+	image, err := ms.imageProcessor.ProcessCropped(c, photo, MealPhotoSize, MealPhotoSize)
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to upload photo", err)
+	}
+
+	meal.ImageURL = image.URL
+	meal.ImagePublicID = image.PublicID
+	meal.ImageVariants = toImageVariants(image)
+	return image.PublicIDs(), nil
+}
+
+// attachMealUpload sets the image of the meal to the upload and claims it from its session, so it cannot be attached
+// to another meal. It runs within the transaction storing the meal, the upload stays in its session if that fails.
+func attachMealUpload(uploadRepository repositories.UploadRepository, meal *models.Meal, upload *models.UploadRef) error {
+	if upload == nil {
+		return nil
+	}
+
+	uploads, err := claimUploads(uploadRepository, upload.Owner, models.MealUpload, []string{upload.ID})
+	if err != nil {
+		return err
+	}
+
+	meal.ImageURL = uploads[0].ImageURL
+	meal.ImagePublicID = uploads[0].ImagePublicID
+	meal.ImageVariants = uploads[0].ImageVariants
+	return nil
+}
+
+// GetVersions retrieves the version history of a meal, oldest version first.
+func (ms *mealService) GetVersions(mealID uint) ([]*models.MealVersion, error) {
+	versions, err := ms.mealRepository.GetVersions(mealID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Meal with ID %d not found", mealID), nil)
+	}
+
+	return versions, nil
 }
 
 // Delete performs a soft delete of a meal identified by the given ID using the meal repository.
@@ -144,3 +292,143 @@ func (ms *mealService) Replace(c context.Context, meal *models.Meal, photo *mult
 func (ms *mealService) Delete(id uint) error {
 	return ms.mealRepository.Delete(&models.Meal{ID: id})
 }
+
+// SetOptionGroups validates the option groups and replaces all option groups of the meal with them.
+// Returns the meal with its new option groups.
+func (ms *mealService) SetOptionGroups(mealID uint, optionGroups []models.OptionGroup) (*models.Meal, error) {
+	for i := range optionGroups {
+		if err := optionGroups[i].Validate(); err != nil {
+			return nil, apperrors.NewValidationErr(err.Error(), err)
+		}
+	}
+
+	var meal *models.Meal
+	err := ms.mealRepository.WithTransaction(func(tx repositories.MealRepository) error {
+		if _, err := tx.GetByID(mealID); err != nil {
+			return err
+		}
+
+		if err := tx.ReplaceOptionGroups(mealID, optionGroups); err != nil {
+			return err
+		}
+
+		var err error
+		meal, err = tx.GetByID(mealID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return meal, nil
+}
+
+// SetSoldOut marks the meal as sold out or back in stock and returns the updated meal.
+func (ms *mealService) SetSoldOut(mealID uint, soldOut bool) (*models.Meal, error) {
+	var meal *models.Meal
+	err := ms.mealRepository.WithTransaction(func(tx repositories.MealRepository) error {
+		if err := tx.SetSoldOut(mealID, soldOut); err != nil {
+			return err
+		}
+
+		var err error
+		meal, err = tx.GetByID(mealID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return meal, nil
+}
+
+// SetAvailabilityWindows validates the windows and replaces all availability windows of the meal with them.
+// Returns the meal with its new availability windows.
+func (ms *mealService) SetAvailabilityWindows(mealID uint, windows []models.AvailabilityWindow) (*models.Meal, error) {
+	if err := validateAvailabilityWindows(windows); err != nil {
+		return nil, err
+	}
+
+	var meal *models.Meal
+	err := ms.mealRepository.WithTransaction(func(tx repositories.MealRepository) error {
+		if _, err := tx.GetByID(mealID); err != nil {
+			return err
+		}
+
+		if err := tx.ReplaceAvailabilityWindows(mealID, windows); err != nil {
+			return err
+		}
+
+		var err error
+		meal, err = tx.GetByID(mealID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return meal, nil
+}
+
+// SetRecipe validates the recipe and replaces all recipe items of the meal with it.
+// Every ingredient of the recipe has to exist. Returns the meal with its new recipe.
+func (ms *mealService) SetRecipe(mealID uint, recipe []models.RecipeItem) (*models.Meal, error) {
+	if err := models.ValidateRecipe(recipe); err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	for _, item := range recipe {
+		if _, err := ms.ingredientRepository.GetByID(item.IngredientID); err != nil {
+			if apperrors.IsNotFoundErr(err) {
+				return nil, apperrors.NewValidationErr(fmt.Sprintf("Ingredient %d does not exist", item.IngredientID), err)
+			}
+			return nil, err
+		}
+	}
+
+	var meal *models.Meal
+	err := ms.mealRepository.WithTransaction(func(tx repositories.MealRepository) error {
+		if _, err := tx.GetByID(mealID); err != nil {
+			return err
+		}
+
+		if err := tx.ReplaceRecipe(mealID, recipe); err != nil {
+			return err
+		}
+
+		var err error
+		meal, err = tx.GetByID(mealID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return meal, nil
+}
+
+// validateAvailabilityWindows returns a validation error if any of the windows is invalid.
+func validateAvailabilityWindows(windows []models.AvailabilityWindow) error {
+	for i := range windows {
+		if err := windows[i].Validate(); err != nil {
+			return apperrors.NewValidationErr(err.Error(), err)
+		}
+	}
+	return nil
+}
+
+// getCategory retrieves the category of a meal, returning a validation error if it does not exist.
+func (ms *mealService) getCategory(categoryID uint) (*models.Category, error) {
+	category, err := ms.categoryRepository.GetByID(categoryID)
+	if err != nil {
+		if apperrors.IsNotFoundErr(err) {
+			return nil, apperrors.NewValidationErr(fmt.Sprintf("Category %d does not exist", categoryID), err)
+		}
+		return nil, err
+	}
+	return category, nil
+}
@@ -1,9 +1,13 @@
 package services_test
 
 import (
+	"bytes"
 	"context"
 	"github.com/Ruclo/MyMeals/internal/repositories"
+	"image"
+	"image/png"
 	"testing"
+	"time"
 
 	"github.com/Ruclo/MyMeals/internal/apperrors"
 	"github.com/Ruclo/MyMeals/internal/models"
@@ -13,6 +17,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"mime/multipart"
 )
@@ -20,18 +25,31 @@ import (
 // MealServiceTestSuite defines the test suite for MealService
 type MealServiceTestSuite struct {
 	suite.Suite
-	mealService      services.MealService
-	mockRepo         *MockMealRepository
-	mockImageStorage *mocks.MockImageStorage
-	ginContext       *gin.Context
+	mealService        services.MealService
+	mockRepo           *MockMealRepository
+	mockCategoryRepo   *MockCategoryRepository
+	mockMenuRepo       *MockMenuRepository
+	mockIngredientRepo *MockIngredientRepository
+	mockUploadRepo     *MockUploadRepository
+	mockImageProcessor *mocks.MockImageProcessor
+	ginContext         *gin.Context
 }
 
 func (s *MealServiceTestSuite) SetupTest() {
 	// Create fresh mocks for each test
 	s.mockRepo = new(MockMealRepository)
-	s.mockImageStorage = new(mocks.MockImageStorage)
+	s.mockCategoryRepo = new(MockCategoryRepository)
+	s.mockMenuRepo = new(MockMenuRepository)
+	s.mockIngredientRepo = new(MockIngredientRepository)
+	s.mockUploadRepo = new(MockUploadRepository)
+	s.mockImageProcessor = new(mocks.MockImageProcessor)
 
-	s.mealService = services.NewMealService(s.mockRepo, s.mockImageStorage)
+	// Meals of the tests belong to the main courses category unless stated otherwise
+	s.mockCategoryRepo.On("GetByID", uint(1)).Return(&models.Category{ID: 1, Name: "Main Courses", Active: true}, nil).Maybe()
+
+	s.mealService = services.NewMealService(s.mockRepo, s.mockCategoryRepo, s.mockMenuRepo, s.mockIngredientRepo,
+		s.mockImageProcessor)
+	s.mockRepo.On("Uploads").Return(s.mockUploadRepo).Maybe()
 
 	// Create a Gin context for testing
 	s.ginContext = &gin.Context{}
@@ -41,7 +59,11 @@ func (s *MealServiceTestSuite) SetupTest() {
 func (s *MealServiceTestSuite) TearDownTest() {
 	// Verify all mock expectations were met
 	s.mockRepo.AssertExpectations(s.T())
-	s.mockImageStorage.AssertExpectations(s.T())
+	s.mockCategoryRepo.AssertExpectations(s.T())
+	s.mockMenuRepo.AssertExpectations(s.T())
+	s.mockIngredientRepo.AssertExpectations(s.T())
+	s.mockUploadRepo.AssertExpectations(s.T())
+	s.mockImageProcessor.AssertExpectations(s.T())
 }
 
 // TestCreate tests the Create method
@@ -60,49 +82,82 @@ func (s *MealServiceTestSuite) TestCreate() {
 			name: "Success",
 			meal: &models.Meal{
 				Name:        "Test Meal",
-				Category:    models.MainCourses,
+				CategoryID:  1,
 				Description: "Test Description",
 				Price:       price1599,
 			},
 			setupMock: func() {
 				// Mock successful upload
-				uploadResult := &storage.ImageResult{
-					URL:      "https://cloudinary.com/test-image.jpg",
-					PublicID: "test-image",
-				}
-				s.mockImageStorage.On("UploadCropped",
+				uploadResult := newTestProcessedImage("https://cloudinary.com/test-image.jpg", "test-image")
+				s.mockImageProcessor.On("ProcessCropped",
 					s.ginContext,
 					mock.AnythingOfType("*multipart.FileHeader"),
 					1000, 1000,
 				).Return(uploadResult, nil)
 
 				// Mock successful meal creation
+				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).Return(nil)
 				s.mockRepo.On("Create", mock.MatchedBy(func(meal *models.Meal) bool {
 					return meal.Name == "Test Meal" &&
 						meal.ImageURL == "https://cloudinary.com/test-image.jpg"
+				})).Run(func(args mock.Arguments) {
+					args.Get(0).(*models.Meal).ID = 5
+				}).Return(nil)
+				s.mockRepo.On("CreateVersion", mock.MatchedBy(func(version *models.MealVersion) bool {
+					return version.MealID == 5 && version.Version == 1 && version.Name == "Test Meal"
 				})).Return(nil)
 			},
 			expectedError: false,
 			checkMeal: func(meal *models.Meal) {
+				s.Equal(uint(1), meal.Version)
 				s.Equal("Test Meal", meal.Name)
-				s.Equal(models.MainCourses, meal.Category)
+				s.Equal("Main Courses", meal.CategoryName())
 				s.Equal("Test Description", meal.Description)
 				s.True(price1599.Equal(meal.Price))
 				s.Equal("https://cloudinary.com/test-image.jpg", meal.ImageURL)
 			},
 		},
+		{
+			name: "Unknown category",
+			meal: &models.Meal{
+				Name:        "Test Meal",
+				CategoryID:  99,
+				Description: "Test Description",
+				Price:       price1599,
+			},
+			setupMock: func() {
+				s.mockCategoryRepo.On("GetByID", uint(99)).
+					Return(nil, apperrors.NewNotFoundErr("Category with ID 99 not found", nil))
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name: "Vegan meal containing milk",
+			meal: &models.Meal{
+				Name:        "Test Meal",
+				CategoryID:  1,
+				Description: "Test Description",
+				Price:       price1599,
+				Allergens:   models.Allergens{models.Milk},
+				DietaryTags: models.DietaryTags{models.Vegan},
+			},
+			setupMock:      func() {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
 		{
 			name: "UploadError",
 			meal: &models.Meal{
 				Name:        "Test Meal",
-				Category:    models.MainCourses,
+				CategoryID:  1,
 				Description: "Test Description",
 				Price:       price1599,
 			},
 			setupMock: func() {
 				// Mock upload error
 				uploadErr := apperrors.NewInternalServerErr("Upload failed", nil)
-				s.mockImageStorage.On("UploadCropped",
+				s.mockImageProcessor.On("ProcessCropped",
 					s.ginContext,
 					mock.AnythingOfType("*multipart.FileHeader"),
 					1000, 1000,
@@ -116,17 +171,14 @@ func (s *MealServiceTestSuite) TestCreate() {
 			name: "DatabaseError",
 			meal: &models.Meal{
 				Name:        "Test Meal",
-				Category:    models.MainCourses,
+				CategoryID:  1,
 				Description: "Test Description",
 				Price:       price1599,
 			},
 			setupMock: func() {
 				// Mock successful upload
-				uploadResult := &storage.ImageResult{
-					URL:      "https://cloudinary.com/test-image.jpg",
-					PublicID: "test-image",
-				}
-				s.mockImageStorage.On("UploadCropped",
+				uploadResult := newTestProcessedImage("https://cloudinary.com/test-image.jpg", "test-image")
+				s.mockImageProcessor.On("ProcessCropped",
 					s.ginContext,
 					mock.AnythingOfType("*multipart.FileHeader"),
 					1000, 1000,
@@ -134,12 +186,13 @@ func (s *MealServiceTestSuite) TestCreate() {
 
 				// Mock database error
 				dbErr := apperrors.NewInternalServerErr("Database error", nil)
+				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).Return(nil)
 				s.mockRepo.On("Create", mock.AnythingOfType("*models.Meal")).Return(dbErr)
 
 				// Mock delete call due to rollback
-				s.mockImageStorage.On("Delete",
+				s.mockImageProcessor.On("Delete",
 					s.ginContext,
-					"test-image",
+					[]string{"test-image", "test-image-webp"},
 				).Return(nil)
 			},
 			expectedError: true,
@@ -155,21 +208,20 @@ func (s *MealServiceTestSuite) TestCreate() {
 			tc.setupMock()
 
 			// Create a dummy file header for testing
-			dummyFileHeader := &multipart.FileHeader{
-				Filename: "test.jpg",
-				Size:     1024,
-			}
+			dummyFileHeader := newTestImageFileHeader(s.T(), "test.png")
 
 			// Create a copy to avoid modifications between tests
 			mealCopy := &models.Meal{
 				Name:        tc.meal.Name,
-				Category:    tc.meal.Category,
+				CategoryID:  tc.meal.CategoryID,
 				Description: tc.meal.Description,
 				Price:       tc.meal.Price,
+				Allergens:   tc.meal.Allergens,
+				DietaryTags: tc.meal.DietaryTags,
 			}
 
 			// Act
-			err := s.mealService.Create(s.ginContext, mealCopy, dummyFileHeader)
+			err := s.mealService.Create(s.ginContext, mealCopy, dummyFileHeader, nil)
 
 			// Assert
 			if tc.expectedError {
@@ -202,11 +254,11 @@ func (s *MealServiceTestSuite) TestGetAll() {
 		{
 			name: "Success",
 			setupMock: func() {
-				meals := []models.Meal{
+				meals := []*models.Meal{
 					{
 						ID:          1,
 						Name:        "Meal 1",
-						Category:    "Category 1",
+						CategoryID:  1,
 						Description: "Description 1",
 						Price:       price999,
 						ImageURL:    "image1.jpg",
@@ -214,19 +266,20 @@ func (s *MealServiceTestSuite) TestGetAll() {
 					{
 						ID:          2,
 						Name:        "Meal 2",
-						Category:    "Category 2",
+						CategoryID:  2,
 						Description: "Description 2",
 						Price:       price1299,
 						ImageURL:    "image2.jpg",
 					},
 				}
+				s.mockMenuRepo.On("GetAll").Return([]*models.Menu{}, nil)
 				s.mockRepo.On("GetAll").Return(meals, nil)
 			},
 			expectedMeals: []models.Meal{
 				{
 					ID:          1,
 					Name:        "Meal 1",
-					Category:    "Category 1",
+					CategoryID:  1,
 					Description: "Description 1",
 					Price:       price999,
 					ImageURL:    "image1.jpg",
@@ -234,7 +287,7 @@ func (s *MealServiceTestSuite) TestGetAll() {
 				{
 					ID:          2,
 					Name:        "Meal 2",
-					Category:    "Category 2",
+					CategoryID:  2,
 					Description: "Description 2",
 					Price:       price1299,
 					ImageURL:    "image2.jpg",
@@ -245,7 +298,8 @@ func (s *MealServiceTestSuite) TestGetAll() {
 		{
 			name: "EmptyList",
 			setupMock: func() {
-				s.mockRepo.On("GetAll").Return([]models.Meal{}, nil)
+				s.mockMenuRepo.On("GetAll").Return([]*models.Menu{}, nil)
+				s.mockRepo.On("GetAll").Return([]*models.Meal{}, nil)
 			},
 			expectedMeals: []models.Meal{},
 			expectedError: false,
@@ -254,6 +308,7 @@ func (s *MealServiceTestSuite) TestGetAll() {
 			name: "DatabaseError",
 			setupMock: func() {
 				dbErr := apperrors.NewInternalServerErr("Database error", nil)
+				s.mockMenuRepo.On("GetAll").Return([]*models.Menu{}, nil)
 				s.mockRepo.On("GetAll").Return(nil, dbErr)
 			},
 			expectedMeals: nil,
@@ -270,7 +325,7 @@ func (s *MealServiceTestSuite) TestGetAll() {
 			tc.setupMock()
 
 			// Act
-			meals, err := s.mealService.GetAll()
+			meals, err := s.mealService.GetAll(models.MealFilter{})
 
 			// Assert
 			if tc.expectedError {
@@ -286,7 +341,7 @@ func (s *MealServiceTestSuite) TestGetAll() {
 				if len(meals) > 0 {
 					s.Equal(tc.expectedMeals[0].ID, meals[0].ID)
 					s.Equal(tc.expectedMeals[0].Name, meals[0].Name)
-					s.Equal(tc.expectedMeals[0].Category, meals[0].Category)
+					s.Equal(tc.expectedMeals[0].CategoryID, meals[0].CategoryID)
 					s.True(tc.expectedMeals[0].Price.Equal(meals[0].Price))
 				}
 			}
@@ -302,6 +357,7 @@ func (s *MealServiceTestSuite) TestUpdate() {
 		name           string
 		meal           *models.Meal
 		photo          *multipart.FileHeader
+		upload         *models.UploadRef
 		setupMock      func()
 		expectedError  bool
 		errorPredicate func(error) bool
@@ -312,62 +368,64 @@ func (s *MealServiceTestSuite) TestUpdate() {
 			meal: &models.Meal{
 				ID:          1,
 				Name:        "Updated Meal",
-				Category:    "Updated Category",
+				CategoryID:  1,
 				Description: "Updated Description",
 				Price:       price1999,
 			},
-			photo: &multipart.FileHeader{Filename: "new-photo.jpg"},
+			photo: newTestImageFileHeader(s.T(), "new-photo.png"),
 			setupMock: func() {
 				existingMeal := &models.Meal{
 					ID:          1,
 					Name:        "Original Meal",
-					Category:    "Original Category",
+					CategoryID:  2,
 					Description: "Original Description",
 					Price:       decimal.NewFromFloat(9.99),
 					ImageURL:    "old-image.jpg",
+					Version:     3,
 				}
-				s.mockRepo.On("GetByID", uint(1)).Return(existingMeal, nil)
-
-				s.mockImageStorage.On("UploadCropped", mock.Anything, mock.AnythingOfType("*multipart.FileHeader"), 1000, 1000).
-					Return(&storage.ImageResult{URL: "new-image.jpg", PublicID: "new-image"}, nil)
+				s.mockRepo.On("GetByID", uint(1)).Return(existingMeal, nil).Once()
 
-				s.mockImageStorage.On("Delete", mock.Anything, "old-image.jpg").Return(nil)
+				s.mockImageProcessor.On("ProcessCropped", mock.Anything, mock.AnythingOfType("*multipart.FileHeader"), 1000, 1000).
+					Return(newTestProcessedImage("new-image.jpg", "new-image"), nil)
 
-				// Mock transaction
-				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).
-					Run(func(args mock.Arguments) {
-						// Execute the transaction function with our mock repo
-						txFunc := args.Get(0).(func(repositories.MealRepository) error)
-						txFunc(s.mockRepo)
-					}).Return(nil)
+				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).Return(nil)
 
-				// Mock creating new meal
-				s.mockRepo.On("Create", mock.MatchedBy(func(meal *models.Meal) bool {
-					return meal.Name == "Updated Meal" &&
-						meal.Category == "Updated Category" &&
+				// The meal gets edited in place
+				s.mockRepo.On("Update", mock.MatchedBy(func(meal *models.Meal) bool {
+					return meal.ID == 1 &&
+						meal.Name == "Updated Meal" &&
+						meal.CategoryID == 1 &&
 						meal.Description == "Updated Description" &&
 						meal.ImageURL == "new-image.jpg" &&
+						len(meal.ImageVariants) == 2 &&
 						meal.Price.Equal(price1999)
-				})).
-					Run(func(args mock.Arguments) {
-						// Set an ID on the new meal
-						newMeal := args.Get(0).(*models.Meal)
-						newMeal.ID = 2 // Simulate auto-increment ID
-					}).
-					Return(nil)
+				})).Run(func(args mock.Arguments) {
+					args.Get(0).(*models.Meal).Version = 4
+				}).Return(nil)
+
+				s.mockRepo.On("CreateVersion", mock.MatchedBy(func(version *models.MealVersion) bool {
+					return version.MealID == 1 && version.Version == 4 && version.ImageURL == "new-image.jpg"
+				})).Return(nil)
 
-				// Mock deleting the old meal
-				s.mockRepo.On("Delete", existingMeal).Return(nil)
+				s.mockRepo.On("GetByID", uint(1)).Return(&models.Meal{
+					ID:          1,
+					Name:        "Updated Meal",
+					CategoryID:  1,
+					Category:    &models.Category{ID: 1, Name: "Main Courses"},
+					Description: "Updated Description",
+					Price:       price1999,
+					ImageURL:    "new-image.jpg",
+					Version:     4,
+				}, nil).Once()
 			},
 			expectedError: false,
 			checkMeal: func(meal *models.Meal) {
-				// Verify the input meal was updated with the new meal's properties
+				s.Equal(uint(1), meal.ID, "the meal keeps its id")
+				s.Equal(uint(4), meal.Version)
 				s.Equal("Updated Meal", meal.Name)
-				s.Equal(models.MealCategory("Updated Category"), meal.Category)
-				s.Equal("Updated Description", meal.Description)
+				s.Equal("Main Courses", meal.CategoryName())
 				s.Equal("new-image.jpg", meal.ImageURL)
 				s.True(price1999.Equal(meal.Price))
-				s.Equal(uint(2), meal.ID) // Should have new ID
 			},
 		},
 		{
@@ -375,54 +433,53 @@ func (s *MealServiceTestSuite) TestUpdate() {
 			meal: &models.Meal{
 				ID:          2,
 				Name:        "Updated Meal No Photo",
-				Category:    "Updated Category",
+				CategoryID:  1,
 				Description: "Updated Description",
 				Price:       price1999,
-				ImageURL:    "existing-image.jpg",
 			},
 			photo: nil,
 			setupMock: func() {
-				// Mock getting the existing meal
 				existingMeal := &models.Meal{
 					ID:          2,
 					Name:        "Original Meal",
-					Category:    "Original Category",
+					CategoryID:  2,
 					Description: "Original Description",
 					Price:       decimal.NewFromFloat(9.99),
 					ImageURL:    "existing-image.jpg",
+					Version:     1,
 				}
-				s.mockRepo.On("GetByID", uint(2)).Return(existingMeal, nil)
+				s.mockRepo.On("GetByID", uint(2)).Return(existingMeal, nil).Once()
 
-				// Mock transaction
-				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).
-					Run(func(args mock.Arguments) {
-						// Execute the transaction function with our mock repo
-						txFunc := args.Get(0).(func(repositories.MealRepository) error)
-						txFunc(s.mockRepo)
-					}).Return(nil)
+				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).Return(nil)
 
-				// Mock creating new meal
-				s.mockRepo.On("Create", mock.MatchedBy(func(meal *models.Meal) bool {
-					return meal.Name == "Updated Meal No Photo" &&
-						meal.Category == "Updated Category" &&
-						meal.Description == "Updated Description" &&
-						meal.Price.Equal(price1999) &&
+				s.mockRepo.On("Update", mock.MatchedBy(func(meal *models.Meal) bool {
+					return meal.ID == 2 &&
+						meal.Name == "Updated Meal No Photo" &&
 						meal.ImageURL == "existing-image.jpg"
-				})).
-					Run(func(args mock.Arguments) {
-						newMeal := args.Get(0).(*models.Meal)
-						newMeal.ID = 3
-					}).
-					Return(nil)
+				})).Run(func(args mock.Arguments) {
+					args.Get(0).(*models.Meal).Version = 2
+				}).Return(nil)
+
+				s.mockRepo.On("CreateVersion", mock.MatchedBy(func(version *models.MealVersion) bool {
+					return version.MealID == 2 && version.Version == 2 && version.Name == "Updated Meal No Photo"
+				})).Return(nil)
 
-				s.mockRepo.On("Delete", existingMeal).Return(nil)
+				s.mockRepo.On("GetByID", uint(2)).Return(&models.Meal{
+					ID:          2,
+					Name:        "Updated Meal No Photo",
+					CategoryID:  1,
+					Description: "Updated Description",
+					Price:       price1999,
+					ImageURL:    "existing-image.jpg",
+					Version:     2,
+				}, nil).Once()
 			},
 			expectedError: false,
 			checkMeal: func(meal *models.Meal) {
+				s.Equal(uint(2), meal.ID)
+				s.Equal(uint(2), meal.Version)
 				s.Equal("Updated Meal No Photo", meal.Name)
-				s.Equal(models.MealCategory("Updated Category"), meal.Category)
-				s.Equal("Updated Description", meal.Description)
-				s.Equal(uint(3), meal.ID) // Should have new ID
+				s.Equal("existing-image.jpg", meal.ImageURL)
 			},
 		},
 		{
@@ -430,7 +487,7 @@ func (s *MealServiceTestSuite) TestUpdate() {
 			meal: &models.Meal{
 				ID:          99,
 				Name:        "Nonexistent Meal",
-				Category:    "Category",
+				CategoryID:  1,
 				Description: "Description",
 				Price:       price1999,
 			},
@@ -450,14 +507,12 @@ func (s *MealServiceTestSuite) TestUpdate() {
 			meal: &models.Meal{
 				ID:          3,
 				Name:        "Transaction Error Meal",
-				Category:    "Category",
+				CategoryID:  1,
 				Description: "Description",
 				Price:       price1999,
-				ImageURL:    "image.jpg",
 			},
 			photo: nil,
 			setupMock: func() {
-				// Mock getting the existing meal
 				existingMeal := &models.Meal{
 					ID:          3,
 					Name:        "Original Meal",
@@ -477,59 +532,98 @@ func (s *MealServiceTestSuite) TestUpdate() {
 			},
 		},
 		{
-			name: "Failed to create new meal",
+			name: "Failed to record version deletes the new photo",
 			meal: &models.Meal{
 				ID:          4,
-				Name:        "Create Error Meal",
-				Category:    "Category",
+				Name:        "Version Error Meal",
+				CategoryID:  1,
 				Description: "Description",
 				Price:       price1999,
-				ImageURL:    "image.jpg",
 			},
-			photo: nil,
+			photo: newTestImageFileHeader(s.T(), "new-photo.png"),
 			setupMock: func() {
-				// Mock getting the existing meal
 				existingMeal := &models.Meal{
 					ID:          4,
 					Name:        "Original Meal",
 					Description: "Original Description",
 					Price:       decimal.NewFromFloat(9.99),
 					ImageURL:    "image.jpg",
+					Version:     1,
 				}
 				s.mockRepo.On("GetByID", uint(4)).Return(existingMeal, nil)
 
-				// Mock transaction
-				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).
-					Run(func(args mock.Arguments) {
-						// Execute the transaction function with our mock repo
-						txFunc := args.Get(0).(func(repositories.MealRepository) error)
+				s.mockImageProcessor.On("ProcessCropped", mock.Anything, mock.AnythingOfType("*multipart.FileHeader"), 1000, 1000).
+					Return(newTestProcessedImage("new-image.jpg", "new-image"), nil)
 
-						// Inside transaction, Create returns an error
-						s.mockRepo.On("Create", mock.AnythingOfType("*models.Meal")).
-							Return(apperrors.NewInternalServerErr("Failed to create meal", nil))
+				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).Return(nil)
+				s.mockRepo.On("Update", mock.AnythingOfType("*models.Meal")).Return(nil)
+				s.mockRepo.On("CreateVersion", mock.AnythingOfType("*models.MealVersion")).
+					Return(apperrors.NewInternalServerErr("Failed to create version", nil))
 
-						// Call the function which should now return an error
-						txFunc(s.mockRepo)
-					}).Return(apperrors.NewInternalServerErr("Failed to create meal", nil))
+				s.mockImageProcessor.On("Delete", mock.Anything, []string{"new-image", "new-image-webp"}).Return(nil)
 			},
 			expectedError: true,
 			errorPredicate: func(err error) bool {
-				return apperrors.IsInternalServerErr(err) && err.Error() == "Failed to create meal"
+				return apperrors.IsInternalServerErr(err) && err.Error() == "Failed to create version"
 			},
 		},
+		{
+			name: "Success with uploaded photo",
+			meal: &models.Meal{
+				ID:          6,
+				Name:        "Uploaded Photo Meal",
+				CategoryID:  1,
+				Description: "Description",
+				Price:       price1999,
+			},
+			upload: &models.UploadRef{ID: "upload", Owner: "staff:admin"},
+			setupMock: func() {
+				s.mockRepo.On("GetByID", uint(6)).Return(&models.Meal{ID: 6, ImageURL: "old-image.jpg", Version: 1}, nil).Once()
+
+				// The upload is claimed from the session, nothing is processed
+				s.mockUploadRepo.On("GetUploads", "staff:admin", models.MealUpload, []string{"upload"}, mock.Anything).
+					Return([]*models.Upload{newTestUpload("upload")}, nil)
+				s.mockUploadRepo.On("DeleteUploads", []string{"upload"}).Return(nil)
+
+				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).Return(nil)
+				s.mockRepo.On("Update", mock.MatchedBy(func(meal *models.Meal) bool {
+					return meal.ImageURL == "upload.jpg" && meal.ImagePublicID == "upload" && len(meal.ImageVariants) == 2
+				})).Return(nil)
+				s.mockRepo.On("CreateVersion", mock.AnythingOfType("*models.MealVersion")).Return(nil)
+				s.mockRepo.On("GetByID", uint(6)).Return(&models.Meal{ID: 6, ImageURL: "upload.jpg"}, nil).Once()
+			},
+			checkMeal: func(meal *models.Meal) {
+				s.Equal("upload.jpg", meal.ImageURL)
+			},
+		},
+		{
+			name: "Photo and uploaded photo",
+			meal: &models.Meal{
+				ID:          7,
+				Name:        "Two Photos Meal",
+				CategoryID:  1,
+				Description: "Description",
+				Price:       price1999,
+			},
+			photo:  newTestImageFileHeader(s.T(), "new-photo.png"),
+			upload: &models.UploadRef{ID: "upload", Owner: "staff:admin"},
+			setupMock: func() {
+				s.mockRepo.On("GetByID", uint(7)).Return(&models.Meal{ID: 7, ImageURL: "old-image.jpg"}, nil)
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
 		{
 			name: "Failed to upload new photo",
 			meal: &models.Meal{
 				ID:          5,
 				Name:        "Photo Upload Error Meal",
-				Category:    "Category",
+				CategoryID:  1,
 				Description: "Description",
 				Price:       price1999,
-				ImageURL:    "old-image.jpg",
 			},
-			photo: &multipart.FileHeader{Filename: "new-photo.jpg"},
+			photo: newTestImageFileHeader(s.T(), "new-photo.png"),
 			setupMock: func() {
-				// Mock getting the existing meal
 				existingMeal := &models.Meal{
 					ID:          5,
 					Name:        "Original Meal",
@@ -539,20 +633,9 @@ func (s *MealServiceTestSuite) TestUpdate() {
 				}
 				s.mockRepo.On("GetByID", uint(5)).Return(existingMeal, nil)
 
-				// Mock transaction
-				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).
-					Run(func(args mock.Arguments) {
-						// Execute the transaction function with our mock repo
-						txFunc := args.Get(0).(func(repositories.MealRepository) error)
-
-						// Inside transaction, upload fails
-						uploadErr := apperrors.NewInternalServerErr("Failed to upload photo", nil)
-						s.mockImageStorage.On("UploadCropped", mock.Anything, mock.AnythingOfType("*multipart.FileHeader"), 1000, 1000).
-							Return(nil, uploadErr)
-
-						// Call the function which should return the upload error
-						txFunc(s.mockRepo)
-					}).Return(apperrors.NewInternalServerErr("Failed to upload photo", nil))
+				uploadErr := apperrors.NewInternalServerErr("Failed to upload photo", nil)
+				s.mockImageProcessor.On("ProcessCropped", mock.Anything, mock.AnythingOfType("*multipart.FileHeader"), 1000, 1000).
+					Return(nil, uploadErr)
 			},
 			expectedError: true,
 			errorPredicate: func(err error) bool {
@@ -573,14 +656,14 @@ func (s *MealServiceTestSuite) TestUpdate() {
 			mealCopy := &models.Meal{
 				ID:          tc.meal.ID,
 				Name:        tc.meal.Name,
-				Category:    tc.meal.Category,
+				CategoryID:  tc.meal.CategoryID,
 				Description: tc.meal.Description,
 				Price:       tc.meal.Price,
 				ImageURL:    tc.meal.ImageURL,
 			}
 
 			// Act
-			err := s.mealService.Replace(ctx, mealCopy, tc.photo)
+			err := s.mealService.Update(ctx, mealCopy, tc.photo, tc.upload)
 
 			// Assert
 			if tc.expectedError {
@@ -598,6 +681,24 @@ func (s *MealServiceTestSuite) TestUpdate() {
 	}
 }
 
+// TestGetVersions tests the GetVersions method
+func (s *MealServiceTestSuite) TestGetVersions() {
+	versions := []*models.MealVersion{
+		{MealID: 1, Version: 1, Name: "Pad Thia", Price: decimal.NewFromInt(12)},
+		{MealID: 1, Version: 2, Name: "Pad Thai", Price: decimal.NewFromInt(12)},
+	}
+	s.mockRepo.On("GetVersions", uint(1)).Return(versions, nil)
+	s.mockRepo.On("GetVersions", uint(9)).Return([]*models.MealVersion{}, nil)
+
+	foundVersions, err := s.mealService.GetVersions(1)
+	s.NoError(err)
+	s.Require().Len(foundVersions, 2)
+	s.Equal([]models.MealChange{{Field: "name", From: "Pad Thia", To: "Pad Thai"}}, foundVersions[1].Diff(foundVersions[0]))
+
+	_, err = s.mealService.GetVersions(9)
+	s.True(apperrors.IsNotFoundErr(err))
+}
+
 // TestDelete tests the Delete method
 func (s *MealServiceTestSuite) TestDelete() {
 	testCases := []struct {
@@ -652,11 +753,343 @@ func (s *MealServiceTestSuite) TestDelete() {
 	}
 }
 
+// TestGetAllFiltered tests filtering the meals by allergens, dietary tags and spicy level
+func (s *MealServiceTestSuite) TestGetAllFiltered() {
+	meals := []*models.Meal{
+		{ID: 1, Name: "Pad Thai", Allergens: models.Allergens{models.Peanuts, models.Eggs}, SpicyLevel: 2, SoldOut: true},
+		{ID: 2, Name: "Falafel", DietaryTags: models.DietaryTags{models.Vegan, models.Vegetarian}, Allergens: models.Allergens{models.Sesame}},
+		{ID: 3, Name: "Salad", DietaryTags: models.DietaryTags{models.Vegan, models.GlutenFree}},
+		{ID: 4, Name: "Pizza", DietaryTags: models.DietaryTags{models.Vegetarian}, Allergens: models.Allergens{models.Gluten, models.Milk}},
+	}
+	mildSpicyLevel := uint(1)
+	now := time.Now()
+
+	testCases := []struct {
+		name            string
+		filter          models.MealFilter
+		expectedMealIDs []uint
+		expectedError   bool
+	}{
+		{
+			name:            "No filter",
+			expectedMealIDs: []uint{1, 2, 3, 4},
+		},
+		{
+			name:            "Exclude allergens",
+			filter:          models.MealFilter{ExcludeAllergens: models.Allergens{models.Gluten, models.Peanuts}},
+			expectedMealIDs: []uint{2, 3},
+		},
+		{
+			name:            "Tags",
+			filter:          models.MealFilter{Tags: models.DietaryTags{models.Vegan, models.GlutenFree}},
+			expectedMealIDs: []uint{3},
+		},
+		{
+			name:            "Spicy level",
+			filter:          models.MealFilter{ExcludeAllergens: models.Allergens{models.Sesame}, MaxSpicyLevel: &mildSpicyLevel},
+			expectedMealIDs: []uint{3, 4},
+		},
+		{
+			name:            "Available now",
+			filter:          models.MealFilter{AvailableAt: &now},
+			expectedMealIDs: []uint{2, 3, 4},
+		},
+		{
+			name:          "Invalid allergen",
+			filter:        models.MealFilter{ExcludeAllergens: models.Allergens{"chocolate"}},
+			expectedError: true,
+		},
+		{
+			name:          "Invalid tag",
+			filter:        models.MealFilter{Tags: models.DietaryTags{"keto"}},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			if !tc.expectedError {
+				s.mockMenuRepo.On("GetAll").Return([]*models.Menu{}, nil)
+				s.mockRepo.On("GetAll").Return(meals, nil)
+			}
+
+			// Act
+			filteredMeals, err := s.mealService.GetAll(tc.filter)
+
+			// Assert
+			if tc.expectedError {
+				s.True(apperrors.IsValidationErr(err))
+				return
+			}
+
+			s.NoError(err)
+			mealIDs := make([]uint, len(filteredMeals))
+			for i, meal := range filteredMeals {
+				mealIDs[i] = meal.ID
+			}
+			s.Equal(tc.expectedMealIDs, mealIDs)
+		})
+	}
+}
+
+// TestGetAllActiveMenu tests that only the meals of the active menu are returned, priced and ordered by the menu
+func (s *MealServiceTestSuite) TestGetAllActiveMenu() {
+	meals := []*models.Meal{
+		{ID: 1, Name: "Pad Thai", Price: decimal.RequireFromString("12.90")},
+		{ID: 2, Name: "Falafel", Price: decimal.RequireFromString("9.50"), DietaryTags: models.DietaryTags{models.Vegan}},
+		{ID: 3, Name: "Soup of the day", Price: decimal.RequireFromString("5.00"), DietaryTags: models.DietaryTags{models.Vegan}},
+	}
+	menus := []*models.Menu{
+		{ID: 1, Name: "Lunch", Active: true, Items: []models.MenuItem{
+			{MealID: 3, Price: decimal.RequireFromString("4.00"), DisplayOrder: 2},
+			{MealID: 1, Price: decimal.RequireFromString("10.90"), DisplayOrder: 1},
+		}},
+		{ID: 2, Name: "Closed", Active: false, Priority: 10, Items: []models.MenuItem{
+			{MealID: 2, Price: decimal.RequireFromString("1.00")},
+		}},
+	}
+	s.mockMenuRepo.On("GetAll").Return(menus, nil)
+	s.mockRepo.On("GetAll").Return(meals, nil)
+
+	menuMeals, err := s.mealService.GetAll(models.MealFilter{})
+	s.Require().NoError(err)
+	s.Require().Len(menuMeals, 2)
+	s.Equal("Pad Thai", menuMeals[0].Name)
+	s.True(decimal.RequireFromString("10.90").Equal(menuMeals[0].Price))
+	s.Equal("Soup of the day", menuMeals[1].Name)
+	s.True(decimal.RequireFromString("4.00").Equal(menuMeals[1].Price))
+
+	s.SetupTest()
+	s.mockMenuRepo.On("GetAll").Return(menus, nil)
+	s.mockRepo.On("GetAll").Return(meals, nil)
+
+	menuMeals, err = s.mealService.GetAll(models.MealFilter{Tags: models.DietaryTags{models.Vegan}})
+	s.Require().NoError(err)
+	s.Require().Len(menuMeals, 1)
+	s.Equal(uint(3), menuMeals[0].ID)
+}
+
+// TestSetOptionGroups tests the SetOptionGroups method
+func (s *MealServiceTestSuite) TestSetOptionGroups() {
+	testCases := []struct {
+		name           string
+		optionGroups   []models.OptionGroup
+		expectedError  bool
+		errorPredicate func(error) bool
+	}{
+		{
+			name: "Success",
+			optionGroups: []models.OptionGroup{
+				{Name: "Size", MinSelected: 1, MaxSelected: 1, Options: []models.MealOption{
+					{Name: "Small"}, {Name: "Large", PriceDelta: decimal.NewFromInt(2)},
+				}},
+				{Name: "Remove", MultiSelect: true, MaxSelected: 2, Options: []models.MealOption{
+					{Name: "No onions"}, {Name: "No pickles"},
+				}},
+			},
+		},
+		{
+			name: "Single select group allowing several options",
+			optionGroups: []models.OptionGroup{
+				{Name: "Size", MaxSelected: 2, Options: []models.MealOption{{Name: "Small"}, {Name: "Large"}}},
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name: "Minimum above maximum",
+			optionGroups: []models.OptionGroup{
+				{Name: "Extras", MultiSelect: true, MinSelected: 2, MaxSelected: 1,
+					Options: []models.MealOption{{Name: "Cheese"}, {Name: "Bacon"}}},
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Group without options",
+			optionGroups:   []models.OptionGroup{{Name: "Sauce", MaxSelected: 1}},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			meal := &models.Meal{ID: 1, Name: "Burger", OptionGroups: tc.optionGroups}
+			if !tc.expectedError {
+				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).
+					Return(nil)
+				s.mockRepo.On("GetByID", uint(1)).Return(meal, nil)
+				s.mockRepo.On("ReplaceOptionGroups", uint(1), tc.optionGroups).Return(nil)
+			}
+
+			// Act
+			updatedMeal, err := s.mealService.SetOptionGroups(1, tc.optionGroups)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				if tc.errorPredicate != nil {
+					s.True(tc.errorPredicate(err))
+				}
+			} else {
+				s.NoError(err)
+				s.Equal(meal, updatedMeal)
+			}
+		})
+	}
+}
+
+// TestSetSoldOut tests the SetSoldOut method
+func (s *MealServiceTestSuite) TestSetSoldOut() {
+	meal := &models.Meal{ID: 1, Name: "Salmon", SoldOut: true}
+	s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).Return(nil)
+	s.mockRepo.On("SetSoldOut", uint(1), true).Return(nil)
+	s.mockRepo.On("GetByID", uint(1)).Return(meal, nil)
+
+	updatedMeal, err := s.mealService.SetSoldOut(1, true)
+	s.NoError(err)
+	s.Equal(meal, updatedMeal)
+	s.False(updatedMeal.AvailableAt(time.Now()))
+
+	s.mockRepo.On("SetSoldOut", uint(9), false).Return(apperrors.NewNotFoundErr("Meal with ID 9 not found", nil))
+	_, err = s.mealService.SetSoldOut(9, false)
+	s.True(apperrors.IsNotFoundErr(err))
+}
+
+// TestSetAvailabilityWindows tests the SetAvailabilityWindows method
+func (s *MealServiceTestSuite) TestSetAvailabilityWindows() {
+	testCases := []struct {
+		name          string
+		windows       []models.AvailabilityWindow
+		expectedError bool
+	}{
+		{
+			name: "Success",
+			windows: []models.AvailabilityWindow{
+				models.NewAvailabilityWindow([]time.Weekday{time.Saturday, time.Sunday}, 8*60, 11*60),
+				models.NewAvailabilityWindow([]time.Weekday{time.Friday}, 22*60, 2*60),
+			},
+		},
+		{
+			name:          "No days",
+			windows:       []models.AvailabilityWindow{models.NewAvailabilityWindow(nil, 8*60, 11*60)},
+			expectedError: true,
+		},
+		{
+			name:          "Empty window",
+			windows:       []models.AvailabilityWindow{models.NewAvailabilityWindow([]time.Weekday{time.Monday}, 9*60, 9*60)},
+			expectedError: true,
+		},
+		{
+			name:          "End after midnight",
+			windows:       []models.AvailabilityWindow{models.NewAvailabilityWindow([]time.Weekday{time.Monday}, 9*60, 24*60)},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			meal := &models.Meal{ID: 1, Name: "Pancakes", AvailabilityWindows: tc.windows}
+			if !tc.expectedError {
+				s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).
+					Return(nil)
+				s.mockRepo.On("GetByID", uint(1)).Return(meal, nil)
+				s.mockRepo.On("ReplaceAvailabilityWindows", uint(1), tc.windows).Return(nil)
+			}
+
+			// Act
+			updatedMeal, err := s.mealService.SetAvailabilityWindows(1, tc.windows)
+
+			// Assert
+			if tc.expectedError {
+				s.True(apperrors.IsValidationErr(err))
+			} else {
+				s.NoError(err)
+				s.Equal(meal, updatedMeal)
+			}
+		})
+	}
+}
+
+// TestSetRecipe tests the SetRecipe method
+func (s *MealServiceTestSuite) TestSetRecipe() {
+	recipe := []models.RecipeItem{
+		{IngredientID: 1, Quantity: decimal.RequireFromString("0.120")},
+		{IngredientID: 2, Quantity: decimal.NewFromInt(2)},
+	}
+	meal := &models.Meal{ID: 1, Name: "Pad Thai", Recipe: recipe}
+
+	s.mockIngredientRepo.On("GetByID", uint(1)).Return(&models.Ingredient{ID: 1, Name: "Rice noodles"}, nil)
+	s.mockIngredientRepo.On("GetByID", uint(2)).Return(&models.Ingredient{ID: 2, Name: "Eggs"}, nil)
+	s.mockRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MealRepository) error")).Return(nil)
+	s.mockRepo.On("GetByID", uint(1)).Return(meal, nil)
+	s.mockRepo.On("ReplaceRecipe", uint(1), recipe).Return(nil)
+
+	updatedMeal, err := s.mealService.SetRecipe(1, recipe)
+	s.NoError(err)
+	s.Equal(meal, updatedMeal)
+
+	_, err = s.mealService.SetRecipe(1, []models.RecipeItem{
+		{IngredientID: 1, Quantity: decimal.NewFromInt(1)},
+		{IngredientID: 1, Quantity: decimal.NewFromInt(2)},
+	})
+	s.True(apperrors.IsValidationErr(err), "ingredients cannot be used twice")
+
+	_, err = s.mealService.SetRecipe(1, []models.RecipeItem{{IngredientID: 2, Quantity: decimal.Zero}})
+	s.True(apperrors.IsValidationErr(err), "quantities have to be positive")
+
+	s.mockIngredientRepo.On("GetByID", uint(9)).Return(nil, apperrors.NewNotFoundErr("Ingredient with ID 9 not found", nil))
+	_, err = s.mealService.SetRecipe(1, []models.RecipeItem{{IngredientID: 9, Quantity: decimal.NewFromInt(1)}})
+	s.True(apperrors.IsValidationErr(err))
+}
+
 // Run the test suite
 func TestMealServiceSuite(t *testing.T) {
 	suite.Run(t, new(MealServiceTestSuite))
 }
 
+// newTestImageFileHeader builds a multipart file header backed by a small PNG image,
+// so that the service's image validation can open and sniff it.
+func newTestImageFileHeader(t *testing.T, filename string) *multipart.FileHeader {
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("photo", filename)
+	require.NoError(t, err)
+	_, err = part.Write(img.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	return form.File["photo"][0]
+}
+
+// newTestProcessedImage returns an image processed into a single size, stored in its own format and as WebP.
+func newTestProcessedImage(url, publicID string) *storage.ProcessedImage {
+	return &storage.ProcessedImage{
+		ImageResult: storage.ImageResult{URL: url, PublicID: publicID},
+		Variants: []storage.ImageVariant{
+			{ImageResult: storage.ImageResult{URL: url, PublicID: publicID},
+				Size: "thumbnail", ContentType: "image/jpeg", Width: 2, Height: 2},
+			{ImageResult: storage.ImageResult{URL: url + ".webp", PublicID: publicID + "-webp"},
+				Size: "thumbnail", ContentType: "image/webp", Width: 2, Height: 2},
+		},
+	}
+}
+
 // MockMealRepository implementation
 type MockMealRepository struct {
 	mock.Mock
@@ -670,12 +1103,20 @@ func (m *MockMealRepository) GetByID(id uint) (*models.Meal, error) {
 	return args.Get(0).(*models.Meal), args.Error(1)
 }
 
-func (m *MockMealRepository) GetAll() ([]models.Meal, error) {
+func (m *MockMealRepository) GetAll() ([]*models.Meal, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Meal), args.Error(1)
+}
+
+func (m *MockMealRepository) GetAllWithDeleted() ([]*models.Meal, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]models.Meal), args.Error(1)
+	return args.Get(0).([]*models.Meal), args.Error(1)
 }
 
 func (m *MockMealRepository) Create(meal *models.Meal) error {
@@ -688,6 +1129,11 @@ func (m *MockMealRepository) Delete(meal *models.Meal) error {
 	return args.Error(0)
 }
 
+func (m *MockMealRepository) Uploads() repositories.UploadRepository {
+	args := m.Called()
+	return args.Get(0).(repositories.UploadRepository)
+}
+
 // WithTransaction implementation for the mock repository
 func (m *MockMealRepository) WithTransaction(fn func(txRepo repositories.MealRepository) error) error {
 	args := m.Called(fn)
@@ -704,3 +1150,41 @@ func (m *MockMealRepository) WithTransaction(fn func(txRepo repositories.MealRep
 
 	return err
 }
+
+func (m *MockMealRepository) ReplaceOptionGroups(mealID uint, optionGroups []models.OptionGroup) error {
+	args := m.Called(mealID, optionGroups)
+	return args.Error(0)
+}
+
+func (m *MockMealRepository) SetSoldOut(mealID uint, soldOut bool) error {
+	args := m.Called(mealID, soldOut)
+	return args.Error(0)
+}
+
+func (m *MockMealRepository) ReplaceAvailabilityWindows(mealID uint, windows []models.AvailabilityWindow) error {
+	args := m.Called(mealID, windows)
+	return args.Error(0)
+}
+
+func (m *MockMealRepository) Update(meal *models.Meal) error {
+	args := m.Called(meal)
+	return args.Error(0)
+}
+
+func (m *MockMealRepository) CreateVersion(version *models.MealVersion) error {
+	args := m.Called(version)
+	return args.Error(0)
+}
+
+func (m *MockMealRepository) GetVersions(mealID uint) ([]*models.MealVersion, error) {
+	args := m.Called(mealID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MealVersion), args.Error(1)
+}
+
+func (m *MockMealRepository) ReplaceRecipe(mealID uint, recipe []models.RecipeItem) error {
+	args := m.Called(mealID, recipe)
+	return args.Error(0)
+}
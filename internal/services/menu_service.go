@@ -0,0 +1,194 @@
+package services
+
+import (
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"time"
+)
+
+// MenuService defines operations for managing menus and their schedules
+// and for finding out which menu is offered at a given time.
+type MenuService interface {
+	GetAll() ([]*models.Menu, error)
+	Create(menu *models.Menu) error
+	Update(menu *models.Menu) (*models.Menu, error)
+	Delete(menuID uint) error
+	SetItems(menuID uint, items []models.MenuItem) (*models.Menu, error)
+	SetSchedules(menuID uint, schedules []models.AvailabilityWindow) (*models.Menu, error)
+	Preview(at time.Time) (*models.Menu, []*models.Meal, error)
+}
+
+type menuService struct {
+	menuRepository repositories.MenuRepository
+	mealRepository repositories.MealRepository
+}
+
+func NewMenuService(menuRepository repositories.MenuRepository, mealRepository repositories.MealRepository) MenuService {
+	return &menuService{
+		menuRepository: menuRepository,
+		mealRepository: mealRepository,
+	}
+}
+
+// GetAll retrieves all menus with their items and schedules.
+func (ms *menuService) GetAll() ([]*models.Menu, error) {
+	return ms.menuRepository.GetAll()
+}
+
+// Create validates and adds a new menu without any meals or schedules,
+// returning an error if a menu with the same name already exists.
+func (ms *menuService) Create(menu *models.Menu) error {
+	if err := menu.Validate(); err != nil {
+		return apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	if err := ms.checkNameAvailable(menu); err != nil {
+		return err
+	}
+
+	return ms.menuRepository.Create(menu)
+}
+
+// Update validates and replaces the name, priority, active flag and date range of an existing menu.
+// Returns the updated menu with its items and schedules.
+func (ms *menuService) Update(menu *models.Menu) (*models.Menu, error) {
+	if err := menu.Validate(); err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	if err := ms.checkNameAvailable(menu); err != nil {
+		return nil, err
+	}
+
+	if err := ms.menuRepository.Update(menu); err != nil {
+		return nil, err
+	}
+
+	return ms.menuRepository.GetByID(menu.ID)
+}
+
+// Delete removes a menu along with its items and schedules.
+func (ms *menuService) Delete(menuID uint) error {
+	return ms.menuRepository.Delete(menuID)
+}
+
+// SetItems validates the items and replaces all meals of the menu with them.
+// Every meal of the menu has to exist. Returns the menu with its new items.
+func (ms *menuService) SetItems(menuID uint, items []models.MenuItem) (*models.Menu, error) {
+	if err := models.ValidateMenuItems(items); err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	for _, item := range items {
+		if _, err := ms.mealRepository.GetByID(item.MealID); err != nil {
+			if apperrors.IsNotFoundErr(err) {
+				return nil, apperrors.NewValidationErr(fmt.Sprintf("Meal %d does not exist", item.MealID), err)
+			}
+			return nil, err
+		}
+	}
+
+	var menu *models.Menu
+	err := ms.menuRepository.WithTransaction(func(tx repositories.MenuRepository) error {
+		if _, err := tx.GetByID(menuID); err != nil {
+			return err
+		}
+
+		if err := tx.ReplaceItems(menuID, items); err != nil {
+			return err
+		}
+
+		var err error
+		menu, err = tx.GetByID(menuID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return menu, nil
+}
+
+// SetSchedules validates the schedules and replaces all schedules of the menu with them.
+// Returns the menu with its new schedules.
+func (ms *menuService) SetSchedules(menuID uint, schedules []models.AvailabilityWindow) (*models.Menu, error) {
+	if err := validateAvailabilityWindows(schedules); err != nil {
+		return nil, err
+	}
+
+	var menu *models.Menu
+	err := ms.menuRepository.WithTransaction(func(tx repositories.MenuRepository) error {
+		if _, err := tx.GetByID(menuID); err != nil {
+			return err
+		}
+
+		if err := tx.ReplaceSchedules(menuID, schedules); err != nil {
+			return err
+		}
+
+		var err error
+		menu, err = tx.GetByID(menuID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return menu, nil
+}
+
+// Preview returns the menu active at the given time along with its meals, priced and ordered by the menu.
+// If no menu is active, the menu is nil and all meals are returned at their regular prices.
+func (ms *menuService) Preview(at time.Time) (*models.Menu, []*models.Meal, error) {
+	return menuMealsAt(ms.menuRepository, ms.mealRepository, at)
+}
+
+// checkNameAvailable returns an error if another menu already has the name of the menu.
+func (ms *menuService) checkNameAvailable(menu *models.Menu) error {
+	found, err := ms.menuRepository.GetByName(menu.Name)
+	if err == nil && found.ID != menu.ID {
+		return apperrors.NewAlreadyExistsErr(fmt.Sprintf("Menu %s already exists", menu.Name), nil)
+	}
+
+	if err != nil && !apperrors.IsNotFoundErr(err) {
+		return err
+	}
+
+	return nil
+}
+
+// activeMenuAt returns the menu active at the given time, or nil if no menu is active.
+func activeMenuAt(menuRepository repositories.MenuRepository, at time.Time) (*models.Menu, error) {
+	menus, err := menuRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return models.ActiveMenu(menus, at), nil
+}
+
+// menuMealsAt returns the menu active at the given time along with its meals, priced and ordered by the menu.
+// If no menu is active, the menu is nil and all meals are returned at their regular prices.
+func menuMealsAt(menuRepository repositories.MenuRepository,
+	mealRepository repositories.MealRepository,
+	at time.Time) (*models.Menu, []*models.Meal, error) {
+	menu, err := activeMenuAt(menuRepository, at)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meals, err := mealRepository.GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if menu != nil {
+		meals = menu.Apply(meals)
+	}
+
+	return menu, meals, nil
+}
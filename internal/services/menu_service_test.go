@@ -0,0 +1,343 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// MenuServiceTestSuite defines the test suite for MenuService
+type MenuServiceTestSuite struct {
+	suite.Suite
+	menuService  services.MenuService
+	mockMenuRepo *MockMenuRepository
+	mockMealRepo *MockMealRepository
+}
+
+func (s *MenuServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockMenuRepo = new(MockMenuRepository)
+	s.mockMealRepo = new(MockMealRepository)
+	s.menuService = services.NewMenuService(s.mockMenuRepo, s.mockMealRepo)
+}
+
+// TearDownTest runs after each test
+func (s *MenuServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockMenuRepo.AssertExpectations(s.T())
+	s.mockMealRepo.AssertExpectations(s.T())
+}
+
+// TestCreate tests the Create method
+func (s *MenuServiceTestSuite) TestCreate() {
+	christmasEve := time.Date(2026, time.December, 24, 0, 0, 0, 0, time.UTC)
+	newYear := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		menu           *models.Menu
+		setupMock      func(menu *models.Menu)
+		expectedError  bool
+		errorPredicate func(error) bool
+	}{
+		{
+			name: "Success",
+			menu: &models.Menu{Name: "Christmas", Priority: 10, Active: true, StartDate: &christmasEve, EndDate: &newYear},
+			setupMock: func(menu *models.Menu) {
+				s.mockMenuRepo.On("GetByName", "Christmas").Return(nil, apperrors.NewNotFoundErr("Menu Christmas not found", nil))
+				s.mockMenuRepo.On("Create", menu).Return(nil)
+			},
+		},
+		{
+			name: "Name already exists",
+			menu: &models.Menu{Name: "Lunch", Active: true},
+			setupMock: func(menu *models.Menu) {
+				s.mockMenuRepo.On("GetByName", "Lunch").Return(&models.Menu{ID: 1, Name: "Lunch"}, nil)
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsAlreadyExistsErr,
+		},
+		{
+			name:           "Missing name",
+			menu:           &models.Menu{Name: " "},
+			setupMock:      func(menu *models.Menu) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Ends before it starts",
+			menu:           &models.Menu{Name: "Christmas", StartDate: &newYear, EndDate: &christmasEve},
+			setupMock:      func(menu *models.Menu) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+			tc.setupMock(tc.menu)
+
+			// Act
+			err := s.menuService.Create(tc.menu)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				s.True(tc.errorPredicate(err))
+			} else {
+				s.NoError(err)
+			}
+		})
+	}
+}
+
+// TestSetItems tests the SetItems method
+func (s *MenuServiceTestSuite) TestSetItems() {
+	s.Run("Success", func() {
+		s.SetupTest()
+
+		items := []models.MenuItem{
+			{MealID: 1, Price: decimal.RequireFromString("10.90"), DisplayOrder: 1},
+			{MealID: 2, Price: decimal.RequireFromString("4.00"), DisplayOrder: 2},
+		}
+		menu := &models.Menu{ID: 3, Name: "Lunch", Items: items}
+		s.mockMealRepo.On("GetByID", uint(1)).Return(&models.Meal{ID: 1}, nil)
+		s.mockMealRepo.On("GetByID", uint(2)).Return(&models.Meal{ID: 2}, nil)
+		s.mockMenuRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MenuRepository) error")).Return(nil)
+		s.mockMenuRepo.On("GetByID", uint(3)).Return(menu, nil)
+		s.mockMenuRepo.On("ReplaceItems", uint(3), items).Return(nil)
+
+		updatedMenu, err := s.menuService.SetItems(3, items)
+		s.NoError(err)
+		s.Equal(menu, updatedMenu)
+	})
+
+	s.Run("Meal twice", func() {
+		s.SetupTest()
+
+		_, err := s.menuService.SetItems(3, []models.MenuItem{
+			{MealID: 1, Price: decimal.RequireFromString("10.90")},
+			{MealID: 1, Price: decimal.RequireFromString("9.90")},
+		})
+		s.True(apperrors.IsValidationErr(err))
+	})
+
+	s.Run("Free meal", func() {
+		s.SetupTest()
+
+		_, err := s.menuService.SetItems(3, []models.MenuItem{{MealID: 1, Price: decimal.Zero}})
+		s.True(apperrors.IsValidationErr(err))
+	})
+
+	s.Run("Missing meal", func() {
+		s.SetupTest()
+
+		s.mockMealRepo.On("GetByID", uint(9)).Return(nil, apperrors.NewNotFoundErr("Meal with ID 9 not found", nil))
+
+		_, err := s.menuService.SetItems(3, []models.MenuItem{{MealID: 9, Price: decimal.RequireFromString("10.90")}})
+		s.True(apperrors.IsValidationErr(err))
+	})
+}
+
+// TestSetSchedules tests the SetSchedules method
+func (s *MenuServiceTestSuite) TestSetSchedules() {
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	schedules := []models.AvailabilityWindow{models.NewAvailabilityWindow(weekdays, 11*60, 15*60)}
+	menu := &models.Menu{ID: 3, Name: "Lunch", Schedules: schedules}
+	s.mockMenuRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.MenuRepository) error")).Return(nil)
+	s.mockMenuRepo.On("GetByID", uint(3)).Return(menu, nil)
+	s.mockMenuRepo.On("ReplaceSchedules", uint(3), schedules).Return(nil)
+
+	updatedMenu, err := s.menuService.SetSchedules(3, schedules)
+	s.NoError(err)
+	s.Equal(menu, updatedMenu)
+
+	_, err = s.menuService.SetSchedules(3, []models.AvailabilityWindow{models.NewAvailabilityWindow(weekdays, 11*60, 11*60)})
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// TestPreview tests which menu and meals are offered at different times
+func (s *MenuServiceTestSuite) TestPreview() {
+	christmasEve := time.Date(2026, time.December, 24, 0, 0, 0, 0, time.UTC)
+	christmasDay := time.Date(2026, time.December, 25, 0, 0, 0, 0, time.UTC)
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	menus := []*models.Menu{
+		{ID: 1, Name: "Lunch", Active: true,
+			Schedules: []models.AvailabilityWindow{models.NewAvailabilityWindow(weekdays, 11*60, 15*60)},
+			Items:     []models.MenuItem{{MealID: 1, Price: decimal.RequireFromString("10.90")}}},
+		{ID: 2, Name: "Christmas", Active: true, Priority: 10, StartDate: &christmasEve, EndDate: &christmasDay,
+			Items: []models.MenuItem{{MealID: 2, Price: decimal.RequireFromString("29.00")}}},
+	}
+
+	testCases := []struct {
+		name            string
+		at              time.Time
+		expectedMenu    string
+		expectedMealIDs []uint
+	}{
+		{
+			name:            "Weekday lunch",
+			at:              time.Date(2026, time.December, 23, 12, 0, 0, 0, time.Local),
+			expectedMenu:    "Lunch",
+			expectedMealIDs: []uint{1},
+		},
+		{
+			name:            "Seasonal menu wins over lunch",
+			at:              time.Date(2026, time.December, 24, 12, 0, 0, 0, time.Local),
+			expectedMenu:    "Christmas",
+			expectedMealIDs: []uint{2},
+		},
+		{
+			name:            "Last day of the seasonal menu",
+			at:              time.Date(2026, time.December, 25, 23, 30, 0, 0, time.Local),
+			expectedMenu:    "Christmas",
+			expectedMealIDs: []uint{2},
+		},
+		{
+			name:            "No menu active",
+			at:              time.Date(2026, time.December, 23, 18, 0, 0, 0, time.Local),
+			expectedMealIDs: []uint{1, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+			s.mockMenuRepo.On("GetAll").Return(menus, nil)
+			s.mockMealRepo.On("GetAll").Return([]*models.Meal{
+				{ID: 1, Name: "Pad Thai", Price: decimal.RequireFromString("12.90")},
+				{ID: 2, Name: "Roast goose", Price: decimal.RequireFromString("32.00")},
+			}, nil)
+
+			// Act
+			menu, meals, err := s.menuService.Preview(tc.at)
+
+			// Assert
+			s.Require().NoError(err)
+			if tc.expectedMenu == "" {
+				s.Nil(menu)
+			} else {
+				s.Require().NotNil(menu)
+				s.Equal(tc.expectedMenu, menu.Name)
+			}
+
+			mealIDs := make([]uint, len(meals))
+			for i, meal := range meals {
+				mealIDs[i] = meal.ID
+				if menu != nil {
+					s.True(menu.Item(meal.ID).Price.Equal(meal.Price))
+				}
+			}
+			s.Equal(tc.expectedMealIDs, mealIDs)
+		})
+	}
+}
+
+// TestPreviewTimeZone tests that schedules are checked in the time zone of the restaurant
+func (s *MenuServiceTestSuite) TestPreviewTimeZone() {
+	timeZone := models.TimeZone
+	defer func() { models.TimeZone = timeZone }()
+	models.TimeZone = time.FixedZone("UTC+2", 2*60*60)
+
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	s.mockMenuRepo.On("GetAll").Return([]*models.Menu{
+		{ID: 1, Name: "Lunch", Active: true,
+			Schedules: []models.AvailabilityWindow{models.NewAvailabilityWindow(weekdays, 11*60, 15*60)},
+			Items:     []models.MenuItem{{MealID: 1, Price: decimal.RequireFromString("10.90")}}},
+	}, nil)
+	s.mockMealRepo.On("GetAll").Return([]*models.Meal{
+		{ID: 1, Name: "Pad Thai", Price: decimal.RequireFromString("12.90")},
+	}, nil)
+
+	// 10:00 UTC is 12:00 in the restaurant
+	menu, _, err := s.menuService.Preview(time.Date(2026, time.December, 23, 10, 0, 0, 0, time.UTC))
+	s.Require().NoError(err)
+	s.Require().NotNil(menu)
+	s.Equal("Lunch", menu.Name)
+
+	// 13:30 UTC is 15:30 in the restaurant, after lunch
+	menu, _, err = s.menuService.Preview(time.Date(2026, time.December, 23, 13, 30, 0, 0, time.UTC))
+	s.Require().NoError(err)
+	s.Nil(menu)
+}
+
+// Run the test suite
+func TestMenuServiceSuite(t *testing.T) {
+	suite.Run(t, new(MenuServiceTestSuite))
+}
+
+// MockMenuRepository implementation
+type MockMenuRepository struct {
+	mock.Mock
+}
+
+// WithTransaction implementation for the mock repository
+func (m *MockMenuRepository) WithTransaction(fn func(txRepo repositories.MenuRepository) error) error {
+	args := m.Called(fn)
+
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+
+	return fn(m)
+}
+
+func (m *MockMenuRepository) GetAll() ([]*models.Menu, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Menu), args.Error(1)
+}
+
+func (m *MockMenuRepository) GetByID(ID uint) (*models.Menu, error) {
+	args := m.Called(ID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Menu), args.Error(1)
+}
+
+func (m *MockMenuRepository) GetByName(name string) (*models.Menu, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Menu), args.Error(1)
+}
+
+func (m *MockMenuRepository) Create(menu *models.Menu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) Update(menu *models.Menu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) Delete(ID uint) error {
+	args := m.Called(ID)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) ReplaceItems(menuID uint, items []models.MenuItem) error {
+	args := m.Called(menuID, items)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) ReplaceSchedules(menuID uint, schedules []models.AvailabilityWindow) error {
+	args := m.Called(menuID, schedules)
+	return args.Error(0)
+}
@@ -4,41 +4,61 @@ import (
 	"context"
 	"fmt"
 	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/config"
 	"github.com/Ruclo/MyMeals/internal/events"
 	"github.com/Ruclo/MyMeals/internal/models"
 	"github.com/Ruclo/MyMeals/internal/repositories"
 	"github.com/Ruclo/MyMeals/internal/storage"
+	"github.com/shopspring/decimal"
+	"log"
 	"mime/multipart"
+	"strings"
 	"time"
 )
 
-// OrderService defines operations for managing orders, adding meals, creating reviews, and marking orders as completed.
+// OrderService defines operations for managing orders, adding meals, creating reviews,
+// moving ordered meals through the kitchen workflow, cancelling orders, voiding ordered meals and tipping.
 type OrderService interface {
 	GetByID(id uint) (*models.Order, error)
 	GetOrders(olderThan time.Time, pageSize uint) ([]*models.Order, error)
 	GetAllPendingOrders() ([]*models.Order, error)
-	Create(order *models.Order) error
+	Create(order *models.Order, tableTokenVersion uint) error
 	AddMealsToOrder(meals *[]models.OrderMeal) (*models.Order, error)
-	CreateReview(c context.Context, review *models.Review, photos []*multipart.FileHeader) error
-	MarkCompleted(orderID, mealID uint) (*models.Order, error)
+	CreateReview(c context.Context, review *models.Review, photos []*multipart.FileHeader, uploadIDs []string) error
+	UpdateStatus(statusChange *models.OrderMealStatusChange) (*models.Order, error)
+	Cancel(orderID uint) (*models.Order, error)
+	VoidOrderMeal(void *models.OrderMealVoid) (*models.Order, error)
+	SetTip(orderID uint, amount, percent decimal.Decimal) (*models.Order, error)
 }
 
 type orderService struct {
-	orderRepository  repositories.OrderRepository
-	mealRepository   repositories.MealRepository
-	imageStorage     storage.ImageStorage
-	orderBroadcaster events.OrderBroadcaster
+	orderRepository     repositories.OrderRepository
+	mealRepository      repositories.MealRepository
+	menuRepository      repositories.MenuRepository
+	promotionRepository repositories.PromotionRepository
+	tableRepository     repositories.TableRepository
+	imageProcessor      storage.ImageProcessor
+	orderBroadcaster    events.OrderBroadcaster
+	stockBroadcaster    events.StockBroadcaster
 }
 
 func NewOrderService(orderRepository repositories.OrderRepository,
 	mealRepository repositories.MealRepository,
-	imageStorage storage.ImageStorage,
-	orderBroadcaster events.OrderBroadcaster) OrderService {
+	menuRepository repositories.MenuRepository,
+	promotionRepository repositories.PromotionRepository,
+	tableRepository repositories.TableRepository,
+	imageProcessor storage.ImageProcessor,
+	orderBroadcaster events.OrderBroadcaster,
+	stockBroadcaster events.StockBroadcaster) OrderService {
 	return &orderService{
-		orderRepository:  orderRepository,
-		mealRepository:   mealRepository,
-		imageStorage:     imageStorage,
-		orderBroadcaster: orderBroadcaster,
+		orderRepository:     orderRepository,
+		mealRepository:      mealRepository,
+		menuRepository:      menuRepository,
+		promotionRepository: promotionRepository,
+		tableRepository:     tableRepository,
+		imageProcessor:      imageProcessor,
+		orderBroadcaster:    orderBroadcaster,
+		stockBroadcaster:    stockBroadcaster,
 	}
 }
 
@@ -67,6 +87,7 @@ func (os *orderService) GetOrders(olderThan time.Time, pageSize uint) ([]*models
 }
 
 // GetAllPendingOrders retrieves all orders with a pending status from the order repository.
+// Lines with allergies flagged come first in every order so the kitchen does not miss them.
 func (os *orderService) GetAllPendingOrders() ([]*models.Order, error) {
 	params := repositories.OrderQueryParams{
 		OlderThan:   time.Time{},
@@ -74,16 +95,117 @@ func (os *orderService) GetAllPendingOrders() ([]*models.Order, error) {
 		OnlyPending: true,
 	}
 
-	return os.orderRepository.GetOrders(params)
+	orders, err := os.orderRepository.GetOrders(params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		order.SortAllergiesFirst()
+	}
+
+	return orders, nil
 }
 
-// Create handles the creation of a new order. Broadcasts the newly created order via OrderBroadcaster.
-func (os *orderService) Create(order *models.Order) error {
-	return os.orderRepository.WithTransaction(func(tx repositories.OrderRepository) error {
-		err := tx.Create(order)
+// Create handles the creation of a new order. Lines of the same meal with the same options, note and allergies get merged.
+// The order has to be made to an existing active table
+// and joins the open session of the table, opening one if needed.
+// The customer has to order with the current, not revoked token of the table, tableTokenVersion is its version.
+// Snapshots the name, price and tax rate of every ordered meal, meals are priced by the currently active menu if there is one.
+// Takes the ingredients of the ordered meals off the stock in the same transaction as the order is created.
+// Applies the promotions active right now to the ordered meals, including those of the promo code the customer entered,
+// which has to be valid right now and counts towards its usage limit.
+// Orders made at large tables get charged the service charge, the customer can add a tip to the order.
+// Broadcasts the newly created order via OrderBroadcaster.
+func (os *orderService) Create(order *models.Order, tableTokenVersion uint) error {
+	if err := order.SetTip(order.TipAmount, order.TipPercent); err != nil {
+		return apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	table, err := os.tableRepository.GetByNumber(order.TableNo)
+	if err != nil {
+		if apperrors.IsNotFoundErr(err) {
+			return apperrors.NewValidationErr(fmt.Sprintf("Table %d does not exist", order.TableNo), err)
+		}
+		return err
+	}
+
+	if table.TokenRevoked || table.TokenVersion != tableTokenVersion {
+		return apperrors.NewUnauthorizedErr("Table token is no longer valid", nil)
+	}
+
+	if !table.Active {
+		return apperrors.NewValidationErr(fmt.Sprintf("Table %d is not in use", order.TableNo), nil)
+	}
+	order.ServiceChargeRate = config.ConfigInstance.ServiceChargeRate(table.Seats)
+
+	now := time.Now()
+	menu, err := activeMenuAt(os.menuRepository, now)
+	if err != nil {
+		return err
+	}
+
+	promotions, err := os.promotionRepository.GetAll(true)
+	if err != nil {
+		return err
+	}
+
+	order.PromoCode = models.NormalizePromoCode(order.PromoCode)
+	promoCodePromotion, err := findPromoCodePromotion(promotions, order.PromoCode, now)
+	if err != nil {
+		return err
+	}
+
+	orderMeals := order.OrderMeals
+	order.OrderMeals = nil
+	usage := models.IngredientUsage{}
+	categories := make(map[uint]uint)
+	for _, orderMeal := range orderMeals {
+		meal, err := os.snapshotMeal(&orderMeal, menu, usage)
+		if err != nil {
+			return err
+		}
+		categories[meal.ID] = meal.CategoryID
+
+		if existing := order.FindOrderMeal(&orderMeal); existing != nil {
+			existing.Quantity += orderMeal.Quantity
+		} else {
+			order.OrderMeals = append(order.OrderMeals, orderMeal)
+		}
+	}
+
+	var lowStock []*models.Ingredient
+	err = os.orderRepository.WithTransaction(func(tx repositories.OrderRepository) error {
+		session, err := tx.GetOrOpenTableSession(order.TableNo, time.Now())
 		if err != nil {
 			return err
 		}
+		order.TableSessionID = &session.ID
+
+		err = tx.Create(order)
+		if err != nil {
+			return err
+		}
+
+		lowStock, err = consumeIngredients(tx, usage)
+		if err != nil {
+			return err
+		}
+
+		items := make([]models.PromotionItem, len(order.OrderMeals))
+		for i, orderMeal := range order.OrderMeals {
+			items[i] = promotionItem(&orderMeal, categories, orderMeal.Quantity)
+		}
+
+		if err = applyPromotions(tx, order.ID, promotions, items, order.PromoCode, now); err != nil {
+			return err
+		}
+
+		if promoCodePromotion != nil {
+			if err = tx.RedeemPromotion(promoCodePromotion.ID); err != nil {
+				return err
+			}
+		}
 
 		foundOrder, err := tx.GetByID(order.ID)
 		if err != nil {
@@ -97,9 +219,21 @@ func (os *orderService) Create(order *models.Order) error {
 		*order = *foundOrder
 		return nil
 	})
+
+	if err != nil {
+		return err
+	}
+
+	os.broadcastLowStock(lowStock)
+	return nil
 }
 
-// AddMealsToOrder adds one or more meals to an existing order, updating quantities if meals already exist in the order.
+// AddMealsToOrder adds one or more meals to an existing order which is neither cancelled nor paid.
+// Meals ordered with the same options, note and allergies as an existing line of the order are merged into the line
+// by updating its quantity, other meals get a new line.
+// Lines are only merged if they are priced and taxed the same, so meals ordered after a price change get a new line.
+// Takes the ingredients of the added meals off the stock in the same transaction as the meals are added.
+// Applies the promotions active right now to the added meals, including those of the promo code of the order.
 // It validates the existence of each meal and returns the updated order or an error in case of failure.
 func (os *orderService) AddMealsToOrder(meals *[]models.OrderMeal) (*models.Order, error) {
 
@@ -107,22 +241,48 @@ func (os *orderService) AddMealsToOrder(meals *[]models.OrderMeal) (*models.Orde
 		return nil, apperrors.NewValidationErr("No meals attached", nil)
 	}
 
-	for _, orderMeal := range *meals {
-		_, err := os.mealRepository.GetByID(orderMeal.MealID)
+	now := time.Now()
+	menu, err := activeMenuAt(os.menuRepository, now)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions, err := os.promotionRepository.GetAll(true)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := models.IngredientUsage{}
+	categories := make(map[uint]uint)
+	for i := range *meals {
+		meal, err := os.snapshotMeal(&(*meals)[i], menu, usage)
 		if err != nil {
 			return nil, err
 		}
+		categories[meal.ID] = meal.CategoryID
 	}
 
 	var order *models.Order
+	var lowStock []*models.Ingredient
 
-	err := os.orderRepository.WithTransaction(func(tx repositories.OrderRepository) error {
+	err = os.orderRepository.WithTransaction(func(tx repositories.OrderRepository) error {
+		// The order is locked, so it cannot get paid before the new meals are added
+		existingOrder, err := tx.GetByIDForUpdate((*meals)[0].OrderID)
+		if err != nil {
+			return err
+		}
 
-		for _, orderMeal := range *meals {
-			foundOrderMeal, err := tx.GetOrderMeal(orderMeal.OrderID, orderMeal.MealID)
-			if err != nil && !apperrors.IsNotFoundErr(err) {
-				return err
-			}
+		if existingOrder.CancelledAt != nil {
+			return apperrors.NewValidationErr("Order has been cancelled", nil)
+		}
+
+		if existingOrder.PaidAt != nil {
+			return apperrors.NewValidationErr("Order has already been paid", nil)
+		}
+
+		items := make([]models.PromotionItem, len(*meals))
+		for i, orderMeal := range *meals {
+			foundOrderMeal := existingOrder.FindOrderMeal(&orderMeal)
 
 			if foundOrderMeal != nil {
 				foundOrderMeal.Quantity += orderMeal.Quantity
@@ -130,6 +290,7 @@ func (os *orderService) AddMealsToOrder(meals *[]models.OrderMeal) (*models.Orde
 				if err != nil {
 					return err
 				}
+				items[i] = promotionItem(foundOrderMeal, categories, orderMeal.Quantity)
 			} else {
 				orderMeal.Completed = 0
 
@@ -137,8 +298,20 @@ func (os *orderService) AddMealsToOrder(meals *[]models.OrderMeal) (*models.Orde
 				if err != nil {
 					return err
 				}
+				existingOrder.OrderMeals = append(existingOrder.OrderMeals, orderMeal)
+				items[i] = promotionItem(&orderMeal, categories, orderMeal.Quantity)
 			}
 		}
+
+		lowStock, err = consumeIngredients(tx, usage)
+		if err != nil {
+			return err
+		}
+
+		if err = applyPromotions(tx, existingOrder.ID, promotions, items, existingOrder.PromoCode, now); err != nil {
+			return err
+		}
+
 		foundOrder, err := tx.GetByID((*meals)[0].OrderID)
 		if err != nil {
 			return err
@@ -153,14 +326,158 @@ func (os *orderService) AddMealsToOrder(meals *[]models.OrderMeal) (*models.Orde
 	if err != nil {
 		return nil, err
 	}
+
+	os.broadcastLowStock(lowStock)
 	return order, nil
 }
 
-// CreateReview handles the creation of a review for a specified order, uploads photos
-// and broadcasts the updated order.
-func (os *orderService) CreateReview(c context.Context, review *models.Review, photos []*multipart.FileHeader) error {
-	const MaxReviewPhotos = 3
-	if len(photos) > MaxReviewPhotos {
+// snapshotMeal looks up the ordered meal, checks that it can be ordered right now,
+// validates the flagged allergies and the chosen options and copies the name, price, tax rate
+// and the chosen options onto the order meal. Adds the ingredients used by the ordered meal to usage.
+// If a menu is active, the meal has to be on the menu and is priced by it. Returns the ordered meal.
+func (os *orderService) snapshotMeal(orderMeal *models.OrderMeal, menu *models.Menu, usage models.IngredientUsage) (*models.Meal, error) {
+	if err := orderMeal.Allergens.Valid(); err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	meal, err := os.mealRepository.GetByID(orderMeal.MealID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = meal.CheckAvailable(time.Now()); err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	if menu != nil {
+		if err = menu.PriceMeal(meal); err != nil {
+			return nil, apperrors.NewValidationErr(err.Error(), err)
+		}
+	}
+
+	options, err := meal.ResolveOptions(orderMeal.OptionIDs())
+	if err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	orderMeal.SnapshotMeal(meal, options, config.ConfigInstance.VATRate(meal.CategoryName()))
+	usage.Add(meal.Recipe, orderMeal.Quantity)
+	return meal, nil
+}
+
+// findPromoCodePromotion returns the active promotion of the promo code, or nil if no promo code was entered.
+// Returns a validation error if the promo code does not exist, is used up or cannot be used at the given time.
+func findPromoCodePromotion(promotions []*models.Promotion, code string, at time.Time) (*models.Promotion, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	for _, promotion := range promotions {
+		if promotion.Code == nil || *promotion.Code != code {
+			continue
+		}
+
+		if promotion.UsedUp() {
+			return nil, apperrors.NewValidationErr(fmt.Sprintf("Promo code %s has been used up", code), nil)
+		}
+
+		if !promotion.AppliesAt(at, code) {
+			return nil, apperrors.NewValidationErr(fmt.Sprintf("Promo code %s cannot be used right now", code), nil)
+		}
+
+		return promotion, nil
+	}
+
+	return nil, apperrors.NewValidationErr(fmt.Sprintf("Promo code %s is not valid", code), nil)
+}
+
+// promotionItem describes quantity units of the order meal for the promotions.
+// categories maps the ids of the ordered meals to their categories.
+func promotionItem(orderMeal *models.OrderMeal, categories map[uint]uint, quantity uint) models.PromotionItem {
+	return models.PromotionItem{
+		OrderMealID: orderMeal.ID,
+		MealID:      orderMeal.MealID,
+		CategoryID:  categories[orderMeal.MealID],
+		UnitPrice:   orderMeal.UnitPrice,
+		Quantity:    quantity,
+	}
+}
+
+// applyPromotions grants the discounts of the promotions applying at the given time to the items of the order
+// within the transaction.
+func applyPromotions(tx repositories.OrderRepository, orderID uint, promotions []*models.Promotion,
+	items []models.PromotionItem, code string, at time.Time) error {
+	discounts := models.ApplyPromotions(promotions, items, code, at)
+	if len(discounts) == 0 {
+		return nil
+	}
+
+	for i := range discounts {
+		discounts[i].OrderID = orderID
+	}
+
+	return tx.CreateDiscounts(discounts)
+}
+
+// consumeIngredients takes the used ingredients off the stock within the transaction
+// and returns the ingredients which dropped to their low stock threshold.
+// Fails with a validation error if there is not enough of an ingredient in stock.
+func consumeIngredients(tx repositories.OrderRepository, usage models.IngredientUsage) ([]*models.Ingredient, error) {
+	if len(usage) == 0 {
+		return nil, nil
+	}
+
+	ingredients, err := tx.ConsumeIngredients(usage)
+	if err != nil {
+		return nil, err
+	}
+
+	var lowStock []*models.Ingredient
+	for _, ingredient := range ingredients {
+		if ingredient.BecameLowStock(usage[ingredient.ID]) {
+			lowStock = append(lowStock, ingredient)
+		}
+	}
+
+	return lowStock, nil
+}
+
+// returnIngredients puts the ingredients of meals which are not going to be prepared back on the stock
+// within the transaction. portions maps the ids of the meals to the number of their portions.
+func returnIngredients(tx repositories.OrderRepository, portions map[uint]uint) error {
+	usage := models.IngredientUsage{}
+	for mealID, count := range portions {
+		recipe, err := tx.GetRecipe(mealID)
+		if err != nil {
+			return err
+		}
+		usage.Add(recipe, count)
+	}
+
+	if len(usage) == 0 {
+		return nil
+	}
+
+	return tx.ReturnIngredients(usage)
+}
+
+// broadcastLowStock notifies staff about the ingredients which dropped to their low stock threshold.
+// It runs once the order is stored, so failed notifications are logged instead of failing the order.
+func (os *orderService) broadcastLowStock(ingredients []*models.Ingredient) {
+	for _, ingredient := range ingredients {
+		if err := os.stockBroadcaster.BroadcastLowStock(ingredient); err != nil {
+			log.Printf("Failed to broadcast low stock of %s: %v", ingredient.Name, err)
+		}
+	}
+}
+
+// CreateReview handles the creation of a review for a specified order, uploads the variants of photos,
+// attaches the photos uploaded into upload sessions of the order beforehand and broadcasts the updated order.
+func (os *orderService) CreateReview(c context.Context,
+	review *models.Review,
+	photos []*multipart.FileHeader,
+	uploadIDs []string) error {
+	if len(photos)+len(uploadIDs) > models.MaxReviewPhotos {
 		return apperrors.NewValidationErr("Too many review photos attached", nil)
 	}
 
@@ -173,59 +490,116 @@ func (os *orderService) CreateReview(c context.Context, review *models.Review, p
 		return apperrors.NewAlreadyExistsErr("Order already has a review", nil)
 	}
 
-	var results []*storage.ImageResult
+	if err = review.ValidateMealRatings(order.OrderMeals); err != nil {
+		return apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	var images []*storage.ProcessedImage
 
 	for _, photo := range photos {
-		var result *storage.ImageResult
-		result, err = os.imageStorage.Upload(c, photo)
+		var image *storage.ProcessedImage
+		image, err = os.imageProcessor.Process(c, photo)
 		if err != nil {
 			break
 		}
 
-		results = append(results, result)
+		images = append(images, image)
+	}
+
+	if err == nil {
+		err = os.orderRepository.WithTransaction(func(tx repositories.OrderRepository) error {
+			// The uploads stay in their sessions if the review fails to be created
+			uploads, err := claimUploads(tx.Uploads(), models.OrderUploadOwner(review.OrderID),
+				models.ReviewUpload, uploadIDs)
+			if err != nil {
+				return err
+			}
+
+			setReviewPhotos(review, uploads, images)
+			return tx.CreateReview(review)
+		})
 	}
 
 	// Try to delete photos on error
 	if err != nil {
-		for _, result := range results {
-			if os.imageStorage.Delete(c, result.PublicID) != nil {
-				fmt.Println("Failed to delete photo with public ID:", result.PublicID)
+		for _, image := range images {
+			if err := os.imageProcessor.Delete(c, image.PublicIDs()...); err != nil {
+				log.Printf("Failed to delete review photo %s: %v", image.PublicID, err)
 			}
 		}
 		return err
 
 	}
 
-	var photoUrls []string
+	os.orderBroadcaster.BroadcastOrder(order) //:c
+	return nil
+}
+
+// setReviewPhotos attaches the uploads followed by the processed images to the review.
+func setReviewPhotos(review *models.Review, uploads []*models.Upload, images []*storage.ProcessedImage) {
+	var photoUrls, photoPublicIDs []string
+	var photoVariants models.PhotoVariants
 
-	for _, result := range results {
-		photoUrls = append(photoUrls, result.URL)
+	for _, upload := range uploads {
+		photoUrls = append(photoUrls, upload.ImageURL)
+		photoPublicIDs = append(photoPublicIDs, upload.ImagePublicID)
+		photoVariants = append(photoVariants, upload.ImageVariants)
+	}
+
+	for _, image := range images {
+		photoUrls = append(photoUrls, image.URL)
+		photoPublicIDs = append(photoPublicIDs, image.PublicID)
+		photoVariants = append(photoVariants, toImageVariants(image))
 	}
 
 	review.PhotoURLs = photoUrls
-	os.orderBroadcaster.BroadcastOrder(order) //:c
-	return os.orderRepository.CreateReview(review)
+	review.PhotoPublicIDs = photoPublicIDs
+	review.PhotoVariants = photoVariants
 }
 
-// MarkCompleted marks an order meal as fully completed in terms of quantity and
-// updates the associated order in the database.
-func (os *orderService) MarkCompleted(orderID, mealID uint) (*models.Order, error) {
+// UpdateStatus moves units of an order meal from one kitchen status to another, records the transition
+// and broadcasts the updated order.
+// If the source status is empty, the usual previous status of the target status is used.
+// If the quantity is zero, all units in the source status are moved.
+func (os *orderService) UpdateStatus(statusChange *models.OrderMealStatusChange) (*models.Order, error) {
+	if err := statusChange.ToStatus.Valid(); err != nil {
+		return nil, apperrors.NewValidationErr("Invalid target status", err)
+	}
+
+	if statusChange.FromStatus == "" {
+		statusChange.FromStatus = statusChange.ToStatus.Previous()
+	}
+
+	if err := statusChange.FromStatus.Valid(); err != nil {
+		return nil, apperrors.NewValidationErr("Invalid source status", err)
+	}
 
 	var order *models.Order
 	err := os.orderRepository.WithTransaction(func(tx repositories.OrderRepository) error {
-		orderMeal, err := tx.GetOrderMeal(orderID, mealID)
+		orderMeal, err := tx.GetOrderMeal(statusChange.OrderID, statusChange.OrderMealID)
 		if err != nil {
 			return err
 		}
 
-		orderMeal.Completed = orderMeal.Quantity
+		if statusChange.Quantity == 0 {
+			statusChange.Quantity = orderMeal.CountIn(statusChange.FromStatus)
+		}
 
-		err = tx.UpdateOrderMeal(orderMeal)
+		err = orderMeal.Transition(statusChange.FromStatus, statusChange.ToStatus, statusChange.Quantity)
 		if err != nil {
+			return apperrors.NewValidationErr(err.Error(), err)
+		}
+
+		if err = tx.UpdateOrderMeal(orderMeal); err != nil {
 			return err
 		}
 
-		order, err = tx.GetByID(orderID)
+		statusChange.ChangedAt = time.Now()
+		if err = tx.CreateStatusChange(statusChange); err != nil {
+			return err
+		}
+
+		order, err = tx.GetByID(statusChange.OrderID)
 		if err != nil {
 			return err
 		}
@@ -243,3 +617,197 @@ func (os *orderService) MarkCompleted(orderID, mealID uint) (*models.Order, erro
 	return order, err
 
 }
+
+// Cancel cancels an order on behalf of the customer, cancelling all of its meals.
+// Orders can only be cancelled before the kitchen starts working on them.
+// Puts the ingredients of the cancelled meals back on the stock in the same transaction
+// and releases the promo code of the order, so the order no longer counts towards its usage limit.
+// Broadcasts the cancelled order.
+func (os *orderService) Cancel(orderID uint) (*models.Order, error) {
+	var order *models.Order
+	err := os.orderRepository.WithTransaction(func(tx repositories.OrderRepository) error {
+		foundOrder, err := tx.GetByID(orderID)
+		if err != nil {
+			return err
+		}
+
+		if foundOrder.CancelledAt != nil {
+			return apperrors.NewValidationErr("Order has already been cancelled", nil)
+		}
+
+		if foundOrder.Started() {
+			return apperrors.NewValidationErr("Order cannot be cancelled after the kitchen started preparing it", nil)
+		}
+
+		now := time.Now()
+		portions := make(map[uint]uint)
+		for _, orderMeal := range foundOrder.OrderMeals {
+			pending := orderMeal.CountIn(models.PendingStatus)
+			if pending == 0 {
+				continue
+			}
+			portions[orderMeal.MealID] += pending
+
+			if err = orderMeal.Transition(models.PendingStatus, models.CancelledStatus, pending); err != nil {
+				return apperrors.NewValidationErr(err.Error(), err)
+			}
+
+			if err = tx.UpdateOrderMeal(&orderMeal); err != nil {
+				return err
+			}
+
+			err = tx.CreateStatusChange(&models.OrderMealStatusChange{
+				OrderID:     orderMeal.OrderID,
+				OrderMealID: orderMeal.ID,
+				FromStatus:  models.PendingStatus,
+				ToStatus:    models.CancelledStatus,
+				Quantity:    pending,
+				ChangedBy:   models.CustomerActor,
+				ChangedAt:   now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = returnIngredients(tx, portions); err != nil {
+			return err
+		}
+
+		if foundOrder.PromoCode != "" {
+			if err = tx.ReleasePromoCode(foundOrder.PromoCode); err != nil {
+				return err
+			}
+		}
+
+		if err = tx.Cancel(orderID, now); err != nil {
+			return err
+		}
+
+		if foundOrder.TableSessionID != nil {
+			if err = tx.CloseSettledSession(*foundOrder.TableSessionID, now); err != nil {
+				return err
+			}
+		}
+
+		order, err = tx.GetByID(orderID)
+		if err != nil {
+			return err
+		}
+		return os.orderBroadcaster.BroadcastOrder(order)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// VoidOrderMeal takes units of an order meal off the order on behalf of a staff member,
+// appends the void to the void log and broadcasts the updated order.
+// The ingredients of voided pending units are put back on the stock, the kitchen has not used them yet.
+// If the source status is empty, pending units are voided.
+// If the quantity is zero, all units in the source status are voided. Meals of paid or cancelled orders cannot be voided.
+func (os *orderService) VoidOrderMeal(void *models.OrderMealVoid) (*models.Order, error) {
+	if strings.TrimSpace(void.Reason) == "" {
+		return nil, apperrors.NewValidationErr("Void reason is required", nil)
+	}
+
+	if void.FromStatus == "" {
+		void.FromStatus = models.PendingStatus
+	}
+
+	if err := void.FromStatus.Valid(); err != nil {
+		return nil, apperrors.NewValidationErr("Invalid source status", err)
+	}
+
+	var order *models.Order
+	err := os.orderRepository.WithTransaction(func(tx repositories.OrderRepository) error {
+		foundOrder, err := tx.GetByID(void.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if err = checkOrderOpen(foundOrder); err != nil {
+			return err
+		}
+
+		orderMeal, err := tx.GetOrderMeal(void.OrderID, void.OrderMealID)
+		if err != nil {
+			return err
+		}
+
+		if void.Quantity == 0 {
+			void.Quantity = orderMeal.CountIn(void.FromStatus)
+		}
+
+		if err = orderMeal.Void(void.FromStatus, void.Quantity); err != nil {
+			return apperrors.NewValidationErr(err.Error(), err)
+		}
+
+		if err = tx.UpdateOrderMeal(orderMeal); err != nil {
+			return err
+		}
+
+		void.VoidedAt = time.Now()
+		if err = tx.CreateVoid(void); err != nil {
+			return err
+		}
+
+		if void.FromStatus == models.PendingStatus {
+			if err = returnIngredients(tx, map[uint]uint{orderMeal.MealID: void.Quantity}); err != nil {
+				return err
+			}
+		}
+
+		order, err = tx.GetByID(void.OrderID)
+		if err != nil {
+			return err
+		}
+		return os.orderBroadcaster.BroadcastOrder(order)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// SetTip sets the tip the customer adds to the bill of an order, either a fixed amount or a percentage of the bill.
+// The tip can be changed until the order is paid. Returns the updated order.
+func (os *orderService) SetTip(orderID uint, amount, percent decimal.Decimal) (*models.Order, error) {
+	var order *models.Order
+	err := os.orderRepository.WithTransaction(func(tx repositories.OrderRepository) error {
+		foundOrder, err := tx.GetByID(orderID)
+		if err != nil {
+			return err
+		}
+
+		if foundOrder.CancelledAt != nil {
+			return apperrors.NewValidationErr("Order has been cancelled", nil)
+		}
+
+		if foundOrder.PaidAt != nil {
+			return apperrors.NewValidationErr("Order has already been paid", nil)
+		}
+
+		if err = foundOrder.SetTip(amount, percent); err != nil {
+			return apperrors.NewValidationErr(err.Error(), err)
+		}
+
+		if err = tx.UpdateTip(foundOrder); err != nil {
+			return err
+		}
+
+		order, err = tx.GetByID(orderID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
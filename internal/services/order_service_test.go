@@ -0,0 +1,1547 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/Ruclo/MyMeals/internal/testing/mocks"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// OrderServiceTestSuite defines the test suite for OrderService
+type OrderServiceTestSuite struct {
+	suite.Suite
+	orderService       services.OrderService
+	mockOrderRepo      *MockOrderRepository
+	mockMealRepo       *MockMealRepository
+	mockMenuRepo       *MockMenuRepository
+	noActiveMenu       *mock.Call
+	mockPromotionRepo  *MockPromotionRepository
+	noPromotions       *mock.Call
+	mockTableRepo      *MockTableRepository
+	mockUploadRepo     *MockUploadRepository
+	mockImageProcessor *mocks.MockImageProcessor
+	mockBroadcaster    *mocks.MockOrderBroadcaster
+	mockStock          *mocks.MockStockBroadcaster
+}
+
+func (s *OrderServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockOrderRepo = new(MockOrderRepository)
+	s.mockMealRepo = new(MockMealRepository)
+	s.mockMenuRepo = new(MockMenuRepository)
+	s.mockPromotionRepo = new(MockPromotionRepository)
+	s.mockTableRepo = new(MockTableRepository)
+	s.mockUploadRepo = new(MockUploadRepository)
+	s.mockImageProcessor = new(mocks.MockImageProcessor)
+	s.mockBroadcaster = new(mocks.MockOrderBroadcaster)
+	s.mockStock = new(mocks.MockStockBroadcaster)
+
+	// Meals are ordered without any menu being active unless stated otherwise
+	s.noActiveMenu = s.mockMenuRepo.On("GetAll").Return([]*models.Menu{}, nil).Maybe()
+	// and without any promotions
+	s.noPromotions = s.mockPromotionRepo.On("GetAll", true).Return([]*models.Promotion{}, nil).Maybe()
+
+	s.orderService = services.NewOrderService(s.mockOrderRepo, s.mockMealRepo, s.mockMenuRepo, s.mockPromotionRepo,
+		s.mockTableRepo, s.mockImageProcessor, s.mockBroadcaster, s.mockStock)
+	s.mockOrderRepo.On("Uploads").Return(s.mockUploadRepo).Maybe()
+}
+
+// TearDownTest runs after each test
+func (s *OrderServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockOrderRepo.AssertExpectations(s.T())
+	s.mockMealRepo.AssertExpectations(s.T())
+	s.mockMenuRepo.AssertExpectations(s.T())
+	s.mockPromotionRepo.AssertExpectations(s.T())
+	s.mockTableRepo.AssertExpectations(s.T())
+	s.mockUploadRepo.AssertExpectations(s.T())
+	s.mockImageProcessor.AssertExpectations(s.T())
+	s.mockBroadcaster.AssertExpectations(s.T())
+	s.mockStock.AssertExpectations(s.T())
+}
+
+// TestCreate tests the Create method
+func (s *OrderServiceTestSuite) TestCreate() {
+	burger := &models.Meal{ID: 1, Name: "Burger", Category: &models.Category{Name: "Main Courses", Active: true}, Price: decimal.RequireFromString("12.50")}
+	lemonade := &models.Meal{ID: 2, Name: "Lemonade", Category: &models.Category{Name: "Drinks", Active: true}, Price: decimal.RequireFromString("3.20")}
+
+	s.Run("Success", func() {
+		s.SetupTest()
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{
+			{MealID: 1, Quantity: 2},
+			{MealID: 2, Quantity: 1},
+		}}
+		s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+		s.mockMealRepo.On("GetByID", uint(1)).Return(burger, nil)
+		s.mockMealRepo.On("GetByID", uint(2)).Return(lemonade, nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+			Return(nil)
+		s.mockOrderRepo.On("GetOrOpenTableSession", 4, mock.AnythingOfType("time.Time")).
+			Return(&models.TableSession{ID: 3, TableNo: 4}, nil)
+		s.mockOrderRepo.On("Create", order).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Order).ID = 7
+		}).Return(nil)
+		s.mockOrderRepo.On("GetByID", uint(7)).Return(order, nil)
+		s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+
+		s.NoError(s.orderService.Create(order, 1))
+
+		s.Require().NotNil(order.TableSessionID)
+		s.Equal(uint(3), *order.TableSessionID)
+		s.Equal("Burger", order.OrderMeals[0].MealName)
+		s.True(burger.Price.Equal(order.OrderMeals[0].UnitPrice))
+		s.Equal("Lemonade", order.OrderMeals[1].MealName)
+
+		// Prices change after the order was placed, the snapshot does not
+		burger.Price = decimal.RequireFromString("99")
+		bill := order.Bill()
+		s.True(decimal.RequireFromString("28.20").Equal(bill.Subtotal))
+		s.True(decimal.RequireFromString("28.20").Equal(bill.Total))
+	})
+
+	s.Run("Ingredients", func() {
+		s.SetupTest()
+
+		beefBurger := &models.Meal{ID: 5, Name: "Beef burger", Price: decimal.RequireFromString("14.00"), Recipe: []models.RecipeItem{
+			{IngredientID: 1, Quantity: decimal.NewFromInt(1)},
+			{IngredientID: 2, Quantity: decimal.RequireFromString("0.150")},
+		}}
+		buns := &models.Ingredient{ID: 1, Name: "Buns", Unit: "pcs", Stock: decimal.NewFromInt(9), LowStockThreshold: decimal.NewFromInt(10)}
+		beef := &models.Ingredient{ID: 2, Name: "Beef", Unit: "kg", Stock: decimal.NewFromInt(4), LowStockThreshold: decimal.NewFromInt(1)}
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 5, Quantity: 2}}}
+		s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+		s.mockMealRepo.On("GetByID", uint(5)).Return(beefBurger, nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+			Return(nil)
+		s.mockOrderRepo.On("GetOrOpenTableSession", 4, mock.AnythingOfType("time.Time")).
+			Return(&models.TableSession{ID: 3, TableNo: 4}, nil)
+		s.mockOrderRepo.On("Create", order).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Order).ID = 7
+		}).Return(nil)
+		s.mockOrderRepo.On("ConsumeIngredients", mock.MatchedBy(func(usage models.IngredientUsage) bool {
+			return len(usage) == 2 && usage[1].Equal(decimal.NewFromInt(2)) && usage[2].Equal(decimal.RequireFromString("0.3"))
+		})).Return([]*models.Ingredient{buns, beef}, nil)
+		s.mockStock.On("BroadcastLowStock", buns).Return(errors.New("no listeners"))
+		s.mockOrderRepo.On("GetByID", uint(7)).Return(order, nil)
+		s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+
+		s.NoError(s.orderService.Create(order, 1), "failed low stock notifications do not fail the order")
+	})
+
+	s.Run("Low stock is not broadcast if the order fails", func() {
+		s.SetupTest()
+
+		beefBurger := &models.Meal{ID: 5, Name: "Beef burger", Price: decimal.RequireFromString("14.00"), Recipe: []models.RecipeItem{
+			{IngredientID: 1, Quantity: decimal.NewFromInt(1)},
+		}}
+		buns := &models.Ingredient{ID: 1, Name: "Buns", Unit: "pcs", Stock: decimal.NewFromInt(9), LowStockThreshold: decimal.NewFromInt(10)}
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 5, Quantity: 2}}}
+		s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+		s.mockMealRepo.On("GetByID", uint(5)).Return(beefBurger, nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+			Return(nil)
+		s.mockOrderRepo.On("GetOrOpenTableSession", 4, mock.AnythingOfType("time.Time")).
+			Return(&models.TableSession{ID: 3, TableNo: 4}, nil)
+		s.mockOrderRepo.On("Create", order).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Order).ID = 7
+		}).Return(nil)
+		s.mockOrderRepo.On("ConsumeIngredients", mock.Anything).Return([]*models.Ingredient{buns}, nil)
+		s.mockOrderRepo.On("GetByID", uint(7)).Return(nil, apperrors.NewInternalServerErr("Database error", nil))
+
+		err := s.orderService.Create(order, 1)
+		s.True(apperrors.IsInternalServerErr(err))
+		s.mockStock.AssertNotCalled(s.T(), "BroadcastLowStock", mock.Anything)
+	})
+
+	s.Run("Ingredients out of stock", func() {
+		s.SetupTest()
+
+		omelette := &models.Meal{ID: 6, Name: "Omelette", Price: decimal.RequireFromString("9.00"), Recipe: []models.RecipeItem{
+			{IngredientID: 3, Quantity: decimal.NewFromInt(3)},
+		}}
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 6, Quantity: 4}}}
+		s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+		s.mockMealRepo.On("GetByID", uint(6)).Return(omelette, nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+			Return(nil)
+		s.mockOrderRepo.On("GetOrOpenTableSession", 4, mock.AnythingOfType("time.Time")).
+			Return(&models.TableSession{ID: 3, TableNo: 4}, nil)
+		s.mockOrderRepo.On("Create", order).Return(nil)
+		s.mockOrderRepo.On("ConsumeIngredients", mock.Anything).
+			Return(nil, apperrors.NewValidationErr("Not enough Eggs in stock", nil))
+
+		err := s.orderService.Create(order, 1)
+		s.True(apperrors.IsValidationErr(err))
+	})
+
+	s.Run("Active menu", func() {
+		s.SetupTest()
+
+		lunch := &models.Menu{ID: 1, Name: "Lunch", Active: true, Items: []models.MenuItem{
+			{MealID: 8, Price: decimal.RequireFromString("8.90")},
+		}}
+		s.noActiveMenu.Unset()
+		s.mockMenuRepo.On("GetAll").Return([]*models.Menu{lunch}, nil)
+
+		soup := &models.Meal{ID: 8, Name: "Soup of the day", Price: decimal.RequireFromString("6.50")}
+		steak := &models.Meal{ID: 9, Name: "Steak", Price: decimal.RequireFromString("29.00")}
+		s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+		s.mockMealRepo.On("GetByID", uint(8)).Return(soup, nil)
+		s.mockMealRepo.On("GetByID", uint(9)).Return(steak, nil)
+
+		// Meals which are not on the active menu cannot be ordered
+		err := s.orderService.Create(&models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{
+			{MealID: 8, Quantity: 1},
+			{MealID: 9, Quantity: 1},
+		}}, 1)
+		s.True(apperrors.IsValidationErr(err))
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 8, Quantity: 2}}}
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+			Return(nil)
+		s.mockOrderRepo.On("GetOrOpenTableSession", 4, mock.AnythingOfType("time.Time")).
+			Return(&models.TableSession{ID: 3, TableNo: 4}, nil)
+		s.mockOrderRepo.On("Create", order).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Order).ID = 7
+		}).Return(nil)
+		s.mockOrderRepo.On("GetByID", uint(7)).Return(order, nil)
+		s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+
+		s.NoError(s.orderService.Create(order, 1))
+		s.True(decimal.RequireFromString("8.90").Equal(order.OrderMeals[0].UnitPrice))
+	})
+
+	s.Run("Options and identical lines", func() {
+		s.SetupTest()
+
+		pizza := &models.Meal{ID: 3, Name: "Pizza", Category: &models.Category{Name: "Main Courses", Active: true}, Price: decimal.RequireFromString("9.00"),
+			OptionGroups: []models.OptionGroup{
+				{Name: "Size", MinSelected: 1, MaxSelected: 1, Options: []models.MealOption{
+					{ID: 1, Name: "Small"},
+					{ID: 2, Name: "Large", PriceDelta: decimal.RequireFromString("3.00")},
+				}},
+				{Name: "Extras", MultiSelect: true, MaxSelected: 2, Options: []models.MealOption{
+					{ID: 3, Name: "Olives", PriceDelta: decimal.RequireFromString("0.50")},
+					{ID: 4, Name: "Ham", PriceDelta: decimal.RequireFromString("1.50")},
+				}},
+			}}
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{
+			{MealID: 3, Quantity: 1, Options: []models.OrderMealOption{{MealOptionID: 4}, {MealOptionID: 2}}},
+			{MealID: 3, Quantity: 1, Options: []models.OrderMealOption{{MealOptionID: 1}}},
+			{MealID: 3, Quantity: 2, Options: []models.OrderMealOption{{MealOptionID: 2}, {MealOptionID: 4}}},
+		}}
+		s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+		s.mockMealRepo.On("GetByID", uint(3)).Return(pizza, nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+			Return(nil)
+		s.mockOrderRepo.On("GetOrOpenTableSession", 4, mock.AnythingOfType("time.Time")).
+			Return(&models.TableSession{ID: 3, TableNo: 4}, nil)
+		s.mockOrderRepo.On("Create", order).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Order).ID = 7
+		}).Return(nil)
+		s.mockOrderRepo.On("GetByID", uint(7)).Return(order, nil)
+		s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+
+		s.NoError(s.orderService.Create(order, 1))
+
+		s.Require().Len(order.OrderMeals, 2)
+		s.Equal(uint(3), order.OrderMeals[0].Quantity)
+		s.Equal([]uint{2, 4}, order.OrderMeals[0].OptionIDs())
+		s.True(decimal.RequireFromString("13.50").Equal(order.OrderMeals[0].UnitPrice))
+		s.Equal("Size", order.OrderMeals[0].Options[0].GroupName)
+		s.True(decimal.RequireFromString("9.00").Equal(order.OrderMeals[1].UnitPrice))
+	})
+
+	s.Run("Invalid options", func() {
+		pizza := &models.Meal{ID: 3, Name: "Pizza", Price: decimal.RequireFromString("9.00"),
+			OptionGroups: []models.OptionGroup{
+				{Name: "Size", MinSelected: 1, MaxSelected: 1, Options: []models.MealOption{
+					{ID: 1, Name: "Small"}, {ID: 2, Name: "Large"},
+				}},
+			}}
+
+		for _, optionIDs := range [][]uint{{}, {1, 2}, {1, 1}, {1, 9}} {
+			s.SetupTest()
+
+			options := make([]models.OrderMealOption, len(optionIDs))
+			for i, optionID := range optionIDs {
+				options[i] = models.OrderMealOption{MealOptionID: optionID}
+			}
+
+			order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 3, Quantity: 1, Options: options}}}
+			s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+			s.mockMealRepo.On("GetByID", uint(3)).Return(pizza, nil)
+
+			err := s.orderService.Create(order, 1)
+			s.True(apperrors.IsValidationErr(err), "options %v must be rejected", optionIDs)
+		}
+	})
+
+	s.Run("Meal not found", func() {
+		s.SetupTest()
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 9, Quantity: 1}}}
+		s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+		s.mockMealRepo.On("GetByID", uint(9)).Return(nil, apperrors.NewNotFoundErr("Meal not found", nil))
+
+		err := s.orderService.Create(order, 1)
+		s.Error(err)
+		s.True(apperrors.IsNotFoundErr(err))
+	})
+
+	s.Run("Unavailable meals", func() {
+		laterThisWeek := (time.Now().Weekday() + 2) % 7
+		outsideWindow := models.NewAvailabilityWindow([]time.Weekday{laterThisWeek}, 7*60, 11*60)
+
+		unavailableMeals := []*models.Meal{
+			{ID: 5, Name: "Salmon", Price: decimal.NewFromInt(20), SoldOut: true},
+			{ID: 5, Name: "Fish and chips", Price: decimal.NewFromInt(15), Recipe: []models.RecipeItem{
+				{IngredientID: 1, Quantity: decimal.NewFromInt(1), Ingredient: &models.Ingredient{Name: "Cod", Stock: decimal.RequireFromString("0.5")}},
+			}},
+			{ID: 5, Name: "Pancakes", Price: decimal.NewFromInt(8), AvailabilityWindows: []models.AvailabilityWindow{outsideWindow}},
+			{ID: 5, Name: "Omelette", Price: decimal.NewFromInt(9),
+				Category: &models.Category{Name: "Breakfast", Active: true, AvailabilityWindows: []models.AvailabilityWindow{outsideWindow}}},
+			{ID: 5, Name: "Eggs Benedict", Price: decimal.NewFromInt(11), Category: &models.Category{Name: "Brunch"}},
+		}
+
+		for _, meal := range unavailableMeals {
+			s.SetupTest()
+
+			order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 5, Quantity: 1}}}
+			s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+			s.mockMealRepo.On("GetByID", uint(5)).Return(meal, nil)
+
+			err := s.orderService.Create(order, 1)
+			s.True(apperrors.IsValidationErr(err), "%s must not be orderable", meal.Name)
+		}
+	})
+
+	s.Run("Table does not exist", func() {
+		s.SetupTest()
+
+		order := &models.Order{TableNo: 40, OrderMeals: []models.OrderMeal{{MealID: 1, Quantity: 1}}}
+		s.mockTableRepo.On("GetByNumber", 40).Return(nil, apperrors.NewNotFoundErr("Table 40 not found", nil))
+
+		err := s.orderService.Create(order, 1)
+		s.Error(err)
+		s.True(apperrors.IsValidationErr(err))
+	})
+
+	s.Run("Rotated table token", func() {
+		s.SetupTest()
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 1, Quantity: 1}}}
+		s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 2}, nil)
+
+		err := s.orderService.Create(order, 1)
+		s.Error(err)
+		s.True(apperrors.IsUnauthorizedErr(err))
+	})
+
+	s.Run("Revoked table token", func() {
+		s.SetupTest()
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 1, Quantity: 1}}}
+		s.mockTableRepo.On("GetByNumber", 4).
+			Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1, TokenRevoked: true}, nil)
+
+		err := s.orderService.Create(order, 1)
+		s.Error(err)
+		s.True(apperrors.IsUnauthorizedErr(err))
+	})
+
+	s.Run("Table not in use", func() {
+		s.SetupTest()
+
+		order := &models.Order{TableNo: 4, OrderMeals: []models.OrderMeal{{MealID: 1, Quantity: 1}}}
+		s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: false, TokenVersion: 1}, nil)
+
+		err := s.orderService.Create(order, 1)
+		s.Error(err)
+		s.True(apperrors.IsValidationErr(err))
+	})
+}
+
+// TestCreatePromotions tests the discounts promotions grant to new orders
+func (s *OrderServiceTestSuite) TestCreatePromotions() {
+	mains, drinks := uint(1), uint(2)
+	burger := &models.Meal{ID: 1, Name: "Burger", CategoryID: mains, Category: &models.Category{Name: "Main Courses", Active: true},
+		Price: decimal.RequireFromString("12.50")}
+	lemonade := &models.Meal{ID: 2, Name: "Lemonade", CategoryID: drinks, Category: &models.Category{Name: "Drinks", Active: true},
+		Price: decimal.RequireFromString("3.20")}
+
+	happyHour := &models.Promotion{ID: 1, Name: "Happy hour", Kind: models.PercentageOffPromotion, Active: true,
+		CategoryID: &drinks, Percent: decimal.NewFromInt(50)}
+	burgerDeal := &models.Promotion{ID: 2, Name: "Three for two", Kind: models.BuyXGetYPromotion, Active: true,
+		CategoryID: &mains, BuyQuantity: 2, FreeQuantity: 1}
+	combo := &models.Promotion{ID: 3, Name: "Burger menu", Kind: models.ComboPromotion, Active: true, Priority: 5,
+		Amount:     decimal.RequireFromString("14.00"),
+		ComboMeals: []models.PromotionComboMeal{{MealID: 1}, {MealID: 2}}}
+	welcome := "WELCOME"
+	welcomeCode := &models.Promotion{ID: 4, Name: "Welcome", Kind: models.FixedOffPromotion, Active: true,
+		Amount: decimal.RequireFromString("2.00"), Code: &welcome}
+	laterThisWeek := (time.Now().Weekday() + 2) % 7
+	lateHappyHour := &models.Promotion{ID: 5, Name: "Late happy hour", Kind: models.PercentageOffPromotion, Active: true,
+		Percent: decimal.NewFromInt(50),
+		Windows: []models.AvailabilityWindow{models.NewAvailabilityWindow([]time.Weekday{laterThisWeek}, 22*60, 23*60)}}
+
+	testCases := []struct {
+		name              string
+		promotions        []*models.Promotion
+		promoCode         string
+		burgers           uint
+		lemonades         uint
+		expectedDiscounts []models.OrderDiscount
+		expectedRedeemed  uint
+	}{
+		{
+			name:       "Percentage off a category",
+			promotions: []*models.Promotion{happyHour},
+			burgers:    2,
+			lemonades:  2,
+			expectedDiscounts: []models.OrderDiscount{
+				{OrderMealID: 2, Name: "Happy hour", UnitAmount: decimal.RequireFromString("1.60"), Quantity: 2},
+			},
+		},
+		{
+			name:       "Buy two get one free",
+			promotions: []*models.Promotion{burgerDeal},
+			burgers:    4,
+			lemonades:  1,
+			expectedDiscounts: []models.OrderDiscount{
+				{OrderMealID: 1, Name: "Three for two", UnitAmount: decimal.RequireFromString("12.50"), Quantity: 1},
+			},
+		},
+		{
+			name:       "Combo",
+			promotions: []*models.Promotion{combo},
+			burgers:    2,
+			lemonades:  1,
+			expectedDiscounts: []models.OrderDiscount{
+				{OrderMealID: 1, Name: "Burger menu", UnitAmount: decimal.RequireFromString("1.35"), Quantity: 1},
+				{OrderMealID: 2, Name: "Burger menu", UnitAmount: decimal.RequireFromString("0.35"), Quantity: 1},
+			},
+		},
+		{
+			name:       "Every unit is discounted once by priority",
+			promotions: []*models.Promotion{happyHour, combo},
+			burgers:    1,
+			lemonades:  2,
+			expectedDiscounts: []models.OrderDiscount{
+				{OrderMealID: 1, Name: "Burger menu", UnitAmount: decimal.RequireFromString("1.35"), Quantity: 1},
+				{OrderMealID: 2, Name: "Burger menu", UnitAmount: decimal.RequireFromString("0.35"), Quantity: 1},
+				{OrderMealID: 2, Name: "Happy hour", UnitAmount: decimal.RequireFromString("1.60"), Quantity: 1},
+			},
+		},
+		{
+			name:       "Outside of the window",
+			promotions: []*models.Promotion{lateHappyHour},
+			burgers:    1,
+			lemonades:  1,
+		},
+		{
+			name:       "Promo code",
+			promotions: []*models.Promotion{welcomeCode, happyHour},
+			promoCode:  " welcome ",
+			burgers:    1,
+			lemonades:  1,
+			expectedDiscounts: []models.OrderDiscount{
+				{OrderMealID: 1, Name: "Welcome", UnitAmount: decimal.RequireFromString("2.00"), Quantity: 1},
+				{OrderMealID: 2, Name: "Welcome", UnitAmount: decimal.RequireFromString("2.00"), Quantity: 1},
+			},
+			expectedRedeemed: 4,
+		},
+		{
+			name:       "Promo code not entered",
+			promotions: []*models.Promotion{welcomeCode},
+			burgers:    1,
+			lemonades:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+			s.noPromotions.Unset()
+			s.mockPromotionRepo.On("GetAll", true).Return(tc.promotions, nil)
+
+			order := &models.Order{TableNo: 4, PromoCode: tc.promoCode, OrderMeals: []models.OrderMeal{
+				{MealID: 1, Quantity: tc.burgers},
+				{MealID: 2, Quantity: tc.lemonades},
+			}}
+			s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+			s.mockMealRepo.On("GetByID", uint(1)).Return(burger, nil)
+			s.mockMealRepo.On("GetByID", uint(2)).Return(lemonade, nil)
+			s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+				Return(nil)
+			s.mockOrderRepo.On("GetOrOpenTableSession", 4, mock.AnythingOfType("time.Time")).
+				Return(&models.TableSession{ID: 3, TableNo: 4}, nil)
+			s.mockOrderRepo.On("Create", order).Run(func(args mock.Arguments) {
+				createdOrder := args.Get(0).(*models.Order)
+				createdOrder.ID = 7
+				for i := range createdOrder.OrderMeals {
+					createdOrder.OrderMeals[i].ID = uint(i + 1)
+				}
+			}).Return(nil)
+
+			var discounts []models.OrderDiscount
+			if len(tc.expectedDiscounts) > 0 {
+				s.mockOrderRepo.On("CreateDiscounts", mock.Anything).Run(func(args mock.Arguments) {
+					discounts = args.Get(0).([]models.OrderDiscount)
+				}).Return(nil)
+			}
+			if tc.expectedRedeemed != 0 {
+				s.mockOrderRepo.On("RedeemPromotion", tc.expectedRedeemed).Return(nil)
+			}
+			s.mockOrderRepo.On("GetByID", uint(7)).Return(order, nil)
+			s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+
+			// Act
+			err := s.orderService.Create(order, 1)
+
+			// Assert
+			s.Require().NoError(err)
+			s.Equal(models.NormalizePromoCode(tc.promoCode), order.PromoCode)
+			s.Require().Len(discounts, len(tc.expectedDiscounts))
+			for i, expected := range tc.expectedDiscounts {
+				s.Equal(uint(7), discounts[i].OrderID)
+				s.Equal(expected.OrderMealID, discounts[i].OrderMealID)
+				s.Equal(expected.Name, discounts[i].Name)
+				s.True(expected.UnitAmount.Equal(discounts[i].UnitAmount), "%s != %s", expected.UnitAmount, discounts[i].UnitAmount)
+				s.Equal(expected.Quantity, discounts[i].Quantity)
+			}
+		})
+	}
+}
+
+// TestCreateInvalidPromoCode tests that orders with promo codes which cannot be used are rejected
+func (s *OrderServiceTestSuite) TestCreateInvalidPromoCode() {
+	code := "SPRING"
+	limit := uint(100)
+	testCases := []struct {
+		name      string
+		promotion *models.Promotion
+	}{
+		{
+			name: "Unknown promo code",
+		},
+		{
+			name: "Used up",
+			promotion: &models.Promotion{ID: 1, Name: "Spring", Kind: models.FixedOffPromotion, Active: true,
+				Amount: decimal.NewFromInt(5), Code: &code, UsageLimit: &limit, UsageCount: 100},
+		},
+		{
+			name: "Outside of the window",
+			promotion: &models.Promotion{ID: 1, Name: "Spring", Kind: models.FixedOffPromotion, Active: true,
+				Amount: decimal.NewFromInt(5), Code: &code, Windows: []models.AvailabilityWindow{
+					models.NewAvailabilityWindow([]time.Weekday{(time.Now().Weekday() + 2) % 7}, 7*60, 8*60),
+				}},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+			if tc.promotion != nil {
+				s.noPromotions.Unset()
+				s.mockPromotionRepo.On("GetAll", true).Return([]*models.Promotion{tc.promotion}, nil)
+			}
+
+			order := &models.Order{TableNo: 4, PromoCode: "spring", OrderMeals: []models.OrderMeal{{MealID: 1, Quantity: 1}}}
+			s.mockTableRepo.On("GetByNumber", 4).Return(&models.Table{Number: 4, Seats: 2, Active: true, TokenVersion: 1}, nil)
+
+			// Act
+			err := s.orderService.Create(order, 1)
+
+			// Assert
+			s.True(apperrors.IsValidationErr(err))
+		})
+	}
+}
+
+// TestAddMealsToOrder tests the AddMealsToOrder method
+func (s *OrderServiceTestSuite) TestAddMealsToOrder() {
+	burger := &models.Meal{ID: 1, Name: "Burger", Category: &models.Category{Name: "Main Courses", Active: true}, Price: decimal.RequireFromString("12.50"),
+		OptionGroups: []models.OptionGroup{
+			{Name: "Remove", MultiSelect: true, MaxSelected: 1, Options: []models.MealOption{{ID: 5, Name: "No onions"}}},
+		}}
+
+	existingOrder := &models.Order{ID: 1, OrderMeals: []models.OrderMeal{
+		{ID: 10, OrderID: 1, MealID: 1, Quantity: 1, UnitPrice: decimal.RequireFromString("12.50")},
+	}}
+	meals := []models.OrderMeal{
+		{OrderID: 1, MealID: 1, Quantity: 2},
+		{OrderID: 1, MealID: 1, Quantity: 1, Options: []models.OrderMealOption{{MealOptionID: 5}}},
+		{OrderID: 1, MealID: 1, Quantity: 1, Note: "No bun", Allergens: models.Allergens{models.Gluten}},
+	}
+
+	s.mockMealRepo.On("GetByID", uint(1)).Return(burger, nil)
+	s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+		Return(nil)
+	s.mockOrderRepo.On("GetByIDForUpdate", uint(1)).Return(existingOrder, nil)
+	s.mockOrderRepo.On("GetByID", uint(1)).Return(existingOrder, nil)
+	s.mockOrderRepo.On("UpdateOrderMeal", mock.MatchedBy(func(orderMeal *models.OrderMeal) bool {
+		return orderMeal.ID == 10 && orderMeal.Quantity == 3
+	})).Return(nil).Once()
+	s.mockOrderRepo.On("CreateOrderMeal", mock.MatchedBy(func(orderMeal *models.OrderMeal) bool {
+		return orderMeal.MealID == 1 && len(orderMeal.Options) == 1 && orderMeal.Quantity == 1
+	})).Return(nil).Once()
+	s.mockOrderRepo.On("CreateOrderMeal", mock.MatchedBy(func(orderMeal *models.OrderMeal) bool {
+		return orderMeal.MealID == 1 && orderMeal.Note == "No bun" && orderMeal.HasAllergies()
+	})).Return(nil).Once()
+	s.mockBroadcaster.On("BroadcastOrder", existingOrder).Return(nil)
+
+	order, err := s.orderService.AddMealsToOrder(&meals)
+	s.NoError(err)
+	s.Equal(existingOrder, order)
+	s.Len(order.OrderMeals, 3)
+
+	invalidMeals := []models.OrderMeal{{OrderID: 1, MealID: 1, Quantity: 1, Allergens: models.Allergens{"shellfish"}}}
+	_, err = s.orderService.AddMealsToOrder(&invalidMeals)
+	s.True(apperrors.IsValidationErr(err), "unknown allergens must be rejected")
+}
+
+// TestAddMealsToOrderPriceChange tests that meals ordered after a price change are not merged into the earlier line
+func (s *OrderServiceTestSuite) TestAddMealsToOrderPriceChange() {
+	burger := &models.Meal{ID: 1, Name: "Burger", Price: decimal.RequireFromString("12.50")}
+	existingOrder := &models.Order{ID: 1, OrderMeals: []models.OrderMeal{
+		{ID: 10, OrderID: 1, MealID: 1, Quantity: 1, UnitPrice: decimal.RequireFromString("11.00")},
+	}}
+	meals := []models.OrderMeal{{OrderID: 1, MealID: 1, Quantity: 2}}
+
+	s.mockMealRepo.On("GetByID", uint(1)).Return(burger, nil)
+	s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+		Return(nil)
+	s.mockOrderRepo.On("GetByIDForUpdate", uint(1)).Return(existingOrder, nil)
+	s.mockOrderRepo.On("GetByID", uint(1)).Return(existingOrder, nil)
+	s.mockOrderRepo.On("CreateOrderMeal", mock.MatchedBy(func(orderMeal *models.OrderMeal) bool {
+		return orderMeal.Quantity == 2 && orderMeal.UnitPrice.Equal(decimal.RequireFromString("12.50"))
+	})).Return(nil).Once()
+	s.mockBroadcaster.On("BroadcastOrder", existingOrder).Return(nil)
+
+	order, err := s.orderService.AddMealsToOrder(&meals)
+	s.NoError(err)
+	s.Require().Len(order.OrderMeals, 2)
+	s.Equal(uint(1), order.OrderMeals[0].Quantity)
+	s.True(decimal.RequireFromString("11.00").Equal(order.OrderMeals[0].UnitPrice), "the earlier line keeps its price")
+}
+
+// TestAddMealsToOrderPaid tests that meals cannot be added to a paid order
+func (s *OrderServiceTestSuite) TestAddMealsToOrderPaid() {
+	paidAt := time.Now()
+	burger := &models.Meal{ID: 1, Name: "Burger", Price: decimal.RequireFromString("12.50")}
+	meals := []models.OrderMeal{{OrderID: 1, MealID: 1, Quantity: 1}}
+
+	s.mockMealRepo.On("GetByID", uint(1)).Return(burger, nil)
+	s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+		Return(nil)
+	s.mockOrderRepo.On("GetByIDForUpdate", uint(1)).Return(&models.Order{ID: 1, PaidAt: &paidAt}, nil)
+
+	_, err := s.orderService.AddMealsToOrder(&meals)
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// TestAddMealsToOrderPromotions tests that the promo code of an order applies to meals added to it
+func (s *OrderServiceTestSuite) TestAddMealsToOrderPromotions() {
+	code := "WELCOME"
+	s.noPromotions.Unset()
+	s.mockPromotionRepo.On("GetAll", true).Return([]*models.Promotion{
+		{ID: 4, Name: "Welcome", Kind: models.FixedOffPromotion, Active: true, Amount: decimal.NewFromInt(1), Code: &code},
+	}, nil)
+
+	burger := &models.Meal{ID: 1, Name: "Burger", Price: decimal.RequireFromString("12.50")}
+	existingOrder := &models.Order{ID: 1, PromoCode: "WELCOME", OrderMeals: []models.OrderMeal{
+		{ID: 10, OrderID: 1, MealID: 1, Quantity: 1, UnitPrice: decimal.RequireFromString("12.50")},
+	}}
+	meals := []models.OrderMeal{{OrderID: 1, MealID: 1, Quantity: 2}}
+
+	s.mockMealRepo.On("GetByID", uint(1)).Return(burger, nil)
+	s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+		Return(nil)
+	s.mockOrderRepo.On("GetByIDForUpdate", uint(1)).Return(existingOrder, nil)
+	s.mockOrderRepo.On("GetByID", uint(1)).Return(existingOrder, nil)
+	s.mockOrderRepo.On("UpdateOrderMeal", mock.AnythingOfType("*models.OrderMeal")).Return(nil)
+	s.mockOrderRepo.On("CreateDiscounts", mock.MatchedBy(func(discounts []models.OrderDiscount) bool {
+		return len(discounts) == 1 && discounts[0].OrderID == 1 && discounts[0].OrderMealID == 10 &&
+			discounts[0].Quantity == 2 && discounts[0].UnitAmount.Equal(decimal.NewFromInt(1))
+	})).Return(nil)
+	s.mockBroadcaster.On("BroadcastOrder", existingOrder).Return(nil)
+
+	_, err := s.orderService.AddMealsToOrder(&meals)
+	s.NoError(err)
+}
+
+// TestGetAllPendingOrders tests that lines with allergies come first in pending orders
+func (s *OrderServiceTestSuite) TestGetAllPendingOrders() {
+	orders := []*models.Order{
+		{ID: 1, OrderMeals: []models.OrderMeal{
+			{ID: 1, MealID: 1, Quantity: 1},
+			{ID: 2, MealID: 2, Quantity: 1, Allergens: models.Allergens{models.Milk}},
+			{ID: 3, MealID: 3, Quantity: 1},
+			{ID: 4, MealID: 1, Quantity: 1, Allergens: models.Allergens{models.Nuts}},
+		}},
+		{ID: 2, OrderMeals: []models.OrderMeal{{ID: 5, MealID: 1, Quantity: 1}}},
+	}
+	s.mockOrderRepo.On("GetOrders", repositories.OrderQueryParams{OnlyPending: true}).Return(orders, nil)
+
+	pending, err := s.orderService.GetAllPendingOrders()
+	s.NoError(err)
+	s.Require().Len(pending, 2)
+	s.True(pending[0].HasAllergies())
+	s.False(pending[1].HasAllergies())
+
+	var orderMealIDs []uint
+	for _, orderMeal := range pending[0].OrderMeals {
+		orderMealIDs = append(orderMealIDs, orderMeal.ID)
+	}
+	s.Equal([]uint{2, 4, 1, 3}, orderMealIDs)
+}
+
+// TestBill tests the bill calculation of an order
+func (s *OrderServiceTestSuite) TestBill() {
+	order := &models.Order{OrderMeals: []models.OrderMeal{
+		{MealID: 1, UnitPrice: decimal.RequireFromString("10.00"), TaxRate: decimal.RequireFromString("0.1"),
+			Quantity: 3, Cancelled: 1},
+		{MealID: 2, UnitPrice: decimal.RequireFromString("2.55"), TaxRate: decimal.RequireFromString("0.2"),
+			Quantity: 2, Voided: 1},
+		{MealID: 3, UnitPrice: decimal.RequireFromString("4.45"), TaxRate: decimal.RequireFromString("0.1"),
+			Quantity: 1},
+	}}
+
+	bill := order.Bill()
+
+	s.True(decimal.RequireFromString("27.00").Equal(bill.Subtotal))
+	s.Require().Len(bill.TaxLines, 2)
+	s.True(decimal.RequireFromString("0.1").Equal(bill.TaxLines[0].Rate))
+	s.True(decimal.RequireFromString("24.45").Equal(bill.TaxLines[0].Base))
+	s.True(decimal.RequireFromString("2.45").Equal(bill.TaxLines[0].Amount))
+	s.True(decimal.RequireFromString("0.51").Equal(bill.TaxLines[1].Amount))
+	s.True(decimal.RequireFromString("29.96").Equal(bill.Total))
+}
+
+// TestBillDiscounts tests that discounts lower the taxed base and do not count for voided or cancelled meals
+func (s *OrderServiceTestSuite) TestBillDiscounts() {
+	happyHour := uint(1)
+	order := &models.Order{
+		OrderMeals: []models.OrderMeal{
+			{ID: 1, MealID: 1, UnitPrice: decimal.RequireFromString("10.00"), TaxRate: decimal.RequireFromString("0.1"),
+				Quantity: 3, Voided: 1},
+			{ID: 2, MealID: 2, UnitPrice: decimal.RequireFromString("4.00"), TaxRate: decimal.RequireFromString("0.2"),
+				Quantity: 2},
+		},
+		Discounts: []models.OrderDiscount{
+			{ID: 1, OrderMealID: 1, PromotionID: &happyHour, Name: "Happy hour", UnitAmount: decimal.RequireFromString("5.00"), Quantity: 3},
+			{ID: 2, OrderMealID: 2, PromotionID: &happyHour, Name: "Happy hour", UnitAmount: decimal.RequireFromString("2.00"), Quantity: 1},
+		},
+	}
+
+	bill := order.Bill()
+
+	s.True(decimal.RequireFromString("28.00").Equal(bill.Subtotal))
+	s.Require().Len(bill.Discounts, 1)
+	s.Equal("Happy hour", bill.Discounts[0].Name)
+	s.True(decimal.RequireFromString("12.00").Equal(bill.Discounts[0].Amount), "the voided meal is not discounted")
+	s.Require().Len(bill.TaxLines, 2)
+	s.True(decimal.RequireFromString("10.00").Equal(bill.TaxLines[0].Base))
+	s.True(decimal.RequireFromString("6.00").Equal(bill.TaxLines[1].Base))
+	s.True(decimal.RequireFromString("18.20").Equal(bill.Total))
+}
+
+// TestBillServiceChargeAndTip tests that the service charge is not taxed and a percentage tip includes it
+func (s *OrderServiceTestSuite) TestBillServiceChargeAndTip() {
+	order := &models.Order{
+		ServiceChargeRate: decimal.RequireFromString("0.1"),
+		TipPercent:        decimal.NewFromInt(10),
+		OrderMeals: []models.OrderMeal{
+			{ID: 1, MealID: 1, UnitPrice: decimal.RequireFromString("10.00"), TaxRate: decimal.RequireFromString("0.1"),
+				Quantity: 2},
+		},
+	}
+
+	bill := order.Bill()
+
+	s.True(decimal.RequireFromString("2.00").Equal(bill.ServiceCharge))
+	s.Require().Len(bill.TaxLines, 1)
+	s.True(decimal.RequireFromString("20.00").Equal(bill.TaxLines[0].Base), "the service charge is not taxed")
+	s.True(decimal.RequireFromString("2.40").Equal(bill.Tip))
+	s.True(decimal.RequireFromString("26.40").Equal(bill.Total))
+}
+
+// TestSetTip tests the SetTip method
+func (s *OrderServiceTestSuite) TestSetTip() {
+	paidAt := time.Now()
+
+	testCases := []struct {
+		name           string
+		order          *models.Order
+		amount         string
+		percent        string
+		expectedError  bool
+		errorPredicate func(error) bool
+	}{
+		{
+			name:    "Fixed amount",
+			order:   &models.Order{ID: 1},
+			amount:  "2.50",
+			percent: "0",
+		},
+		{
+			name:    "Percentage",
+			order:   &models.Order{ID: 1, TipAmount: decimal.NewFromInt(2)},
+			amount:  "0",
+			percent: "15",
+		},
+		{
+			name:           "Both amount and percentage",
+			order:          &models.Order{ID: 1},
+			amount:         "2",
+			percent:        "10",
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Order already paid",
+			order:          &models.Order{ID: 1, PaidAt: &paidAt},
+			amount:         "2",
+			percent:        "0",
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			amount := decimal.RequireFromString(tc.amount)
+			percent := decimal.RequireFromString(tc.percent)
+			s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+				Return(nil)
+			s.mockOrderRepo.On("GetByID", uint(1)).Return(tc.order, nil)
+			if !tc.expectedError {
+				s.mockOrderRepo.On("UpdateTip", mock.MatchedBy(func(order *models.Order) bool {
+					return order.TipAmount.Equal(amount) && order.TipPercent.Equal(percent)
+				})).Return(nil)
+			}
+
+			// Act
+			order, err := s.orderService.SetTip(1, amount, percent)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				if tc.errorPredicate != nil {
+					s.True(tc.errorPredicate(err))
+				}
+			} else {
+				s.NoError(err)
+				s.Equal(tc.order, order)
+			}
+		})
+	}
+}
+
+// TestCreateReviewMealRatings tests rating single meals of the order in a review
+func (s *OrderServiceTestSuite) TestCreateReviewMealRatings() {
+	longComment := strings.Repeat("a", models.MaxMealRatingCommentLength+1)
+
+	testCases := []struct {
+		name           string
+		mealRatings    []models.ReviewMealRating
+		expectedMeals  []uint
+		errorPredicate func(error) bool
+	}{
+		{
+			name:          "Rated meals of the order",
+			mealRatings:   []models.ReviewMealRating{{OrderMealID: 10, Rating: 2}, {OrderMealID: 11, Rating: 5}},
+			expectedMeals: []uint{1, 2},
+		},
+		{
+			name:           "Meal of another order",
+			mealRatings:    []models.ReviewMealRating{{OrderMealID: 99, Rating: 4}},
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Voided meal",
+			mealRatings:    []models.ReviewMealRating{{OrderMealID: 12, Rating: 1}},
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Meal rated twice",
+			mealRatings:    []models.ReviewMealRating{{OrderMealID: 10, Rating: 2}, {OrderMealID: 10, Rating: 3}},
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Rating out of range",
+			mealRatings:    []models.ReviewMealRating{{OrderMealID: 10, Rating: 6}},
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Comment too long",
+			mealRatings:    []models.ReviewMealRating{{OrderMealID: 10, Rating: 3, Comment: &longComment}},
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			order := &models.Order{ID: 1, OrderMeals: []models.OrderMeal{
+				{ID: 10, OrderID: 1, MealID: 1, Quantity: 2},
+				{ID: 11, OrderID: 1, MealID: 2, Quantity: 1},
+				{ID: 12, OrderID: 1, MealID: 3, Quantity: 1, Voided: 1},
+			}}
+			s.mockOrderRepo.On("GetByID", uint(1)).Return(order, nil)
+			if tc.errorPredicate == nil {
+				s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+				s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).Return(nil)
+				s.mockOrderRepo.On("CreateReview", mock.AnythingOfType("*models.Review")).Return(nil)
+			}
+
+			review := &models.Review{OrderID: 1, Rating: 4, MealRatings: tc.mealRatings}
+
+			// Act
+			err := s.orderService.CreateReview(context.Background(), review, nil, nil)
+
+			// Assert
+			if tc.errorPredicate != nil {
+				s.True(tc.errorPredicate(err))
+			} else {
+				s.NoError(err)
+				for i, mealID := range tc.expectedMeals {
+					s.Equal(mealID, review.MealRatings[i].MealID)
+				}
+			}
+		})
+	}
+}
+
+// TestCreateReviewPhotos tests storing the variants of review photos
+func (s *OrderServiceTestSuite) TestCreateReviewPhotos() {
+	photos := []*multipart.FileHeader{
+		newTestImageFileHeader(s.T(), "first.png"),
+		newTestImageFileHeader(s.T(), "second.png"),
+	}
+
+	s.Run("Photos are stored with their variants", func() {
+		s.SetupTest()
+
+		order := &models.Order{ID: 1}
+		s.mockOrderRepo.On("GetByID", uint(1)).Return(order, nil)
+		s.mockImageProcessor.On("Process", mock.Anything, photos[0]).
+			Return(newTestProcessedImage("first.jpg", "first"), nil)
+		s.mockImageProcessor.On("Process", mock.Anything, photos[1]).
+			Return(newTestProcessedImage("second.jpg", "second"), nil)
+		s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).Return(nil)
+		s.mockOrderRepo.On("CreateReview", mock.AnythingOfType("*models.Review")).Return(nil)
+
+		review := &models.Review{OrderID: 1, Rating: 5}
+		s.NoError(s.orderService.CreateReview(context.Background(), review, photos, nil))
+
+		s.Equal([]string{"first.jpg", "second.jpg"}, []string(review.PhotoURLs))
+		s.Equal([]string{"first", "second"}, []string(review.PhotoPublicIDs))
+		s.Require().Len(review.PhotoVariants, 2)
+		s.Equal([]string{"second", "second-webp"}, review.PhotoVariants[1].PublicIDs())
+		s.Equal("image/webp", review.PhotoVariants[1][1].ContentType)
+	})
+
+	s.Run("Stored photos are deleted if a photo fails", func() {
+		s.SetupTest()
+
+		s.mockOrderRepo.On("GetByID", uint(1)).Return(&models.Order{ID: 1}, nil)
+		s.mockImageProcessor.On("Process", mock.Anything, photos[0]).
+			Return(newTestProcessedImage("first.jpg", "first"), nil)
+		s.mockImageProcessor.On("Process", mock.Anything, photos[1]).
+			Return(nil, apperrors.NewValidationErr("Invalid image", nil))
+		s.mockImageProcessor.On("Delete", mock.Anything, []string{"first", "first-webp"}).Return(nil)
+
+		err := s.orderService.CreateReview(context.Background(), &models.Review{OrderID: 1, Rating: 5}, photos, nil)
+		s.True(apperrors.IsValidationErr(err))
+	})
+
+	s.Run("Uploaded photos are attached before the sent photos", func() {
+		s.SetupTest()
+
+		order := &models.Order{ID: 1}
+		uploads := []*models.Upload{newTestUpload("a"), newTestUpload("b")}
+		s.mockOrderRepo.On("GetByID", uint(1)).Return(order, nil)
+		s.mockImageProcessor.On("Process", mock.Anything, photos[0]).
+			Return(newTestProcessedImage("first.jpg", "first"), nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).Return(nil)
+		s.mockUploadRepo.On("GetUploads", "order:1", models.ReviewUpload, []string{"b", "a"}, mock.Anything).
+			Return(uploads, nil)
+		s.mockUploadRepo.On("DeleteUploads", []string{"b", "a"}).Return(nil)
+		s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).Return(nil)
+		s.mockOrderRepo.On("CreateReview", mock.AnythingOfType("*models.Review")).Return(nil)
+
+		review := &models.Review{OrderID: 1, Rating: 5}
+		s.NoError(s.orderService.CreateReview(context.Background(), review, photos[:1], []string{"b", "a"}))
+
+		s.Equal([]string{"b.jpg", "a.jpg", "first.jpg"}, []string(review.PhotoURLs))
+		s.Equal([]string{"b", "a", "first"}, []string(review.PhotoPublicIDs))
+		s.Require().Len(review.PhotoVariants, 3)
+		s.Equal([]string{"a", "a-webp"}, review.PhotoVariants[1].PublicIDs())
+	})
+
+	s.Run("Sent photos are deleted if an upload is not found", func() {
+		s.SetupTest()
+
+		s.mockOrderRepo.On("GetByID", uint(1)).Return(&models.Order{ID: 1}, nil)
+		s.mockImageProcessor.On("Process", mock.Anything, photos[0]).
+			Return(newTestProcessedImage("first.jpg", "first"), nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).Return(nil)
+		s.mockUploadRepo.On("GetUploads", "order:1", models.ReviewUpload, []string{"expired"}, mock.Anything).
+			Return([]*models.Upload{}, nil)
+		s.mockImageProcessor.On("Delete", mock.Anything, []string{"first", "first-webp"}).Return(nil)
+
+		err := s.orderService.CreateReview(context.Background(), &models.Review{OrderID: 1, Rating: 5},
+			photos[:1], []string{"expired"})
+		s.True(apperrors.IsValidationErr(err))
+	})
+
+	s.Run("Sent photos are deleted and uploads are kept if the review fails to be created", func() {
+		s.SetupTest()
+
+		s.mockOrderRepo.On("GetByID", uint(1)).Return(&models.Order{ID: 1}, nil)
+		s.mockImageProcessor.On("Process", mock.Anything, photos[0]).
+			Return(newTestProcessedImage("first.jpg", "first"), nil)
+		s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).Return(nil)
+		s.mockUploadRepo.On("GetUploads", "order:1", models.ReviewUpload, []string{"a"}, mock.Anything).
+			Return([]*models.Upload{newTestUpload("a")}, nil)
+		s.mockUploadRepo.On("DeleteUploads", []string{"a"}).Return(nil)
+		s.mockOrderRepo.On("CreateReview", mock.AnythingOfType("*models.Review")).
+			Return(apperrors.NewInternalServerErr("Failed to create review", nil))
+		s.mockImageProcessor.On("Delete", mock.Anything, []string{"first", "first-webp"}).Return(nil)
+
+		err := s.orderService.CreateReview(context.Background(), &models.Review{OrderID: 1, Rating: 5},
+			photos[:1], []string{"a"})
+		s.True(apperrors.IsInternalServerErr(err))
+		s.mockBroadcaster.AssertNotCalled(s.T(), "BroadcastOrder", mock.Anything)
+	})
+
+	s.Run("Too many photos", func() {
+		s.SetupTest()
+
+		err := s.orderService.CreateReview(context.Background(), &models.Review{OrderID: 1, Rating: 5},
+			photos, []string{"a", "b"})
+		s.True(apperrors.IsValidationErr(err))
+	})
+}
+
+// TestUpdateStatus tests the UpdateStatus method
+func (s *OrderServiceTestSuite) TestUpdateStatus() {
+	testCases := []struct {
+		name           string
+		orderMeal      *models.OrderMeal
+		statusChange   *models.OrderMealStatusChange
+		expectedError  bool
+		errorPredicate func(error) bool
+		checkOrderMeal func(*models.OrderMeal)
+	}{
+		{
+			name:      "Accept all pending meals",
+			orderMeal: &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 3},
+			statusChange: &models.OrderMealStatusChange{
+				OrderID: 1, OrderMealID: 2, ToStatus: models.AcceptedStatus, ChangedBy: "cook",
+			},
+			checkOrderMeal: func(orderMeal *models.OrderMeal) {
+				s.Equal(uint(3), orderMeal.CountIn(models.AcceptedStatus))
+				s.Zero(orderMeal.CountIn(models.PendingStatus))
+			},
+		},
+		{
+			name:      "Partially finish cooking",
+			orderMeal: &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 3, Cooking: 3},
+			statusChange: &models.OrderMealStatusChange{
+				OrderID: 1, OrderMealID: 2, ToStatus: models.ReadyStatus, Quantity: 2, ChangedBy: "cook",
+			},
+			checkOrderMeal: func(orderMeal *models.OrderMeal) {
+				s.Equal(uint(1), orderMeal.CountIn(models.CookingStatus))
+				s.Equal(uint(2), orderMeal.CountIn(models.ReadyStatus))
+				s.Equal(uint(2), orderMeal.Completed)
+			},
+		},
+		{
+			name:      "Serve ready meals",
+			orderMeal: &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 2, Completed: 2},
+			statusChange: &models.OrderMealStatusChange{
+				OrderID: 1, OrderMealID: 2, ToStatus: models.ServedStatus, ChangedBy: "waiter",
+			},
+			checkOrderMeal: func(orderMeal *models.OrderMeal) {
+				s.Equal(uint(2), orderMeal.CountIn(models.ServedStatus))
+				s.Zero(orderMeal.CountIn(models.ReadyStatus))
+				s.Equal(uint(2), orderMeal.Completed)
+			},
+		},
+		{
+			name:      "Cancel a cooking meal",
+			orderMeal: &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 2, Cooking: 2},
+			statusChange: &models.OrderMealStatusChange{
+				OrderID: 1, OrderMealID: 2, FromStatus: models.CookingStatus, ToStatus: models.CancelledStatus,
+				Quantity: 1, ChangedBy: "cook",
+			},
+			checkOrderMeal: func(orderMeal *models.OrderMeal) {
+				s.Equal(uint(1), orderMeal.CountIn(models.CookingStatus))
+				s.Equal(uint(1), orderMeal.CountIn(models.CancelledStatus))
+			},
+		},
+		{
+			name:      "Transition not allowed",
+			orderMeal: &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 2},
+			statusChange: &models.OrderMealStatusChange{
+				OrderID: 1, OrderMealID: 2, FromStatus: models.PendingStatus, ToStatus: models.ServedStatus,
+				ChangedBy: "cook",
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:      "Not enough meals in source status",
+			orderMeal: &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 2, Accepted: 1},
+			statusChange: &models.OrderMealStatusChange{
+				OrderID: 1, OrderMealID: 2, ToStatus: models.CookingStatus, Quantity: 2, ChangedBy: "cook",
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:      "Nothing to move",
+			orderMeal: &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 2},
+			statusChange: &models.OrderMealStatusChange{
+				OrderID: 1, OrderMealID: 2, ToStatus: models.ReadyStatus, ChangedBy: "cook",
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name: "Invalid status",
+			statusChange: &models.OrderMealStatusChange{
+				OrderID: 1, OrderMealID: 2, ToStatus: "burnt", ChangedBy: "cook",
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			order := &models.Order{ID: 1}
+			if tc.orderMeal != nil {
+				s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+					Return(nil)
+				s.mockOrderRepo.On("GetOrderMeal", uint(1), uint(2)).Return(tc.orderMeal, nil)
+			}
+			if !tc.expectedError {
+				s.mockOrderRepo.On("UpdateOrderMeal", tc.orderMeal).Return(nil)
+				s.mockOrderRepo.On("CreateStatusChange", mock.MatchedBy(func(statusChange *models.OrderMealStatusChange) bool {
+					return statusChange.ChangedBy == tc.statusChange.ChangedBy &&
+						statusChange.Quantity > 0 &&
+						!statusChange.ChangedAt.IsZero()
+				})).Return(nil)
+				s.mockOrderRepo.On("GetByID", uint(1)).Return(order, nil)
+				s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+			}
+
+			// Act
+			foundOrder, err := s.orderService.UpdateStatus(tc.statusChange)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				if tc.errorPredicate != nil {
+					s.True(tc.errorPredicate(err))
+				}
+			} else {
+				s.NoError(err)
+				s.Equal(order, foundOrder)
+				tc.checkOrderMeal(tc.orderMeal)
+			}
+		})
+	}
+}
+
+// TestCancel tests the Cancel method
+func (s *OrderServiceTestSuite) TestCancel() {
+	cancelledAt := time.Now()
+	sessionID := uint(5)
+
+	testCases := []struct {
+		name           string
+		order          *models.Order
+		expectedError  bool
+		errorPredicate func(error) bool
+	}{
+		{
+			name: "Success",
+			order: &models.Order{ID: 1, OrderMeals: []models.OrderMeal{
+				{ID: 1, OrderID: 1, MealID: 2, Quantity: 2},
+				{ID: 2, OrderID: 1, MealID: 3, Quantity: 1, Voided: 1},
+			}},
+		},
+		{
+			name: "Success releases the promo code",
+			order: &models.Order{ID: 1, PromoCode: "WELCOME", OrderMeals: []models.OrderMeal{
+				{ID: 1, OrderID: 1, MealID: 2, Quantity: 2},
+			}},
+		},
+		{
+			name: "Success closes the table session",
+			order: &models.Order{ID: 1, TableSessionID: &sessionID, OrderMeals: []models.OrderMeal{
+				{ID: 1, OrderID: 1, MealID: 2, Quantity: 2},
+			}},
+		},
+		{
+			name: "Kitchen already started",
+			order: &models.Order{ID: 1, OrderMeals: []models.OrderMeal{
+				{ID: 1, OrderID: 1, MealID: 2, Quantity: 2},
+				{ID: 2, OrderID: 1, MealID: 3, Quantity: 1, Accepted: 1},
+			}},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name: "Already cancelled",
+			order: &models.Order{ID: 1, CancelledAt: &cancelledAt, OrderMeals: []models.OrderMeal{
+				{ID: 1, OrderID: 1, MealID: 2, Quantity: 2, Cancelled: 2},
+			}},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+				Return(nil)
+			s.mockOrderRepo.On("GetByID", uint(1)).Return(tc.order, nil)
+			if !tc.expectedError {
+				s.mockOrderRepo.On("UpdateOrderMeal", mock.MatchedBy(func(orderMeal *models.OrderMeal) bool {
+					return orderMeal.MealID == 2 && orderMeal.Cancelled == 2
+				})).Return(nil).Once()
+				s.mockOrderRepo.On("CreateStatusChange", mock.MatchedBy(func(statusChange *models.OrderMealStatusChange) bool {
+					return statusChange.OrderMealID == 1 &&
+						statusChange.ToStatus == models.CancelledStatus &&
+						statusChange.ChangedBy == models.CustomerActor
+				})).Return(nil).Once()
+				s.mockOrderRepo.On("GetRecipe", uint(2)).Return([]models.RecipeItem{
+					{MealID: 2, IngredientID: 1, Quantity: decimal.RequireFromString("0.5")},
+				}, nil)
+				s.mockOrderRepo.On("ReturnIngredients", mock.MatchedBy(func(usage models.IngredientUsage) bool {
+					return len(usage) == 1 && usage[1].Equal(decimal.NewFromInt(1))
+				})).Return(nil)
+				if tc.order.PromoCode != "" {
+					s.mockOrderRepo.On("ReleasePromoCode", tc.order.PromoCode).Return(nil)
+				}
+				s.mockOrderRepo.On("Cancel", uint(1), mock.AnythingOfType("time.Time")).Return(nil)
+				if tc.order.TableSessionID != nil {
+					s.mockOrderRepo.On("CloseSettledSession", sessionID, mock.AnythingOfType("time.Time")).Return(nil)
+				}
+				s.mockBroadcaster.On("BroadcastOrder", tc.order).Return(nil)
+			}
+
+			// Act
+			order, err := s.orderService.Cancel(1)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				if tc.errorPredicate != nil {
+					s.True(tc.errorPredicate(err))
+				}
+			} else {
+				s.NoError(err)
+				s.Equal(tc.order, order)
+			}
+		})
+	}
+}
+
+// TestVoidOrderMeal tests the VoidOrderMeal method
+func (s *OrderServiceTestSuite) TestVoidOrderMeal() {
+	testCases := []struct {
+		name           string
+		order          *models.Order
+		orderMeal      *models.OrderMeal
+		void           *models.OrderMealVoid
+		expectedError  bool
+		errorPredicate func(error) bool
+		expectRestock  bool
+		checkOrderMeal func(*models.OrderMeal)
+	}{
+		{
+			name:          "Void pending meals",
+			orderMeal:     &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 3},
+			void:          &models.OrderMealVoid{OrderID: 1, OrderMealID: 2, Quantity: 1, Reason: "Wrong meal", VoidedBy: "waiter"},
+			expectRestock: true,
+			checkOrderMeal: func(orderMeal *models.OrderMeal) {
+				s.Equal(uint(2), orderMeal.CountIn(models.PendingStatus))
+				s.Equal(uint(1), orderMeal.CountIn(models.VoidedStatus))
+			},
+		},
+		{
+			name:      "Void served meals",
+			orderMeal: &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 2, Completed: 2, Served: 2},
+			void: &models.OrderMealVoid{OrderID: 1, OrderMealID: 2, FromStatus: models.ServedStatus,
+				Reason: "Cold food", VoidedBy: "admin"},
+			checkOrderMeal: func(orderMeal *models.OrderMeal) {
+				s.Zero(orderMeal.CountIn(models.ServedStatus))
+				s.Zero(orderMeal.Completed)
+				s.Equal(uint(2), orderMeal.CountIn(models.VoidedStatus))
+			},
+		},
+		{
+			name:           "Missing reason",
+			void:           &models.OrderMealVoid{OrderID: 1, OrderMealID: 2, Quantity: 1, Reason: "  ", VoidedBy: "waiter"},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:      "Cannot void cancelled meals",
+			orderMeal: &models.OrderMeal{ID: 2, OrderID: 1, MealID: 2, Quantity: 2, Cancelled: 2},
+			void: &models.OrderMealVoid{OrderID: 1, OrderMealID: 2, FromStatus: models.CancelledStatus,
+				Reason: "Mistake", VoidedBy: "waiter"},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Cannot void meals of paid orders",
+			order:          &models.Order{ID: 1, PaidAt: &time.Time{}},
+			void:           &models.OrderMealVoid{OrderID: 1, OrderMealID: 2, Quantity: 1, Reason: "Cold food", VoidedBy: "admin"},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Cannot void meals of cancelled orders",
+			order:          &models.Order{ID: 1, CancelledAt: &time.Time{}},
+			void:           &models.OrderMealVoid{OrderID: 1, OrderMealID: 2, Quantity: 1, Reason: "Mistake", VoidedBy: "waiter"},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			order := tc.order
+			if order == nil {
+				order = &models.Order{ID: 1}
+			}
+			if tc.order != nil || tc.orderMeal != nil {
+				s.mockOrderRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.OrderRepository) error")).
+					Return(nil)
+				s.mockOrderRepo.On("GetByID", uint(1)).Return(order, nil)
+			}
+			if tc.orderMeal != nil {
+				s.mockOrderRepo.On("GetOrderMeal", uint(1), uint(2)).Return(tc.orderMeal, nil)
+			}
+			if !tc.expectedError {
+				s.mockOrderRepo.On("UpdateOrderMeal", tc.orderMeal).Return(nil)
+				s.mockOrderRepo.On("CreateVoid", mock.MatchedBy(func(void *models.OrderMealVoid) bool {
+					return void.Reason == tc.void.Reason && void.Quantity > 0 && !void.VoidedAt.IsZero()
+				})).Return(nil)
+				s.mockBroadcaster.On("BroadcastOrder", order).Return(nil)
+			}
+			if tc.expectRestock {
+				s.mockOrderRepo.On("GetRecipe", uint(2)).Return([]models.RecipeItem{
+					{MealID: 2, IngredientID: 1, Quantity: decimal.NewFromInt(2)},
+				}, nil)
+				s.mockOrderRepo.On("ReturnIngredients", mock.MatchedBy(func(usage models.IngredientUsage) bool {
+					return len(usage) == 1 && usage[1].Equal(decimal.NewFromInt(2))
+				})).Return(nil)
+			}
+
+			// Act
+			foundOrder, err := s.orderService.VoidOrderMeal(tc.void)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				if tc.errorPredicate != nil {
+					s.True(tc.errorPredicate(err))
+				}
+			} else {
+				s.NoError(err)
+				s.Equal(order, foundOrder)
+				tc.checkOrderMeal(tc.orderMeal)
+			}
+		})
+	}
+}
+
+// Run the test suite
+func TestOrderServiceSuite(t *testing.T) {
+	suite.Run(t, new(OrderServiceTestSuite))
+}
+
+// MockOrderRepository implementation
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+// WithTransaction implementation for the mock repository
+func (m *MockOrderRepository) WithTransaction(fn func(tx repositories.OrderRepository) error) error {
+	args := m.Called(fn)
+
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+
+	return fn(m)
+}
+
+func (m *MockOrderRepository) Uploads() repositories.UploadRepository {
+	args := m.Called()
+	return args.Get(0).(repositories.UploadRepository)
+}
+
+func (m *MockOrderRepository) GetOrders(params repositories.OrderQueryParams) ([]*models.Order, error) {
+	args := m.Called(params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetByID(orderID uint) (*models.Order, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetByIDForUpdate(orderID uint) (*models.Order, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) Create(order *models.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) Cancel(orderID uint, cancelledAt time.Time) error {
+	args := m.Called(orderID, cancelledAt)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetOrOpenTableSession(tableNo int, openedAt time.Time) (*models.TableSession, error) {
+	args := m.Called(tableNo, openedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TableSession), args.Error(1)
+}
+
+func (m *MockOrderRepository) CloseSettledSession(sessionID uint, closedAt time.Time) error {
+	args := m.Called(sessionID, closedAt)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetOrderMeal(orderID, orderMealID uint) (*models.OrderMeal, error) {
+	args := m.Called(orderID, orderMealID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OrderMeal), args.Error(1)
+}
+
+func (m *MockOrderRepository) CreateOrderMeal(orderMeal *models.OrderMeal) error {
+	args := m.Called(orderMeal)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) UpdateOrderMeal(orderMeal *models.OrderMeal) error {
+	args := m.Called(orderMeal)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) CreateStatusChange(statusChange *models.OrderMealStatusChange) error {
+	args := m.Called(statusChange)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) CreateVoid(void *models.OrderMealVoid) error {
+	args := m.Called(void)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) CreateReview(review *models.Review) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetRecipe(mealID uint) ([]models.RecipeItem, error) {
+	args := m.Called(mealID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.RecipeItem), args.Error(1)
+}
+
+func (m *MockOrderRepository) ReturnIngredients(usage models.IngredientUsage) error {
+	args := m.Called(usage)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) ConsumeIngredients(usage models.IngredientUsage) ([]*models.Ingredient, error) {
+	args := m.Called(usage)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Ingredient), args.Error(1)
+}
+
+func (m *MockOrderRepository) CreateDiscounts(discounts []models.OrderDiscount) error {
+	args := m.Called(discounts)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) RedeemPromotion(promotionID uint) error {
+	args := m.Called(promotionID)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) ReleasePromoCode(code string) error {
+	args := m.Called(code)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) UpdateTip(order *models.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/payments"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"log"
+	"time"
+)
+
+// PaymentService defines operations for paying the bills of orders, including splitting bills between payers.
+type PaymentService interface {
+	GetPayments(orderID uint) ([]*models.Payment, *models.Balance, error)
+	RecordPayment(c context.Context, payment *models.Payment) (*models.Balance, error)
+	SplitEvenly(orderID uint, payers uint) ([]models.SplitShare, error)
+	SplitByLines(orderID uint, assignments []models.PayerLines) ([]models.SplitShare, error)
+}
+
+type paymentService struct {
+	paymentRepository repositories.PaymentRepository
+	orderRepository   repositories.OrderRepository
+	paymentProvider   payments.PaymentProvider
+}
+
+func NewPaymentService(paymentRepository repositories.PaymentRepository,
+	orderRepository repositories.OrderRepository,
+	paymentProvider payments.PaymentProvider) PaymentService {
+	return &paymentService{
+		paymentRepository: paymentRepository,
+		orderRepository:   orderRepository,
+		paymentProvider:   paymentProvider,
+	}
+}
+
+// GetPayments retrieves the payments of an order together with its balance.
+func (ps *paymentService) GetPayments(orderID uint) ([]*models.Payment, *models.Balance, error) {
+	order, err := ps.orderRepository.GetByID(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orderPayments, err := ps.paymentRepository.GetByOrderID(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return orderPayments, order.Balance(orderPayments), nil
+}
+
+// RecordPayment charges the payment along with its tip through the payment provider and records it against the order.
+// Payments cannot exceed the outstanding balance, the tip of the payment does not count towards it.
+// The order stays locked from checking the balance until the payment is recorded, so concurrent payments cannot
+// exceed it together. The charge is refunded if the payment fails to be recorded.
+// Once the balance is paid in full, the order gets closed together with its table session,
+// if all other orders of the session are settled too, and the tip added to the bill of the order is received.
+// Returns the updated balance.
+func (ps *paymentService) RecordPayment(c context.Context, payment *models.Payment) (*models.Balance, error) {
+	if err := payment.Method.Valid(); err != nil {
+		return nil, apperrors.NewValidationErr("Invalid payment method", err)
+	}
+
+	if !payment.Amount.IsPositive() {
+		return nil, apperrors.NewValidationErr("Payment amount must be positive", nil)
+	}
+
+	if payment.Tip.IsNegative() {
+		return nil, apperrors.NewValidationErr("Tip cannot be negative", nil)
+	}
+
+	var charge *payments.ChargeResult
+	var balance *models.Balance
+	err := ps.paymentRepository.WithTransaction(func(tx repositories.PaymentRepository) error {
+		order, err := tx.GetOrderForUpdate(payment.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if err = checkOrderOpen(order); err != nil {
+			return err
+		}
+
+		orderPayments, err := tx.GetByOrderID(order.ID)
+		if err != nil {
+			return err
+		}
+
+		balance = order.Balance(orderPayments)
+		if payment.Amount.GreaterThan(balance.Outstanding) {
+			return apperrors.NewValidationErr("Payment exceeds the outstanding balance", nil)
+		}
+
+		charge, err = ps.paymentProvider.Charge(c, payment.Method, payment.Amount.Add(payment.Tip), payment.Reference)
+		if err != nil {
+			return err
+		}
+
+		payment.TransactionID = charge.TransactionID
+		if err = tx.Create(payment); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if payment.Tip.IsPositive() {
+			err = tx.CreateTip(&models.Tip{OrderID: order.ID, PaymentID: &payment.ID, Amount: payment.Tip, ReceivedAt: now})
+			if err != nil {
+				return err
+			}
+		}
+
+		balance = order.Balance(append(orderPayments, payment))
+		if !balance.Outstanding.IsZero() {
+			return nil
+		}
+
+		if err = tx.MarkOrderPaid(order.ID, now); err != nil {
+			return err
+		}
+
+		if tip := order.Bill().Tip; tip.IsPositive() {
+			if err = tx.CreateTip(&models.Tip{OrderID: order.ID, Amount: tip, ReceivedAt: now}); err != nil {
+				return err
+			}
+		}
+
+		if order.TableSessionID != nil {
+			return tx.CloseSettledSession(*order.TableSessionID, now)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if charge != nil {
+			if refundErr := ps.paymentProvider.Refund(c, charge.TransactionID); refundErr != nil {
+				log.Printf("Failed to refund charge %s of order %d: %v", charge.TransactionID, payment.OrderID, refundErr)
+			}
+			payment.TransactionID = ""
+		}
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// SplitEvenly splits the outstanding balance of an order evenly between the given number of payers.
+func (ps *paymentService) SplitEvenly(orderID uint, payers uint) ([]models.SplitShare, error) {
+	order, err := ps.getOpenOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	orderPayments, err := ps.paymentRepository.GetByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := models.SplitEvenly(order.Balance(orderPayments).Outstanding, payers)
+	if err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	return shares, nil
+}
+
+// SplitByLines splits the bill of an order between payers by the order meals assigned to each of them.
+// Payments are not tied to order meals, so bills which are partly paid can only be split evenly.
+func (ps *paymentService) SplitByLines(orderID uint, assignments []models.PayerLines) ([]models.SplitShare, error) {
+	order, err := ps.getOpenOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	orderPayments, err := ps.paymentRepository.GetByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orderPayments) > 0 {
+		return nil, apperrors.NewValidationErr("Bills which are partly paid cannot be split by lines", nil)
+	}
+
+	shares, err := order.SplitByLines(assignments)
+	if err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	return shares, nil
+}
+
+// getOpenOrder retrieves an order which can still be paid, see checkOrderOpen.
+func (ps *paymentService) getOpenOrder(orderID uint) (*models.Order, error) {
+	order, err := ps.orderRepository.GetByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkOrderOpen(order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// checkOrderOpen checks that the order can still be paid, that is that it is neither cancelled nor paid.
+func checkOrderOpen(order *models.Order) error {
+	if order.CancelledAt != nil {
+		return apperrors.NewValidationErr("Order has been cancelled", nil)
+	}
+
+	if order.PaidAt != nil {
+		return apperrors.NewValidationErr("Order has already been paid", nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/payments"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// PaymentServiceTestSuite defines the test suite for PaymentService
+type PaymentServiceTestSuite struct {
+	suite.Suite
+	paymentService  services.PaymentService
+	mockPaymentRepo *MockPaymentRepository
+	mockOrderRepo   *MockOrderRepository
+	provider        *payments.InMemoryProvider
+}
+
+func (s *PaymentServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockPaymentRepo = new(MockPaymentRepository)
+	s.mockOrderRepo = new(MockOrderRepository)
+	s.provider = payments.NewInMemoryProvider()
+
+	s.paymentService = services.NewPaymentService(s.mockPaymentRepo, s.mockOrderRepo, s.provider)
+}
+
+// TearDownTest runs after each test
+func (s *PaymentServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockPaymentRepo.AssertExpectations(s.T())
+	s.mockOrderRepo.AssertExpectations(s.T())
+}
+
+// newTestOrder returns an order with a bill total of 30.00
+func newTestOrder() *models.Order {
+	return &models.Order{ID: 1, OrderMeals: []models.OrderMeal{
+		{ID: 1, OrderID: 1, MealID: 1, UnitPrice: decimal.RequireFromString("10.00"), Quantity: 2},
+		{ID: 2, OrderID: 1, MealID: 2, UnitPrice: decimal.RequireFromString("5.00"), Quantity: 2},
+	}}
+}
+
+// TestRecordPayment tests the RecordPayment method
+func (s *PaymentServiceTestSuite) TestRecordPayment() {
+	paidAt := time.Now()
+
+	testCases := []struct {
+		name             string
+		order            *models.Order
+		existingPayments []*models.Payment
+		payment          *models.Payment
+		expectCharge     bool
+		expectClose      bool
+		expectedTips     []string
+		expectedError    bool
+		errorPredicate   func(error) bool
+		expectedBalance  string
+	}{
+		{
+			name:            "Partial payment",
+			order:           newTestOrder(),
+			payment:         &models.Payment{OrderID: 1, Method: models.CashPayment, Amount: decimal.NewFromInt(10)},
+			expectCharge:    true,
+			expectedBalance: "20",
+		},
+		{
+			name:  "Final payment closes the order",
+			order: newTestOrder(),
+			existingPayments: []*models.Payment{
+				{OrderID: 1, Method: models.CardPayment, Amount: decimal.NewFromInt(20)},
+			},
+			payment:         &models.Payment{OrderID: 1, Method: models.VoucherPayment, Amount: decimal.NewFromInt(10), Reference: "GIFT"},
+			expectCharge:    true,
+			expectClose:     true,
+			expectedBalance: "0",
+		},
+		{
+			name: "Final payment closes the table session",
+			order: func() *models.Order {
+				order := newTestOrder()
+				sessionID := uint(5)
+				order.TableSessionID = &sessionID
+				return order
+			}(),
+			payment:         &models.Payment{OrderID: 1, Method: models.CardPayment, Amount: decimal.NewFromInt(30)},
+			expectCharge:    true,
+			expectClose:     true,
+			expectedBalance: "0",
+		},
+		{
+			name:            "Payment with a tip",
+			order:           newTestOrder(),
+			payment:         &models.Payment{OrderID: 1, Method: models.CardPayment, Amount: decimal.NewFromInt(10), Tip: decimal.NewFromInt(2)},
+			expectCharge:    true,
+			expectedTips:    []string{"2"},
+			expectedBalance: "20",
+		},
+		{
+			name: "Final payment records the tip on the bill",
+			order: func() *models.Order {
+				order := newTestOrder()
+				order.TipPercent = decimal.NewFromInt(10)
+				return order
+			}(),
+			payment:         &models.Payment{OrderID: 1, Method: models.CashPayment, Amount: decimal.NewFromInt(33)},
+			expectCharge:    true,
+			expectClose:     true,
+			expectedTips:    []string{"3"},
+			expectedBalance: "0",
+		},
+		{
+			name:           "Negative tip",
+			payment:        &models.Payment{OrderID: 1, Method: models.CashPayment, Amount: decimal.NewFromInt(10), Tip: decimal.NewFromInt(-1)},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:  "Overpayment",
+			order: newTestOrder(),
+			existingPayments: []*models.Payment{
+				{OrderID: 1, Method: models.CardPayment, Amount: decimal.NewFromInt(25)},
+			},
+			payment:        &models.Payment{OrderID: 1, Method: models.CashPayment, Amount: decimal.NewFromInt(10)},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name: "Order already paid",
+			order: func() *models.Order {
+				order := newTestOrder()
+				order.PaidAt = &paidAt
+				return order
+			}(),
+			payment:        &models.Payment{OrderID: 1, Method: models.CashPayment, Amount: decimal.NewFromInt(10)},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Invalid method",
+			payment:        &models.Payment{OrderID: 1, Method: "iou", Amount: decimal.NewFromInt(10)},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Non positive amount",
+			payment:        &models.Payment{OrderID: 1, Method: models.CashPayment, Amount: decimal.Zero},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			if tc.order != nil {
+				s.mockPaymentRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.PaymentRepository) error")).
+					Return(nil)
+				s.mockPaymentRepo.On("GetOrderForUpdate", uint(1)).Return(tc.order, nil)
+			}
+			if tc.order != nil && tc.order.PaidAt == nil {
+				s.mockPaymentRepo.On("GetByOrderID", uint(1)).Return(tc.existingPayments, nil)
+			}
+			if tc.expectCharge {
+				s.mockPaymentRepo.On("Create", tc.payment).Return(nil)
+			}
+			for _, tip := range tc.expectedTips {
+				amount := decimal.RequireFromString(tip)
+				s.mockPaymentRepo.On("CreateTip", mock.MatchedBy(func(t *models.Tip) bool {
+					return t.OrderID == 1 && t.Amount.Equal(amount)
+				})).Return(nil).Once()
+			}
+			if tc.expectClose {
+				s.mockPaymentRepo.On("MarkOrderPaid", uint(1), mock.AnythingOfType("time.Time")).Return(nil)
+				if tc.order.TableSessionID != nil {
+					s.mockPaymentRepo.On("CloseSettledSession", *tc.order.TableSessionID, mock.AnythingOfType("time.Time")).
+						Return(nil)
+				}
+			}
+
+			// Act
+			balance, err := s.paymentService.RecordPayment(context.Background(), tc.payment)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				if tc.errorPredicate != nil {
+					s.True(tc.errorPredicate(err))
+				}
+				s.Empty(s.provider.Charges())
+			} else {
+				s.NoError(err)
+				s.True(decimal.RequireFromString(tc.expectedBalance).Equal(balance.Outstanding))
+				s.Require().Len(s.provider.Charges(), 1)
+				s.True(tc.payment.Amount.Add(tc.payment.Tip).Equal(s.provider.Charges()[0].Amount), "the tip is charged along with the payment")
+				s.NotEmpty(tc.payment.TransactionID)
+			}
+		})
+	}
+}
+
+// TestRecordPaymentRefund tests that the charge is refunded if the payment fails to be recorded
+func (s *PaymentServiceTestSuite) TestRecordPaymentRefund() {
+	s.mockPaymentRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.PaymentRepository) error")).
+		Return(nil)
+	s.mockPaymentRepo.On("GetOrderForUpdate", uint(1)).Return(newTestOrder(), nil)
+	s.mockPaymentRepo.On("GetByOrderID", uint(1)).Return([]*models.Payment{}, nil)
+	s.mockPaymentRepo.On("Create", mock.AnythingOfType("*models.Payment")).
+		Return(apperrors.NewInternalServerErr("Failed to create payment", nil))
+
+	payment := &models.Payment{OrderID: 1, Method: models.CardPayment, Amount: decimal.NewFromInt(10)}
+	_, err := s.paymentService.RecordPayment(context.Background(), payment)
+
+	s.True(apperrors.IsInternalServerErr(err))
+	s.Require().Len(s.provider.Charges(), 1)
+	s.True(s.provider.Charges()[0].Refunded)
+	s.Empty(payment.TransactionID)
+}
+
+// TestSplitEvenly tests the SplitEvenly method
+func (s *PaymentServiceTestSuite) TestSplitEvenly() {
+	s.mockOrderRepo.On("GetByID", uint(1)).Return(newTestOrder(), nil)
+	s.mockPaymentRepo.On("GetByOrderID", uint(1)).Return([]*models.Payment{
+		{OrderID: 1, Method: models.CashPayment, Amount: decimal.NewFromInt(10)},
+	}, nil)
+
+	shares, err := s.paymentService.SplitEvenly(1, 3)
+	s.NoError(err)
+	s.Require().Len(shares, 3)
+	s.True(decimal.RequireFromString("6.67").Equal(shares[0].Amount))
+	s.True(decimal.RequireFromString("6.67").Equal(shares[1].Amount))
+	s.True(decimal.RequireFromString("6.66").Equal(shares[2].Amount))
+
+	_, err = s.paymentService.SplitEvenly(1, 0)
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// TestSplitByLines tests the SplitByLines method
+func (s *PaymentServiceTestSuite) TestSplitByLines() {
+	s.mockOrderRepo.On("GetByID", uint(1)).Return(newTestOrder(), nil)
+	s.mockPaymentRepo.On("GetByOrderID", uint(1)).Return([]*models.Payment{}, nil)
+
+	shares, err := s.paymentService.SplitByLines(1, []models.PayerLines{
+		{Payer: "Alice", Lines: []models.LineShare{{OrderMealID: 1, Quantity: 1}, {OrderMealID: 2, Quantity: 2}}},
+		{Payer: "Bob", Lines: []models.LineShare{{OrderMealID: 1, Quantity: 1}}},
+	})
+	s.NoError(err)
+	s.Require().Len(shares, 2)
+	s.Equal("Alice", shares[0].Payer)
+	s.True(decimal.RequireFromString("20").Equal(shares[0].Amount))
+	s.True(decimal.RequireFromString("10").Equal(shares[1].Amount))
+
+	_, err = s.paymentService.SplitByLines(1, []models.PayerLines{
+		{Payer: "Alice", Lines: []models.LineShare{{OrderMealID: 1, Quantity: 2}}},
+	})
+	s.True(apperrors.IsValidationErr(err), "unassigned units must be rejected")
+
+	_, err = s.paymentService.SplitByLines(1, []models.PayerLines{
+		{Payer: "Alice", Lines: []models.LineShare{{OrderMealID: 1, Quantity: 3}, {OrderMealID: 2, Quantity: 2}}},
+	})
+	s.True(apperrors.IsValidationErr(err), "units cannot be assigned twice")
+}
+
+// TestSplitByLinesServiceChargeAndTip tests that SplitByLines shares the service charge and the tip
+func (s *PaymentServiceTestSuite) TestSplitByLinesServiceChargeAndTip() {
+	order := newTestOrder()
+	order.ServiceChargeRate = decimal.RequireFromString("0.1")
+	order.TipAmount = decimal.NewFromInt(3)
+	s.mockOrderRepo.On("GetByID", uint(1)).Return(order, nil)
+	s.mockPaymentRepo.On("GetByOrderID", uint(1)).Return([]*models.Payment{}, nil)
+
+	shares, err := s.paymentService.SplitByLines(1, []models.PayerLines{
+		{Payer: "Alice", Lines: []models.LineShare{{OrderMealID: 1, Quantity: 1}, {OrderMealID: 2, Quantity: 2}}},
+		{Payer: "Bob", Lines: []models.LineShare{{OrderMealID: 1, Quantity: 1}}},
+	})
+	s.NoError(err)
+	s.Require().Len(shares, 2)
+	s.True(decimal.RequireFromString("24").Equal(shares[0].Amount))
+	s.True(decimal.RequireFromString("12").Equal(shares[1].Amount))
+}
+
+// TestSplitByLinesPartlyPaid tests that bills which are partly paid cannot be split by lines
+func (s *PaymentServiceTestSuite) TestSplitByLinesPartlyPaid() {
+	s.mockOrderRepo.On("GetByID", uint(1)).Return(newTestOrder(), nil)
+	s.mockPaymentRepo.On("GetByOrderID", uint(1)).Return([]*models.Payment{
+		{OrderID: 1, Method: models.CashPayment, Amount: decimal.NewFromInt(10)},
+	}, nil)
+
+	_, err := s.paymentService.SplitByLines(1, []models.PayerLines{
+		{Payer: "Alice", Lines: []models.LineShare{{OrderMealID: 1, Quantity: 2}, {OrderMealID: 2, Quantity: 2}}},
+	})
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// Run the test suite
+func TestPaymentServiceSuite(t *testing.T) {
+	suite.Run(t, new(PaymentServiceTestSuite))
+}
+
+// MockPaymentRepository implementation
+type MockPaymentRepository struct {
+	mock.Mock
+}
+
+// WithTransaction implementation for the mock repository
+func (m *MockPaymentRepository) WithTransaction(fn func(txRepo repositories.PaymentRepository) error) error {
+	args := m.Called(fn)
+
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+
+	return fn(m)
+}
+
+func (m *MockPaymentRepository) GetOrderForUpdate(orderID uint) (*models.Order, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockPaymentRepository) GetByOrderID(orderID uint) ([]*models.Payment, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) Create(payment *models.Payment) error {
+	args := m.Called(payment)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) MarkOrderPaid(orderID uint, paidAt time.Time) error {
+	args := m.Called(orderID, paidAt)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) CloseSettledSession(sessionID uint, closedAt time.Time) error {
+	args := m.Called(sessionID, closedAt)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) CreateTip(tip *models.Tip) error {
+	args := m.Called(tip)
+	return args.Error(0)
+}
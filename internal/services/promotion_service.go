@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+)
+
+// PromotionService defines operations for managing promotions.
+// Promotions are applied to orders by the OrderService.
+type PromotionService interface {
+	GetAll() ([]*models.Promotion, error)
+	Create(promotion *models.Promotion) error
+	Update(promotion *models.Promotion) (*models.Promotion, error)
+	Delete(promotionID uint) error
+}
+
+type promotionService struct {
+	promotionRepository repositories.PromotionRepository
+	categoryRepository  repositories.CategoryRepository
+	mealRepository      repositories.MealRepository
+}
+
+func NewPromotionService(promotionRepository repositories.PromotionRepository,
+	categoryRepository repositories.CategoryRepository,
+	mealRepository repositories.MealRepository) PromotionService {
+	return &promotionService{
+		promotionRepository: promotionRepository,
+		categoryRepository:  categoryRepository,
+		mealRepository:      mealRepository,
+	}
+}
+
+// GetAll retrieves all promotions with their combo meals and windows.
+func (ps *promotionService) GetAll() ([]*models.Promotion, error) {
+	return ps.promotionRepository.GetAll(false)
+}
+
+// Create validates and adds a new promotion along with its combo meals and windows,
+// returning an error if a promotion with the same name or promo code already exists.
+func (ps *promotionService) Create(promotion *models.Promotion) error {
+	if err := ps.validate(promotion); err != nil {
+		return err
+	}
+
+	return ps.promotionRepository.Create(promotion)
+}
+
+// Update validates and replaces the settings, combo meals and windows of an existing promotion.
+// The number of orders its promo code was used for is kept. Returns the updated promotion.
+func (ps *promotionService) Update(promotion *models.Promotion) (*models.Promotion, error) {
+	if err := ps.validate(promotion); err != nil {
+		return nil, err
+	}
+
+	var updatedPromotion *models.Promotion
+	err := ps.promotionRepository.WithTransaction(func(tx repositories.PromotionRepository) error {
+		if err := tx.Update(promotion); err != nil {
+			return err
+		}
+
+		if err := tx.ReplaceComboMeals(promotion.ID, promotion.ComboMeals); err != nil {
+			return err
+		}
+
+		if err := tx.ReplaceWindows(promotion.ID, promotion.Windows); err != nil {
+			return err
+		}
+
+		var err error
+		updatedPromotion, err = tx.GetByID(promotion.ID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedPromotion, nil
+}
+
+// Delete removes a promotion along with its combo meals and windows. Discounts it already granted are kept.
+func (ps *promotionService) Delete(promotionID uint) error {
+	return ps.promotionRepository.Delete(promotionID)
+}
+
+// validate normalizes the promo code of the promotion and checks that its settings are valid,
+// that its category and combo meals exist and that its name and promo code are not taken by another promotion.
+func (ps *promotionService) validate(promotion *models.Promotion) error {
+	if promotion.Code != nil {
+		code := models.NormalizePromoCode(*promotion.Code)
+		promotion.Code = &code
+	}
+
+	if err := promotion.Validate(); err != nil {
+		return apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	if promotion.CategoryID != nil {
+		if _, err := ps.categoryRepository.GetByID(*promotion.CategoryID); err != nil {
+			if apperrors.IsNotFoundErr(err) {
+				return apperrors.NewValidationErr(fmt.Sprintf("Category %d does not exist", *promotion.CategoryID), err)
+			}
+			return err
+		}
+	}
+
+	for _, comboMeal := range promotion.ComboMeals {
+		if _, err := ps.mealRepository.GetByID(comboMeal.MealID); err != nil {
+			if apperrors.IsNotFoundErr(err) {
+				return apperrors.NewValidationErr(fmt.Sprintf("Meal %d does not exist", comboMeal.MealID), err)
+			}
+			return err
+		}
+	}
+
+	found, err := ps.promotionRepository.GetByName(promotion.Name)
+	if err == nil && found.ID != promotion.ID {
+		return apperrors.NewAlreadyExistsErr(fmt.Sprintf("Promotion %s already exists", promotion.Name), nil)
+	}
+
+	if err != nil && !apperrors.IsNotFoundErr(err) {
+		return err
+	}
+
+	if promotion.Code == nil {
+		return nil
+	}
+
+	found, err = ps.promotionRepository.GetByCode(*promotion.Code)
+	if err == nil && found.ID != promotion.ID {
+		return apperrors.NewAlreadyExistsErr(fmt.Sprintf("Promo code %s already exists", *promotion.Code), nil)
+	}
+
+	if err != nil && !apperrors.IsNotFoundErr(err) {
+		return err
+	}
+
+	return nil
+}
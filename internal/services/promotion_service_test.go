@@ -0,0 +1,300 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// PromotionServiceTestSuite defines the test suite for PromotionService
+type PromotionServiceTestSuite struct {
+	suite.Suite
+	promotionService  services.PromotionService
+	mockPromotionRepo *MockPromotionRepository
+	mockCategoryRepo  *MockCategoryRepository
+	mockMealRepo      *MockMealRepository
+}
+
+func (s *PromotionServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockPromotionRepo = new(MockPromotionRepository)
+	s.mockCategoryRepo = new(MockCategoryRepository)
+	s.mockMealRepo = new(MockMealRepository)
+	s.promotionService = services.NewPromotionService(s.mockPromotionRepo, s.mockCategoryRepo, s.mockMealRepo)
+}
+
+// TearDownTest runs after each test
+func (s *PromotionServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockPromotionRepo.AssertExpectations(s.T())
+	s.mockCategoryRepo.AssertExpectations(s.T())
+	s.mockMealRepo.AssertExpectations(s.T())
+}
+
+// TestCreate tests the Create method
+func (s *PromotionServiceTestSuite) TestCreate() {
+	drinks := uint(2)
+	missingCategory := uint(9)
+	code := " spring "
+	emptyCode := ""
+	limit := uint(100)
+
+	testCases := []struct {
+		name           string
+		promotion      *models.Promotion
+		setupMock      func(promotion *models.Promotion)
+		expectedError  bool
+		errorPredicate func(error) bool
+	}{
+		{
+			name: "Happy hour",
+			promotion: &models.Promotion{Name: "Happy hour", Kind: models.PercentageOffPromotion, Active: true,
+				CategoryID: &drinks, Percent: decimal.NewFromInt(50)},
+			setupMock: func(promotion *models.Promotion) {
+				s.mockCategoryRepo.On("GetByID", drinks).Return(&models.Category{ID: drinks, Name: "Drinks"}, nil)
+				s.mockPromotionRepo.On("GetByName", "Happy hour").
+					Return(nil, apperrors.NewNotFoundErr("Promotion Happy hour not found", nil))
+				s.mockPromotionRepo.On("Create", promotion).Return(nil)
+			},
+		},
+		{
+			name: "Promo code",
+			promotion: &models.Promotion{Name: "Spring", Kind: models.FixedOffPromotion, Active: true,
+				Amount: decimal.NewFromInt(5), Code: &code, UsageLimit: &limit},
+			setupMock: func(promotion *models.Promotion) {
+				s.mockPromotionRepo.On("GetByName", "Spring").
+					Return(nil, apperrors.NewNotFoundErr("Promotion Spring not found", nil))
+				s.mockPromotionRepo.On("GetByCode", "SPRING").
+					Return(nil, apperrors.NewNotFoundErr("Promo code SPRING not found", nil))
+				s.mockPromotionRepo.On("Create", mock.MatchedBy(func(promotion *models.Promotion) bool {
+					return *promotion.Code == "SPRING"
+				})).Return(nil)
+			},
+		},
+		{
+			name: "Combo",
+			promotion: &models.Promotion{Name: "Burger menu", Kind: models.ComboPromotion, Active: true,
+				Amount: decimal.NewFromInt(14), ComboMeals: []models.PromotionComboMeal{{MealID: 1}, {MealID: 2}}},
+			setupMock: func(promotion *models.Promotion) {
+				s.mockMealRepo.On("GetByID", uint(1)).Return(&models.Meal{ID: 1}, nil)
+				s.mockMealRepo.On("GetByID", uint(2)).Return(&models.Meal{ID: 2}, nil)
+				s.mockPromotionRepo.On("GetByName", "Burger menu").
+					Return(nil, apperrors.NewNotFoundErr("Promotion Burger menu not found", nil))
+				s.mockPromotionRepo.On("Create", promotion).Return(nil)
+			},
+		},
+		{
+			name: "Name already exists",
+			promotion: &models.Promotion{Name: "Happy hour", Kind: models.PercentageOffPromotion,
+				Percent: decimal.NewFromInt(20)},
+			setupMock: func(promotion *models.Promotion) {
+				s.mockPromotionRepo.On("GetByName", "Happy hour").Return(&models.Promotion{ID: 1, Name: "Happy hour"}, nil)
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsAlreadyExistsErr,
+		},
+		{
+			name: "Promo code already exists",
+			promotion: &models.Promotion{Name: "Spring", Kind: models.FixedOffPromotion,
+				Amount: decimal.NewFromInt(5), Code: &code},
+			setupMock: func(promotion *models.Promotion) {
+				s.mockPromotionRepo.On("GetByName", "Spring").
+					Return(nil, apperrors.NewNotFoundErr("Promotion Spring not found", nil))
+				s.mockPromotionRepo.On("GetByCode", "SPRING").Return(&models.Promotion{ID: 1, Name: "Spring sale"}, nil)
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsAlreadyExistsErr,
+		},
+		{
+			name: "Missing category",
+			promotion: &models.Promotion{Name: "Happy hour", Kind: models.PercentageOffPromotion,
+				CategoryID: &missingCategory, Percent: decimal.NewFromInt(50)},
+			setupMock: func(promotion *models.Promotion) {
+				s.mockCategoryRepo.On("GetByID", missingCategory).
+					Return(nil, apperrors.NewNotFoundErr("Category with ID 9 not found", nil))
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name: "Missing combo meal",
+			promotion: &models.Promotion{Name: "Burger menu", Kind: models.ComboPromotion,
+				Amount: decimal.NewFromInt(14), ComboMeals: []models.PromotionComboMeal{{MealID: 1}, {MealID: 9}}},
+			setupMock: func(promotion *models.Promotion) {
+				s.mockMealRepo.On("GetByID", uint(1)).Return(&models.Meal{ID: 1}, nil)
+				s.mockMealRepo.On("GetByID", uint(9)).Return(nil, apperrors.NewNotFoundErr("Meal with ID 9 not found", nil))
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Percent over 100",
+			promotion:      &models.Promotion{Name: "Free drinks", Kind: models.PercentageOffPromotion, Percent: decimal.NewFromInt(150)},
+			setupMock:      func(promotion *models.Promotion) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Buy X get nothing",
+			promotion:      &models.Promotion{Name: "Three for two", Kind: models.BuyXGetYPromotion, BuyQuantity: 2},
+			setupMock:      func(promotion *models.Promotion) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name: "Combo of a single meal",
+			promotion: &models.Promotion{Name: "Burger menu", Kind: models.ComboPromotion,
+				Amount: decimal.NewFromInt(14), ComboMeals: []models.PromotionComboMeal{{MealID: 1}}},
+			setupMock:      func(promotion *models.Promotion) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name: "Empty promo code",
+			promotion: &models.Promotion{Name: "Spring", Kind: models.FixedOffPromotion,
+				Amount: decimal.NewFromInt(5), Code: &emptyCode},
+			setupMock:      func(promotion *models.Promotion) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name: "Usage limit without a promo code",
+			promotion: &models.Promotion{Name: "Spring", Kind: models.FixedOffPromotion,
+				Amount: decimal.NewFromInt(5), UsageLimit: &limit},
+			setupMock:      func(promotion *models.Promotion) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+		{
+			name:           "Invalid kind",
+			promotion:      &models.Promotion{Name: "Spring", Kind: "mystery"},
+			setupMock:      func(promotion *models.Promotion) {},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+			tc.setupMock(tc.promotion)
+
+			// Act
+			err := s.promotionService.Create(tc.promotion)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				s.True(tc.errorPredicate(err))
+			} else {
+				s.NoError(err)
+			}
+		})
+	}
+}
+
+// TestUpdate tests the Update method
+func (s *PromotionServiceTestSuite) TestUpdate() {
+	code := "SPRING"
+	promotion := &models.Promotion{ID: 3, Name: "Spring", Kind: models.FixedOffPromotion, Active: true,
+		Amount: decimal.NewFromInt(5), Code: &code}
+	updated := &models.Promotion{ID: 3, Name: "Spring", Kind: models.FixedOffPromotion, Active: true,
+		Amount: decimal.NewFromInt(5), Code: &code, UsageCount: 12}
+
+	s.mockPromotionRepo.On("GetByName", "Spring").Return(&models.Promotion{ID: 3, Name: "Spring"}, nil)
+	s.mockPromotionRepo.On("GetByCode", "SPRING").Return(&models.Promotion{ID: 3, Name: "Spring"}, nil)
+	s.mockPromotionRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.PromotionRepository) error")).Return(nil)
+	s.mockPromotionRepo.On("Update", promotion).Return(nil)
+	s.mockPromotionRepo.On("ReplaceComboMeals", uint(3), []models.PromotionComboMeal(nil)).Return(nil)
+	s.mockPromotionRepo.On("ReplaceWindows", uint(3), []models.AvailabilityWindow(nil)).Return(nil)
+	s.mockPromotionRepo.On("GetByID", uint(3)).Return(updated, nil)
+
+	updatedPromotion, err := s.promotionService.Update(promotion)
+	s.NoError(err)
+	s.Equal(updated, updatedPromotion)
+}
+
+// Run the test suite
+func TestPromotionServiceSuite(t *testing.T) {
+	suite.Run(t, new(PromotionServiceTestSuite))
+}
+
+// MockPromotionRepository implementation
+type MockPromotionRepository struct {
+	mock.Mock
+}
+
+// WithTransaction implementation for the mock repository
+func (m *MockPromotionRepository) WithTransaction(fn func(txRepo repositories.PromotionRepository) error) error {
+	args := m.Called(fn)
+
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+
+	return fn(m)
+}
+
+func (m *MockPromotionRepository) GetAll(onlyActive bool) ([]*models.Promotion, error) {
+	args := m.Called(onlyActive)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) GetByID(ID uint) (*models.Promotion, error) {
+	args := m.Called(ID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) GetByName(name string) (*models.Promotion, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) GetByCode(code string) (*models.Promotion, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) Create(promotion *models.Promotion) error {
+	args := m.Called(promotion)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) Update(promotion *models.Promotion) error {
+	args := m.Called(promotion)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) Delete(ID uint) error {
+	args := m.Called(ID)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) ReplaceComboMeals(promotionID uint, comboMeals []models.PromotionComboMeal) error {
+	args := m.Called(promotionID, comboMeals)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) ReplaceWindows(promotionID uint, windows []models.AvailabilityWindow) error {
+	args := m.Called(promotionID, windows)
+	return args.Error(0)
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"log"
+	"strings"
+	"time"
+)
+
+// ReviewService defines operations for reading, moderating and replying to the reviews of orders.
+type ReviewService interface {
+	GetFeed(olderThan time.Time, pageSize uint) ([]*models.ReviewFeedItem, error)
+	GetReviews(status models.ReviewStatus, olderThan time.Time, pageSize uint) ([]*models.ReviewFeedItem, error)
+	Moderate(reviewID uint, status models.ReviewStatus, moderatedBy string) (*models.Review, error)
+	RemovePhoto(c context.Context, reviewID uint, index int) (*models.Review, error)
+	Reply(reply *models.ReviewReply) (*models.Review, error)
+	GetMealRating(mealID uint) (*models.MealRating, error)
+}
+
+type reviewService struct {
+	reviewRepository repositories.ReviewRepository
+	mealRepository   repositories.MealRepository
+	imageProcessor   storage.ImageProcessor
+}
+
+func NewReviewService(reviewRepository repositories.ReviewRepository,
+	mealRepository repositories.MealRepository,
+	imageProcessor storage.ImageProcessor) ReviewService {
+	return &reviewService{
+		reviewRepository: reviewRepository,
+		mealRepository:   mealRepository,
+		imageProcessor:   imageProcessor,
+	}
+}
+
+// GetFeed retrieves a page of the public reviews created before olderThan, newest first.
+// Hidden reviews are left out.
+func (rs *reviewService) GetFeed(olderThan time.Time, pageSize uint) ([]*models.ReviewFeedItem, error) {
+	return rs.getReviews(repositories.ReviewQueryParams{
+		Statuses:  []models.ReviewStatus{models.PublishedReview, models.FlaggedReview},
+		OlderThan: olderThan,
+		PageSize:  pageSize,
+	})
+}
+
+// GetReviews retrieves a page of the reviews in the given status created before olderThan, newest first.
+// An empty status retrieves reviews in any status.
+func (rs *reviewService) GetReviews(status models.ReviewStatus, olderThan time.Time, pageSize uint) ([]*models.ReviewFeedItem, error) {
+	params := repositories.ReviewQueryParams{
+		OlderThan: olderThan,
+		PageSize:  pageSize,
+	}
+
+	if status != "" {
+		if err := status.Valid(); err != nil {
+			return nil, apperrors.NewValidationErr(err.Error(), err)
+		}
+		params.Statuses = []models.ReviewStatus{status}
+	}
+
+	return rs.getReviews(params)
+}
+
+// getReviews retrieves the reviews along with the names of the meals of the reviewed orders.
+func (rs *reviewService) getReviews(params repositories.ReviewQueryParams) ([]*models.ReviewFeedItem, error) {
+	const MaximumPageSize = 100
+
+	if params.PageSize > MaximumPageSize {
+		params.PageSize = MaximumPageSize
+	}
+
+	reviews, err := rs.reviewRepository.GetReviews(params)
+	if err != nil {
+		return nil, err
+	}
+
+	orderIDs := make([]uint, len(reviews))
+	for i, review := range reviews {
+		orderIDs[i] = review.OrderID
+	}
+
+	mealNames, err := rs.reviewRepository.GetMealNames(orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.ReviewFeedItem, len(reviews))
+	for i, review := range reviews {
+		items[i] = &models.ReviewFeedItem{Review: *review, MealNames: mealNames[review.OrderID]}
+	}
+
+	return items, nil
+}
+
+// Moderate changes the status of a review, which hides, flags or publishes it again.
+func (rs *reviewService) Moderate(reviewID uint, status models.ReviewStatus, moderatedBy string) (*models.Review, error) {
+	if err := status.Valid(); err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	var review *models.Review
+	err := rs.reviewRepository.WithTransaction(func(tx repositories.ReviewRepository) error {
+		var err error
+		review, err = tx.GetByID(reviewID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		review.Status = status
+		review.ModeratedBy = moderatedBy
+		review.ModeratedAt = &now
+		return tx.UpdateStatus(review)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// RemovePhoto removes the photo at the index from a review and deletes all its variants from the image storage.
+// Photos of reviews which do not know the public IDs of their photos are only removed from the review.
+func (rs *reviewService) RemovePhoto(c context.Context, reviewID uint, index int) (*models.Review, error) {
+	var review *models.Review
+	var publicIDs []string
+	err := rs.reviewRepository.WithTransaction(func(tx repositories.ReviewRepository) error {
+		var err error
+		review, err = tx.GetByID(reviewID)
+		if err != nil {
+			return err
+		}
+
+		publicIDs, err = review.RemovePhoto(index)
+		if err != nil {
+			return apperrors.NewValidationErr(err.Error(), err)
+		}
+
+		return tx.UpdatePhotos(review)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(publicIDs) > 0 {
+		if err = rs.imageProcessor.Delete(c, publicIDs...); err != nil {
+			log.Printf("Failed to delete review photo %s: %v", strings.Join(publicIDs, ", "), err)
+		}
+	}
+
+	return review, nil
+}
+
+// Reply adds the reply of a staff member to a review and returns the review with all its replies.
+func (rs *reviewService) Reply(reply *models.ReviewReply) (*models.Review, error) {
+	reply.Message = strings.TrimSpace(reply.Message)
+	if reply.Message == "" {
+		return nil, apperrors.NewValidationErr("Reply cannot be empty", nil)
+	}
+
+	var review *models.Review
+	err := rs.reviewRepository.WithTransaction(func(tx repositories.ReviewRepository) error {
+		if _, err := tx.GetByID(reply.ReviewID); err != nil {
+			return err
+		}
+
+		reply.CreatedAt = time.Now()
+		if err := tx.CreateReply(reply); err != nil {
+			return err
+		}
+
+		var err error
+		review, err = tx.GetByID(reply.ReviewID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// GetMealRating retrieves the average of the ratings the meal got in reviews which are not hidden.
+func (rs *reviewService) GetMealRating(mealID uint) (*models.MealRating, error) {
+	if _, err := rs.mealRepository.GetByID(mealID); err != nil {
+		return nil, err
+	}
+
+	return rs.reviewRepository.GetMealRating(mealID)
+}
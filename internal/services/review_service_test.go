@@ -0,0 +1,276 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/Ruclo/MyMeals/internal/testing/mocks"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// ReviewServiceTestSuite defines the test suite for ReviewService
+type ReviewServiceTestSuite struct {
+	suite.Suite
+	reviewService      services.ReviewService
+	mockReviewRepo     *MockReviewRepository
+	mockMealRepo       *MockMealRepository
+	mockImageProcessor *mocks.MockImageProcessor
+}
+
+func (s *ReviewServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockReviewRepo = new(MockReviewRepository)
+	s.mockMealRepo = new(MockMealRepository)
+	s.mockImageProcessor = new(mocks.MockImageProcessor)
+	s.reviewService = services.NewReviewService(s.mockReviewRepo, s.mockMealRepo, s.mockImageProcessor)
+}
+
+// TearDownTest runs after each test
+func (s *ReviewServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockReviewRepo.AssertExpectations(s.T())
+	s.mockMealRepo.AssertExpectations(s.T())
+	s.mockImageProcessor.AssertExpectations(s.T())
+}
+
+// TestGetFeed tests that the feed leaves out hidden reviews and includes the meal names of the orders
+func (s *ReviewServiceTestSuite) TestGetFeed() {
+	olderThan := time.Now()
+	s.mockReviewRepo.On("GetReviews", repositories.ReviewQueryParams{
+		Statuses:  []models.ReviewStatus{models.PublishedReview, models.FlaggedReview},
+		OlderThan: olderThan,
+		PageSize:  100,
+	}).Return([]*models.Review{{ID: 2, OrderID: 7}, {ID: 1, OrderID: 3}}, nil)
+	s.mockReviewRepo.On("GetMealNames", []uint{7, 3}).Return(map[uint][]string{7: {"Burger", "Lemonade"}}, nil)
+
+	items, err := s.reviewService.GetFeed(olderThan, 500)
+
+	s.NoError(err)
+	s.Require().Len(items, 2)
+	s.Equal(uint(2), items[0].ID)
+	s.Equal([]string{"Burger", "Lemonade"}, items[0].MealNames)
+	s.Empty(items[1].MealNames)
+}
+
+// TestGetReviews tests filtering the reviews by status
+func (s *ReviewServiceTestSuite) TestGetReviews() {
+	s.mockReviewRepo.On("GetReviews", repositories.ReviewQueryParams{
+		Statuses: []models.ReviewStatus{models.HiddenReview},
+		PageSize: 10,
+	}).Return([]*models.Review{}, nil)
+	s.mockReviewRepo.On("GetMealNames", []uint{}).Return(map[uint][]string{}, nil)
+
+	items, err := s.reviewService.GetReviews(models.HiddenReview, time.Time{}, 10)
+	s.NoError(err)
+	s.Empty(items)
+
+	_, err = s.reviewService.GetReviews("deleted", time.Time{}, 10)
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// TestModerate tests changing the status of a review
+func (s *ReviewServiceTestSuite) TestModerate() {
+	s.mockReviewRepo.On("WithTransaction", mock.Anything).Return(nil)
+	s.mockReviewRepo.On("GetByID", uint(1)).Return(&models.Review{ID: 1, Status: models.PublishedReview}, nil)
+	s.mockReviewRepo.On("UpdateStatus", mock.MatchedBy(func(review *models.Review) bool {
+		return review.Status == models.HiddenReview && review.ModeratedBy == "admin" && review.ModeratedAt != nil
+	})).Return(nil)
+
+	review, err := s.reviewService.Moderate(1, models.HiddenReview, "admin")
+
+	s.NoError(err)
+	s.Equal(models.HiddenReview, review.Status)
+
+	_, err = s.reviewService.Moderate(1, "deleted", "admin")
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// TestRemovePhoto tests removing photos from reviews
+func (s *ReviewServiceTestSuite) TestRemovePhoto() {
+	testCases := []struct {
+		name          string
+		review        *models.Review
+		index         int
+		deleted       []string
+		expectedURLs  pq.StringArray
+		expectedError func(error) bool
+	}{
+		{
+			name: "Photo with public ID",
+			review: &models.Review{ID: 1, PhotoURLs: pq.StringArray{"a.jpg", "b.jpg"},
+				PhotoPublicIDs: pq.StringArray{"a", "b"}},
+			index:        1,
+			deleted:      []string{"b"},
+			expectedURLs: pq.StringArray{"a.jpg"},
+		},
+		{
+			name: "Photo with variants",
+			review: &models.Review{ID: 1, PhotoURLs: pq.StringArray{"a.jpg", "b.jpg"},
+				PhotoPublicIDs: pq.StringArray{"a", "b"},
+				PhotoVariants: models.PhotoVariants{
+					{{PublicID: "a-thumbnail"}, {PublicID: "a"}},
+					{{PublicID: "b-thumbnail"}, {PublicID: "b"}},
+				}},
+			index:        0,
+			deleted:      []string{"a-thumbnail", "a"},
+			expectedURLs: pq.StringArray{"b.jpg"},
+		},
+		{
+			name:         "Photo without public ID",
+			review:       &models.Review{ID: 1, PhotoURLs: pq.StringArray{"a.jpg"}, PhotoPublicIDs: pq.StringArray{}},
+			index:        0,
+			expectedURLs: pq.StringArray{},
+		},
+		{
+			name:          "Index out of range",
+			review:        &models.Review{ID: 1, PhotoURLs: pq.StringArray{"a.jpg"}},
+			index:         1,
+			expectedError: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.SetupTest()
+
+			s.mockReviewRepo.On("WithTransaction", mock.Anything).Return(nil)
+			s.mockReviewRepo.On("GetByID", uint(1)).Return(tc.review, nil)
+			if tc.expectedError == nil {
+				s.mockReviewRepo.On("UpdatePhotos", tc.review).Return(nil)
+			}
+			if tc.deleted != nil {
+				s.mockImageProcessor.On("Delete", mock.Anything, tc.deleted).Return(errors.New("storage unavailable"))
+			}
+
+			review, err := s.reviewService.RemovePhoto(context.Background(), 1, tc.index)
+
+			if tc.expectedError != nil {
+				s.True(tc.expectedError(err))
+			} else {
+				s.NoError(err, "failing to delete the photo from the storage does not fail the removal")
+				s.Equal(tc.expectedURLs, review.PhotoURLs)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+// TestReply tests replying to reviews
+func (s *ReviewServiceTestSuite) TestReply() {
+	s.mockReviewRepo.On("WithTransaction", mock.Anything).Return(nil)
+	s.mockReviewRepo.On("GetByID", uint(1)).Return(&models.Review{ID: 1}, nil).Once()
+	s.mockReviewRepo.On("CreateReply", mock.MatchedBy(func(reply *models.ReviewReply) bool {
+		return reply.Message == "Thank you!" && !reply.CreatedAt.IsZero()
+	})).Return(nil)
+	s.mockReviewRepo.On("GetByID", uint(1)).Return(&models.Review{ID: 1,
+		Replies: []models.ReviewReply{{ID: 1, ReviewID: 1, Message: "Thank you!"}}}, nil).Once()
+
+	review, err := s.reviewService.Reply(&models.ReviewReply{ReviewID: 1, Message: "  Thank you! ", RepliedBy: "waiter"})
+
+	s.NoError(err)
+	s.Len(review.Replies, 1)
+
+	_, err = s.reviewService.Reply(&models.ReviewReply{ReviewID: 1, Message: "   ", RepliedBy: "waiter"})
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// TestReplyReviewNotFound tests replying to a review which does not exist
+func (s *ReviewServiceTestSuite) TestReplyReviewNotFound() {
+	s.mockReviewRepo.On("WithTransaction", mock.Anything).Return(nil)
+	s.mockReviewRepo.On("GetByID", uint(9)).Return(nil, apperrors.NewNotFoundErr("Review not found", nil))
+
+	_, err := s.reviewService.Reply(&models.ReviewReply{ReviewID: 9, Message: "Thanks", RepliedBy: "waiter"})
+
+	s.True(apperrors.IsNotFoundErr(err))
+}
+
+// TestGetMealRating tests retrieving the rating of a meal
+func (s *ReviewServiceTestSuite) TestGetMealRating() {
+	s.mockMealRepo.On("GetByID", uint(1)).Return(&models.Meal{ID: 1}, nil)
+	s.mockReviewRepo.On("GetMealRating", uint(1)).Return(&models.MealRating{MealID: 1, Reviews: 2, Average: 3.5}, nil)
+	s.mockMealRepo.On("GetByID", uint(2)).Return(nil, apperrors.NewNotFoundErr("Meal not found", nil))
+
+	rating, err := s.reviewService.GetMealRating(1)
+	s.NoError(err)
+	s.Equal(3.5, rating.Average)
+
+	_, err = s.reviewService.GetMealRating(2)
+	s.True(apperrors.IsNotFoundErr(err))
+}
+
+// Run the test suite
+func TestReviewServiceSuite(t *testing.T) {
+	suite.Run(t, new(ReviewServiceTestSuite))
+}
+
+// MockReviewRepository implementation
+type MockReviewRepository struct {
+	mock.Mock
+}
+
+// WithTransaction implementation for the mock repository
+func (m *MockReviewRepository) WithTransaction(fn func(txRepo repositories.ReviewRepository) error) error {
+	args := m.Called(fn)
+
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+
+	return fn(m)
+}
+
+func (m *MockReviewRepository) GetReviews(params repositories.ReviewQueryParams) ([]*models.Review, error) {
+	args := m.Called(params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) GetByID(reviewID uint) (*models.Review, error) {
+	args := m.Called(reviewID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) GetMealNames(orderIDs []uint) (map[uint][]string, error) {
+	args := m.Called(orderIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint][]string), args.Error(1)
+}
+
+func (m *MockReviewRepository) UpdateStatus(review *models.Review) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) UpdatePhotos(review *models.Review) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) CreateReply(reply *models.ReviewReply) error {
+	args := m.Called(reply)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) GetMealRating(mealID uint) (*models.MealRating, error) {
+	args := m.Called(mealID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MealRating), args.Error(1)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"time"
+)
+
+// TableService defines operations for managing the tables of the restaurant and their sessions.
+type TableService interface {
+	GetAll() ([]*models.Table, error)
+	Create(table *models.Table) error
+	Update(table *models.Table) error
+	GetOverview() ([]*models.TableOverview, error)
+	CloseSession(tableNo int) error
+	IssueToken(tableNo int) (*models.Table, error)
+	RotateToken(tableNo int) (*models.Table, error)
+	RevokeToken(tableNo int) error
+}
+
+type tableService struct {
+	tableRepository repositories.TableRepository
+}
+
+func NewTableService(tableRepository repositories.TableRepository) TableService {
+	return &tableService{tableRepository: tableRepository}
+}
+
+// GetAll retrieves all tables ordered by their number.
+func (ts *tableService) GetAll() ([]*models.Table, error) {
+	return ts.tableRepository.GetAll()
+}
+
+// Create adds a new table, returning an error if a table with the same number already exists.
+func (ts *tableService) Create(table *models.Table) error {
+	_, err := ts.tableRepository.GetByNumber(table.Number)
+	if err == nil {
+		return apperrors.NewAlreadyExistsErr(fmt.Sprintf("Table %d already exists", table.Number), nil)
+	}
+
+	if !apperrors.IsNotFoundErr(err) {
+		return err
+	}
+
+	return ts.tableRepository.Create(table)
+}
+
+// Update replaces the seats, area and active flag of an existing table.
+func (ts *tableService) Update(table *models.Table) error {
+	return ts.tableRepository.Update(table)
+}
+
+// GetOverview returns every table together with its open session, if it has one.
+func (ts *tableService) GetOverview() ([]*models.TableOverview, error) {
+	tables, err := ts.tableRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := ts.tableRepository.GetOpenSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionsByTable := make(map[int]*models.TableSession, len(sessions))
+	for _, session := range sessions {
+		sessionsByTable[session.TableNo] = session
+	}
+
+	overview := make([]*models.TableOverview, len(tables))
+	for i, table := range tables {
+		overview[i] = &models.TableOverview{
+			Table:   table,
+			Session: sessionsByTable[table.Number],
+		}
+	}
+
+	return overview, nil
+}
+
+// CloseSession closes the open session of a table. Sessions with orders which are neither paid
+// nor cancelled cannot be closed.
+func (ts *tableService) CloseSession(tableNo int) error {
+	return ts.tableRepository.WithTransaction(func(tx repositories.TableRepository) error {
+		session, err := tx.GetOpenSession(tableNo)
+		if err != nil {
+			return err
+		}
+
+		if !session.Settled() {
+			return apperrors.NewValidationErr(fmt.Sprintf("Table %d still has unpaid orders", tableNo), nil)
+		}
+
+		return tx.CloseSession(session.ID, time.Now())
+	})
+}
+
+// IssueToken returns the table to issue its current token for.
+// Revoked tokens cannot be issued until they get rotated.
+func (ts *tableService) IssueToken(tableNo int) (*models.Table, error) {
+	table, err := ts.tableRepository.GetByNumber(tableNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if table.TokenRevoked {
+		return nil, apperrors.NewValidationErr(fmt.Sprintf("Token of table %d is revoked, rotate it to issue a new one", tableNo), nil)
+	}
+
+	return table, nil
+}
+
+// RotateToken invalidates all previously issued tokens of the table and returns the table with the new token version.
+func (ts *tableService) RotateToken(tableNo int) (*models.Table, error) {
+	var table *models.Table
+
+	err := ts.tableRepository.WithTransaction(func(tx repositories.TableRepository) error {
+		var err error
+		table, err = tx.GetByNumber(tableNo)
+		if err != nil {
+			return err
+		}
+
+		table.RotateToken()
+		return tx.UpdateToken(table)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// RevokeToken makes the current token of the table invalid until it gets rotated.
+func (ts *tableService) RevokeToken(tableNo int) error {
+	return ts.tableRepository.WithTransaction(func(tx repositories.TableRepository) error {
+		table, err := tx.GetByNumber(tableNo)
+		if err != nil {
+			return err
+		}
+
+		table.TokenRevoked = true
+		return tx.UpdateToken(table)
+	})
+}
@@ -0,0 +1,258 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// TableServiceTestSuite defines the test suite for TableService
+type TableServiceTestSuite struct {
+	suite.Suite
+	tableService  services.TableService
+	mockTableRepo *MockTableRepository
+}
+
+func (s *TableServiceTestSuite) SetupTest() {
+	// Create a fresh mock repo for each test
+	s.mockTableRepo = new(MockTableRepository)
+	s.tableService = services.NewTableService(s.mockTableRepo)
+}
+
+// TearDownTest runs after each test
+func (s *TableServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockTableRepo.AssertExpectations(s.T())
+}
+
+// TestCreate tests the Create method
+func (s *TableServiceTestSuite) TestCreate() {
+	testCases := []struct {
+		name           string
+		table          *models.Table
+		setupMock      func(table *models.Table)
+		expectedError  bool
+		errorPredicate func(error) bool
+	}{
+		{
+			name:  "Success",
+			table: &models.Table{Number: 3, Seats: 4, Area: "Terrace", Active: true},
+			setupMock: func(table *models.Table) {
+				s.mockTableRepo.On("GetByNumber", 3).Return(nil, apperrors.NewNotFoundErr("Table 3 not found", nil))
+				s.mockTableRepo.On("Create", table).Return(nil)
+			},
+		},
+		{
+			name:  "Table already exists",
+			table: &models.Table{Number: 3, Seats: 4, Area: "Terrace", Active: true},
+			setupMock: func(table *models.Table) {
+				s.mockTableRepo.On("GetByNumber", 3).Return(&models.Table{Number: 3}, nil)
+			},
+			expectedError:  true,
+			errorPredicate: apperrors.IsAlreadyExistsErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+			tc.setupMock(tc.table)
+
+			// Act
+			err := s.tableService.Create(tc.table)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				if tc.errorPredicate != nil {
+					s.True(tc.errorPredicate(err))
+				}
+			} else {
+				s.NoError(err)
+			}
+		})
+	}
+}
+
+// TestGetOverview tests the GetOverview method
+func (s *TableServiceTestSuite) TestGetOverview() {
+	paidAt := time.Now()
+	session := &models.TableSession{ID: 1, TableNo: 2, Orders: []models.Order{
+		{ID: 1, PaidAt: &paidAt, OrderMeals: []models.OrderMeal{
+			{UnitPrice: decimal.NewFromInt(10), Quantity: 1, Completed: 1, Served: 1},
+		}, Payments: []*models.Payment{{Amount: decimal.NewFromInt(10)}}},
+		{ID: 2, OrderMeals: []models.OrderMeal{
+			{UnitPrice: decimal.NewFromInt(4), Quantity: 3, Accepted: 1},
+		}},
+	}}
+
+	s.mockTableRepo.On("GetAll").Return([]*models.Table{{Number: 1}, {Number: 2}}, nil)
+	s.mockTableRepo.On("GetOpenSessions").Return([]*models.TableSession{session}, nil)
+
+	overview, err := s.tableService.GetOverview()
+	s.NoError(err)
+	s.Require().Len(overview, 2)
+	s.Nil(overview[0].Session)
+	s.Equal(session, overview[1].Session)
+
+	balance := overview[1].Session.Balance()
+	s.True(decimal.NewFromInt(22).Equal(balance.Total))
+	s.True(decimal.NewFromInt(12).Equal(balance.Outstanding))
+	s.Equal(uint(3), overview[1].Session.UnservedMeals())
+}
+
+// TestCloseSession tests the CloseSession method
+func (s *TableServiceTestSuite) TestCloseSession() {
+	paidAt := time.Now()
+
+	testCases := []struct {
+		name           string
+		session        *models.TableSession
+		expectedError  bool
+		errorPredicate func(error) bool
+	}{
+		{
+			name:    "Success",
+			session: &models.TableSession{ID: 1, TableNo: 2, Orders: []models.Order{{ID: 1, PaidAt: &paidAt}}},
+		},
+		{
+			name:           "Unpaid orders",
+			session:        &models.TableSession{ID: 1, TableNo: 2, Orders: []models.Order{{ID: 1}}},
+			expectedError:  true,
+			errorPredicate: apperrors.IsValidationErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			// Setup fresh mocks
+			s.SetupTest()
+
+			s.mockTableRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.TableRepository) error")).
+				Return(nil)
+			s.mockTableRepo.On("GetOpenSession", 2).Return(tc.session, nil)
+			if !tc.expectedError {
+				s.mockTableRepo.On("CloseSession", uint(1), mock.AnythingOfType("time.Time")).Return(nil)
+			}
+
+			// Act
+			err := s.tableService.CloseSession(2)
+
+			// Assert
+			if tc.expectedError {
+				s.Error(err)
+				if tc.errorPredicate != nil {
+					s.True(tc.errorPredicate(err))
+				}
+			} else {
+				s.NoError(err)
+			}
+		})
+	}
+}
+
+// TestTokens tests issuing, rotating and revoking table tokens
+func (s *TableServiceTestSuite) TestTokens() {
+	table := &models.Table{Number: 2, Seats: 4, Active: true, TokenVersion: 1}
+
+	s.mockTableRepo.On("WithTransaction", mock.AnythingOfType("func(repositories.TableRepository) error")).
+		Return(nil)
+	s.mockTableRepo.On("GetByNumber", 2).Return(table, nil)
+	s.mockTableRepo.On("UpdateToken", table).Return(nil)
+
+	issued, err := s.tableService.IssueToken(2)
+	s.NoError(err)
+	s.Equal(uint(1), issued.TokenVersion)
+
+	s.NoError(s.tableService.RevokeToken(2))
+	s.True(table.TokenRevoked)
+
+	_, err = s.tableService.IssueToken(2)
+	s.True(apperrors.IsValidationErr(err), "revoked tokens cannot be issued")
+
+	rotated, err := s.tableService.RotateToken(2)
+	s.NoError(err)
+	s.Equal(uint(2), rotated.TokenVersion)
+	s.False(rotated.TokenRevoked)
+}
+
+// Run the test suite
+func TestTableServiceSuite(t *testing.T) {
+	suite.Run(t, new(TableServiceTestSuite))
+}
+
+// MockTableRepository implementation
+type MockTableRepository struct {
+	mock.Mock
+}
+
+// WithTransaction implementation for the mock repository
+func (m *MockTableRepository) WithTransaction(fn func(txRepo repositories.TableRepository) error) error {
+	args := m.Called(fn)
+
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+
+	return fn(m)
+}
+
+func (m *MockTableRepository) GetAll() ([]*models.Table, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Table), args.Error(1)
+}
+
+func (m *MockTableRepository) GetByNumber(number int) (*models.Table, error) {
+	args := m.Called(number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Table), args.Error(1)
+}
+
+func (m *MockTableRepository) Create(table *models.Table) error {
+	args := m.Called(table)
+	return args.Error(0)
+}
+
+func (m *MockTableRepository) Update(table *models.Table) error {
+	args := m.Called(table)
+	return args.Error(0)
+}
+
+func (m *MockTableRepository) UpdateToken(table *models.Table) error {
+	args := m.Called(table)
+	return args.Error(0)
+}
+
+func (m *MockTableRepository) GetOpenSessions() ([]*models.TableSession, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TableSession), args.Error(1)
+}
+
+func (m *MockTableRepository) GetOpenSession(tableNo int) (*models.TableSession, error) {
+	args := m.Called(tableNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TableSession), args.Error(1)
+}
+
+func (m *MockTableRepository) CloseSession(sessionID uint, closedAt time.Time) error {
+	args := m.Called(sessionID, closedAt)
+	return args.Error(0)
+}
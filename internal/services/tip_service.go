@@ -0,0 +1,53 @@
+package services
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"time"
+)
+
+// TipService defines operations for reporting the tips received for orders.
+type TipService interface {
+	Report(from, to time.Time) (*models.TipReport, error)
+}
+
+type tipService struct {
+	tipRepository repositories.TipRepository
+	shifts        []models.Shift
+}
+
+func NewTipService(tipRepository repositories.TipRepository, shifts []models.Shift) TipService {
+	return &tipService{
+		tipRepository: tipRepository,
+		shifts:        shifts,
+	}
+}
+
+// Report reports the tips received from the start of the time range until before its end,
+// split per shift and per staff member who completed the meals of the tipped orders.
+func (ts *tipService) Report(from, to time.Time) (*models.TipReport, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	tips, err := ts.tipRepository.GetReceived(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderIDs []uint
+	seen := make(map[uint]bool)
+	for _, tip := range tips {
+		if !seen[tip.OrderID] {
+			seen[tip.OrderID] = true
+			orderIDs = append(orderIDs, tip.OrderID)
+		}
+	}
+
+	completions, err := ts.tipRepository.GetCompletions(orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewTipReport(from, to, tips, completions, ts.shifts), nil
+}
@@ -0,0 +1,109 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// TipServiceTestSuite defines the test suite for TipService
+type TipServiceTestSuite struct {
+	suite.Suite
+	tipService  services.TipService
+	mockTipRepo *MockTipRepository
+}
+
+func (s *TipServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockTipRepo = new(MockTipRepository)
+	s.tipService = services.NewTipService(s.mockTipRepo, []models.Shift{
+		{Name: "Lunch", StartMinute: 10 * 60, EndMinute: 16 * 60},
+		{Name: "Dinner", StartMinute: 16 * 60, EndMinute: 2 * 60},
+	})
+}
+
+// TearDownTest runs after each test
+func (s *TipServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockTipRepo.AssertExpectations(s.T())
+}
+
+// TestReport tests the Report method
+func (s *TipServiceTestSuite) TestReport() {
+	from := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 2)
+
+	s.mockTipRepo.On("GetReceived", from, to).Return([]*models.Tip{
+		{ID: 1, OrderID: 1, Amount: decimal.RequireFromString("10.00"), ReceivedAt: from.Add(13 * time.Hour)},
+		{ID: 2, OrderID: 2, Amount: decimal.RequireFromString("5.00"), ReceivedAt: from.Add(25 * time.Hour)},
+		{ID: 3, OrderID: 3, Amount: decimal.RequireFromString("3.00"), ReceivedAt: from.Add(8 * time.Hour)},
+	}, nil)
+	s.mockTipRepo.On("GetCompletions", []uint{1, 2, 3}).Return([]models.OrderMealStatusChange{
+		{OrderID: 1, ToStatus: models.ReadyStatus, Quantity: 2, ChangedBy: "bob"},
+		{OrderID: 1, ToStatus: models.ReadyStatus, Quantity: 1, ChangedBy: "alice"},
+		{OrderID: 2, ToStatus: models.ReadyStatus, Quantity: 1, ChangedBy: "alice"},
+	}, nil)
+
+	report, err := s.tipService.Report(from, to)
+
+	s.NoError(err)
+	s.True(decimal.RequireFromString("18.00").Equal(report.Total))
+
+	s.Require().Len(report.Shifts, 3)
+	s.Equal(models.OutsideShifts.Name, report.Shifts[0].Shift.Name)
+	s.Equal("Lunch", report.Shifts[1].Shift.Name)
+	s.Require().Len(report.Shifts[1].Staff, 2)
+	s.Equal("alice", report.Shifts[1].Staff[0].Username)
+	s.True(decimal.RequireFromString("3.34").Equal(report.Shifts[1].Staff[0].Amount), "the remaining cent goes to alice")
+	s.True(decimal.RequireFromString("6.66").Equal(report.Shifts[1].Staff[1].Amount))
+	s.Equal("Dinner", report.Shifts[2].Shift.Name)
+	s.True(from.Equal(report.Shifts[2].Date), "the dinner shift started the day before")
+
+	s.Require().Len(report.Staff, 3)
+	s.Equal("alice", report.Staff[0].Username)
+	s.True(decimal.RequireFromString("8.34").Equal(report.Staff[0].Amount))
+	s.Equal("bob", report.Staff[1].Username)
+	s.Equal(models.UnassignedStaff, report.Staff[2].Username)
+	s.True(decimal.RequireFromString("3.00").Equal(report.Staff[2].Amount))
+}
+
+// TestReportInvalidRange tests that Report rejects ranges ending before they start
+func (s *TipServiceTestSuite) TestReportInvalidRange() {
+	now := time.Now()
+
+	_, err := s.tipService.Report(now, now)
+
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// Run the test suite
+func TestTipServiceSuite(t *testing.T) {
+	suite.Run(t, new(TipServiceTestSuite))
+}
+
+// MockTipRepository implementation
+type MockTipRepository struct {
+	mock.Mock
+}
+
+func (m *MockTipRepository) GetReceived(from, to time.Time) ([]*models.Tip, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Tip), args.Error(1)
+}
+
+func (m *MockTipRepository) GetCompletions(orderIDs []uint) ([]models.OrderMealStatusChange, error) {
+	args := m.Called(orderIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrderMealStatusChange), args.Error(1)
+}
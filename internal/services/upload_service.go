@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"log"
+	"mime/multipart"
+	"time"
+)
+
+// UploadService defines operations for upload sessions, which images are uploaded into ahead of the review
+// or the meal they are attached to, so creating the review or the meal does not wait for the uploads.
+// Uploads are attached by their IDs, sessions expire after their time to live and the images which were
+// not attached by then are deleted.
+type UploadService interface {
+	CreateSession(purpose models.UploadPurpose, owner string) (*models.UploadSession, error)
+	Upload(c context.Context, sessionID, owner string, photo *multipart.FileHeader) (*models.Upload, error)
+	DeleteUpload(c context.Context, sessionID, uploadID, owner string) error
+	DeleteExpired(c context.Context) (int, error)
+	Run(c context.Context, interval time.Duration)
+}
+
+type uploadService struct {
+	uploadRepository repositories.UploadRepository
+	imageProcessor   storage.ImageProcessor
+	sessionTTL       time.Duration
+}
+
+func NewUploadService(uploadRepository repositories.UploadRepository,
+	imageProcessor storage.ImageProcessor,
+	sessionTTL time.Duration) UploadService {
+	return &uploadService{
+		uploadRepository: uploadRepository,
+		imageProcessor:   imageProcessor,
+		sessionTTL:       sessionTTL,
+	}
+}
+
+// CreateSession starts an upload session of the owner for the purpose, which expires after the time to live.
+func (us *uploadService) CreateSession(purpose models.UploadPurpose, owner string) (*models.UploadSession, error) {
+	if err := purpose.Valid(); err != nil {
+		return nil, apperrors.NewValidationErr(err.Error(), err)
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.UploadSession{
+		ID:        id,
+		Purpose:   purpose,
+		Owner:     owner,
+		ExpiresAt: time.Now().Add(us.sessionTTL),
+		Uploads:   []models.Upload{},
+	}
+
+	if err = us.uploadRepository.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Upload stores the variants of a photo the way they are stored for the purpose of the session
+// and adds the photo to the session. Sessions which expired or hold their maximum number of uploads are rejected.
+func (us *uploadService) Upload(c context.Context,
+	sessionID, owner string,
+	photo *multipart.FileHeader) (*models.Upload, error) {
+	session, err := us.getSession(sessionID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Expired(time.Now()) {
+		return nil, apperrors.NewValidationErr(fmt.Sprintf("Upload session %s expired", sessionID), nil)
+	}
+
+	if len(session.Uploads) >= session.Purpose.MaxUploads() {
+		return nil, apperrors.NewValidationErr(
+			fmt.Sprintf("Upload session %s takes at most %d photos", sessionID, session.Purpose.MaxUploads()), nil)
+	}
+
+	if err = validateImageFile(photo); err != nil {
+		return nil, err
+	}
+
+	var image *storage.ProcessedImage
+	if session.Purpose == models.MealUpload {
+		image, err = us.imageProcessor.ProcessCropped(c, photo, MealPhotoSize, MealPhotoSize)
+	} else {
+		image, err = us.imageProcessor.Process(c, photo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		us.imageProcessor.Delete(c, image.PublicIDs()...)
+		return nil, err
+	}
+
+	upload := &models.Upload{
+		ID:              id,
+		UploadSessionID: session.ID,
+		ImageURL:        image.URL,
+		ImagePublicID:   image.PublicID,
+		ImageVariants:   toImageVariants(image),
+	}
+
+	if err = us.uploadRepository.CreateUpload(upload); err != nil {
+		us.imageProcessor.Delete(c, image.PublicIDs()...)
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// DeleteUpload removes a photo which is no longer going to be attached from its session and deletes its variants.
+func (us *uploadService) DeleteUpload(c context.Context, sessionID, uploadID, owner string) error {
+	session, err := us.getSession(sessionID, owner)
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range session.Uploads {
+		if upload.ID != uploadID {
+			continue
+		}
+
+		if err = us.uploadRepository.DeleteUploads([]string{upload.ID}); err != nil {
+			return err
+		}
+
+		// Variants which fail to be deleted are left to the orphaned image cleanup
+		if err = us.imageProcessor.Delete(c, upload.PublicIDs()...); err != nil {
+			log.Printf("Failed to delete upload %s: %v", upload.ID, err)
+		}
+		return nil
+	}
+
+	return apperrors.NewNotFoundErr(fmt.Sprintf("Upload %s not found in session %s", uploadID, sessionID), nil)
+}
+
+// DeleteExpired deletes the expired upload sessions along with the variants of the photos which were not attached
+// and returns the number of deleted sessions. Deleting the other sessions continues if deleting one of them fails,
+// the errors are returned along with the number.
+func (us *uploadService) DeleteExpired(c context.Context) (int, error) {
+	sessions, err := us.uploadRepository.GetExpiredSessions(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	var errs []error
+	for _, session := range sessions {
+		// The session is deleted first, so its photos cannot be attached while they are being deleted
+		if err = us.uploadRepository.DeleteSession(session.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted++
+
+		if publicIDs := session.PublicIDs(); len(publicIDs) > 0 {
+			if err = us.imageProcessor.Delete(c, publicIDs...); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return deleted, errors.Join(errs...)
+}
+
+// Run deletes the expired upload sessions every interval until the context is cancelled.
+func (us *uploadService) Run(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			deleted, err := us.DeleteExpired(c)
+			if err != nil {
+				log.Printf("Failed to delete expired upload sessions: %v", err)
+			}
+			if deleted > 0 {
+				log.Printf("Deleted %d expired upload sessions", deleted)
+			}
+		}
+	}
+}
+
+// getSession retrieves an upload session of the owner. Sessions of other owners are not found.
+func (us *uploadService) getSession(sessionID, owner string) (*models.UploadSession, error) {
+	session, err := us.uploadRepository.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Owner != owner {
+		return nil, apperrors.NewNotFoundErr(fmt.Sprintf("Upload session %s not found", sessionID), nil)
+	}
+
+	return session, nil
+}
+
+// claimUploads removes the uploads with the IDs from the unexpired upload sessions of the owner for the purpose,
+// so they cannot be attached twice, and returns them in the order of the IDs.
+// Fails with a validation error if any of the uploads is not found. It has to run within the transaction storing
+// what the uploads are attached to, so they stay in their sessions if that fails.
+func claimUploads(uploadRepository repositories.UploadRepository,
+	owner string,
+	purpose models.UploadPurpose,
+	uploadIDs []string) ([]*models.Upload, error) {
+	if len(uploadIDs) == 0 {
+		return nil, nil
+	}
+
+	uploads, err := uploadRepository.GetUploads(owner, purpose, uploadIDs, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	uploadsByID := make(map[string]*models.Upload, len(uploads))
+	for _, upload := range uploads {
+		uploadsByID[upload.ID] = upload
+	}
+
+	claimed := make([]*models.Upload, len(uploadIDs))
+	for i, uploadID := range uploadIDs {
+		upload, ok := uploadsByID[uploadID]
+		if !ok {
+			return nil, apperrors.NewValidationErr(fmt.Sprintf("Upload %s not found or already attached", uploadID), nil)
+		}
+		delete(uploadsByID, uploadID)
+		claimed[i] = upload
+	}
+
+	if err = uploadRepository.DeleteUploads(uploadIDs); err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// newUploadID returns a random hex encoded ID of an upload session or an upload.
+func newUploadID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", apperrors.NewInternalServerErr("Failed to generate upload id", err)
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,257 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/Ruclo/MyMeals/internal/repositories"
+	"github.com/Ruclo/MyMeals/internal/services"
+	"github.com/Ruclo/MyMeals/internal/testing/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// UploadServiceTestSuite defines the test suite for UploadService
+type UploadServiceTestSuite struct {
+	suite.Suite
+	uploadService      services.UploadService
+	mockUploadRepo     *MockUploadRepository
+	mockImageProcessor *mocks.MockImageProcessor
+}
+
+func (s *UploadServiceTestSuite) SetupTest() {
+	// Create fresh mocks for each test
+	s.mockUploadRepo = new(MockUploadRepository)
+	s.mockImageProcessor = new(mocks.MockImageProcessor)
+	s.uploadService = services.NewUploadService(s.mockUploadRepo, s.mockImageProcessor, time.Hour)
+}
+
+// TearDownTest runs after each test
+func (s *UploadServiceTestSuite) TearDownTest() {
+	// Verify all mock expectations were met
+	s.mockUploadRepo.AssertExpectations(s.T())
+	s.mockImageProcessor.AssertExpectations(s.T())
+}
+
+// TestCreateSession tests that sessions are started for their owner and expire after the time to live
+func (s *UploadServiceTestSuite) TestCreateSession() {
+	s.mockUploadRepo.On("CreateSession", mock.AnythingOfType("*models.UploadSession")).Return(nil)
+
+	session, err := s.uploadService.CreateSession(models.ReviewUpload, "order:1")
+
+	s.NoError(err)
+	s.Len(session.ID, 32)
+	s.Equal(models.ReviewUpload, session.Purpose)
+	s.Equal("order:1", session.Owner)
+	s.WithinDuration(time.Now().Add(time.Hour), session.ExpiresAt, time.Minute)
+
+	_, err = s.uploadService.CreateSession("avatar", "order:1")
+	s.True(apperrors.IsValidationErr(err))
+}
+
+// TestUpload tests uploading photos into sessions of different purposes and states
+func (s *UploadServiceTestSuite) TestUpload() {
+	photo := newTestImageFileHeader(s.T(), "photo.png")
+
+	s.Run("Review photos keep their aspect ratio", func() {
+		s.SetupTest()
+		s.mockUploadRepo.On("GetSession", "session").Return(newTestUploadSession(models.ReviewUpload), nil)
+		s.mockImageProcessor.On("Process", mock.Anything, photo).Return(newTestProcessedImage("photo.jpg", "photo"), nil)
+		s.mockUploadRepo.On("CreateUpload", mock.MatchedBy(func(upload *models.Upload) bool {
+			return upload.UploadSessionID == "session" && upload.ImageURL == "photo.jpg" && len(upload.ImageVariants) == 2
+		})).Return(nil)
+
+		upload, err := s.uploadService.Upload(context.Background(), "session", "order:1", photo)
+
+		s.NoError(err)
+		s.NotEmpty(upload.ID)
+		s.Equal([]string{"photo", "photo-webp"}, upload.PublicIDs())
+	})
+
+	s.Run("Meal photos are cropped", func() {
+		s.SetupTest()
+		s.mockUploadRepo.On("GetSession", "session").Return(newTestUploadSession(models.MealUpload), nil)
+		s.mockImageProcessor.On("ProcessCropped", mock.Anything, photo, services.MealPhotoSize, services.MealPhotoSize).
+			Return(newTestProcessedImage("meal.jpg", "meal"), nil)
+		s.mockUploadRepo.On("CreateUpload", mock.AnythingOfType("*models.Upload")).Return(nil)
+
+		_, err := s.uploadService.Upload(context.Background(), "session", "order:1", photo)
+
+		s.NoError(err)
+	})
+
+	s.Run("Sessions of other owners are not found", func() {
+		s.SetupTest()
+		s.mockUploadRepo.On("GetSession", "session").Return(newTestUploadSession(models.ReviewUpload), nil)
+
+		_, err := s.uploadService.Upload(context.Background(), "session", "order:2", photo)
+
+		s.True(apperrors.IsNotFoundErr(err))
+	})
+
+	s.Run("Expired session", func() {
+		s.SetupTest()
+		session := newTestUploadSession(models.ReviewUpload)
+		session.ExpiresAt = time.Now().Add(-time.Minute)
+		s.mockUploadRepo.On("GetSession", "session").Return(session, nil)
+
+		_, err := s.uploadService.Upload(context.Background(), "session", "order:1", photo)
+
+		s.True(apperrors.IsValidationErr(err))
+	})
+
+	s.Run("Full session", func() {
+		s.SetupTest()
+		session := newTestUploadSession(models.MealUpload)
+		session.Uploads = []models.Upload{*newTestUpload("meal")}
+		s.mockUploadRepo.On("GetSession", "session").Return(session, nil)
+
+		_, err := s.uploadService.Upload(context.Background(), "session", "order:1", photo)
+
+		s.True(apperrors.IsValidationErr(err))
+	})
+
+	s.Run("Variants are deleted if the upload fails to be stored", func() {
+		s.SetupTest()
+		s.mockUploadRepo.On("GetSession", "session").Return(newTestUploadSession(models.ReviewUpload), nil)
+		s.mockImageProcessor.On("Process", mock.Anything, photo).Return(newTestProcessedImage("photo.jpg", "photo"), nil)
+		s.mockUploadRepo.On("CreateUpload", mock.AnythingOfType("*models.Upload")).
+			Return(apperrors.NewInternalServerErr("Failed to create upload", nil))
+		s.mockImageProcessor.On("Delete", mock.Anything, []string{"photo", "photo-webp"}).Return(nil)
+
+		_, err := s.uploadService.Upload(context.Background(), "session", "order:1", photo)
+
+		s.True(apperrors.IsInternalServerErr(err))
+	})
+}
+
+// TestDeleteUpload tests removing a photo from its session
+func (s *UploadServiceTestSuite) TestDeleteUpload() {
+	session := newTestUploadSession(models.ReviewUpload)
+	session.Uploads = []models.Upload{*newTestUpload("first"), *newTestUpload("second")}
+	s.mockUploadRepo.On("GetSession", "session").Return(session, nil)
+	s.mockUploadRepo.On("DeleteUploads", []string{"second"}).Return(nil)
+	s.mockImageProcessor.On("Delete", mock.Anything, []string{"second", "second-webp"}).Return(nil)
+
+	s.NoError(s.uploadService.DeleteUpload(context.Background(), "session", "second", "order:1"))
+
+	err := s.uploadService.DeleteUpload(context.Background(), "session", "third", "order:1")
+	s.True(apperrors.IsNotFoundErr(err))
+}
+
+// TestDeleteExpired tests that expired sessions are deleted before their photos and that a failure does not stop the rest
+func (s *UploadServiceTestSuite) TestDeleteExpired() {
+	failed := newTestUploadSession(models.ReviewUpload)
+	failed.ID = "failed"
+	failed.Uploads = []models.Upload{*newTestUpload("kept")}
+	abandoned := newTestUploadSession(models.ReviewUpload)
+	abandoned.ID = "abandoned"
+	abandoned.Uploads = []models.Upload{*newTestUpload("first"), *newTestUpload("second")}
+	empty := newTestUploadSession(models.MealUpload)
+	empty.ID = "empty"
+
+	s.mockUploadRepo.On("GetExpiredSessions", mock.AnythingOfType("time.Time")).
+		Return([]*models.UploadSession{failed, abandoned, empty}, nil)
+	s.mockUploadRepo.On("DeleteSession", "failed").Return(apperrors.NewInternalServerErr("Database error", nil))
+	s.mockUploadRepo.On("DeleteSession", "abandoned").Return(nil)
+	s.mockUploadRepo.On("DeleteSession", "empty").Return(nil)
+	s.mockImageProcessor.On("Delete", mock.Anything, []string{"first", "first-webp", "second", "second-webp"}).
+		Return(nil)
+
+	deleted, err := s.uploadService.DeleteExpired(context.Background())
+
+	s.Error(err)
+	s.Equal(2, deleted)
+}
+
+func TestUploadServiceSuite(t *testing.T) {
+	suite.Run(t, new(UploadServiceTestSuite))
+}
+
+// newTestUploadSession returns an upload session of order 1 for the purpose, which expires in an hour.
+func newTestUploadSession(purpose models.UploadPurpose) *models.UploadSession {
+	return &models.UploadSession{
+		ID:        "session",
+		Purpose:   purpose,
+		Owner:     "order:1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+// newTestUpload returns an upload of the image with the public ID, with the same variants as newTestProcessedImage.
+func newTestUpload(publicID string) *models.Upload {
+	return &models.Upload{
+		ID:            publicID,
+		ImageURL:      publicID + ".jpg",
+		ImagePublicID: publicID,
+		ImageVariants: models.ImageVariants{
+			{Size: "thumbnail", ContentType: "image/jpeg", Width: 2, Height: 2,
+				URL: publicID + ".jpg", PublicID: publicID},
+			{Size: "thumbnail", ContentType: "image/webp", Width: 2, Height: 2,
+				URL: publicID + ".jpg.webp", PublicID: publicID + "-webp"},
+		},
+	}
+}
+
+// MockUploadRepository implementation
+type MockUploadRepository struct {
+	mock.Mock
+}
+
+func (m *MockUploadRepository) WithTransaction(fn func(txRepo repositories.UploadRepository) error) error {
+	args := m.Called(fn)
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+	return fn(m)
+}
+
+func (m *MockUploadRepository) CreateSession(session *models.UploadSession) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockUploadRepository) GetSession(sessionID string) (*models.UploadSession, error) {
+	args := m.Called(sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UploadSession), args.Error(1)
+}
+
+func (m *MockUploadRepository) CreateUpload(upload *models.Upload) error {
+	args := m.Called(upload)
+	return args.Error(0)
+}
+
+func (m *MockUploadRepository) GetUploads(owner string,
+	purpose models.UploadPurpose,
+	uploadIDs []string,
+	at time.Time) ([]*models.Upload, error) {
+	args := m.Called(owner, purpose, uploadIDs, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Upload), args.Error(1)
+}
+
+func (m *MockUploadRepository) DeleteUploads(uploadIDs []string) error {
+	args := m.Called(uploadIDs)
+	return args.Error(0)
+}
+
+func (m *MockUploadRepository) GetExpiredSessions(at time.Time) ([]*models.UploadSession, error) {
+	args := m.Called(at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.UploadSession), args.Error(1)
+}
+
+func (m *MockUploadRepository) DeleteSession(sessionID string) error {
+	args := m.Called(sessionID)
+	return args.Error(0)
+}
@@ -228,7 +228,7 @@ func (s *UserServiceTestSuite) TestChangePassword() {
 					Role:     models.RegularStaffRole,
 				}
 				s.mockRepo.On("GetByUsername", "testuser").Return(mockUser, nil)
-				s.mockRepo.On("Replace", mock.AnythingOfType("*models.User")).Return(nil)
+				s.mockRepo.On("Update", mock.AnythingOfType("*models.User")).Return(nil)
 			},
 			expectedError: false,
 		},
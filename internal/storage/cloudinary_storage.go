@@ -1,12 +1,16 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"mime/multipart"
 
 	"github.com/Ruclo/MyMeals/internal/apperrors"
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
@@ -55,6 +59,19 @@ func (c *CloudinaryStorage) UploadCropped(ctx context.Context,
 	}, nil
 }
 
+// UploadEncoded uploads an encoded image without transformations.
+func (c *CloudinaryStorage) UploadEncoded(ctx context.Context, data []byte, contentType string) (*ImageResult, error) {
+	result, err := c.client.Upload.Upload(ctx, bytes.NewReader(data), uploader.UploadParams{})
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to upload image", err)
+	}
+
+	return &ImageResult{
+		URL:      result.SecureURL,
+		PublicID: result.PublicID,
+	}, nil
+}
+
 // Delete removes an image from storage.
 func (c *CloudinaryStorage) Delete(ctx context.Context, publicID string) error {
 	_, err := c.client.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: publicID})
@@ -63,3 +80,36 @@ func (c *CloudinaryStorage) Delete(ctx context.Context, publicID string) error {
 	}
 	return nil
 }
+
+// List returns all uploaded images of the Cloudinary account, paging through the Admin API.
+func (c *CloudinaryStorage) List(ctx context.Context) ([]StoredImage, error) {
+	var images []StoredImage
+	params := admin.AssetsParams{
+		AssetType:    api.Image,
+		DeliveryType: string(api.Upload),
+		MaxResults:   500,
+	}
+
+	for {
+		result, err := c.client.Admin.Assets(ctx, params)
+		if err == nil && result.Error.Message != "" {
+			err = errors.New(result.Error.Message)
+		}
+		if err != nil {
+			return nil, apperrors.NewInternalServerErr("Failed to list images", err)
+		}
+
+		for _, asset := range result.Assets {
+			images = append(images, StoredImage{
+				PublicID:  asset.PublicID,
+				URL:       asset.SecureURL,
+				CreatedAt: asset.CreatedAt,
+			})
+		}
+
+		if result.NextCursor == "" {
+			return images, nil
+		}
+		params.NextCursor = result.NextCursor
+	}
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"mime/multipart"
+)
+
+// ImageVariantSize is a size images are scaled down to, limiting their greater dimension.
+type ImageVariantSize struct {
+	Name         string
+	MaxDimension int
+}
+
+// ImageVariantSizes are the sizes of the variants of processed images, smallest first.
+var ImageVariantSizes = []ImageVariantSize{
+	{Name: "thumbnail", MaxDimension: 320},
+	{Name: "medium", MaxDimension: 960},
+	{Name: "large", MaxDimension: MaxImageDimension},
+}
+
+// ImageVariant is a copy of a processed image scaled to one of the variant sizes and encoded as one of the formats.
+type ImageVariant struct {
+	ImageResult
+	Size        string
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// ProcessedImage is an image stored in all its variants.
+// Its URL and public ID are those of its largest variant in the format of the original image,
+// which can be shown by clients which do not pick one of the variants.
+type ProcessedImage struct {
+	ImageResult
+	Variants []ImageVariant
+}
+
+// PublicIDs returns the public IDs of all variants of the image.
+func (p *ProcessedImage) PublicIDs() []string {
+	publicIDs := make([]string, len(p.Variants))
+	for i, variant := range p.Variants {
+		publicIDs[i] = variant.PublicID
+	}
+	return publicIDs
+}
+
+// ImageProcessor prepares uploaded images for the web before they are stored, independent of the image storage.
+// The metadata of images, like the location embedded by phones, is stripped and images are turned upright.
+// Every image is stored in each of the ImageVariantSizes, in its own format and, unless it is stored as JPEG, as WebP.
+type ImageProcessor interface {
+	// Process stores the variants of an image.
+	Process(ctx context.Context, file *multipart.FileHeader) (*ProcessedImage, error)
+
+	// ProcessCropped stores the variants of the region of the given size from the center of an image.
+	ProcessCropped(ctx context.Context, file *multipart.FileHeader, width, height int) (*ProcessedImage, error)
+
+	// Delete removes the variants with the public IDs from storage.
+	Delete(ctx context.Context, publicIDs ...string) error
+}
+
+type imageProcessor struct {
+	imageStorage ImageStorage
+}
+
+func NewImageProcessor(imageStorage ImageStorage) ImageProcessor {
+	return &imageProcessor{imageStorage: imageStorage}
+}
+
+func (p *imageProcessor) Process(ctx context.Context, file *multipart.FileHeader) (*ProcessedImage, error) {
+	img, format, err := decodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.process(ctx, img, format)
+}
+
+func (p *imageProcessor) ProcessCropped(ctx context.Context,
+	file *multipart.FileHeader, width, height int) (*ProcessedImage, error) {
+	img, format, err := decodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.process(ctx, cropCenter(img, width, height), format)
+}
+
+// Delete tries to remove all variants, even if removing one of them fails, and returns the errors of all that failed.
+func (p *imageProcessor) Delete(ctx context.Context, publicIDs ...string) error {
+	var errs []error
+	for _, publicID := range publicIDs {
+		if err := p.imageStorage.Delete(ctx, publicID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// process scales the image to the variant sizes and stores each size as the types returned by variantContentTypes.
+// Images are never scaled up, so a size is skipped if the image is as small as the previous size already.
+// If storing a variant fails, the variants stored before are removed.
+func (p *imageProcessor) process(ctx context.Context, img image.Image, format string) (*ProcessedImage, error) {
+	processed := &ProcessedImage{}
+	fallbackContentType := imageContentType(format)
+
+	var previousSize image.Point
+	for _, size := range ImageVariantSizes {
+		scaled := limitSize(img, size.MaxDimension)
+		if scaled.Bounds().Size() == previousSize {
+			continue
+		}
+		previousSize = scaled.Bounds().Size()
+
+		for _, contentType := range variantContentTypes(format) {
+			variant, err := p.store(ctx, scaled, contentType)
+			if err != nil {
+				p.Delete(ctx, processed.PublicIDs()...)
+				return nil, err
+			}
+
+			variant.Size = size.Name
+			processed.Variants = append(processed.Variants, *variant)
+			if contentType == fallbackContentType {
+				processed.ImageResult = variant.ImageResult
+			}
+		}
+	}
+
+	return processed, nil
+}
+
+// store encodes the scaled image as the MIME type and uploads it.
+func (p *imageProcessor) store(ctx context.Context, img image.Image, contentType string) (*ImageVariant, error) {
+	var buf bytes.Buffer
+	if err := encodeImageAs(&buf, img, contentType); err != nil {
+		return nil, err
+	}
+
+	result, err := p.imageStorage.UploadEncoded(ctx, buf.Bytes(), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImageVariant{
+		ImageResult: *result,
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}, nil
+}
@@ -0,0 +1,199 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"github.com/Ruclo/MyMeals/internal/testing/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// withOrientation inserts an EXIF segment with the orientation after the start of a JPEG image.
+func withOrientation(t *testing.T, data []byte, orientation byte) []byte {
+	require.Equal(t, []byte{0xFF, 0xD8}, data[:2])
+
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, // big endian header, first directory at 8
+		0x00, 0x01, // one entry
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, orientation, 0x00, 0x00, // orientation, short
+		0x00, 0x00, 0x00, 0x00, // no next directory
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xFF, 0xE1, 0x00, byte(len(payload) + 2)}, payload...)
+
+	return append(append([]byte{0xFF, 0xD8}, segment...), data[2:]...)
+}
+
+// encodeHalfRedJPEG encodes a JPEG image whose left half is red and right half is white.
+func encodeHalfRedJPEG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}))
+	return buf.Bytes()
+}
+
+// encodeGradientPNG encodes a PNG image whose colors fade from left to right and top to bottom.
+func encodeGradientPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// storedSize returns the size in bytes of the stored file with the public ID.
+func storedSize(t *testing.T, dir, publicID string) int64 {
+	info, err := os.Stat(filepath.Join(dir, publicID))
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestImageProcessor(t *testing.T) {
+	dir := t.TempDir()
+	imageStorage, err := storage.NewLocalImageStorage(dir, "/images")
+	require.NoError(t, err)
+	imageProcessor := storage.NewImageProcessor(imageStorage)
+	ctx := context.Background()
+
+	t.Run("Process stores every size of JPEG images as JPEG only", func(t *testing.T) {
+		processed, err := imageProcessor.Process(ctx, newFileHeader(t, "wide.jpg", encodeJPEG(t, 2400, 1200)))
+		require.NoError(t, err)
+		require.Len(t, processed.Variants, 3)
+
+		expected := []struct {
+			size  string
+			width int
+		}{
+			{"thumbnail", 320}, {"medium", 960}, {"large", 1920},
+		}
+		for i, variant := range processed.Variants {
+			assert.Equal(t, expected[i].size, variant.Size)
+			assert.Equal(t, "image/jpeg", variant.ContentType)
+			assert.Equal(t, expected[i].width, variant.Width)
+			assert.Equal(t, expected[i].width/2, variant.Height)
+
+			img, format := decodeStored(t, dir, variant.PublicID)
+			assert.Equal(t, "jpeg", format)
+			assert.Equal(t, image.Pt(variant.Width, variant.Height), img.Bounds().Size())
+		}
+
+		assert.Equal(t, processed.Variants[2].ImageResult, processed.ImageResult,
+			"the image is the largest variant in its own format")
+	})
+
+	t.Run("Process stores every size of PNG images as PNG and as smaller WebP", func(t *testing.T) {
+		processed, err := imageProcessor.Process(ctx, newFileHeader(t, "wide.png", encodeGradientPNG(t, 1200, 600)))
+		require.NoError(t, err)
+		require.Len(t, processed.Variants, 6)
+
+		expected := []struct {
+			size        string
+			contentType string
+			width       int
+		}{
+			{"thumbnail", "image/png", 320}, {"thumbnail", "image/webp", 320},
+			{"medium", "image/png", 960}, {"medium", "image/webp", 960},
+			{"large", "image/png", 1200}, {"large", "image/webp", 1200},
+		}
+		for i, variant := range processed.Variants {
+			assert.Equal(t, expected[i].size, variant.Size)
+			assert.Equal(t, expected[i].contentType, variant.ContentType)
+			assert.Equal(t, expected[i].width, variant.Width)
+
+			img, format := decodeStored(t, dir, variant.PublicID)
+			assert.Equal(t, map[string]string{"image/png": "png", "image/webp": "webp"}[variant.ContentType], format)
+			assert.Equal(t, image.Pt(variant.Width, variant.Height), img.Bounds().Size())
+		}
+
+		for i := 0; i < len(processed.Variants); i += 2 {
+			pngSize := storedSize(t, dir, processed.Variants[i].PublicID)
+			webpSize := storedSize(t, dir, processed.Variants[i+1].PublicID)
+			assert.Less(t, webpSize, pngSize, "the %s WebP is smaller than the PNG", processed.Variants[i].Size)
+		}
+		assert.Equal(t, processed.Variants[4].ImageResult, processed.ImageResult,
+			"the image is the largest variant in its own format")
+	})
+
+	t.Run("Small images are not scaled up", func(t *testing.T) {
+		processed, err := imageProcessor.ProcessCropped(ctx, newFileHeader(t, "icon.png", encodePNG(t, 400, 100)), 256, 256)
+		require.NoError(t, err)
+		require.Len(t, processed.Variants, 2)
+		assert.Equal(t, "image/png", processed.Variants[0].ContentType)
+		assert.Equal(t, 256, processed.Variants[0].Width)
+		assert.Equal(t, 100, processed.Variants[0].Height)
+	})
+
+	t.Run("Images are turned upright", func(t *testing.T) {
+		// Where the red left half of the image ends up
+		testCases := []struct {
+			orientation byte
+			size        image.Point
+			red, white  image.Point
+		}{
+			{orientation: 1, size: image.Pt(40, 20), red: image.Pt(5, 10), white: image.Pt(35, 10)},
+			{orientation: 2, size: image.Pt(40, 20), red: image.Pt(35, 10), white: image.Pt(5, 10)},
+			{orientation: 3, size: image.Pt(40, 20), red: image.Pt(35, 10), white: image.Pt(5, 10)},
+			{orientation: 6, size: image.Pt(20, 40), red: image.Pt(10, 5), white: image.Pt(10, 35)},
+			{orientation: 8, size: image.Pt(20, 40), red: image.Pt(10, 35), white: image.Pt(10, 5)},
+		}
+
+		for _, tc := range testCases {
+			data := withOrientation(t, encodeHalfRedJPEG(t, 40, 20), tc.orientation)
+			processed, err := imageProcessor.Process(ctx, newFileHeader(t, "rotated.jpg", data))
+			require.NoError(t, err)
+
+			img, _ := decodeStored(t, dir, processed.PublicID)
+			require.Equal(t, tc.size, img.Bounds().Size(), "orientation %d", tc.orientation)
+			assert.True(t, isRed(img.At(tc.red.X, tc.red.Y)), "orientation %d", tc.orientation)
+			assert.False(t, isRed(img.At(tc.white.X, tc.white.Y)), "orientation %d", tc.orientation)
+
+			stored, err := os.ReadFile(filepath.Join(dir, processed.PublicID))
+			require.NoError(t, err)
+			assert.NotContains(t, string(stored), "Exif", "the metadata is stripped")
+		}
+	})
+
+	t.Run("Stored variants are deleted if a variant fails", func(t *testing.T) {
+		mockStorage := new(mocks.MockImageStorage)
+		mockStorage.On("UploadEncoded", mock.Anything, mock.Anything, "image/png").
+			Return(&storage.ImageResult{URL: "/images/a.png", PublicID: "a.png"}, nil)
+		mockStorage.On("UploadEncoded", mock.Anything, mock.Anything, "image/webp").
+			Return(nil, errors.New("storage unavailable"))
+		mockStorage.On("Delete", mock.Anything, "a.png").Return(nil)
+
+		_, err := storage.NewImageProcessor(mockStorage).Process(ctx, newFileHeader(t, "a.png", encodePNG(t, 10, 10)))
+		assert.Error(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"mime/multipart"
+	"time"
 )
 
 // ImageResult represents the result of an image upload.
@@ -11,6 +12,13 @@ type ImageResult struct {
 	PublicID string
 }
 
+// StoredImage is an image found in the image storage.
+type StoredImage struct {
+	PublicID  string
+	URL       string
+	CreatedAt time.Time
+}
+
 // ImageStorage defines an interface for image storage operations.
 type ImageStorage interface {
 	// Upload uploads an image with default parameters.
@@ -19,6 +27,12 @@ type ImageStorage interface {
 	// UploadCropped uploads an image with cropping parameters.
 	UploadCropped(ctx context.Context, file *multipart.FileHeader, width, height int) (*ImageResult, error)
 
+	// UploadEncoded uploads an image encoded as the MIME type as it is, without processing it.
+	UploadEncoded(ctx context.Context, data []byte, contentType string) (*ImageResult, error)
+
 	// Delete removes an image from storage.
 	Delete(ctx context.Context, publicID string) error
+
+	// List returns all images in storage.
+	List(ctx context.Context) ([]StoredImage, error)
 }
@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxImageDimension is the greater dimension images are scaled down to by Upload.
+const MaxImageDimension = 1920
+
+// maxImagePixels guards against images which are small files but decode to huge bitmaps.
+const maxImagePixels = 50_000_000
+
+// jpegQuality is the quality images without transparency are stored in.
+const jpegQuality = 90
+
+// The MIME types images are stored as.
+const (
+	JPEGContentType = "image/jpeg"
+	PNGContentType  = "image/png"
+	WebPContentType = "image/webp"
+)
+
+// decodeImage decodes an uploaded JPEG, PNG, GIF or WebP image and returns it along with its format.
+// Only the first frame of animated images is decoded. JPEG images are rotated and flipped upright
+// according to their EXIF orientation, since their metadata is not kept once they are encoded again.
+func decodeImage(file *multipart.FileHeader) (image.Image, string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, "", apperrors.NewInternalServerErr("Failed to open image", err)
+	}
+	defer f.Close()
+
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, "", apperrors.NewValidationErr("Unsupported image format", err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, "", apperrors.NewValidationErr(fmt.Sprintf("Image of %dx%d pixels is too large",
+			config.Width, config.Height), nil)
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, "", apperrors.NewInternalServerErr("Failed to read image", err)
+	}
+
+	img, format, err := image.Decode(f)
+	if err != nil {
+		return nil, "", apperrors.NewValidationErr("Invalid image", err)
+	}
+
+	if format == "jpeg" {
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return nil, "", apperrors.NewInternalServerErr("Failed to read image", err)
+		}
+		img = orient(img, jpegOrientation(f))
+	}
+
+	return img, format, nil
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG image, from 1 for upright images to 8.
+// Images without a valid orientation are upright.
+func jpegOrientation(r io.Reader) int {
+	br := bufio.NewReader(r)
+
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return 1
+	}
+
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(br, marker[:]); err != nil || marker[0] != 0xFF {
+			return 1
+		}
+
+		// The metadata segments precede the start of the image data
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return 1
+		}
+
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(br, segment); err != nil {
+			return 1
+		}
+
+		if marker[1] == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+	}
+}
+
+// tiffOrientation returns the orientation tag of the first image file directory of TIFF encoded EXIF data.
+func tiffOrientation(tiff []byte) int {
+	const orientationTag = 0x0112
+
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+
+	return 1
+}
+
+// orient rotates and flips the image as described by its EXIF orientation, so it is displayed upright.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	width, height := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if orientation >= 5 {
+		// Orientations 5 to 8 transpose the image
+		dst = image.NewRGBA(image.Rect(0, 0, height, width))
+	}
+
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = width-1-x, y
+			case 3:
+				sx, sy = width-1-x, height-1-y
+			case 4:
+				sx, sy = x, height-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, height-1-x
+			case 7:
+				sx, sy = width-1-y, height-1-x
+			case 8:
+				sx, sy = width-1-y, x
+			}
+
+			i, j := dst.PixOffset(x, y), src.PixOffset(sx, sy)
+			copy(dst.Pix[i:i+4], src.Pix[j:j+4])
+		}
+	}
+
+	return dst
+}
+
+// limitSize scales the image down, keeping its aspect ratio, so neither dimension exceeds maxDimension.
+// Smaller images are returned as they are.
+func limitSize(img image.Image, maxDimension int) image.Image {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width <= maxDimension && height <= maxDimension {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*maxDimension/width)
+		width = maxDimension
+	} else {
+		width = max(1, width*maxDimension/height)
+		height = maxDimension
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return scaled
+}
+
+// cropCenter cuts the region of the given size out of the center of the image, like the crop of Cloudinary does.
+// The image is not scaled, so images smaller than the region are only cropped along their larger dimensions.
+func cropCenter(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	width, height = min(width, bounds.Dx()), min(height, bounds.Dy())
+
+	x := bounds.Min.X + (bounds.Dx()-width)/2
+	y := bounds.Min.Y + (bounds.Dy()-height)/2
+
+	cropped := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(cropped, cropped.Bounds(), img, image.Pt(x, y), draw.Src)
+	return cropped
+}
+
+// imageExtension returns the file extension images of the format are stored with.
+// Images of formats which support transparency are stored as PNGs, all others as JPEGs.
+func imageExtension(format string) string {
+	switch format {
+	case "png", "gif", "webp":
+		return ".png"
+	default:
+		return ".jpg"
+	}
+}
+
+// imageContentType returns the MIME type of images of the format as they are stored.
+func imageContentType(format string) string {
+	if imageExtension(format) == ".png" {
+		return PNGContentType
+	}
+	return JPEGContentType
+}
+
+// variantContentTypes returns the MIME types the variants of images of the format are stored as.
+// WebP is only encoded losslessly, which is smaller than PNG but larger than JPEG,
+// so images stored as JPEGs, mostly photos, are not stored as WebP.
+func variantContentTypes(format string) []string {
+	contentType := imageContentType(format)
+	if contentType == JPEGContentType {
+		return []string{contentType}
+	}
+	return []string{contentType, WebPContentType}
+}
+
+// contentTypeExtension returns the file extension of images of the MIME type,
+// or an empty string if images of the type cannot be stored.
+func contentTypeExtension(contentType string) string {
+	switch contentType {
+	case JPEGContentType:
+		return ".jpg"
+	case PNGContentType:
+		return ".png"
+	case WebPContentType:
+		return ".webp"
+	default:
+		return ""
+	}
+}
+
+// randomName returns a random hex encoded name, which images and their references are stored under.
+func randomName() (string, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", apperrors.NewInternalServerErr("Failed to name image", err)
+	}
+	return hex.EncodeToString(name), nil
+}
+
+// encodeImage encodes the image in the format of files with the extension returned by imageExtension.
+func encodeImage(w io.Writer, img image.Image, format string) error {
+	return encodeImageAs(w, img, imageContentType(format))
+}
+
+// encodeImageAs encodes the image as the MIME type, which is one of the types images are stored as.
+// WebP images are encoded losslessly.
+func encodeImageAs(w io.Writer, img image.Image, contentType string) error {
+	var err error
+	switch contentType {
+	case PNGContentType:
+		err = png.Encode(w, img)
+	case WebPContentType:
+		err = nativewebp.Encode(w, img, nil)
+	default:
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+
+	if err != nil {
+		return apperrors.NewInternalServerErr("Failed to encode image", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+)
+
+// LocalImageStorage implements ImageStorage by writing images to a directory of the local filesystem.
+// The directory has to be served under the base URL, e.g. by a static route of the API.
+// Public IDs are the names of the image files in the directory.
+type LocalImageStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalImageStorage creates a new local storage instance, creating the directory if it does not exist.
+func NewLocalImageStorage(dir, baseURL string) (ImageStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalImageStorage{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Upload stores an image, limiting the greater dimension to 1920 pixels.
+func (s *LocalImageStorage) Upload(ctx context.Context, file *multipart.FileHeader) (*ImageResult, error) {
+	img, format, err := decodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveImage(ctx, limitSize(img, MaxImageDimension), format)
+}
+
+// UploadCropped stores the region of the given size from the center of an image.
+func (s *LocalImageStorage) UploadCropped(ctx context.Context,
+	file *multipart.FileHeader, width, height int) (*ImageResult, error) {
+	img, format, err := decodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveImage(ctx, cropCenter(img, width, height), format)
+}
+
+// UploadEncoded stores an encoded image as it is.
+func (s *LocalImageStorage) UploadEncoded(ctx context.Context, data []byte, contentType string) (*ImageResult, error) {
+	extension := contentTypeExtension(contentType)
+	if extension == "" {
+		return nil, apperrors.NewValidationErr("Unsupported image type "+contentType, nil)
+	}
+
+	return s.save(ctx, bytes.NewReader(data), extension)
+}
+
+// Delete removes an image from storage. Deleting an image which does not exist succeeds.
+func (s *LocalImageStorage) Delete(ctx context.Context, publicID string) error {
+	if publicID != filepath.Base(publicID) || strings.HasPrefix(publicID, ".") {
+		return apperrors.NewValidationErr("Invalid image public ID "+publicID, nil)
+	}
+
+	err := os.Remove(filepath.Join(s.dir, publicID))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return apperrors.NewInternalServerErr("Failed to delete image", err)
+	}
+	return nil
+}
+
+// List returns the images in the directory, along with the times they were last modified.
+// Temporary files of uploads in progress are left out.
+func (s *LocalImageStorage) List(ctx context.Context) ([]StoredImage, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to list images", err)
+	}
+
+	var images []StoredImage
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, apperrors.NewInternalServerErr("Failed to list images", err)
+		}
+
+		images = append(images, StoredImage{
+			PublicID:  entry.Name(),
+			URL:       s.baseURL + "/" + entry.Name(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+
+	return images, nil
+}
+
+// saveImage encodes the image and saves it.
+func (s *LocalImageStorage) saveImage(ctx context.Context, img image.Image, format string) (*ImageResult, error) {
+	var buf bytes.Buffer
+	if err := encodeImage(&buf, img, format); err != nil {
+		return nil, err
+	}
+
+	return s.save(ctx, &buf, imageExtension(format))
+}
+
+// save writes the encoded image to a file with a random name, so the URLs of images are never reused.
+// The image is written to a temporary file first, so incomplete images are never served.
+func (s *LocalImageStorage) save(ctx context.Context, data io.Reader, extension string) (*ImageResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, apperrors.NewInternalServerErr("Image upload cancelled", err)
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	publicID := name + extension
+
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to store image", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return nil, apperrors.NewInternalServerErr("Failed to store image", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to store image", err)
+	}
+
+	// Temporary files are only readable by their owner, stored images are public.
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to store image", err)
+	}
+
+	if err = os.Rename(tmp.Name(), filepath.Join(s.dir, publicID)); err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to store image", err)
+	}
+
+	return &ImageResult{
+		URL:      s.baseURL + "/" + publicID,
+		PublicID: publicID,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFileHeader returns the header of a file uploaded in a multipart form.
+func newFileHeader(t *testing.T, name string, data []byte) *multipart.FileHeader {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("photo", name)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["photo"][0]
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	img.Set(width/2, height/2, color.NRGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil))
+	return buf.Bytes()
+}
+
+func decodeStored(t *testing.T, dir, publicID string) (image.Image, string) {
+	f, err := os.Open(filepath.Join(dir, publicID))
+	require.NoError(t, err)
+	defer f.Close()
+
+	img, format, err := image.Decode(f)
+	require.NoError(t, err)
+	return img, format
+}
+
+func TestLocalImageStorage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "uploads")
+	imageStorage, err := storage.NewLocalImageStorage(dir, "http://localhost:8080/images/")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("Upload limits the size", func(t *testing.T) {
+		result, err := imageStorage.Upload(ctx, newFileHeader(t, "wide.jpg", encodeJPEG(t, 3840, 1000)))
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(result.PublicID, ".jpg"))
+		assert.Equal(t, "http://localhost:8080/images/"+result.PublicID, result.URL)
+
+		img, format := decodeStored(t, dir, result.PublicID)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, image.Pt(1920, 500), img.Bounds().Size())
+	})
+
+	t.Run("Upload keeps small images and transparency", func(t *testing.T) {
+		result, err := imageStorage.Upload(ctx, newFileHeader(t, "icon.png", encodePNG(t, 100, 200)))
+		require.NoError(t, err)
+
+		img, format := decodeStored(t, dir, result.PublicID)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, image.Pt(100, 200), img.Bounds().Size())
+	})
+
+	t.Run("UploadCropped crops the center", func(t *testing.T) {
+		result, err := imageStorage.UploadCropped(ctx, newFileHeader(t, "icon.png", encodePNG(t, 400, 100)), 256, 256)
+		require.NoError(t, err)
+
+		img, _ := decodeStored(t, dir, result.PublicID)
+		assert.Equal(t, image.Pt(256, 100), img.Bounds().Size())
+		_, _, _, alpha := img.At(128, 50).RGBA()
+		assert.NotZero(t, alpha, "the center of the image is kept")
+	})
+
+	t.Run("Invalid image", func(t *testing.T) {
+		_, err := imageStorage.Upload(ctx, newFileHeader(t, "notes.txt", []byte("not an image")))
+		assert.True(t, apperrors.IsValidationErr(err))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		result, err := imageStorage.Upload(ctx, newFileHeader(t, "icon.png", encodePNG(t, 10, 10)))
+		require.NoError(t, err)
+
+		require.NoError(t, imageStorage.Delete(ctx, result.PublicID))
+		assert.NoFileExists(t, filepath.Join(dir, result.PublicID))
+		assert.NoError(t, imageStorage.Delete(ctx, result.PublicID), "deleting a deleted image succeeds")
+		assert.True(t, apperrors.IsValidationErr(imageStorage.Delete(ctx, "../config.go")))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		result, err := imageStorage.Upload(ctx, newFileHeader(t, "icon.png", encodePNG(t, 10, 10)))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".upload-123"), []byte{}, 0o600))
+		defer os.Remove(filepath.Join(dir, ".upload-123"))
+
+		images, err := imageStorage.List(ctx)
+		require.NoError(t, err)
+
+		publicIDs := make([]string, len(images))
+		for i, image := range images {
+			publicIDs[i] = image.PublicID
+			assert.False(t, strings.HasPrefix(image.PublicID, "."), "temporary files are not listed")
+			if image.PublicID == result.PublicID {
+				assert.Equal(t, result.URL, image.URL)
+				assert.WithinDuration(t, time.Now(), image.CreatedAt, time.Minute)
+			}
+		}
+		assert.Contains(t, publicIDs, result.PublicID)
+	})
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, strings.HasPrefix(entry.Name(), "."), "temporary files are removed")
+	}
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/minio/minio-go/v7"
+)
+
+// S3Client is the part of the S3 API the S3 image storage uses, it is implemented by *minio.Client.
+type S3Client interface {
+	BucketExists(ctx context.Context, bucket string) (bool, error)
+	MakeBucket(ctx context.Context, bucket string, opts minio.MakeBucketOptions) error
+	PutObject(ctx context.Context, bucket, key string, reader io.Reader, size int64,
+		opts minio.PutObjectOptions) (minio.UploadInfo, error)
+	StatObject(ctx context.Context, bucket, key string, opts minio.StatObjectOptions) (minio.ObjectInfo, error)
+	RemoveObject(ctx context.Context, bucket, key string, opts minio.RemoveObjectOptions) error
+	ListObjects(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo
+	PresignedGetObject(ctx context.Context, bucket, key string, expiry time.Duration, params url.Values) (*url.URL, error)
+}
+
+// ImageSigner signs short lived URLs of images which are not publicly readable.
+type ImageSigner interface {
+	// PresignedURL returns a URL the image with the key can be downloaded from for a limited time.
+	PresignedURL(ctx context.Context, key string) (string, error)
+}
+
+// S3Options configure the bucket of the S3 image storage and how its images are linked.
+// If PublicURL is empty, the bucket is private and images link to BaseURL, which has to redirect
+// to the presigned URLs of the images, see S3ImageStorage.PresignedURL.
+type S3Options struct {
+	Bucket        string
+	PublicURL     string
+	BaseURL       string
+	PresignExpiry time.Duration
+}
+
+// S3ImageStorage implements ImageStorage using an S3 compatible object storage, like AWS S3 or MinIO.
+// Images are resized and cropped before the upload.
+//
+// Images are stored under the SHA-256 hash of their content, so identical images share a single object.
+// Every upload also stores an empty reference object under refs/<image key>/, and its public ID is
+// the image key followed by the name of the reference. Delete removes the reference and only removes
+// the image once no references are left. An image uploaded again while its last reference is being deleted
+// may still lose its object, so deletes should happen well after the image was replaced.
+type S3ImageStorage struct {
+	client  S3Client
+	options S3Options
+}
+
+// NewS3ImageStorage creates a new S3 storage instance, creating the bucket if it does not exist.
+func NewS3ImageStorage(ctx context.Context, client S3Client, options S3Options) (*S3ImageStorage, error) {
+	exists, err := client.BucketExists(ctx, options.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		if err = client.MakeBucket(ctx, options.Bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	options.PublicURL = strings.TrimSuffix(options.PublicURL, "/")
+	options.BaseURL = strings.TrimSuffix(options.BaseURL, "/")
+	return &S3ImageStorage{client: client, options: options}, nil
+}
+
+// Upload uploads an image, limiting the greater dimension to 1920 pixels.
+func (s *S3ImageStorage) Upload(ctx context.Context, file *multipart.FileHeader) (*ImageResult, error) {
+	img, format, err := decodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.putImage(ctx, limitSize(img, MaxImageDimension), format)
+}
+
+// UploadCropped uploads the region of the given size from the center of an image.
+func (s *S3ImageStorage) UploadCropped(ctx context.Context,
+	file *multipart.FileHeader, width, height int) (*ImageResult, error) {
+	img, format, err := decodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.putImage(ctx, cropCenter(img, width, height), format)
+}
+
+// UploadEncoded uploads an encoded image as it is.
+func (s *S3ImageStorage) UploadEncoded(ctx context.Context, data []byte, contentType string) (*ImageResult, error) {
+	extension := contentTypeExtension(contentType)
+	if extension == "" {
+		return nil, apperrors.NewValidationErr("Unsupported image type "+contentType, nil)
+	}
+
+	return s.put(ctx, data, extension, contentType)
+}
+
+// Delete removes the reference of the public ID and the image once it has no references left.
+// Deleting an image which does not exist succeeds.
+func (s *S3ImageStorage) Delete(ctx context.Context, publicID string) error {
+	key, reference, found := strings.Cut(publicID, "/")
+	if !found || !imageKeyPattern.MatchString(key) || !referencePattern.MatchString(reference) {
+		return apperrors.NewValidationErr("Invalid image public ID "+publicID, nil)
+	}
+
+	err := s.client.RemoveObject(ctx, s.options.Bucket, referencePrefix(key)+reference, minio.RemoveObjectOptions{})
+	if err != nil {
+		return apperrors.NewInternalServerErr("Failed to delete image", err)
+	}
+
+	for object := range s.client.ListObjects(ctx, s.options.Bucket, minio.ListObjectsOptions{
+		Prefix:  referencePrefix(key),
+		MaxKeys: 1,
+	}) {
+		if object.Err != nil {
+			return apperrors.NewInternalServerErr("Failed to delete image", object.Err)
+		}
+		// The image is still referenced by another upload
+		return nil
+	}
+
+	if err = s.client.RemoveObject(ctx, s.options.Bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return apperrors.NewInternalServerErr("Failed to delete image", err)
+	}
+	return nil
+}
+
+// List returns an image for every reference, since each one has its own public ID, along with the time it was added.
+// References to the same image share its URL.
+func (s *S3ImageStorage) List(ctx context.Context) ([]StoredImage, error) {
+	var images []StoredImage
+	for object := range s.client.ListObjects(ctx, s.options.Bucket, minio.ListObjectsOptions{
+		Prefix:    "refs/",
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return nil, apperrors.NewInternalServerErr("Failed to list images", object.Err)
+		}
+
+		key, reference, found := strings.Cut(strings.TrimPrefix(object.Key, "refs/"), "/")
+		if !found || !imageKeyPattern.MatchString(key) || !referencePattern.MatchString(reference) {
+			continue
+		}
+
+		images = append(images, StoredImage{
+			PublicID:  key + "/" + reference,
+			URL:       s.url(key),
+			CreatedAt: object.LastModified,
+		})
+	}
+
+	return images, nil
+}
+
+// PresignedURL returns a URL the image with the key can be downloaded from until the presign expiry passes.
+func (s *S3ImageStorage) PresignedURL(ctx context.Context, key string) (string, error) {
+	if !imageKeyPattern.MatchString(key) {
+		return "", apperrors.NewNotFoundErr("Image "+key+" not found", nil)
+	}
+
+	presignedURL, err := s.client.PresignedGetObject(ctx, s.options.Bucket, key, s.options.PresignExpiry, nil)
+	if err != nil {
+		return "", apperrors.NewInternalServerErr("Failed to sign image url", err)
+	}
+	return presignedURL.String(), nil
+}
+
+// imageKeyPattern matches the keys of images, the hex encoded SHA-256 hash of the image and its extension.
+var imageKeyPattern = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|png|webp)$`)
+
+// referencePattern matches the names of the references of images.
+var referencePattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// referencePrefix returns the prefix of the keys of the references of the image with the key.
+func referencePrefix(key string) string {
+	return "refs/" + key + "/"
+}
+
+// putImage encodes and puts the image.
+func (s *S3ImageStorage) putImage(ctx context.Context, img image.Image, format string) (*ImageResult, error) {
+	var buf bytes.Buffer
+	if err := encodeImage(&buf, img, format); err != nil {
+		return nil, err
+	}
+
+	return s.put(ctx, buf.Bytes(), imageExtension(format), imageContentType(format))
+}
+
+// put uploads the encoded image unless an identical image exists already, and adds a reference to it.
+// The reference is added first, so a concurrent Delete of the last other reference keeps the image.
+func (s *S3ImageStorage) put(ctx context.Context, data []byte, extension, contentType string) (*ImageResult, error) {
+	hash := sha256.Sum256(data)
+	key := hex.EncodeToString(hash[:]) + extension
+
+	reference, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.client.PutObject(ctx, s.options.Bucket, referencePrefix(key)+reference, bytes.NewReader(nil), 0,
+		minio.PutObjectOptions{})
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to upload image", err)
+	}
+
+	_, err = s.client.StatObject(ctx, s.options.Bucket, key, minio.StatObjectOptions{})
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		_, err = s.client.PutObject(ctx, s.options.Bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+			ContentType: contentType,
+			// Images never change, since their key is the hash of their content
+			CacheControl: "public, max-age=31536000, immutable",
+		})
+	}
+	if err != nil {
+		return nil, apperrors.NewInternalServerErr("Failed to upload image", err)
+	}
+
+	return &ImageResult{
+		URL:      s.url(key),
+		PublicID: key + "/" + reference,
+	}, nil
+}
+
+// url returns the URL of the image with the key, in the public bucket or under the base URL of the API.
+func (s *S3ImageStorage) url(key string) string {
+	if s.options.PublicURL != "" {
+		return s.options.PublicURL + "/" + key
+	}
+	return s.options.BaseURL + "/" + key
+}
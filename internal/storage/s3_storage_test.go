@@ -0,0 +1,253 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ruclo/MyMeals/internal/apperrors"
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3Client is an in-memory S3Client storing the objects of a single bucket.
+type fakeS3Client struct {
+	mu       sync.Mutex
+	bucket   string
+	objects  map[string][]byte
+	headers  map[string]minio.PutObjectOptions
+	modified map[string]time.Time
+}
+
+func newFakeS3Client() *fakeS3Client {
+	return &fakeS3Client{
+		objects:  map[string][]byte{},
+		headers:  map[string]minio.PutObjectOptions{},
+		modified: map[string]time.Time{},
+	}
+}
+
+func (f *fakeS3Client) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	return f.bucket == bucket, nil
+}
+
+func (f *fakeS3Client) MakeBucket(ctx context.Context, bucket string, opts minio.MakeBucketOptions) error {
+	f.bucket = bucket
+	return nil
+}
+
+func (f *fakeS3Client) PutObject(ctx context.Context, bucket, key string, reader io.Reader, size int64,
+	opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[key] = data
+	f.headers[key] = opts
+	f.modified[key] = time.Now()
+	return minio.UploadInfo{Bucket: bucket, Key: key, Size: size}, nil
+}
+
+func (f *fakeS3Client) StatObject(ctx context.Context, bucket, key string,
+	opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[key]
+	if !ok {
+		return minio.ObjectInfo{}, minio.ErrorResponse{Code: "NoSuchKey", StatusCode: 404}
+	}
+	return minio.ObjectInfo{Key: key, Size: int64(len(data))}, nil
+}
+
+func (f *fakeS3Client) RemoveObject(ctx context.Context, bucket, key string, opts minio.RemoveObjectOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, key)
+	return nil
+}
+
+func (f *fakeS3Client) ListObjects(ctx context.Context, bucket string,
+	opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := []string{}
+	for key := range f.objects {
+		if strings.HasPrefix(key, opts.Prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if opts.MaxKeys > 0 && len(keys) > opts.MaxKeys {
+		keys = keys[:opts.MaxKeys]
+	}
+
+	objects := make(chan minio.ObjectInfo, len(keys))
+	for _, key := range keys {
+		objects <- minio.ObjectInfo{Key: key, LastModified: f.modified[key]}
+	}
+	close(objects)
+	return objects
+}
+
+func (f *fakeS3Client) PresignedGetObject(ctx context.Context, bucket, key string, expiry time.Duration,
+	params url.Values) (*url.URL, error) {
+	return url.Parse("http://minio:9000/" + bucket + "/" + key + "?X-Amz-Expires=" + expiry.String())
+}
+
+func (f *fakeS3Client) countPrefix(prefix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func decodeObject(t *testing.T, client *fakeS3Client, key string) (image.Image, string) {
+	client.mu.Lock()
+	data, ok := client.objects[key]
+	client.mu.Unlock()
+	require.True(t, ok, "object %s exists", key)
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img, format
+}
+
+func TestS3ImageStorage(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeS3Client()
+	imageStorage, err := storage.NewS3ImageStorage(ctx, client, storage.S3Options{
+		Bucket:        "images",
+		BaseURL:       "http://localhost:8080/images/",
+		PresignExpiry: 15 * time.Minute,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "images", client.bucket, "the bucket is created")
+
+	t.Run("Upload limits the size", func(t *testing.T) {
+		result, err := imageStorage.Upload(ctx, newFileHeader(t, "wide.jpg", encodeJPEG(t, 3840, 1000)))
+		require.NoError(t, err)
+
+		key, _, found := strings.Cut(result.PublicID, "/")
+		require.True(t, found)
+		assert.True(t, strings.HasSuffix(key, ".jpg"))
+		assert.Equal(t, "http://localhost:8080/images/"+key, result.URL)
+		assert.Equal(t, "image/jpeg", client.headers[key].ContentType)
+
+		img, format := decodeObject(t, client, key)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, image.Pt(1920, 500), img.Bounds().Size())
+	})
+
+	t.Run("UploadCropped crops the center", func(t *testing.T) {
+		result, err := imageStorage.UploadCropped(ctx, newFileHeader(t, "icon.png", encodePNG(t, 400, 100)), 256, 256)
+		require.NoError(t, err)
+
+		key, _, _ := strings.Cut(result.PublicID, "/")
+		img, format := decodeObject(t, client, key)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, image.Pt(256, 100), img.Bounds().Size())
+	})
+
+	t.Run("Identical images are stored once", func(t *testing.T) {
+		data := encodePNG(t, 30, 30)
+		first, err := imageStorage.Upload(ctx, newFileHeader(t, "first.png", data))
+		require.NoError(t, err)
+		second, err := imageStorage.Upload(ctx, newFileHeader(t, "second.png", data))
+		require.NoError(t, err)
+
+		assert.Equal(t, first.URL, second.URL)
+		assert.NotEqual(t, first.PublicID, second.PublicID)
+
+		key, _, _ := strings.Cut(first.PublicID, "/")
+		assert.Equal(t, 2, client.countPrefix("refs/"+key+"/"))
+
+		require.NoError(t, imageStorage.Delete(ctx, first.PublicID))
+		assert.Equal(t, 1, client.countPrefix(key), "the image is kept while it is referenced")
+
+		require.NoError(t, imageStorage.Delete(ctx, second.PublicID))
+		assert.Zero(t, client.countPrefix(key))
+		assert.Zero(t, client.countPrefix("refs/"+key+"/"))
+
+		assert.NoError(t, imageStorage.Delete(ctx, second.PublicID), "deleting a deleted image succeeds")
+	})
+
+	t.Run("Invalid image", func(t *testing.T) {
+		_, err := imageStorage.Upload(ctx, newFileHeader(t, "notes.txt", []byte("not an image")))
+		assert.True(t, apperrors.IsValidationErr(err))
+	})
+
+	t.Run("List returns every reference", func(t *testing.T) {
+		data := encodePNG(t, 40, 40)
+		first, err := imageStorage.Upload(ctx, newFileHeader(t, "first.png", data))
+		require.NoError(t, err)
+		second, err := imageStorage.Upload(ctx, newFileHeader(t, "second.png", data))
+		require.NoError(t, err)
+
+		images, err := imageStorage.List(ctx)
+		require.NoError(t, err)
+
+		listed := map[string]storage.StoredImage{}
+		for _, image := range images {
+			listed[image.PublicID] = image
+		}
+		for _, result := range []*storage.ImageResult{first, second} {
+			require.Contains(t, listed, result.PublicID)
+			assert.Equal(t, result.URL, listed[result.PublicID].URL)
+			assert.WithinDuration(t, time.Now(), listed[result.PublicID].CreatedAt, time.Minute)
+		}
+	})
+
+	t.Run("Invalid public ID", func(t *testing.T) {
+		for _, publicID := range []string{"", "image.jpg", "refs/" + strings.Repeat("a", 64) + ".jpg",
+			strings.Repeat("a", 64) + ".jpg/../../secret"} {
+			assert.True(t, apperrors.IsValidationErr(imageStorage.Delete(ctx, publicID)), publicID)
+		}
+	})
+
+	t.Run("PresignedURL", func(t *testing.T) {
+		key := strings.Repeat("a", 64) + ".png"
+		presignedURL, err := imageStorage.PresignedURL(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "http://minio:9000/images/"+key+"?X-Amz-Expires=15m0s", presignedURL)
+
+		_, err = imageStorage.PresignedURL(ctx, "refs/"+key)
+		assert.True(t, apperrors.IsNotFoundErr(err))
+	})
+}
+
+func TestS3ImageStorage_PublicURL(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeS3Client()
+	client.bucket = "images"
+	imageStorage, err := storage.NewS3ImageStorage(ctx, client, storage.S3Options{
+		Bucket:    "images",
+		PublicURL: "https://images.example.com/",
+		BaseURL:   "http://localhost:8080/images",
+	})
+	require.NoError(t, err)
+
+	result, err := imageStorage.Upload(ctx, newFileHeader(t, "icon.png", encodePNG(t, 10, 10)))
+	require.NoError(t, err)
+
+	key, _, _ := strings.Cut(result.PublicID, "/")
+	assert.Equal(t, "https://images.example.com/"+key, result.URL)
+	assert.Equal(t, "public, max-age=31536000, immutable", client.headers[key].CacheControl)
+}
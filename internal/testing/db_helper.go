@@ -20,11 +20,34 @@ func NewTestDB(t *testing.T) *gorm.DB {
 
 	// Migrate the schema
 	err = db.AutoMigrate(
+		&models.Category{},
 		&models.Meal{},
+		&models.MealVersion{},
+		&models.Menu{},
+		&models.MenuItem{},
+		&models.Promotion{},
+		&models.PromotionComboMeal{},
+		&models.AvailabilityWindow{},
+		&models.OptionGroup{},
+		&models.MealOption{},
+		&models.Ingredient{},
+		&models.RecipeItem{},
 		&models.Order{},
 		&models.OrderMeal{},
+		&models.OrderMealOption{},
+		&models.OrderMealStatusChange{},
+		&models.OrderMealVoid{},
+		&models.OrderDiscount{},
+		&models.Payment{},
+		&models.Tip{},
+		&models.Table{},
+		&models.TableSession{},
 		&models.Review{},
+		&models.ReviewReply{},
+		&models.ReviewMealRating{},
 		&models.User{},
+		&models.UploadSession{},
+		&models.Upload{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"mime/multipart"
+
+	"github.com/Ruclo/MyMeals/internal/storage"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockImageProcessor is a mock implementation of storage.ImageProcessor
+type MockImageProcessor struct {
+	mock.Mock
+}
+
+// Process mocks the Process method
+func (m *MockImageProcessor) Process(ctx context.Context, file *multipart.FileHeader) (*storage.ProcessedImage, error) {
+	args := m.Called(ctx, file)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*storage.ProcessedImage), args.Error(1)
+}
+
+// ProcessCropped mocks the ProcessCropped method
+func (m *MockImageProcessor) ProcessCropped(ctx context.Context, file *multipart.FileHeader, width, height int) (*storage.ProcessedImage, error) {
+	args := m.Called(ctx, file, width, height)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*storage.ProcessedImage), args.Error(1)
+}
+
+// Delete mocks the Delete method
+func (m *MockImageProcessor) Delete(ctx context.Context, publicIDs ...string) error {
+	args := m.Called(ctx, publicIDs)
+	return args.Error(0)
+}
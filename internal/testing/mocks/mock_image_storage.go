@@ -31,8 +31,26 @@ func (m *MockImageStorage) UploadCropped(ctx context.Context, file *multipart.Fi
 	return args.Get(0).(*storage.ImageResult), args.Error(1)
 }
 
+// UploadEncoded mocks the UploadEncoded method
+func (m *MockImageStorage) UploadEncoded(ctx context.Context, data []byte, contentType string) (*storage.ImageResult, error) {
+	args := m.Called(ctx, data, contentType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*storage.ImageResult), args.Error(1)
+}
+
 // Delete mocks the Delete method
 func (m *MockImageStorage) Delete(ctx context.Context, publicID string) error {
 	args := m.Called(ctx, publicID)
 	return args.Error(0)
 }
+
+// List mocks the List method
+func (m *MockImageStorage) List(ctx context.Context) ([]storage.StoredImage, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]storage.StoredImage), args.Error(1)
+}
@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOrderBroadcaster is a mock implementation of events.OrderBroadcaster
+type MockOrderBroadcaster struct {
+	mock.Mock
+}
+
+// BroadcastOrder mocks the BroadcastOrder method
+func (m *MockOrderBroadcaster) BroadcastOrder(order *models.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"github.com/Ruclo/MyMeals/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStockBroadcaster is a mock implementation of events.StockBroadcaster
+type MockStockBroadcaster struct {
+	mock.Mock
+}
+
+// BroadcastLowStock mocks the BroadcastLowStock method
+func (m *MockStockBroadcaster) BroadcastLowStock(ingredient *models.Ingredient) error {
+	args := m.Called(ingredient)
+	return args.Error(0)
+}